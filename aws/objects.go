@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/httpserver"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3Objects reads(and writes) the objects of a bucket, implementing httpserver.ObjectStore and httpserver.URLSigner,
+// eg. to serve the recordings of the calls:
+//
+//	recordings, err := aws.NewS3Objects(s3URL)
+//	...
+//	mux.Handle("/recordings/", httpserver.ServeObject(recordings, key, httpserver.WithSignedRedirects(64<<20, time.Hour)))
+type S3Objects struct {
+	client *s3.S3
+	bucket string
+}
+
+// NewS3Objects returns the objects of the bucket of the URL(see ParseAmazonS3URL), in its region and at its endpoint
+func NewS3Objects(u S3URL) (*S3Objects, error) {
+	config := &aws.Config{Region: aws.String(u.Region)}
+	if u.EndPoint != "" {
+		config.Endpoint = aws.String(u.EndPoint)
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, errors.NewError("Error creating session", err, false)
+	}
+	return &S3Objects{client: s3.New(sess), bucket: u.Bucket}, nil
+}
+
+// Stat returns the metadata of the object, with the code errors.NotFound if it does not exist
+func (o *S3Objects) Stat(ctx context.Context, key string) (httpserver.Object, error) {
+	output, err := o.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return httpserver.Object{}, objectError(err, key)
+	}
+
+	return httpserver.Object{
+		Size:        aws.Int64Value(output.ContentLength),
+		ContentType: aws.StringValue(output.ContentType),
+		ModTime:     aws.TimeValue(output.LastModified),
+		ETag:        aws.StringValue(output.ETag),
+	}, nil
+}
+
+// Open returns the content of the object from the offset to its end
+func (o *S3Objects) Open(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(key),
+	}
+	if offset > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	output, err := o.client.GetObjectWithContext(ctx, input)
+	if err != nil {
+		return nil, objectError(err, key)
+	}
+	return output.Body, nil
+}
+
+// Put writes the object, eg. through failover.Objects
+func (o *S3Objects) Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	if _, err := o.client.PutObjectWithContext(ctx, input); err != nil {
+		return errors.NewError("Error writing "+key, err, false)
+	}
+	return nil
+}
+
+// SignedURL returns a URL the object can be downloaded from until the expiry, without credentials
+func (o *S3Objects) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	request, _ := o.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(key),
+	})
+	request.SetContext(ctx)
+
+	url, err := request.Presign(expiry)
+	if err != nil {
+		return "", errors.NewError("Error signing the URL of "+key, err, false)
+	}
+	return url, nil
+}
+
+// Wraps the error of a request for the object, with the code errors.NotFound if the object does not exist
+func objectError(err error, key string) error {
+	wrapped := errors.NewError("Error reading "+key, err, false)
+	if failure, ok := err.(awserr.RequestFailure); ok && failure.StatusCode() == http.StatusNotFound {
+		return errors.WithCode(wrapped, errors.NotFound)
+	}
+	return wrapped
+}
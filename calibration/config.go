@@ -0,0 +1,130 @@
+package calibration
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CurveConfig is the serialized form of a curve. Type is one of "identity", "platt" or "piecewise".
+type CurveConfig struct {
+	Type   string  `yaml:"type" json:"type"`
+	A      float64 `yaml:"a" json:"a"`
+	B      float64 `yaml:"b" json:"b"`
+	Points []Point `yaml:"points" json:"points"`
+}
+
+// ModelConfig holds the curve of a model and overrides for specific intents
+type ModelConfig struct {
+	Default CurveConfig            `yaml:"default" json:"default"`
+	Intents map[string]CurveConfig `yaml:"intents" json:"intents"`
+}
+
+// Config is the calibration of all models. Eg.
+//
+//	models:
+//	  slu-v3:
+//	    default:
+//	      type: platt
+//	      a: -6.1
+//	      b: 3.2
+//	    intents:
+//	      book_appointment:
+//	        type: piecewise
+//	        points: [{raw: 0, calibrated: 0}, {raw: 0.6, calibrated: 0.4}, {raw: 1, calibrated: 0.95}]
+type Config struct {
+	Models map[string]ModelConfig `yaml:"models" json:"models"`
+}
+
+// Build returns the curve described by the config
+func (c CurveConfig) Build() (Curve, error) {
+	switch c.Type {
+	case "", "identity":
+		return Identity{}, nil
+	case "platt":
+		return Platt{A: c.A, B: c.B}, nil
+	case "piecewise", "isotonic":
+		return NewPiecewise(c.Points)
+	}
+	return nil, fmt.Errorf("unknown calibration curve type %q", c.Type)
+}
+
+// Calibrator looks up the curve for a model and intent and applies it
+type Calibrator struct {
+	mutex  sync.RWMutex
+	models map[string]*model
+}
+
+type model struct {
+	fallback Curve
+	intents  map[string]Curve
+}
+
+// NewCalibrator builds the curves of the config
+func NewCalibrator(config Config) (*Calibrator, error) {
+	c := &Calibrator{}
+	if err := c.Reload(config); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadFile builds a calibrator from a YAML(or JSON) config file
+func LoadFile(path string) (*Calibrator, error) {
+	config, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewCalibrator(config)
+}
+
+func readConfig(path string) (config Config, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	err = yaml.Unmarshal(data, &config)
+	return
+}
+
+// Reload atomically replaces the curves of the calibrator. The existing curves are retained on error.
+func (c *Calibrator) Reload(config Config) error {
+	models := make(map[string]*model, len(config.Models))
+	for name, modelConfig := range config.Models {
+		fallback, err := modelConfig.Default.Build()
+		if err != nil {
+			return fmt.Errorf("model %s: %v", name, err)
+		}
+
+		m := &model{fallback: fallback, intents: make(map[string]Curve, len(modelConfig.Intents))}
+		for intent, curveConfig := range modelConfig.Intents {
+			if m.intents[intent], err = curveConfig.Build(); err != nil {
+				return fmt.Errorf("model %s intent %s: %v", name, intent, err)
+			}
+		}
+		models[name] = m
+	}
+
+	c.mutex.Lock()
+	c.models = models
+	c.mutex.Unlock()
+	return nil
+}
+
+// Calibrate maps the raw confidence of an intent predicted by a model to a calibrated probability.
+// The intent's curve is preferred over the model's default. Unknown models are left uncalibrated.
+func (c *Calibrator) Calibrate(modelName, intent string, raw float64) float64 {
+	c.mutex.RLock()
+	m, ok := c.models[modelName]
+	c.mutex.RUnlock()
+
+	if !ok {
+		return Identity{}.Apply(raw)
+	}
+	if curve, ok := m.intents[intent]; ok {
+		return curve.Apply(raw)
+	}
+	return m.fallback.Apply(raw)
+}
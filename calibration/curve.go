@@ -0,0 +1,83 @@
+// Package calibration applies per-model/per-intent confidence calibration curves and thresholding policies
+// shared by SLU post-processing across bots.
+package calibration
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Curve maps a raw model confidence to a calibrated probability
+type Curve interface {
+	Apply(raw float64) float64
+}
+
+// Identity leaves the confidence untouched
+type Identity struct{}
+
+func (Identity) Apply(raw float64) float64 {
+	return clamp(raw)
+}
+
+// Platt scaling: 1 / (1 + exp(A*raw + B))
+type Platt struct {
+	A float64 `yaml:"a" json:"a"`
+	B float64 `yaml:"b" json:"b"`
+}
+
+func (p Platt) Apply(raw float64) float64 {
+	return 1 / (1 + math.Exp(p.A*raw+p.B))
+}
+
+// Point of a piecewise linear curve
+type Point struct {
+	Raw        float64 `yaml:"raw" json:"raw"`
+	Calibrated float64 `yaml:"calibrated" json:"calibrated"`
+}
+
+// Piecewise is a piecewise linear curve(eg. the output of isotonic regression) interpolating between points.
+// Confidences outside the range of the points are mapped to the calibrated value of the nearest point.
+type Piecewise struct {
+	points []Point
+}
+
+// NewPiecewise returns a piecewise linear curve through the points
+func NewPiecewise(points []Point) (*Piecewise, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("a piecewise curve needs at least 2 points, got %d", len(points))
+	}
+
+	sorted := make([]Point, len(points))
+	copy(sorted, points)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Raw < sorted[j].Raw
+	})
+
+	return &Piecewise{points: sorted}, nil
+}
+
+func (p *Piecewise) Apply(raw float64) float64 {
+	points := p.points
+	if raw <= points[0].Raw {
+		return clamp(points[0].Calibrated)
+	}
+	if raw >= points[len(points)-1].Raw {
+		return clamp(points[len(points)-1].Calibrated)
+	}
+
+	// Index of the first point to the right of raw
+	i := sort.Search(len(points), func(i int) bool {
+		return points[i].Raw > raw
+	})
+	left, right := points[i-1], points[i]
+	if right.Raw == left.Raw {
+		return clamp(right.Calibrated)
+	}
+	fraction := (raw - left.Raw) / (right.Raw - left.Raw)
+	return clamp(left.Calibrated + fraction*(right.Calibrated-left.Calibrated))
+}
+
+func clamp(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
@@ -0,0 +1,81 @@
+package calibration
+
+import (
+	"math"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Number of equal width buckets the confidences in [0, 1] are counted in
+const buckets = 10
+
+var decisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "vcore",
+	Subsystem: "calibration",
+	Name:      "decisions_total",
+	Help:      "Decisions taken by the policies on calibrated confidences, by intent, model and decision",
+}, []string{"intent", "model", "decision"})
+
+// Collectors returns the metrics of the policies, to be registered by the service
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{decisions}
+}
+
+// Stats is the distribution of decisions and confidences per intent
+type Stats struct {
+	mutex   sync.Mutex
+	intents map[string]*IntentStats
+}
+
+// IntentStats is the distribution of decisions and confidences of an intent
+type IntentStats struct {
+	Decisions map[Decision]int `json:"decisions"`
+	// Histogram of the confidences. Bucket i counts confidences in [i/10, (i+1)/10)
+	Confidences [buckets]int `json:"confidences"`
+}
+
+func NewStats() *Stats {
+	return &Stats{intents: make(map[string]*IntentStats)}
+}
+
+func (s *Stats) record(intent string, decision Decision, confidence float64) {
+	bucket := int(math.Floor(clamp(confidence) * buckets))
+	if bucket == buckets {
+		bucket--
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats, ok := s.intents[intent]
+	if !ok {
+		stats = &IntentStats{Decisions: make(map[Decision]int)}
+		s.intents[intent] = stats
+	}
+	stats.Decisions[decision]++
+	stats.Confidences[bucket]++
+}
+
+// Snapshot returns a copy of the stats per intent
+func (s *Stats) Snapshot() map[string]IntentStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	snapshot := make(map[string]IntentStats, len(s.intents))
+	for intent, stats := range s.intents {
+		decisions := make(map[Decision]int, len(stats.Decisions))
+		for decision, count := range stats.Decisions {
+			decisions[decision] = count
+		}
+		snapshot[intent] = IntentStats{Decisions: decisions, Confidences: stats.Confidences}
+	}
+	return snapshot
+}
+
+// Reset clears the stats(but not the metrics, see Collectors). Useful when the stats are flushed periodically.
+func (s *Stats) Reset() {
+	s.mutex.Lock()
+	s.intents = make(map[string]*IntentStats)
+	s.mutex.Unlock()
+}
@@ -0,0 +1,100 @@
+package calibration
+
+import (
+	"sync"
+)
+
+type Decision string
+
+const (
+	Accept  Decision = "accept"
+	Confirm Decision = "confirm"
+	Reject  Decision = "reject"
+)
+
+// Thresholds on calibrated confidences. Confidences at or above Accept are accepted, those below Reject are
+// rejected and the ones in between need confirmation.
+//
+// Hysteresis keeps decisions from flip-flopping when confidences hover around a threshold across turns:
+// once a key has been accepted, it stays accepted until the confidence drops below Accept-Hysteresis, and
+// once rejected, it stays rejected until the confidence rises to Reject+Hysteresis.
+type Thresholds struct {
+	Accept     float64 `yaml:"accept" json:"accept"`
+	Reject     float64 `yaml:"reject" json:"reject"`
+	Hysteresis float64 `yaml:"hysteresis" json:"hysteresis"`
+}
+
+// Policy decides on calibrated confidences of a model and records the distribution of its decisions, as stats and
+// as metrics(see Collectors)
+type Policy struct {
+	model      string
+	thresholds Thresholds
+	mutex      sync.Mutex
+	// Last decision taken per key(eg. call ID + intent)
+	last  map[string]Decision
+	stats *Stats
+}
+
+// NewPolicy returns a policy using the thresholds for the confidences of the model(eg. "slu-v3")
+func NewPolicy(model string, thresholds Thresholds) *Policy {
+	return &Policy{
+		model:      model,
+		thresholds: thresholds,
+		last:       make(map[string]Decision),
+		stats:      NewStats(),
+	}
+}
+
+// Decide returns the decision for a confidence without any hysteresis
+func (p *Policy) Decide(intent string, confidence float64) Decision {
+	decision := decide(p.thresholds, "", confidence)
+	p.record(intent, decision, confidence)
+	return decision
+}
+
+// DecideFor returns the decision for a confidence taking the previous decision for the key into account.
+// Call Forget once the key(eg. a call) is done with.
+func (p *Policy) DecideFor(key, intent string, confidence float64) Decision {
+	p.mutex.Lock()
+	decision := decide(p.thresholds, p.last[key], confidence)
+	p.last[key] = decision
+	p.mutex.Unlock()
+
+	p.record(intent, decision, confidence)
+	return decision
+}
+
+// Forget drops the previous decision for the key
+func (p *Policy) Forget(key string) {
+	p.mutex.Lock()
+	delete(p.last, key)
+	p.mutex.Unlock()
+}
+
+// Stats returns the distribution of decisions taken by the policy
+func (p *Policy) Stats() *Stats {
+	return p.stats
+}
+
+func (p *Policy) record(intent string, decision Decision, confidence float64) {
+	p.stats.record(intent, decision, confidence)
+	decisions.WithLabelValues(intent, p.model, string(decision)).Inc()
+}
+
+func decide(t Thresholds, previous Decision, confidence float64) Decision {
+	accept, reject := t.Accept, t.Reject
+	switch previous {
+	case Accept:
+		accept -= t.Hysteresis
+	case Reject:
+		reject += t.Hysteresis
+	}
+
+	if confidence >= accept {
+		return Accept
+	}
+	if confidence < reject {
+		return Reject
+	}
+	return Confirm
+}
@@ -0,0 +1,94 @@
+// Package campaign provides the primitives used by dialers to execute outbound call campaigns: chunking of
+// contact lists, pacing calls against per-slot throughput targets, quiet hours and a retry-later queue for
+// unanswered calls with attempt caps.
+package campaign
+
+import (
+	"time"
+)
+
+// Contact to be called as part of a campaign
+type Contact struct {
+	ID         string            `json:"id"`
+	Phone      string            `json:"phone"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Chunk splits the contacts into chunks of at most size contacts each, retaining their order
+func Chunk(contacts []Contact, size int) [][]Contact {
+	if size <= 0 {
+		size = len(contacts)
+	}
+
+	var chunks [][]Contact
+	for start := 0; start < len(contacts); start += size {
+		end := start + size
+		if end > len(contacts) {
+			end = len(contacts)
+		}
+		chunks = append(chunks, contacts[start:end])
+	}
+	return chunks
+}
+
+// QuietHours is a daily window(in a location) during which contacts must not be called.
+// Windows crossing midnight(eg. 21:00 to 09:00) are supported.
+type QuietHours struct {
+	// Offsets from midnight
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// NewQuietHours returns quiet hours between the times of the day given as "15:04"
+func NewQuietHours(start, end string, location *time.Location) (*QuietHours, error) {
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return nil, err
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		location = time.Local
+	}
+
+	return &QuietHours{
+		Start:    sinceMidnight(startTime),
+		End:      sinceMidnight(endTime),
+		Location: location,
+	}, nil
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+// Contains is true if t falls within the quiet hours
+func (q *QuietHours) Contains(t time.Time) bool {
+	if q == nil || q.Start == q.End {
+		return false
+	}
+
+	offset := sinceMidnight(t.In(q.Location))
+	if q.Start < q.End {
+		return offset >= q.Start && offset < q.End
+	}
+	return offset >= q.Start || offset < q.End
+}
+
+// NextAllowed returns t if it is outside the quiet hours, otherwise the time at which the quiet hours end
+func (q *QuietHours) NextAllowed(t time.Time) time.Time {
+	if !q.Contains(t) {
+		return t
+	}
+
+	local := t.In(q.Location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, q.Location)
+	end := midnight.Add(q.End)
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
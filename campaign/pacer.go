@@ -0,0 +1,85 @@
+package campaign
+
+import (
+	"sync"
+	"time"
+)
+
+// Slot is a window of time within which Target calls are to be placed
+type Slot struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Target int       `json:"target"`
+}
+
+// Pacer spreads the calls of each slot evenly over the slot so that the slot meets its target without
+// bursting at its start
+type Pacer struct {
+	mutex      sync.Mutex
+	slots      []Slot
+	dispatched []int
+}
+
+func NewPacer(slots ...Slot) *Pacer {
+	return &Pacer{
+		slots:      slots,
+		dispatched: make([]int, len(slots)),
+	}
+}
+
+// Allowance returns the number of calls that can be dispatched at the given time to stay on target.
+// Returns 0 outside of the slots.
+func (p *Pacer) Allowance(now time.Time) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	i := p.slot(now)
+	if i < 0 {
+		return 0
+	}
+
+	slot := p.slots[i]
+	elapsed := now.Sub(slot.Start)
+	duration := slot.End.Sub(slot.Start)
+
+	// Calls due by now if the target were spread evenly across the slot, counting the one due at the start of the
+	// slot so that the first call is allowed right away
+	due := int(int64(slot.Target)*int64(elapsed)/int64(duration)) + 1
+	if due > slot.Target {
+		due = slot.Target
+	}
+	if allowance := due - p.dispatched[i]; allowance > 0 {
+		return allowance
+	}
+	return 0
+}
+
+// Record marks calls as dispatched in the slot at the given time
+func (p *Pacer) Record(now time.Time, calls int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if i := p.slot(now); i >= 0 {
+		p.dispatched[i] += calls
+	}
+}
+
+// Dispatched returns the number of calls dispatched in each slot
+func (p *Pacer) Dispatched() []int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	dispatched := make([]int, len(p.dispatched))
+	copy(dispatched, p.dispatched)
+	return dispatched
+}
+
+// Index of the slot containing the time or -1
+func (p *Pacer) slot(now time.Time) int {
+	for i, slot := range p.slots {
+		if !now.Before(slot.Start) && now.Before(slot.End) {
+			return i
+		}
+	}
+	return -1
+}
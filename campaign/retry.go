@@ -0,0 +1,124 @@
+package campaign
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// RetryPolicy decides when unanswered contacts are called again.
+// Backoff[i] is the wait before attempt i+2, the last value being reused for subsequent attempts.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     []time.Duration
+	QuietHours  *QuietHours
+}
+
+func (p RetryPolicy) wait(attempts int) time.Duration {
+	if len(p.Backoff) == 0 {
+		return 0
+	}
+	if attempts-1 < len(p.Backoff) {
+		return p.Backoff[attempts-1]
+	}
+	return p.Backoff[len(p.Backoff)-1]
+}
+
+// Retry is a contact scheduled to be called again
+type Retry struct {
+	Contact     Contact   `json:"contact"`
+	Attempts    int       `json:"attempts"`
+	NextAt      time.Time `json:"next_at"`
+	LastOutcome string    `json:"last_outcome"`
+}
+
+// RetryQueue holds the contacts to be called again ordered by when they are due
+type RetryQueue struct {
+	mutex    sync.Mutex
+	policy   RetryPolicy
+	retries  retryHeap
+	attempts map[string]int
+}
+
+func NewRetryQueue(policy RetryPolicy) *RetryQueue {
+	return &RetryQueue{
+		policy:   policy,
+		attempts: make(map[string]int),
+	}
+}
+
+// Schedule records an unsuccessful attempt on the contact and queues it to be called again as per the policy.
+// Returns false(and forgets the contact) if the contact has exhausted its attempts.
+func (q *RetryQueue) Schedule(contact Contact, outcome string, now time.Time) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.attempts[contact.ID]++
+	attempts := q.attempts[contact.ID]
+	if q.policy.MaxAttempts > 0 && attempts >= q.policy.MaxAttempts {
+		delete(q.attempts, contact.ID)
+		return false
+	}
+
+	next := now.Add(q.policy.wait(attempts))
+	if q.policy.QuietHours != nil {
+		next = q.policy.QuietHours.NextAllowed(next)
+	}
+
+	heap.Push(&q.retries, &Retry{
+		Contact:     contact,
+		Attempts:    attempts,
+		NextAt:      next,
+		LastOutcome: outcome,
+	})
+	return true
+}
+
+// Done forgets the attempts made on a contact once it has been reached
+func (q *RetryQueue) Done(contactID string) {
+	q.mutex.Lock()
+	delete(q.attempts, contactID)
+	q.mutex.Unlock()
+}
+
+// Due removes and returns at most limit(all if limit <= 0) retries due at the given time.
+// Nothing is due during quiet hours.
+func (q *RetryQueue) Due(now time.Time, limit int) []Retry {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.policy.QuietHours.Contains(now) {
+		return nil
+	}
+
+	var due []Retry
+	for q.retries.Len() > 0 && !q.retries[0].NextAt.After(now) {
+		if limit > 0 && len(due) >= limit {
+			break
+		}
+		due = append(due, *heap.Pop(&q.retries).(*Retry))
+	}
+	return due
+}
+
+// Len returns the number of retries queued
+func (q *RetryQueue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.retries.Len()
+}
+
+// Min-heap of retries ordered by NextAt
+type retryHeap []*Retry
+
+func (h retryHeap) Len() int            { return len(h) }
+func (h retryHeap) Less(i, j int) bool  { return h[i].NextAt.Before(h[j].NextAt) }
+func (h retryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *retryHeap) Push(x interface{}) { *h = append(*h, x.(*Retry)) }
+func (h *retryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
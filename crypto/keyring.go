@@ -0,0 +1,306 @@
+package crypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"iter"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+// Key is a version of a secret(eg. a JWT signing key, a webhook secret or an encryption key) identified by its ID
+type Key struct {
+	ID     string
+	Secret []byte
+	// Created is the time the key was created at, for the rotation reminders. Keys without it are never due.
+	Created time.Time
+}
+
+// Keyring holds the versions of a secret: data is signed and encrypted with the latest key, and verified and decrypted
+// with any of the keys, so that keys can be rotated without breaking the data signed or encrypted with the previous
+// ones. Eg.
+//
+//	keys, err := crypto.ParseKeys(os.Getenv("WEBHOOK_SECRETS"))
+//	ring, err := crypto.NewKeyring(keys)
+//	id, signature := ring.Sign(body)
+//	...
+//	ok := ring.Verify(id, body, signature)
+type Keyring struct {
+	mutex sync.RWMutex
+	// Oldest to latest
+	keys  []Key
+	clock simulation.Clock
+}
+
+// KeyringOption configures a Keyring
+type KeyringOption func(*Keyring)
+
+// WithClock configures the clock of the rotation reminders, eg. a simulation.Simulation in tests. Defaults to
+// simulation.Real.
+func WithClock(clock simulation.Clock) KeyringOption {
+	return func(k *Keyring) {
+		k.clock = clock
+	}
+}
+
+// NewKeyring returns a keyring of the keys, from the oldest to the latest. Returns an error if there are no keys, or
+// if any of them has no ID, no secret or the ID of another.
+func NewKeyring(keys []Key, opts ...KeyringOption) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.NewError("A keyring needs at least a key", nil, false)
+	}
+
+	ring := &Keyring{clock: simulation.Real}
+	for _, opt := range opts {
+		opt(ring)
+	}
+	for _, key := range keys {
+		if err := ring.Add(key); err != nil {
+			return nil, err
+		}
+	}
+	return ring, nil
+}
+
+// ParseKeys parses comma separated keys of the form `<id>:<base64 secret>[@<date created>]`, from the oldest to the
+// latest. Eg. "2024-01:c2VjcmV0@2024-01-01,2024-04:bmV3ZXI=@2024-04-01"
+func ParseKeys(keys string) ([]Key, error) {
+	var parsed []Key
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		id, rest, ok := strings.Cut(key, ":")
+		if !ok {
+			return nil, errors.NewError("Key "+id+" has no secret", nil, false)
+		}
+		secret, created, _ := strings.Cut(rest, "@")
+		decoded, err := base64.StdEncoding.DecodeString(secret)
+		if err != nil {
+			return nil, errors.NewError("Key "+id+" has an invalid secret", err, false)
+		}
+
+		parsed = append(parsed, Key{ID: id, Secret: decoded})
+		if created != "" {
+			if parsed[len(parsed)-1].Created, err = time.Parse(time.DateOnly, created); err != nil {
+				return nil, errors.NewError("Key "+id+" has an invalid date", err, false)
+			}
+		}
+	}
+	return parsed, nil
+}
+
+// Add adds a key as the latest, rotating the keys. The data signed or encrypted with the previous keys is still
+// accepted until they are retired. IDs are up to 255 bytes, as they are prefixed to the data encrypted.
+func (k *Keyring) Add(key Key) error {
+	if key.ID == "" || len(key.ID) > 255 || len(key.Secret) == 0 {
+		return errors.NewError("Keys need an ID(of up to 255 bytes) and a secret", nil, false)
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	for _, existing := range k.keys {
+		if existing.ID == key.ID {
+			return errors.NewError("Key "+key.ID+" is in the keyring already", nil, false)
+		}
+	}
+	k.keys = append(k.keys, key)
+	return nil
+}
+
+// Retire removes a key, once no data signed or encrypted with it is left(see Reencrypt). The latest key cannot be
+// retired.
+func (k *Keyring) Retire(id string) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	for i, key := range k.keys {
+		if key.ID != id {
+			continue
+		}
+		if i == len(k.keys)-1 {
+			return errors.NewError("The latest key cannot be retired", nil, false)
+		}
+		k.keys = append(k.keys[:i:i], k.keys[i+1:]...)
+		return nil
+	}
+	return nil
+}
+
+// Latest returns the key data is signed and encrypted with
+func (k *Keyring) Latest() Key {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.keys[len(k.keys)-1]
+}
+
+// Key returns the key with the ID, if it is not retired
+func (k *Keyring) Key(id string) (Key, bool) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// Sign returns the HMAC-SHA256 of the message with the latest key, along with the ID of the key(eg. for the kid of a
+// JWT or a header of a webhook)
+func (k *Keyring) Sign(message []byte) (id string, signature []byte) {
+	key := k.Latest()
+	return key.ID, mac(key.Secret, message)
+}
+
+// Verify is true if the signature is the HMAC-SHA256 of the message with the key of the ID. Every key is tried if the
+// ID is empty, for the signatures without one.
+func (k *Keyring) Verify(id string, message, signature []byte) bool {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	for _, key := range k.keys {
+		if (id == "" || key.ID == id) && hmac.Equal(mac(key.Secret, message), signature) {
+			return true
+		}
+	}
+	return false
+}
+
+func mac(secret, message []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+// Encrypt encrypts the data with AES-GCM(AES-256 for 32 byte secrets) under the latest key. The ID of the key is
+// prefixed to the data, to decrypt it once the keys are rotated.
+func (k *Keyring) Encrypt(data []byte) ([]byte, error) {
+	key := k.Latest()
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.NewError("Could not generate a nonce", err, false)
+	}
+
+	sealed := append([]byte{byte(len(key.ID))}, key.ID...)
+	sealed = append(sealed, nonce...)
+	return gcm.Seal(sealed, nonce, data, nil), nil
+}
+
+// Decrypt decrypts the data encrypted by Encrypt with any of the keys
+func (k *Keyring) Decrypt(data []byte) ([]byte, error) {
+	id, rest, err := splitKeyID(data)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := k.Key(id)
+	if !ok {
+		return nil, errors.NewError("Data is encrypted with the unknown key "+id, nil, false)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) < gcm.NonceSize() {
+		return nil, errors.NewError("Encrypted data is too short", nil, false)
+	}
+	decrypted, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.NewError("Could not decrypt the data", err, false)
+	}
+	return decrypted, nil
+}
+
+// EncryptedWith returns the ID of the key the data was encrypted with
+func EncryptedWith(data []byte) (string, error) {
+	id, _, err := splitKeyID(data)
+	return id, err
+}
+
+func splitKeyID(data []byte) (string, []byte, error) {
+	if len(data) == 0 || len(data) < 1+int(data[0]) {
+		return "", nil, errors.NewError("Encrypted data has no key ID", nil, false)
+	}
+	end := 1 + int(data[0])
+	return string(data[1:end]), data[end:], nil
+}
+
+func newGCM(key Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.Secret)
+	if err != nil {
+		return nil, errors.NewError("Key "+key.ID+" is not an AES key", err, false)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.NewError("Could not create the cipher of key "+key.ID, err, false)
+	}
+	return gcm, nil
+}
+
+// Reencrypt re-encrypts the values(keyed by their IDs) which are not encrypted with the latest key, storing them
+// through the function, eg. from a background job after a rotation. Stops at the first error, or once the context is
+// done. Returns the number of values re-encrypted.
+func (k *Keyring) Reencrypt(ctx context.Context, values iter.Seq2[string, []byte], store func(ctx context.Context, id string, value []byte) error) (int, error) {
+	latest := k.Latest().ID
+	reencrypted := 0
+	for id, value := range values {
+		if err := ctx.Err(); err != nil {
+			return reencrypted, err
+		}
+		if keyID, err := EncryptedWith(value); err == nil && keyID == latest {
+			continue
+		}
+
+		decrypted, err := k.Decrypt(value)
+		if err != nil {
+			return reencrypted, errors.NewError("Could not decrypt "+id, err, false)
+		}
+		encrypted, err := k.Encrypt(decrypted)
+		if err != nil {
+			return reencrypted, err
+		}
+		if err := store(ctx, id, encrypted); err != nil {
+			return reencrypted, errors.NewError("Could not store "+id, err, false)
+		}
+		reencrypted++
+	}
+	return reencrypted, nil
+}
+
+// Due is true if the latest key is older than the age, and is to be rotated
+func (k *Keyring) Due(age time.Duration) bool {
+	latest := k.Latest()
+	return !latest.Created.IsZero() && k.clock.Since(latest.Created) >= age
+}
+
+// RemindRotation calls the function with the latest key every interval while it is older than the age(see Due), eg.
+// to alert the owners of the secret, until the context is done
+func (k *Keyring) RemindRotation(ctx context.Context, age, interval time.Duration, remind func(latest Key)) {
+	for {
+		if k.Due(age) {
+			remind(k.Latest())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-k.clock.After(interval):
+		}
+	}
+}
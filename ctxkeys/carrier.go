@@ -0,0 +1,105 @@
+package ctxkeys
+
+import (
+	"context"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Carrier is a serializable copy of the observability values of a context(trace, request ID, tenant, principal),
+// to be restored across an async hop: in a worker goroutine, from the payload of a job or from the headers of a
+// message. It is a map of strings, so that it can be set as headers or marshalled as JSON as is.
+type Carrier map[string]string
+
+const (
+	principalID    = "principal.id"
+	principalEmail = "principal.email"
+)
+
+var (
+	carriedMutex sync.RWMutex
+	carried      = []*Key[string]{RequestID, Tenant}
+)
+
+// Carry adds keys of strings to the ones carried by Inject(the request ID and the tenant by default)
+func Carry(keys ...*Key[string]) {
+	carriedMutex.Lock()
+	defer carriedMutex.Unlock()
+
+	carried = append(carried, keys...)
+}
+
+func carriedKeys() []*Key[string] {
+	carriedMutex.RLock()
+	defer carriedMutex.RUnlock()
+
+	return carried
+}
+
+// Inject copies the carried values of the context, the principal and the trace(OpenTelemetry's traceparent and
+// Sentry's sentry-trace and baggage) into a carrier
+func Inject(ctx context.Context) Carrier {
+	carrier := Carrier{}
+	for _, key := range carriedKeys() {
+		if value, ok := key.Get(ctx); ok {
+			carrier[key.Name()] = value
+		}
+	}
+
+	if principal, ok := Principal.Get(ctx); ok {
+		carrier[principalID] = principal.ID
+		if principal.Email != "" {
+			carrier[principalEmail] = principal.Email
+		}
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(carrier))
+
+	if span := sentry.SpanFromContext(ctx); span != nil {
+		carrier[sentry.SentryTraceHeader] = span.ToSentryTrace()
+		carrier[sentry.SentryBaggageHeader] = span.ToBaggage()
+	} else if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		carrier[sentry.SentryTraceHeader] = hub.GetTraceparent()
+		carrier[sentry.SentryBaggageHeader] = hub.GetBaggage()
+	}
+	return carrier
+}
+
+// Extract restores the values of the carrier on the context. The Sentry trace is continued on a clone of the global
+// hub, so that the events and transactions of the hop are part of the trace of the request which started it.
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	for _, key := range carriedKeys() {
+		if value, ok := carrier[key.Name()]; ok {
+			ctx = key.With(ctx, value)
+		}
+	}
+
+	if id, ok := carrier[principalID]; ok {
+		ctx = Principal.With(ctx, Identity{ID: id, Email: carrier[principalEmail]})
+	}
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+
+	if trace := carrier[sentry.SentryTraceHeader]; trace != "" {
+		hub := sentry.CurrentHub().Clone()
+		if propagationContext, err := sentry.PropagationContextFromHeaders(trace, carrier[sentry.SentryBaggageHeader]); err == nil {
+			hub.Scope().SetPropagationContext(propagationContext)
+		}
+		ctx = sentry.SetHubOnContext(ctx, hub)
+	}
+	return ctx
+}
+
+// Detach returns a context carrying the values of the context(as is, the logger and a clone of the hub included)
+// which is not canceled along with it, for a goroutine outliving the request which starts it
+func Detach(ctx context.Context) context.Context {
+	detached := context.WithoutCancel(ctx)
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		// The goroutine gets its own scope, so that it does not race with the request on the hub
+		detached = sentry.SetHubOnContext(detached, hub.Clone())
+	}
+	return detached
+}
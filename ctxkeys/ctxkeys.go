@@ -0,0 +1,125 @@
+// Package ctxkeys defines the typed keys of everything vcore stores on a context, so that packages share a single
+// key per value instead of defining their own(and colliding, or missing each other's values).
+//
+//	ctx = ctxkeys.RequestID.With(ctx, id)
+//	id, ok := ctxkeys.RequestID.Get(ctx)
+package ctxkeys
+
+import (
+	"context"
+	"net/netip"
+	"sort"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/latency"
+	"github.com/skit-ai/vcore/log/slog"
+)
+
+// Identity of the authenticated caller(principal) of a request
+type Identity struct {
+	ID     string
+	Email  string
+	Extras map[string]string
+}
+
+// Key of a value of type T on a context
+type Key[T any] struct {
+	name string
+}
+
+var (
+	mutex    sync.RWMutex
+	registry = make(map[string]func(ctx context.Context) (interface{}, bool))
+)
+
+// NewKey returns a key of the name, registered to be listed by Snapshot. Names are unique, packages outside vcore
+// are to prefix them with their own name. Panics if the name is already registered.
+func NewKey[T any](name string) *Key[T] {
+	key := &Key[T]{name: name}
+	register(name, func(ctx context.Context) (interface{}, bool) {
+		return key.Get(ctx)
+	})
+	return key
+}
+
+func register(name string, lookup func(ctx context.Context) (interface{}, bool)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, exists := registry[name]; exists {
+		panic("ctxkeys: the key " + name + " is already registered")
+	}
+	registry[name] = lookup
+}
+
+// Name of the key
+func (k *Key[T]) Name() string {
+	return k.name
+}
+
+// With returns a copy of the context carrying the value
+func (k *Key[T]) With(ctx context.Context, value T) context.Context {
+	return context.WithValue(ctx, k, value)
+}
+
+// Get returns the value on the context, and false if there is none
+func (k *Key[T]) Get(ctx context.Context) (T, bool) {
+	value, ok := ctx.Value(k).(T)
+	return value, ok
+}
+
+// Value returns the value on the context, or the zero value of T if there is none
+func (k *Key[T]) Value(ctx context.Context) T {
+	value, _ := k.Get(ctx)
+	return value
+}
+
+// Keys of vcore
+var (
+	RequestID = NewKey[string]("request_id")
+	Tenant    = NewKey[string]("tenant")
+	Principal = NewKey[Identity]("principal")
+	Logger    = NewKey[slog.Logger]("logger")
+	Budget    = NewKey[latency.Budget]("budget")
+	ClientIP  = NewKey[netip.Addr]("client_ip")
+)
+
+func init() {
+	// The hub is stored by sentry-go under its own key, which is shared rather than duplicated
+	register("sentry.hub", func(ctx context.Context) (interface{}, bool) {
+		hub := sentry.GetHubFromContext(ctx)
+		return hub, hub != nil
+	})
+}
+
+// Hub returns the sentry hub on the context(nil if there is none)
+func Hub(ctx context.Context) *sentry.Hub {
+	return sentry.GetHubFromContext(ctx)
+}
+
+// WithHub returns a copy of the context carrying the sentry hub
+func WithHub(ctx context.Context, hub *sentry.Hub) context.Context {
+	return sentry.SetHubOnContext(ctx, hub)
+}
+
+// Entry of a Snapshot
+type Entry struct {
+	Key   string
+	Value interface{}
+}
+
+// Snapshot lists the values of the registered keys on the context sorted by their keys, for debugging
+func Snapshot(ctx context.Context) []Entry {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var entries []Entry
+	for name, lookup := range registry {
+		if value, ok := lookup(ctx); ok {
+			entries = append(entries, Entry{Key: name, Value: value})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
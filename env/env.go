@@ -3,6 +3,7 @@ package env
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 // Bool looks up for boolean env variables and returns it.
@@ -60,3 +61,18 @@ func Float(key string, fallback float64) float64 {
 
 	return parseFloat
 }
+
+// Duration looks up for a time.Duration env variables(eg. "2s", "500ms") and returns it.
+func Duration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+
+	parseDuration, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback
+	}
+
+	return parseDuration
+}
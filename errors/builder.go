@@ -0,0 +1,128 @@
+package errors
+
+import (
+	_err "github.com/pkg/errors"
+)
+
+// Builder builds an annotated error in a single expression(see E)
+type Builder struct {
+	rung rung
+}
+
+// E starts building an error with the message, eg.
+//
+//	return errors.E("Could not find the call").
+//		Code(errors.NotFound).
+//		Tag("service", "dialogue").
+//		Extra("call_id", id).
+//		Wrap(err).
+//		Err()
+func E(msg string) *Builder {
+	return &Builder{rung: rung{msg: msg}}
+}
+
+// Wrap sets the cause of the error. The fatality of the cause is retained unless the error is marked Fatal.
+func (b *Builder) Wrap(cause error) *Builder {
+	b.rung.cause = cause
+	return b
+}
+
+// Code sets the code of the error(see WithCode)
+func (b *Builder) Code(code ErrorCode) *Builder {
+	b.rung.errorCode = code
+	return b
+}
+
+// HTTPStatus sets the status of the HTTP responses to the error(see WithHTTPStatus)
+func (b *Builder) HTTPStatus(status int) *Builder {
+	b.rung.code = status
+	return b
+}
+
+// Tag sets a tag of the error
+func (b *Builder) Tag(key, value string) *Builder {
+	if b.rung.tags == nil {
+		b.rung.tags = make(map[string]string)
+	}
+	b.rung.tags[key] = value
+	return b
+}
+
+// Tags sets the tags of the error
+func (b *Builder) Tags(tags map[string]string) *Builder {
+	for key, value := range tags {
+		b.Tag(key, value)
+	}
+	return b
+}
+
+// Extra sets an extra of the error
+func (b *Builder) Extra(key string, value interface{}) *Builder {
+	if b.rung.extras == nil {
+		b.rung.extras = make(map[string]interface{})
+	}
+	b.rung.extras[key] = value
+	return b
+}
+
+// Extras sets the extras of the error
+func (b *Builder) Extras(extras map[string]interface{}) *Builder {
+	for key, value := range extras {
+		b.Extra(key, value)
+	}
+	return b
+}
+
+// Severity sets the severity of the error(see WithSeverity)
+func (b *Builder) Severity(severity SeverityLevel) *Builder {
+	b.rung.severity = severity
+	return b
+}
+
+// Category sets the category of the error(see WithCategory)
+func (b *Builder) Category(category Category) *Builder {
+	b.rung.category = category
+	return b
+}
+
+// Fingerprint sets the parts of the fingerprint grouping the error in Sentry(see WithFingerprint)
+func (b *Builder) Fingerprint(parts ...string) *Builder {
+	b.rung.fingerprint = append([]string(nil), parts...)
+	return b
+}
+
+// Fatal marks the error as fatal
+func (b *Builder) Fatal() *Builder {
+	b.rung.fatal = true
+	return b
+}
+
+// Ignore marks the error to be ignored(see NewErrorToIgnore)
+func (b *Builder) Ignore() *Builder {
+	b.rung.ignore = true
+	return b
+}
+
+// Err returns the error built, with the stack of the call. The builder can be reused, the errors returned do not
+// share their tags or extras.
+func (b *Builder) Err() error {
+	err := b.rung
+	err.fatal = err.fatal || Fatal(err.cause)
+	err.tags = copyMap(err.tags)
+	err.extras = copyMap(err.extras)
+	if err.fingerprint != nil {
+		err.fingerprint = append([]string(nil), err.fingerprint...)
+	}
+	return _err.WithStack(&err)
+}
+
+func copyMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]V, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,117 @@
+package errors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Category of an error in the taxonomy the error budgets are broken down by
+type Category string
+
+const (
+	// CategoryDependency is a failure of a dependency, eg. a database or an upstream service being unavailable
+	CategoryDependency Category = "dependency"
+	// CategoryValidation is an invalid request or input
+	CategoryValidation Category = "validation"
+	// CategoryAuth is a request failing authentication or authorization
+	CategoryAuth Category = "auth"
+	// CategoryTimeout is a deadline exceeded, of a context or a network call
+	CategoryTimeout Category = "timeout"
+	// CategoryInternal is a bug or any other failure of the service itself
+	CategoryInternal Category = "internal"
+)
+
+// Categories of the codes which are not internal
+var codeCategories = map[ErrorCode]Category{
+	InvalidArgument:    CategoryValidation,
+	OutOfRange:         CategoryValidation,
+	FailedPrecondition: CategoryValidation,
+	Unauthenticated:    CategoryAuth,
+	PermissionDenied:   CategoryAuth,
+	DeadlineExceeded:   CategoryTimeout,
+	Unavailable:        CategoryDependency,
+}
+
+// WithCategory wraps an error with its category, eg.
+//
+//	errors.WithCategory(err, errors.CategoryDependency)
+//
+// Returns nil if the error is nil.
+func WithCategory(err error, category Category) error {
+	if err == nil {
+		return nil
+	}
+
+	return wrap(err, func(e *rung) {
+		e.category = category
+	})
+}
+
+// CategoryOf returns the category set on the error. The category closest to the top of the stack wins.
+// Errors without a category are categorized by their code(see CodeOf), the code of the gRPC status they are caused by
+// or their HTTP status(see WithHTTPStatus): timeouts if they are deadlines(see IsDeadline), validation for invalid
+// requests, auth for unauthenticated or denied requests, dependency failures if unavailable(eg. 502 or 503) and
+// internal otherwise. Returns an empty category if the error is nil.
+func CategoryOf(err error) Category {
+	if err == nil {
+		return ""
+	}
+
+	type categorized interface {
+		Category() Category
+	}
+
+	for e := err; e != nil; {
+		if check, ok := e.(categorized); ok {
+			if category := check.Category(); category != "" {
+				return category
+			}
+		}
+
+		// Going to the cause of the current error(if any)
+		cause, ok := e.(causer)
+		if !ok {
+			break
+		}
+
+		e = cause.Cause()
+	}
+
+	if IsDeadline(err) {
+		return CategoryTimeout
+	}
+	code := CodeOf(err)
+	if code == Unknown {
+		if grpcCode := grpcCode(err); grpcCode != codes.OK {
+			for errorCode, c := range grpcCodes {
+				if c == grpcCode {
+					code = errorCode
+					break
+				}
+			}
+		}
+	}
+	if category, ok := codeCategories[code]; ok {
+		return category
+	}
+	if code == Unknown {
+		return statusCategory(responseCode(err))
+	}
+	return CategoryInternal
+}
+
+// Returns the category of the errors responded with the HTTP status
+func statusCategory(status int) Category {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return CategoryAuth
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return CategoryTimeout
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable:
+		return CategoryDependency
+	case status >= 400 && status < 500:
+		return CategoryValidation
+	}
+	return CategoryInternal
+}
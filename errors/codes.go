@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+)
+
+// ErrorCode is a machine readable code of an error, translated into the status codes of the gRPC(see ToGRPCStatus)
+// and HTTP responses of the error
+type ErrorCode string
+
+// Codes of the errors, as those of gRPC
+const (
+	Canceled           ErrorCode = "canceled"
+	Unknown            ErrorCode = "unknown"
+	InvalidArgument    ErrorCode = "invalid_argument"
+	DeadlineExceeded   ErrorCode = "deadline_exceeded"
+	NotFound           ErrorCode = "not_found"
+	AlreadyExists      ErrorCode = "already_exists"
+	PermissionDenied   ErrorCode = "permission_denied"
+	ResourceExhausted  ErrorCode = "resource_exhausted"
+	FailedPrecondition ErrorCode = "failed_precondition"
+	Aborted            ErrorCode = "aborted"
+	OutOfRange         ErrorCode = "out_of_range"
+	Unimplemented      ErrorCode = "unimplemented"
+	Internal           ErrorCode = "internal"
+	Unavailable        ErrorCode = "unavailable"
+	DataLoss           ErrorCode = "data_loss"
+	Unauthenticated    ErrorCode = "unauthenticated"
+)
+
+// WithCode wraps an error with its code, eg.
+//
+//	errors.WithCode(err, errors.NotFound)
+//
+// Returns nil if the error is nil.
+func WithCode(err error, code ErrorCode) error {
+	if err == nil {
+		return nil
+	}
+
+	return wrap(err, func(e *rung) {
+		e.errorCode = code
+	})
+}
+
+// CodeOf returns the code set on the error. The code closest to the top of the stack wins.
+// Errors without a code are Canceled(or DeadlineExceeded) if caused by the cancellation(or the deadline) of a context,
+// and Unknown otherwise. Returns an empty code if the error is nil.
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+
+	type coded interface {
+		ErrorCode() ErrorCode
+	}
+
+	for e := err; e != nil; {
+		if check, ok := e.(coded); ok {
+			if code := check.ErrorCode(); code != "" {
+				return code
+			}
+		}
+
+		// Going to the cause of the current error(if any)
+		cause, ok := e.(causer)
+		if !ok {
+			break
+		}
+
+		e = cause.Cause()
+	}
+
+	switch cause := DeepestCause(err); {
+	case stderrors.Is(cause, context.Canceled):
+		return Canceled
+	case stderrors.Is(cause, context.DeadlineExceeded):
+		return DeadlineExceeded
+	}
+	return Unknown
+}
@@ -0,0 +1,128 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Extra listing the messages of the errors combined
+const ExtraCombinedErrors = "combined_errors"
+
+// combined is the aggregate of errors, eg. of the items of a batch which failed
+type combined struct {
+	errs []error
+}
+
+func (c *combined) Error() string {
+	messages := make([]string, len(c.errs))
+	for i, err := range c.errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(c.errs), strings.Join(messages, "; "))
+}
+
+func (c *combined) Unwrap() []error {
+	return c.errs
+}
+
+// Tags merges the tags of the errors, the earlier errors winning over the later ones
+func (c *combined) Tags() (tags map[string]string) {
+	for _, err := range c.errs {
+		for k, v := range Tags(err) {
+			if tags == nil {
+				tags = make(map[string]string)
+			}
+			if _, exists := tags[k]; !exists {
+				tags[k] = v
+			}
+		}
+	}
+	return
+}
+
+// Extras merges the extras of the errors, the earlier errors winning over the later ones, along with the messages of
+// the errors(as ExtraCombinedErrors)
+func (c *combined) Extras() map[string]interface{} {
+	extras := make(map[string]interface{})
+	for _, err := range c.errs {
+		for k, v := range Extras(err) {
+			if _, exists := extras[k]; !exists {
+				extras[k] = v
+			}
+		}
+	}
+
+	messages := make([]string, len(c.errs))
+	for i, err := range c.errs {
+		messages[i] = err.Error()
+	}
+	extras[ExtraCombinedErrors] = messages
+	return extras
+}
+
+// Fatal if any of the errors is fatal
+func (c *combined) Fatal() bool {
+	for _, err := range c.errs {
+		if Fatal(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ignore if all the errors are to be ignored
+func (c *combined) Ignore() bool {
+	for _, err := range c.errs {
+		if !Ignore(err) {
+			return false
+		}
+	}
+	return true
+}
+
+var severityOrder = map[SeverityLevel]int{Warning: 0, Error: 1, Critical: 2}
+
+// Severity is the highest severity of the errors
+func (c *combined) Severity() SeverityLevel {
+	highest := Warning
+	for _, err := range c.errs {
+		if severity := Severity(err); severityOrder[severity] > severityOrder[highest] {
+			highest = severity
+		}
+	}
+	return highest
+}
+
+// Combine aggregates the errors into one, eg. to report the items of a batch which failed as a single Sentry event:
+//
+//	var errs []error
+//	for _, item := range batch {
+//		errs = append(errs, process(item))
+//	}
+//	return errors.Combine(errs...)
+//
+// The tags and extras of the combined error are merged from the errors(see Tags and Extras), it is fatal if any of the
+// errors is and has the highest severity of the errors. The errors are returned by its Unwrap() []error. Nil errors are
+// skipped. Returns nil if all the errors are nil, and the error itself if only one is not nil.
+func Combine(errs ...error) error {
+	var nonNil []error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		// Flattening the errors combined already
+		if c, ok := err.(*combined); ok {
+			nonNil = append(nonNil, c.errs...)
+		} else {
+			nonNil = append(nonNil, err)
+		}
+	}
+
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	}
+	return &combined{errs: nonNil}
+}
@@ -0,0 +1,44 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IsCanceled is true if the error is due to a cancellation, eg. of the context of a request the client hung up on:
+// context.Canceled anywhere in the stack, a gRPC status with the code Canceled(eg. returned by a client) or an error
+// with the code Canceled(see WithCode)
+func IsCanceled(err error) bool {
+	if err == nil {
+		return false
+	}
+	return CodeOf(err) == Canceled || stderrors.Is(err, context.Canceled) || grpcCode(err) == codes.Canceled
+}
+
+// IsDeadline is true if the error is due to a deadline exceeded: context.DeadlineExceeded anywhere in the stack, the
+// timeout of a network call(see net.Error), a gRPC status with the code DeadlineExceeded or an error with the code
+// DeadlineExceeded(see WithCode)
+func IsDeadline(err error) bool {
+	if err == nil {
+		return false
+	}
+	if CodeOf(err) == DeadlineExceeded || stderrors.Is(err, context.DeadlineExceeded) ||
+		stderrors.Is(err, os.ErrDeadlineExceeded) || grpcCode(err) == codes.DeadlineExceeded {
+		return true
+	}
+	netErr, ok := As[net.Error](err)
+	return ok && netErr.Timeout()
+}
+
+// Returns the code of the gRPC status in the chain of the error, codes.OK if there is none
+func grpcCode(err error) codes.Code {
+	if cause, ok := As[interface{ GRPCStatus() *status.Status }](err); ok {
+		return cause.GRPCStatus().Code()
+	}
+	return codes.OK
+}
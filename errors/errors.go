@@ -58,13 +58,20 @@ func Fatal(err error) (isFatal bool) {
 // - fatality interface from FSM
 // It represents a rung in the chain of errors leading to the cause.
 type rung struct {
-	msg    string
-	cause  error
-	fatal  bool
-	tags   map[string]string
-	extras map[string]interface{}
-	ignore bool
-	code   int
+	msg         string
+	cause       error
+	fatal       bool
+	tags        map[string]string
+	extras      map[string]interface{}
+	ignore      bool
+	code        int
+	fingerprint []string
+	severity    SeverityLevel
+	errorCode   ErrorCode
+	category    Category
+	kind        *Kind
+	// Messages safe to show to the users, by their locales("" for any locale)
+	userMessages map[string]string
 }
 
 func (e *rung) Error() (errorMsg string) {
@@ -83,6 +90,11 @@ func (e *rung) Cause() error {
 	return e.cause
 }
 
+// Implementing the Unwrap of the standard library, so that errors.Is and errors.As go through the chain
+func (e *rung) Unwrap() error {
+	return e.cause
+}
+
 func (e *rung) Fatal() bool {
 	return e.fatal
 }
@@ -103,6 +115,30 @@ func (e *rung) Code() int {
 	return e.code
 }
 
+func (e *rung) Fingerprint() []string {
+	return e.fingerprint
+}
+
+func (e *rung) Severity() SeverityLevel {
+	return e.severity
+}
+
+func (e *rung) ErrorCode() ErrorCode {
+	return e.errorCode
+}
+
+func (e *rung) Category() Category {
+	return e.category
+}
+
+func (e *rung) Kind() *Kind {
+	return e.kind
+}
+
+func (e *rung) UserMessages() map[string]string {
+	return e.userMessages
+}
+
 // Creates an error which is chained with a cause
 func NewError(_msg string, _cause error, _fatal bool) error {
 	return NewErrorWithTags(_msg, _cause, _fatal, nil)
@@ -170,60 +206,38 @@ type stackTracer interface {
 	StackTrace() _err.StackTrace
 }
 
-// AddTagsToError checks if the input error implements a causer interface. If it does,
-// it checks if the Cause is of internal type *rung. When this check also passes,
-// it adds the input _tags to the existing tags information. In case the checks fail,
-// the original error is returned.
-func AddTagsToError(err error, _tags map[string]string) error {
-	errCauser, ok := err.(causer)
-	if !ok || errCauser == nil {
-		return err
-	}
+// Wraps the error with a rung configured by the function. The rung retains the fatality of the cause, since Fatal
+// stops at the first error which implements it.
+func wrap(err error, configure func(*rung)) error {
+	e := &rung{cause: err, fatal: Fatal(err)}
+	configure(e)
+	return _err.WithStack(e)
+}
 
-	errAsRung, ok := errCauser.Cause().(*rung)
-	if !ok || errAsRung == nil {
+// AddTagsToError wraps the error with the tags, which are merged with the tags of the stack of the error as per the
+// strategy configured(see SetMergeStrategy). The tags of the error itself are not modified, so that the other
+// holders of the error do not see them change. Returns nil if the error is nil.
+func AddTagsToError(err error, _tags map[string]string) error {
+	if err == nil || len(_tags) == 0 {
 		return err
 	}
 
-	existingTags := errAsRung.Tags()
-	if existingTags == nil {
-		existingTags = make(map[string]string)
-	}
-
-	for key, value := range _tags {
-		existingTags[key] = value
-	}
-
-	errAsRung.tags = existingTags
-	return errAsRung
+	return wrap(err, func(e *rung) {
+		e.tags = _tags
+	})
 }
 
-// AddExtrasToError checks if the input error implements a causer interface. If it does,
-// it checks if the Cause is of internal type *rung. When this check also passes,
-// it adds the input _extras to the existing extras information. In case the checks fail,
-// the original error is returned.
+// AddExtrasToError wraps the error with the extras, which are merged with the extras of the stack of the error as per
+// the strategy configured(see SetMergeStrategy). The extras of the error itself are not modified, so that the other
+// holders of the error do not see them change. Returns nil if the error is nil.
 func AddExtrasToError(err error, _extras map[string]interface{}) error {
-	errCauser, ok := err.(causer)
-	if !ok || errCauser == nil {
-		return err
-	}
-
-	errAsRung, ok := errCauser.Cause().(*rung)
-	if !ok || errAsRung == nil {
+	if err == nil || len(_extras) == 0 {
 		return err
 	}
 
-	existingExtras := errAsRung.Extras()
-	if existingExtras == nil {
-		existingExtras = make(map[string]interface{})
-	}
-
-	for key, value := range _extras {
-		existingExtras[key] = value
-	}
-
-	errAsRung.extras = existingExtras
-	return errAsRung
+	return wrap(err, func(e *rung) {
+		e.extras = _extras
+	})
 }
 
 // Determines the stacktrace of an error.
@@ -270,83 +284,31 @@ type causer interface {
 	Cause() error
 }
 
+// Tags returns the tags of the errors in the stack, the values set by more than one error merged with the strategy
+// configured(see SetMergeStrategy), by default the highest error in the stack winning
 func Tags(err error) (cumulativeTags map[string]string) {
-	type tagged interface {
-		Tags() map[string]string
-	}
-
-	// Keep going through all the errors in the stack and make a cumulative map of all the tags
-	for err != nil {
-		if check, ok := err.(tagged); ok {
-			tagsSet := check.Tags()
-			if tagsSet != nil {
-				for k, v := range tagsSet {
-					if cumulativeTags == nil {
-						cumulativeTags = make(map[string]string)
-					}
-					// The highest error in the stack overrides the tag value set by the lower error in the stack
-					if _, exists := cumulativeTags[k]; !exists {
-						cumulativeTags[k] = v
-					}
-				}
-			}
-		}
-
-		// Going to the cause of the current error(if any)
-		cause, ok := err.(causer)
-		if !ok {
-			// Since there is no cause of the current error, it is the root error(original error) that caused the issue
-			// in the first place. Hence breaking the loop.
-			break
-		}
-
-		err = cause.Cause()
-	}
-
-	return
+	return TagsWithStrategy(err, MergeStrategy(mergeStrategy.Load()))
 }
 
+// Extras returns the extras of the errors in the stack, the values set by more than one error merged with the strategy
+// configured(see SetMergeStrategy), by default the highest error in the stack winning. The values of the sensitive
+// extras(see AddSensitiveExtra) are redacted.
 func Extras(err error) (cumulativeExtras map[string]interface{}) {
-	type extra interface {
-		Extras() map[string]interface{}
-	}
-
-	// Keep going through all the errors in the stack and make a cumulative map of all the tags
-	for err != nil {
-		if check, ok := err.(extra); ok {
-			extrasSet := check.Extras()
-			if extrasSet != nil {
-				for k, v := range extrasSet {
-					if cumulativeExtras == nil {
-						cumulativeExtras = make(map[string]interface{})
-					}
-					// The highest error in the stack overrides the tag value set by the lower error in the stack
-					if _, exists := cumulativeExtras[k]; !exists {
-						cumulativeExtras[k] = v
-					}
-				}
-			}
-		}
-
-		// Going to the cause of the current error(if any)
-		cause, ok := err.(causer)
-		if !ok {
-			// Since there is no cause of the current error, it is the root error(original error) that caused the issue
-			// in the first place. Hence breaking the loop.
-			break
-		}
-
-		err = cause.Cause()
-	}
-
-	return
+	return ExtrasWithStrategy(err, MergeStrategy(mergeStrategy.Load()))
 }
 
+// Ignore is true if any error in the stack is to be ignored(see NewErrorToIgnore), or if any of the matchers registered
+// through RegisterIgnorable matches the error
 func Ignore(err error) bool {
 	type ignore interface {
 		Ignore() bool
 	}
 
+	if err == nil {
+		return false
+	}
+	original := err
+
 	// Keep going through all the errors in the stack and find if any error is supposed to be ignored
 	for err != nil {
 		if check, ok := err.(ignore); ok {
@@ -367,7 +329,7 @@ func Ignore(err error) bool {
 		err = cause.Cause()
 	}
 
-	return false
+	return ignorable(original)
 }
 
 // Finds the deepest non-nil cause
@@ -411,6 +373,18 @@ func Code(err error, defaultCode int) (code int) {
 		return http.StatusOK
 	}
 
+	if code = responseCode(err); code <= 0 {
+		if defaultCode <= 0 {
+			code = http.StatusInternalServerError
+		} else {
+			code = defaultCode
+		}
+	}
+	return
+}
+
+// Returns the first non-zero code in the stack of the error, 0 if there is none
+func responseCode(err error) (code int) {
 	type errorCode interface {
 		Code() int
 	}
@@ -435,13 +409,5 @@ func Code(err error, defaultCode int) (code int) {
 
 		err = cause.Cause()
 	}
-
-	if code <= 0 {
-		if defaultCode <= 0 {
-			code = http.StatusInternalServerError
-		} else {
-			code = defaultCode
-		}
-	}
 	return
 }
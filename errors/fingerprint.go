@@ -0,0 +1,44 @@
+package errors
+
+// WithFingerprint wraps an error with the parts of the fingerprint used by Sentry to group it.
+// Errors with the same fingerprint are grouped into the same issue irrespective of their message or the
+// call site they were wrapped at. Eg.
+//
+//	errors.WithFingerprint(err, "slu", "timeout")
+//
+// Returns nil if the error is nil.
+func WithFingerprint(err error, parts ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	return wrap(err, func(e *rung) {
+		e.fingerprint = parts
+	})
+}
+
+// Fingerprint returns the fingerprint set on the error. The fingerprint closest to the top of the stack wins.
+// Returns nil if none of the errors in the stack carry a fingerprint.
+func Fingerprint(err error) []string {
+	type fingerprinted interface {
+		Fingerprint() []string
+	}
+
+	for err != nil {
+		if check, ok := err.(fingerprinted); ok {
+			if fingerprint := check.Fingerprint(); len(fingerprint) > 0 {
+				return fingerprint
+			}
+		}
+
+		// Going to the cause of the current error(if any)
+		cause, ok := err.(causer)
+		if !ok {
+			break
+		}
+
+		err = cause.Cause()
+	}
+
+	return nil
+}
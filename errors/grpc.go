@@ -0,0 +1,92 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var grpcCodes = map[ErrorCode]codes.Code{
+	Canceled:           codes.Canceled,
+	Unknown:            codes.Unknown,
+	InvalidArgument:    codes.InvalidArgument,
+	DeadlineExceeded:   codes.DeadlineExceeded,
+	NotFound:           codes.NotFound,
+	AlreadyExists:      codes.AlreadyExists,
+	PermissionDenied:   codes.PermissionDenied,
+	ResourceExhausted:  codes.ResourceExhausted,
+	FailedPrecondition: codes.FailedPrecondition,
+	Aborted:            codes.Aborted,
+	OutOfRange:         codes.OutOfRange,
+	Unimplemented:      codes.Unimplemented,
+	Internal:           codes.Internal,
+	Unavailable:        codes.Unavailable,
+	DataLoss:           codes.DataLoss,
+	Unauthenticated:    codes.Unauthenticated,
+}
+
+// ToGRPCStatus converts an error into the status returned by gRPC handlers, with the status code of its code(see
+// CodeOf) or of its kind(see RegisterKind). The code(or the name of the kind) and the tags of the error are attached
+// to the status as an ErrorInfo detail, its extras as a Struct detail and the message set for the users(see
+// WithUserMessage) as a LocalizedMessage detail. Errors caused by a status(eg. returned by a client, wrapped by any
+// package) keep the status code of their cause if they have no code of their own. Returns nil if the error is nil.
+func ToGRPCStatus(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+
+	code := CodeOf(err)
+	grpcCode, ok := grpcCodes[code]
+	if !ok {
+		grpcCode = codes.Unknown
+	}
+	if code == Unknown {
+		if cause, ok := As[interface{ GRPCStatus() *status.Status }](err); ok {
+			grpcCode = cause.GRPCStatus().Code()
+		}
+	}
+	reason := string(code)
+	if kind, ok := KindOf(err); ok {
+		reason = kind.Name
+		if kind.GRPCCode != codes.OK {
+			grpcCode = kind.GRPCCode
+		}
+	}
+
+	s := status.New(grpcCode, err.Error())
+	info := &errdetails.ErrorInfo{Reason: reason, Metadata: Tags(err)}
+	details := []protoiface.MessageV1{info}
+	if message, ok := UserMessage(err); ok {
+		details = append(details, &errdetails.LocalizedMessage{Message: message})
+	}
+	if extras := TruncatedExtras(err); len(extras) > 0 {
+		if structured, structErr := structpb.NewStruct(jsonValues(extras)); structErr == nil {
+			details = append(details, structured)
+		}
+	}
+	if withDetails, detailsErr := s.WithDetails(details...); detailsErr == nil {
+		s = withDetails
+	}
+	return s
+}
+
+// Returns the values as their JSON(as structpb takes only JSON values), the values which cannot be encoded as strings
+func jsonValues(values map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		data, err := json.Marshal(value)
+		if err == nil {
+			err = json.Unmarshal(data, &value)
+		}
+		if err != nil {
+			value = fmt.Sprint(value)
+		}
+		converted[key] = value
+	}
+	return converted
+}
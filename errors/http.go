@@ -0,0 +1,58 @@
+package errors
+
+import (
+	"net/http"
+)
+
+// Status of the responses to requests canceled by the clients, as nginx's
+const StatusClientClosedRequest = 499
+
+var httpStatuses = map[ErrorCode]int{
+	Canceled:           StatusClientClosedRequest,
+	Unknown:            http.StatusInternalServerError,
+	InvalidArgument:    http.StatusBadRequest,
+	DeadlineExceeded:   http.StatusGatewayTimeout,
+	NotFound:           http.StatusNotFound,
+	AlreadyExists:      http.StatusConflict,
+	PermissionDenied:   http.StatusForbidden,
+	ResourceExhausted:  http.StatusTooManyRequests,
+	FailedPrecondition: http.StatusBadRequest,
+	Aborted:            http.StatusConflict,
+	OutOfRange:         http.StatusBadRequest,
+	Unimplemented:      http.StatusNotImplemented,
+	Internal:           http.StatusInternalServerError,
+	Unavailable:        http.StatusServiceUnavailable,
+	DataLoss:           http.StatusInternalServerError,
+	Unauthenticated:    http.StatusUnauthorized,
+}
+
+// WithHTTPStatus wraps an error with the status of the HTTP responses to it, eg.
+//
+//	errors.WithHTTPStatus(err, http.StatusUnprocessableEntity)
+//
+// The status is the code of the error(see Code). Returns nil if the error is nil.
+func WithHTTPStatus(err error, status int) error {
+	if err == nil {
+		return nil
+	}
+
+	return wrap(err, func(e *rung) {
+		e.code = status
+	})
+}
+
+// HTTPStatus returns the status of the HTTP responses to the error: the status(or the code, see NewErrorWithCode) set
+// on it, or else the status of its code(see CodeOf), eg. 404 for NotFound, 400 for InvalidArgument, 499 for Canceled
+// and 504 for DeadlineExceeded. Errors without either are internal server errors. Returns 200 if the error is nil.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if status := responseCode(err); status > 0 {
+		return status
+	}
+	if status, ok := httpStatuses[CodeOf(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,85 @@
+package errors
+
+import (
+	stderrors "errors"
+	"sync"
+)
+
+// Matchers of the errors to ignore registered through RegisterIgnorable
+var ignorables struct {
+	sync.RWMutex
+	matchers map[int]func(err error) bool
+	next     int
+}
+
+// RegisterIgnorable registers a matcher of the errors to be ignored(see Ignore), eg. of the known noisy errors of an
+// upstream service:
+//
+//	errors.RegisterIgnorable(errors.IgnoreCode(errors.Canceled))
+//	errors.RegisterIgnorable(errors.IgnoreType[*websocket.CloseError]())
+//
+// Returns a function unregistering the matcher.
+func RegisterIgnorable(matcher func(err error) bool) (unregister func()) {
+	ignorables.Lock()
+	defer ignorables.Unlock()
+
+	if ignorables.matchers == nil {
+		ignorables.matchers = make(map[int]func(err error) bool)
+	}
+	id := ignorables.next
+	ignorables.next++
+	ignorables.matchers[id] = matcher
+
+	return func() {
+		ignorables.Lock()
+		defer ignorables.Unlock()
+		delete(ignorables.matchers, id)
+	}
+}
+
+// IgnoreCode matches the errors with any of the codes(see CodeOf)
+func IgnoreCode(codes ...ErrorCode) func(err error) bool {
+	return func(err error) bool {
+		code := CodeOf(err)
+		for _, c := range codes {
+			if code == c {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// IgnoreType matches the errors with an error of the type T in their chain(see As)
+func IgnoreType[T any]() func(err error) bool {
+	return func(err error) bool {
+		_, ok := As[T](err)
+		return ok
+	}
+}
+
+// IgnoreErrors matches the errors with any of the targets in their chain(see errors.Is of the standard library), eg.
+// io.ErrUnexpectedEOF of the clients hanging up
+func IgnoreErrors(targets ...error) func(err error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if stderrors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Returns if any of the matchers registered matches the error
+func ignorable(err error) bool {
+	ignorables.RLock()
+	defer ignorables.RUnlock()
+
+	for _, matcher := range ignorables.matchers {
+		if matcher(err) {
+			return true
+		}
+	}
+	return false
+}
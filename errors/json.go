@@ -0,0 +1,131 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Body is the machine readable body of the API responses to an error, eg.
+//
+//	{"code": "not_found", "message": "call not found", "details": {"call_id": "42"}, "trace_id": "..."}
+type Body struct {
+	Code ErrorCode `json:"code"`
+	// Kind is the name of the kind of the error(see RegisterKind), if any
+	Kind    string                 `json:"kind,omitempty"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	TraceID string                 `json:"trace_id,omitempty"`
+}
+
+type bodyOptions struct {
+	internalMessages bool
+	redactedMessage  string
+	locales          []string
+}
+
+// BodyOption configures the bodies of the errors
+type BodyOption func(*bodyOptions)
+
+// WithInternalMessages keeps the messages and details of the internal errors(responded 5xx) in their bodies, eg. for
+// internal APIs or in development. They are redacted by default, as they may leak the internals of a service.
+func WithInternalMessages() BodyOption {
+	return func(o *bodyOptions) {
+		o.internalMessages = true
+	}
+}
+
+// WithRedactedMessage configures the message replacing that of the internal errors, defaults to the text of their
+// status(eg. "Internal Server Error")
+func WithRedactedMessage(message string) BodyOption {
+	return func(o *bodyOptions) {
+		o.redactedMessage = message
+	}
+}
+
+// WithLocales configures the locales of the user messages(see WithUserMessages), in the order of preference
+func WithLocales(locales ...string) BodyOption {
+	return func(o *bodyOptions) {
+		o.locales = locales
+	}
+}
+
+// ToBody returns the body of the API responses to an error: its code(see CodeOf) and kind(see KindOf), its message
+// without those of its causes(eg. the errors of a driver), its extras(see TruncatedExtras) as the details and the ID
+// of the trace of the context(if any), so that the clients can report it. The messages and details of the internal
+// errors(see HTTPStatus) are redacted, unless configured with WithInternalMessages. The message set for the users(see
+// WithUserMessage) replaces the message of the error, internal or not. Returns nil if the error is nil.
+func ToBody(ctx context.Context, err error, opts ...BodyOption) *Body {
+	if err == nil {
+		return nil
+	}
+
+	var options bodyOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	body := &Body{Code: CodeOf(err), Message: message(err)}
+	if kind, ok := KindOf(err); ok {
+		body.Kind = kind.Name
+	}
+	if extras := TruncatedExtras(err); len(extras) > 0 {
+		body.Details = jsonValues(extras)
+	}
+	if status := HTTPStatus(err); status >= http.StatusInternalServerError && !options.internalMessages {
+		body.Message = options.redactedMessage
+		if body.Message == "" {
+			body.Message = http.StatusText(status)
+		}
+		body.Details = nil
+	}
+	if message, ok := UserMessage(err, options.locales...); ok {
+		body.Message = message
+	}
+	if ctx != nil {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+			body.TraceID = spanContext.TraceID().String()
+		}
+	}
+	return body
+}
+
+// Returns the message of the error at the top of the stack of the error, without the messages of its causes. The
+// errors wrapping their cause without a message of their own(eg. with the stack, tags or extras) are skipped.
+func message(err error) string {
+	for err != nil {
+		if r, ok := err.(*rung); ok {
+			if r.msg != "" {
+				return r.msg
+			}
+			err = r.cause
+			continue
+		}
+
+		var cause error
+		switch wrapper := err.(type) {
+		case causer:
+			cause = wrapper.Cause()
+		case interface{ Unwrap() error }:
+			cause = wrapper.Unwrap()
+		}
+		if cause == nil {
+			return err.Error()
+		}
+		// The errors of other packages embed the message of their cause, eg. fmt.Errorf("...: %w", err)
+		msg, causeMsg := err.Error(), cause.Error()
+		if msg != causeMsg {
+			return strings.TrimSuffix(strings.TrimSuffix(msg, causeMsg), ": ")
+		}
+		err = cause
+	}
+	return ""
+}
+
+// MarshalJSON returns the JSON of the body of an error(see ToBody)
+func MarshalJSON(ctx context.Context, err error, opts ...BodyOption) ([]byte, error) {
+	return json.Marshal(ToBody(ctx, err, opts...))
+}
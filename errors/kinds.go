@@ -0,0 +1,160 @@
+package errors
+
+import (
+	"sort"
+	"sync"
+
+	_err "github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Kind is a named kind of error shared by the services(eg. "CALL_NOT_FOUND"), so that the clients can tell the errors
+// apart whichever service they come from. The errors of a kind carry its code(see CodeOf) and its HTTP status(see
+// HTTPStatus), and the name of the kind is the reason of the ErrorInfo detail of their gRPC statuses(see
+// ToGRPCStatus).
+type Kind struct {
+	// Name of the kind, unique across the services. Eg. "CALL_NOT_FOUND"
+	Name string
+	Code ErrorCode
+	// HTTPStatus overrides the HTTP status of the code(see HTTPStatus), if set
+	HTTPStatus int
+	// GRPCCode overrides the gRPC status code of the code(see ToGRPCStatus), if set
+	GRPCCode codes.Code
+	// Retryable is true if the calls failing with the errors of the kind can be retried as is(see Retryable)
+	Retryable bool
+	// Message of the errors created without one
+	Message string
+}
+
+var kinds = struct {
+	sync.RWMutex
+	byName map[string]*Kind
+}{byName: make(map[string]*Kind)}
+
+// RegisterKind registers a kind of error, typically in a package shared by the services, eg.
+//
+//	var CallNotFound = errors.RegisterKind(errors.Kind{Name: "CALL_NOT_FOUND", Code: errors.NotFound, Message: "call not found"})
+//	...
+//	return CallNotFound.Wrap(err, "call "+id+" not found")
+//	...
+//	if CallNotFound.Is(err) {
+//
+// Panics if a kind of the name is registered already, or if the kind has no name.
+func RegisterKind(kind Kind) *Kind {
+	if kind.Name == "" {
+		panic("errors: kinds need a name")
+	}
+
+	kinds.Lock()
+	defer kinds.Unlock()
+	if _, ok := kinds.byName[kind.Name]; ok {
+		panic("errors: kind " + kind.Name + " is registered already")
+	}
+	registered := &kind
+	kinds.byName[kind.Name] = registered
+	return registered
+}
+
+// LookupKind returns the kind registered with the name
+func LookupKind(name string) (*Kind, bool) {
+	kinds.RLock()
+	defer kinds.RUnlock()
+	kind, ok := kinds.byName[name]
+	return kind, ok
+}
+
+// Kinds returns the kinds registered, by their names, eg. to document the errors of an API
+func Kinds() []*Kind {
+	kinds.RLock()
+	defer kinds.RUnlock()
+
+	registered := make([]*Kind, 0, len(kinds.byName))
+	for _, kind := range kinds.byName {
+		registered = append(registered, kind)
+	}
+	sort.Slice(registered, func(i, j int) bool { return registered[i].Name < registered[j].Name })
+	return registered
+}
+
+// New returns an error of the kind with the message, the message of the kind if empty
+func (k *Kind) New(msg string) error {
+	return k.Wrap(nil, msg)
+}
+
+// Wrap wraps an error with the kind and the message, the message of the kind if empty. The error may be nil.
+func (k *Kind) Wrap(err error, msg string) error {
+	if msg == "" {
+		msg = k.Message
+	}
+	return _err.WithStack(&rung{
+		msg:       msg,
+		cause:     err,
+		fatal:     Fatal(err),
+		errorCode: k.Code,
+		code:      k.HTTPStatus,
+		kind:      k,
+	})
+}
+
+// Is is true if the error is of the kind(see KindOf)
+func (k *Kind) Is(err error) bool {
+	kind, ok := KindOf(err)
+	return ok && kind == k
+}
+
+// KindOf returns the kind of the error, the kind closest to the top of the stack winning. Like FindTag, it goes through
+// the errors wrapped by other packages too. The gRPC statuses in the chain(eg. returned by the clients of another
+// service) are of the kind registered with the reason of their ErrorInfo detail, if any.
+func KindOf(err error) (*Kind, bool) {
+	type kinded interface {
+		Kind() *Kind
+	}
+
+	for e := err; e != nil; {
+		if check, ok := e.(kinded); ok && check.Kind() != nil {
+			return check.Kind(), true
+		}
+
+		// Going to the cause of the current error(if any), wrapped by github.com/pkg/errors or the standard library
+		switch wrapper := e.(type) {
+		case causer:
+			e = wrapper.Cause()
+		case interface{ Unwrap() error }:
+			e = wrapper.Unwrap()
+		default:
+			e = nil
+		}
+	}
+
+	if cause, ok := As[interface{ GRPCStatus() *status.Status }](err); ok {
+		for _, detail := range cause.GRPCStatus().Details() {
+			if info, ok := detail.(*errdetails.ErrorInfo); ok {
+				return LookupKind(info.Reason)
+			}
+		}
+	}
+	return nil, false
+}
+
+// Retryable is true if the error is of a retryable kind(see Kind.Retryable). Errors of no kind are retryable if they
+// are unavailable, exhausted or aborted, as per their code(see CodeOf) or the code of the gRPC status they are caused
+// by.
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if kind, ok := KindOf(err); ok {
+		return kind.Retryable
+	}
+	switch CodeOf(err) {
+	case Unavailable, ResourceExhausted, Aborted:
+		return true
+	}
+	switch grpcCode(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package errors
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// MergeStrategy decides the value of the tags(and extras) set by more than one error in a stack
+type MergeStrategy int32
+
+const (
+	// OuterWins keeps the value set closest to the top of the stack, ie. by the last wrap
+	OuterWins MergeStrategy = iota
+	// InnerWins keeps the value set closest to the root cause, ie. by the first error to set it
+	InnerWins
+	// Accumulate keeps all the values, from the top of the stack to the root cause. Tags are joined with
+	// AccumulatedSeparator, and extras are a []interface{} of the values.
+	Accumulate
+)
+
+// AccumulatedSeparator joins the values of a tag accumulated through the stack
+const AccumulatedSeparator = ","
+
+var mergeStrategy atomic.Int32
+
+// SetMergeStrategy configures the strategy Tags and Extras merge the values set by more than one error with,
+// defaults to OuterWins
+func SetMergeStrategy(strategy MergeStrategy) {
+	mergeStrategy.Store(int32(strategy))
+}
+
+// TagsWithStrategy returns the tags of the errors in the stack, merged with the strategy
+func TagsWithStrategy(err error, strategy MergeStrategy) map[string]string {
+	type tagged interface {
+		Tags() map[string]string
+	}
+
+	var layers []map[string]string
+	eachCause(err, func(e error) {
+		if check, ok := e.(tagged); ok && len(check.Tags()) > 0 {
+			layers = append(layers, check.Tags())
+		}
+	})
+	return merge(layers, strategy, func(values []string) string {
+		var unique []string
+		seen := make(map[string]bool, len(values))
+		for _, v := range values {
+			if !seen[v] {
+				seen[v] = true
+				unique = append(unique, v)
+			}
+		}
+		return strings.Join(unique, AccumulatedSeparator)
+	})
+}
+
+// ExtrasWithStrategy returns the extras of the errors in the stack, merged with the strategy. The values of the
+// sensitive extras(see AddSensitiveExtra) are redacted.
+func ExtrasWithStrategy(err error, strategy MergeStrategy) map[string]interface{} {
+	type extra interface {
+		Extras() map[string]interface{}
+	}
+
+	var layers []map[string]interface{}
+	eachCause(err, func(e error) {
+		if check, ok := e.(extra); ok && len(check.Extras()) > 0 {
+			layers = append(layers, check.Extras())
+		}
+	})
+	extras := merge(layers, strategy, func(values []interface{}) interface{} {
+		// Extras are not necessarily comparable(eg. slices), hence comparing them deeply
+		var unique []interface{}
+		for _, v := range values {
+			if !slices.ContainsFunc(unique, func(u interface{}) bool { return reflect.DeepEqual(u, v) }) {
+				unique = append(unique, v)
+			}
+		}
+		if len(unique) == 1 {
+			return unique[0]
+		}
+		return unique
+	})
+	extrasRedactor.redact(extras)
+	return extras
+}
+
+// Calls the function with the error and each of its causes, from the top of the stack to the root cause
+func eachCause(err error, f func(error)) {
+	for err != nil {
+		f(err)
+
+		// Going to the cause of the current error(if any)
+		cause, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = cause.Cause()
+	}
+}
+
+// Merges the layers(from the top of the stack to the root cause) with the strategy, accumulating the values of a key
+// set by more than one layer with the function. Returns nil if there are no values.
+func merge[V any](layers []map[string]V, strategy MergeStrategy, accumulate func([]V) V) map[string]V {
+	if len(layers) == 0 {
+		return nil
+	}
+
+	values := make(map[string][]V)
+	for _, layer := range layers {
+		for k, v := range layer {
+			values[k] = append(values[k], v)
+		}
+	}
+
+	merged := make(map[string]V, len(values))
+	for k, v := range values {
+		switch {
+		case len(v) == 1 || strategy == OuterWins:
+			merged[k] = v[0]
+		case strategy == InnerWins:
+			merged[k] = v[len(v)-1]
+		default:
+			merged[k] = accumulate(v)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,50 @@
+package errors
+
+import (
+	"fmt"
+)
+
+// PanicTag is the tag set(to "true") on the errors of the panics recovered(see FromPanic)
+const PanicTag = "panic"
+
+// FromPanic returns an error of the value recovered from a panic, so that panics are reported(and logged) as errors
+// are. Eg.
+//
+//	defer func() {
+//		if err := errors.FromPanic(recover()); err != nil {
+//			surveillance.SentryClient.Capture(err, false)
+//		}
+//	}()
+//
+// The error records the stack from the frame the panic was raised in, is tagged with PanicTag, is fatal and is
+// critical(see Severity). Recovered errors are the cause of the error, so that they can be matched(eg. with
+// errors.Is). Returns nil if the value is nil, ie. there was no panic.
+func FromPanic(recovered interface{}) error {
+	if recovered == nil {
+		return nil
+	}
+
+	err := &rung{
+		tags:     map[string]string{PanicTag: "true"},
+		extras:   map[string]interface{}{"panic.type": fmt.Sprintf("%T", recovered)},
+		fatal:    true,
+		severity: Critical,
+	}
+	if cause, ok := recovered.(error); ok {
+		err.msg, err.cause = "panic", cause
+	} else {
+		err.msg = fmt.Sprintf("panic: %v", recovered)
+	}
+	return &withStack{error: err, stack: panicCallers()}
+}
+
+// Records the stack of the panic being recovered from(see capture)
+func panicCallers() stack {
+	return capture(true)
+}
+
+// IsPanic is true if the error is of a panic recovered(see FromPanic)
+func IsPanic(err error) bool {
+	value, ok := FindTag(err, PanicTag)
+	return ok && value == "true"
+}
@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"regexp"
+	"sync"
+)
+
+// Redacted replaces the values of the sensitive extras read through Extras, as surveillance filters events
+const Redacted = "[Filtered]"
+
+// Patterns of the keys of the extras which are redacted. Tokens and OTPs are matched as the last part of the key(eg.
+// "access_token" but not "tokens_used" or "otp_attempts_left"), as the words are part of keys which are not
+// sensitive(eg. "footprint").
+var defaultSensitiveExtras = []string{
+	`passw(or)?d`, `secret`, `(^|[-_.])token$`, `api[-_]?key`, `authori[sz]ation`, `cookie`, `(^|[-_.])otp$`, `phone`,
+	`mobile`,
+}
+
+// redactor masks the values of the extras whose keys are sensitive, so that the secrets attached to errors for
+// debugging do not reach Sentry or the logs
+type redactor struct {
+	mutex sync.RWMutex
+	keys  []*regexp.Regexp
+}
+
+var extrasRedactor = newRedactor()
+
+func newRedactor() *redactor {
+	r := &redactor{}
+	for _, key := range defaultSensitiveExtras {
+		r.keys = append(r.keys, regexp.MustCompile(`(?i)`+key))
+	}
+	return r
+}
+
+// AddSensitiveExtra registers a pattern(case-insensitive) for the keys of the extras to be redacted, eg. "aadhaar"
+func AddSensitiveExtra(pattern string) error {
+	compiled, err := regexp.Compile(`(?i)` + pattern)
+	if err != nil {
+		return NewError("Invalid pattern of sensitive extras", err, false)
+	}
+
+	extrasRedactor.mutex.Lock()
+	defer extrasRedactor.mutex.Unlock()
+	extrasRedactor.keys = append(extrasRedactor.keys, compiled)
+	return nil
+}
+
+// SetSensitiveExtras replaces the patterns(case-insensitive) of the keys of the extras to be redacted, which default
+// to passwords, secrets, tokens, API keys, authorization headers, cookies, OTPs and phone numbers. No extras are
+// redacted without patterns.
+func SetSensitiveExtras(patterns ...string) error {
+	keys := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile(`(?i)` + pattern)
+		if err != nil {
+			return NewError("Invalid pattern of sensitive extras", err, false)
+		}
+		keys = append(keys, compiled)
+	}
+
+	extrasRedactor.mutex.Lock()
+	defer extrasRedactor.mutex.Unlock()
+	extrasRedactor.keys = keys
+	return nil
+}
+
+// Replaces the values of the sensitive extras in place
+func (r *redactor) redact(extras map[string]interface{}) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for key := range extras {
+		for _, sensitive := range r.keys {
+			if sensitive.MatchString(key) {
+				extras[key] = Redacted
+				break
+			}
+		}
+	}
+}
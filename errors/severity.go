@@ -0,0 +1,51 @@
+package errors
+
+// SeverityLevel of an error, distinguishing a degradation(warning) from an outage(critical)
+type SeverityLevel string
+
+const (
+	Warning  SeverityLevel = "warning"
+	Error    SeverityLevel = "error"
+	Critical SeverityLevel = "critical"
+)
+
+// WithSeverity wraps an error with its severity, eg.
+//
+//	errors.WithSeverity(err, errors.Warning)
+//
+// Returns nil if the error is nil.
+func WithSeverity(err error, severity SeverityLevel) error {
+	if err == nil {
+		return nil
+	}
+
+	return wrap(err, func(e *rung) {
+		e.severity = severity
+	})
+}
+
+// Severity returns the severity set on the error. The severity closest to the top of the stack wins.
+// Errors without a severity(fatal ones included, see Fatal) are errors, the critical severity being set explicitly.
+func Severity(err error) SeverityLevel {
+	type severe interface {
+		Severity() SeverityLevel
+	}
+
+	for e := err; e != nil; {
+		if check, ok := e.(severe); ok {
+			if severity := check.Severity(); severity != "" {
+				return severity
+			}
+		}
+
+		// Going to the cause of the current error(if any)
+		cause, ok := e.(causer)
+		if !ok {
+			break
+		}
+
+		e = cause.Cause()
+	}
+
+	return Error
+}
@@ -0,0 +1,201 @@
+package errors
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	_err "github.com/pkg/errors"
+)
+
+// Default depth of the stacks recorded by WrapWithStack
+const defaultStackDepth = 32
+
+var (
+	internalMutex sync.RWMutex
+	// Prefixes of the functions dropped from the top of the stack traces, as errors do not originate in them
+	internalPrefixes = []string{"github.com/skit-ai/vcore/"}
+	// Prefixes of the functions within the internal prefixes which are not internal
+	externalPrefixes = []string{"github.com/skit-ai/vcore/tests"}
+	// Prefixes of the functions dropped from anywhere in the stack traces
+	filteredPrefixes []string
+
+	stackDepth atomic.Int32
+)
+
+// SetStackDepth configures the number of frames of the stacks recorded by WrapWithStack, which is also the number of
+// frames of the stack traces of the events sent to Sentry(the innermost frames being kept). Defaults to 32, a
+// non-positive depth restores the default.
+func SetStackDepth(depth int) {
+	if depth <= 0 {
+		depth = defaultStackDepth
+	}
+	stackDepth.Store(int32(depth))
+}
+
+// StackDepth returns the number of frames of the stacks(see SetStackDepth)
+func StackDepth() int {
+	if depth := stackDepth.Load(); depth > 0 {
+		return int(depth)
+	}
+	return defaultStackDepth
+}
+
+// FilterFrames drops the frames of the packages(or functions) with the prefixes from anywhere in the stack traces,
+// eg. of the runtime and the middlewares of deep handler chains:
+//
+//	errors.FilterFrames("runtime.", "net/http.", "github.com/go-chi/chi/")
+//
+// Vendored packages are matched by their import path, without the path of the vendor directory. Unlike SkipFrames,
+// the frames of vcore are kept unless filtered too(eg. with "github.com/skit-ai/vcore/").
+func FilterFrames(prefixes ...string) {
+	internalMutex.Lock()
+	defer internalMutex.Unlock()
+	filteredPrefixes = append(filteredPrefixes, prefixes...)
+}
+
+// FilteredFrame is true if the function(qualified by its package) is to be dropped from the stack traces(see
+// FilterFrames)
+func FilteredFrame(function string) bool {
+	if i := strings.LastIndex(function, "/vendor/"); i >= 0 {
+		function = function[i+len("/vendor/"):]
+	}
+
+	internalMutex.RLock()
+	defer internalMutex.RUnlock()
+	for _, prefix := range filteredPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SkipFrames marks the packages(or functions) with the prefixes as internal, so that their frames are dropped from the
+// top of the stack traces, eg. for the helpers of a service wrapping all its errors. The frames of vcore are internal.
+func SkipFrames(prefixes ...string) {
+	internalMutex.Lock()
+	defer internalMutex.Unlock()
+	internalPrefixes = append(internalPrefixes, prefixes...)
+}
+
+// InternalFrame is true if the function(qualified by its package, eg. "github.com/skit-ai/vcore/errors.NewError") is
+// internal(see SkipFrames)
+func InternalFrame(function string) bool {
+	internalMutex.RLock()
+	defer internalMutex.RUnlock()
+
+	for _, prefix := range externalPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range internalPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stack is a call stack, from the innermost caller
+type stack []uintptr
+
+// StackTrace implements the interface of github.com/pkg/errors, read by Stacktrace and by Sentry
+func (s stack) StackTrace() _err.StackTrace {
+	frames := make(_err.StackTrace, len(s))
+	for i, pc := range s {
+		frames[i] = _err.Frame(pc)
+	}
+	return frames
+}
+
+// Records the stack of the caller of the function calling it, without the internal frames at the top and the frames
+// filtered(see FilterFrames), up to the depth of the stacks
+func callers() stack {
+	return capture(false)
+}
+
+// Records the stack of the caller of the function calling it as callers does. If recovering from a panic, the frames
+// are recorded from the frame the panic was raised in instead, the internal frames at the top being kept.
+func capture(panicking bool) stack {
+	depth := StackDepth()
+	// Recording more frames than the depth, as some are dropped
+	pcs := make([]uintptr, 2*depth)
+	pcs = pcs[:runtime.Callers(4, pcs)]
+
+	raised := false
+	if panicking {
+		// The deferred functions recovering run on top of the frames of the panic
+		for i, pc := range pcs {
+			if fn := runtime.FuncForPC(pc - 1); fn != nil && fn.Name() == "runtime.gopanic" {
+				pcs, raised = pcs[i+1:], true
+				break
+			}
+		}
+		// Dropping the functions of the runtime raising the panics, eg. of the nil dereferences
+		for raised && len(pcs) > 1 {
+			if fn := runtime.FuncForPC(pcs[0] - 1); fn == nil || !strings.HasPrefix(fn.Name(), "runtime.") {
+				break
+			}
+			pcs = pcs[1:]
+		}
+	}
+	for !raised && len(pcs) > 1 {
+		// Return addresses are past the call, pointing to the next instruction
+		fn := runtime.FuncForPC(pcs[0] - 1)
+		if fn == nil || !InternalFrame(fn.Name()) {
+			break
+		}
+		pcs = pcs[1:]
+	}
+
+	kept := pcs[:0]
+	for i, pc := range pcs {
+		// Keeping the top frame, the frame the error originated in
+		if fn := runtime.FuncForPC(pc - 1); i > 0 && fn != nil && FilteredFrame(fn.Name()) {
+			continue
+		}
+		if kept = append(kept, pc); len(kept) == depth {
+			break
+		}
+	}
+	return kept
+}
+
+// withStack carries the stack of the site an error was wrapped at
+type withStack struct {
+	error
+	stack
+}
+
+func (w *withStack) Cause() error {
+	return w.error
+}
+
+func (w *withStack) Unwrap() error {
+	return w.error
+}
+
+// WrapWithStack wraps an error with the message, recording the stack of the wrap site. The frames of vcore(and of the
+// packages marked by SkipFrames) are dropped from the top of the stack, so that the events of Sentry show the frame the
+// error originated in rather than the frames of the helpers it went through. Eg.
+//
+//	return errors.WrapWithStack(err, "Could not fetch the call")
+//
+// Returns nil if the error is nil.
+func WrapWithStack(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &withStack{
+		error: &rung{
+			cause: err,
+			msg:   msg,
+			fatal: Fatal(err),
+		},
+		stack: callers(),
+	}
+}
@@ -0,0 +1,131 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Default limit of the size of all the extras of an error, keeping the events well within the size limit of
+// Sentry(1MB). The extras are not limited one by one by default, as surveillance truncates them(see
+// surveillance.WithPayloadLimits).
+const defaultExtrasLimit = 256 << 10
+
+var extraLimit, extrasLimit atomic.Int64
+
+func init() {
+	extrasLimit.Store(defaultExtrasLimit)
+}
+
+// SetExtrasLimits configures the limits(in bytes) of the size of every extra and of all the extras of an error(see
+// TruncateExtras). There is no limit of every extra by default, and a limit of 256KiB of all the extras. A
+// non-positive limit disables the limit.
+func SetExtrasLimits(perKey, total int) {
+	extraLimit.Store(int64(perKey))
+	extrasLimit.Store(int64(total))
+}
+
+// TruncatedExtras returns the extras of the error(see Extras) within the size limits(see TruncateExtras), eg. for the
+// events of Sentry and the bodies of the API responses
+func TruncatedExtras(err error) map[string]interface{} {
+	return TruncateExtras(Extras(err))
+}
+
+// TruncateExtras returns the extras within the size limits(see SetExtrasLimits), the size of a value being its length
+// if it is a string(or bytes), and the length of its JSON otherwise. The values larger than the limit of every extra
+// are truncated to it, values which are not strings becoming the truncated string of their JSON. Once the extras
+// exceed the total limit, the largest values are replaced altogether, and dropped once even their replacements exceed
+// it. Truncated values end with the original size of the value, as the extras truncated by surveillance, eg.
+// "...[truncated, 240000 bytes]". The extras are copied if any value is truncated.
+func TruncateExtras(extras map[string]interface{}) map[string]interface{} {
+	perKey, total := int(extraLimit.Load()), int(extrasLimit.Load())
+	if len(extras) == 0 || (perKey <= 0 && total <= 0) {
+		return extras
+	}
+
+	type sized struct {
+		key   string
+		value interface{}
+		size  int
+		// Size of the value before it was truncated
+		original int
+	}
+
+	values := make([]sized, 0, len(extras))
+	truncated := false
+	for key, value := range extras {
+		text, size := extraText(value)
+		original := size
+		if perKey > 0 && size > perKey {
+			text = truncate(text, perKey, size)
+			value, size, truncated = text, len(text), true
+		}
+		values = append(values, sized{key: key, value: value, size: size, original: original})
+	}
+
+	if total > 0 {
+		// Keeping the smallest values, so that as many extras as possible are kept
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].size != values[j].size {
+				return values[i].size < values[j].size
+			}
+			return values[i].key < values[j].key
+		})
+		sum := 0
+		for i := range values {
+			if sum+values[i].size <= total {
+				sum += values[i].size
+				continue
+			}
+
+			truncated = true
+			replacement := truncate("", 0, values[i].original)
+			if sum+len(replacement) > total {
+				// Dropping the rest of the values, as not even their replacements are within the limit
+				values = values[:i]
+				break
+			}
+			values[i].value, values[i].size = replacement, len(replacement)
+			sum += len(replacement)
+		}
+	}
+
+	if !truncated {
+		return extras
+	}
+	limited := make(map[string]interface{}, len(values))
+	for _, v := range values {
+		limited[v.key] = v.value
+	}
+	return limited
+}
+
+// Returns the text of the value and its size
+func extraText(value interface{}) (string, int) {
+	switch v := value.(type) {
+	case string:
+		return v, len(v)
+	case []byte:
+		return string(v), len(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		text := fmt.Sprint(value)
+		return text, len(text)
+	}
+	return string(data), len(data)
+}
+
+// Returns the prefix of the text within the limit, noting the size of the value
+func truncate(text string, limit, size int) string {
+	suffix := fmt.Sprintf("...[truncated, %d bytes]", size)
+	if keep := limit - len(suffix); keep > 0 {
+		text = text[:keep]
+	} else {
+		text = ""
+	}
+	// The cut might split a multi-byte character
+	return strings.ToValidUTF8(text, "") + suffix
+}
@@ -0,0 +1,104 @@
+package errors
+
+import (
+	stderrors "errors"
+)
+
+// FindTag returns the value of the tag set closest to the top of the stack of the error, and whether it is set. Unlike
+// Tags, it goes through the errors wrapped by other packages(eg. with fmt.Errorf("...: %w", err)) too.
+func FindTag(err error, key string) (value string, found bool) {
+	type tagged interface {
+		Tags() map[string]string
+	}
+
+	Walk(err, func(e error) bool {
+		if check, ok := e.(tagged); ok {
+			value, found = check.Tags()[key]
+		}
+		return !found
+	})
+	return value, found
+}
+
+// Walk calls the function with the error and each of its causes, from the top of the stack to the root cause, until
+// the function returns false. Like FindTag, it goes through the errors wrapped by other packages too, and through each
+// of the errors joined(eg. with Combine or errors.Join of the standard library) in turn. Eg. to count the layers of a
+// kind:
+//
+//	errors.Walk(err, func(e error) bool {
+//		if _, ok := e.(*net.OpError); ok {
+//			netErrors.Inc()
+//		}
+//		return true
+//	})
+//
+// Returns false if the function stopped the walk.
+func Walk(err error, f func(error) bool) bool {
+	for err != nil {
+		if !f(err) {
+			return false
+		}
+
+		// Going to the cause of the current error(if any)
+		switch wrapper := err.(type) {
+		case causer:
+			err = wrapper.Cause()
+		case interface{ Unwrap() error }:
+			err = wrapper.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, wrapped := range wrapper.Unwrap() {
+				if !Walk(wrapped, f) {
+					return false
+				}
+			}
+			return true
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// RootCause returns the error at the bottom of the stack of the error, eg. the error of the driver beneath the layers
+// of the repositories and the handlers. Of the errors joined, it is the root cause of the first. Returns nil if the
+// error is nil.
+func RootCause(err error) error {
+	for err != nil {
+		switch wrapper := err.(type) {
+		case causer:
+			if wrapper.Cause() == nil {
+				return err
+			}
+			err = wrapper.Cause()
+		case interface{ Unwrap() error }:
+			if wrapper.Unwrap() == nil {
+				return err
+			}
+			err = wrapper.Unwrap()
+		case interface{ Unwrap() []error }:
+			if wrapped := wrapper.Unwrap(); len(wrapped) > 0 {
+				return RootCause(wrapped[0])
+			}
+			return err
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// As finds the first error in the chain of the error which is a T(see errors.As of the standard library), eg.
+//
+//	if opErr, ok := errors.As[*net.OpError](err); ok {
+//		...
+//	}
+//
+// T must be an interface or implement error.
+func As[T any](err error) (T, bool) {
+	var target T
+	if err == nil {
+		return target, false
+	}
+	ok := stderrors.As(err, &target)
+	return target, ok
+}
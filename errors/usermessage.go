@@ -0,0 +1,61 @@
+package errors
+
+import (
+	"strings"
+)
+
+// WithUserMessage wraps an error with a message safe to show to the users(eg. "Something went wrong, try again"),
+// which API layers respond with(see ToBody and ToGRPCStatus) instead of the message of the error. The message of the
+// error is left as is, for Sentry and the logs. Returns nil if the error is nil.
+func WithUserMessage(err error, message string) error {
+	return WithUserMessages(err, map[string]string{"": message})
+}
+
+// WithUserMessages wraps an error with the messages safe to show to the users in their locales(eg. "en", "hi-IN"),
+// the message of the locale "" being for any other locale. Returns nil if the error is nil.
+func WithUserMessages(err error, messages map[string]string) error {
+	if err == nil {
+		return nil
+	}
+
+	return wrap(err, func(e *rung) {
+		e.userMessages = copyMap(messages)
+	})
+}
+
+// UserMessage returns the message set on the error for the first of the locales it has a message in, trying the base
+// of the locales too(eg. "hi" for "hi-IN"), or else its message for any locale. The message closest to the top of
+// the stack wins. Returns false if the error has no message for the locales.
+func UserMessage(err error, locales ...string) (string, bool) {
+	type userMessages interface {
+		UserMessages() map[string]string
+	}
+
+	candidates := make([]string, 0, 2*len(locales)+1)
+	for _, locale := range locales {
+		candidates = append(candidates, locale)
+		if base, _, ok := strings.Cut(locale, "-"); ok {
+			candidates = append(candidates, base)
+		}
+	}
+	candidates = append(candidates, "")
+
+	for err != nil {
+		if check, ok := err.(userMessages); ok {
+			messages := check.UserMessages()
+			for _, candidate := range candidates {
+				if message, ok := messages[candidate]; ok {
+					return message, true
+				}
+			}
+		}
+
+		// Going to the cause of the current error(if any)
+		cause, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = cause.Cause()
+	}
+	return "", false
+}
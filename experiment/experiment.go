@@ -0,0 +1,241 @@
+// Package experiment assigns units(tenants, callers) to variants of A/B experiments on call flows.
+// Assignment is deterministic on the unit, exposures are logged through a pluggable logger and experiments
+// can be stopped by a kill switch or a breached guardrail, in which case every unit gets the control variant.
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+)
+
+// Assignments are made over these many buckets
+const buckets = 10000
+
+type Variant struct {
+	Name   string `json:"name" yaml:"name"`
+	Weight int    `json:"weight" yaml:"weight"`
+}
+
+// Experiment describes the variants of an experiment. The first variant is the control.
+// Traffic is the fraction(0, 1] of units enrolled in the experiment, units not enrolled get the control.
+// Salt(defaults to the name) decides the assignment. Changing it reshuffles the units.
+type Experiment struct {
+	Name     string    `json:"name" yaml:"name"`
+	Salt     string    `json:"salt" yaml:"salt"`
+	Traffic  float64   `json:"traffic" yaml:"traffic"`
+	Variants []Variant `json:"variants" yaml:"variants"`
+}
+
+// Control returns the name of the control variant
+func (e Experiment) Control() string {
+	return e.Variants[0].Name
+}
+
+type Reason string
+
+const (
+	Assigned    Reason = "assigned"
+	NotEnrolled Reason = "not_enrolled"
+	Killed      Reason = "killed"
+	GuardRailed Reason = "guardrail"
+	Unknown     Reason = "unknown_experiment"
+)
+
+// Assignment of a unit to a variant
+type Assignment struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+	Unit       string `json:"unit"`
+	Reason     Reason `json:"reason"`
+}
+
+// Enrolled is true if the unit takes part in the experiment
+func (a Assignment) Enrolled() bool {
+	return a.Reason == Assigned
+}
+
+// Exposure is logged every time a unit is exposed to a variant
+type Exposure struct {
+	Assignment
+	Timestamp  time.Time         `json:"timestamp"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// ExposureLogger ships exposures to the analytics pipeline
+type ExposureLogger interface {
+	LogExposure(ctx context.Context, exposure Exposure) error
+}
+
+// KillSwitch reports if an experiment has been turned off(eg. through a feature flag)
+type KillSwitch func(ctx context.Context, experiment string) bool
+
+// Guardrail reports if a guardrail metric of an experiment has been breached
+type Guardrail func(experiment string) bool
+
+// Client assigns units to the variants of the registered experiments
+type Client struct {
+	mutex       sync.RWMutex
+	experiments map[string]Experiment
+	logger      ExposureLogger
+	killSwitch  KillSwitch
+	guardrails  []Guardrail
+	hooks       []func(Exposure)
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithExposureLogger configures where exposures are logged
+func WithExposureLogger(logger ExposureLogger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithKillSwitch configures the kill switch of experiments
+func WithKillSwitch(killSwitch KillSwitch) Option {
+	return func(c *Client) {
+		c.killSwitch = killSwitch
+	}
+}
+
+// WithGuardrail adds a guardrail checked before each assignment
+func WithGuardrail(guardrail Guardrail) Option {
+	return func(c *Client) {
+		c.guardrails = append(c.guardrails, guardrail)
+	}
+}
+
+// WithExposureHook adds a hook called with every exposure. Useful to record guardrail metrics per variant.
+func WithExposureHook(hook func(Exposure)) Option {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+func New(opts ...Option) *Client {
+	c := &Client{experiments: make(map[string]Experiment)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Register validates and adds an experiment, replacing any experiment with the same name
+func (c *Client) Register(experiment Experiment) error {
+	if experiment.Name == "" {
+		return errors.NewError("experiment has no name", nil, false)
+	}
+	if len(experiment.Variants) == 0 {
+		return errors.NewError(fmt.Sprintf("experiment %s has no variants", experiment.Name), nil, false)
+	}
+	for _, variant := range experiment.Variants {
+		if variant.Weight < 0 {
+			return errors.NewError(fmt.Sprintf("variant %s of experiment %s has a negative weight", variant.Name, experiment.Name), nil, false)
+		}
+	}
+	if experiment.Salt == "" {
+		experiment.Salt = experiment.Name
+	}
+	if experiment.Traffic <= 0 || experiment.Traffic > 1 {
+		experiment.Traffic = 1
+	}
+
+	c.mutex.Lock()
+	c.experiments[experiment.Name] = experiment
+	c.mutex.Unlock()
+	return nil
+}
+
+// Assign returns the variant of the experiment for the unit and logs the exposure.
+// Unknown, killed and guard-railed experiments assign the control variant(empty for unknown experiments).
+func (c *Client) Assign(ctx context.Context, name, unit string, attributes map[string]string) Assignment {
+	assignment := c.assign(ctx, name, unit)
+	if assignment.Reason == Unknown {
+		return assignment
+	}
+
+	exposure := Exposure{Assignment: assignment, Timestamp: time.Now(), Attributes: attributes}
+	for _, hook := range c.hooks {
+		hook(exposure)
+	}
+	if c.logger != nil {
+		if err := c.logger.LogExposure(ctx, exposure); err != nil {
+			log.Warnf("Could not log exposure of %s to experiment %s: %s", unit, name, err)
+		}
+	}
+	return assignment
+}
+
+// Variant returns the name of the variant assigned to the unit
+func (c *Client) Variant(ctx context.Context, name, unit string) string {
+	return c.Assign(ctx, name, unit, nil).Variant
+}
+
+func (c *Client) assign(ctx context.Context, name, unit string) Assignment {
+	c.mutex.RLock()
+	experiment, ok := c.experiments[name]
+	c.mutex.RUnlock()
+
+	assignment := Assignment{Experiment: name, Unit: unit}
+	if !ok {
+		assignment.Reason = Unknown
+		return assignment
+	}
+
+	assignment.Variant = experiment.Control()
+	if c.killSwitch != nil && c.killSwitch(ctx, name) {
+		assignment.Reason = Killed
+		return assignment
+	}
+	for _, guardrail := range c.guardrails {
+		if guardrail(name) {
+			assignment.Reason = GuardRailed
+			return assignment
+		}
+	}
+
+	if bucket(experiment.Salt+":traffic", unit) >= int(experiment.Traffic*buckets) {
+		assignment.Reason = NotEnrolled
+		return assignment
+	}
+
+	assignment.Variant = pick(experiment, bucket(experiment.Salt, unit))
+	assignment.Reason = Assigned
+	return assignment
+}
+
+// Picks a variant by splitting the buckets in proportion to the weights of the variants
+func pick(experiment Experiment, bucket int) string {
+	var total int
+	for _, variant := range experiment.Variants {
+		total += variant.Weight
+	}
+	if total == 0 {
+		return experiment.Variants[bucket%len(experiment.Variants)].Name
+	}
+
+	point := bucket * total / buckets
+	for _, variant := range experiment.Variants {
+		if point < variant.Weight {
+			return variant.Name
+		}
+		point -= variant.Weight
+	}
+	return experiment.Control()
+}
+
+// Deterministically hashes the unit into one of the buckets
+func bucket(salt, unit string) int {
+	h := fnv.New64a()
+	h.Write([]byte(salt))
+	h.Write([]byte{0})
+	h.Write([]byte(unit))
+	return int(h.Sum64() % buckets)
+}
@@ -0,0 +1,58 @@
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/skit-ai/vcore/env"
+	"github.com/skit-ai/vcore/transport/amqp"
+)
+
+// AMQPExposureLogger publishes exposures as JSON on an AMQP exchange
+type AMQPExposureLogger struct {
+	producer     *amqp.Producer
+	exchange     string
+	exchangeType string
+	routingKey   string
+}
+
+// NewAMQPExposureLogger returns a logger publishing exposures using the producer
+func NewAMQPExposureLogger(producer *amqp.Producer, exchange, exchangeType, routingKey string) *AMQPExposureLogger {
+	return &AMQPExposureLogger{
+		producer:     producer,
+		exchange:     exchange,
+		exchangeType: exchangeType,
+		routingKey:   routingKey,
+	}
+}
+
+func (l *AMQPExposureLogger) LogExposure(_ context.Context, exposure Exposure) error {
+	body, err := json.Marshal(exposure)
+	if err != nil {
+		return err
+	}
+	return l.producer.Publish(l.exchange, l.exchangeType, l.routingKey, string(body), nil, false)
+}
+
+// ExposureLoggerFunc allows the use of ordinary functions as an ExposureLogger
+type ExposureLoggerFunc func(ctx context.Context, exposure Exposure) error
+
+func (f ExposureLoggerFunc) LogExposure(ctx context.Context, exposure Exposure) error {
+	return f(ctx, exposure)
+}
+
+// EnvKillSwitch turns off the experiments listed(comma separated) in the EXPERIMENTS_DISABLED env variable.
+// "*" turns off all experiments.
+func EnvKillSwitch() KillSwitch {
+	disabled := make(map[string]bool)
+	for _, name := range strings.Split(env.String("EXPERIMENTS_DISABLED", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			disabled[name] = true
+		}
+	}
+
+	return func(_ context.Context, experiment string) bool {
+		return disabled["*"] || disabled[experiment]
+	}
+}
@@ -0,0 +1,229 @@
+// Package failover fails over the storage and the brokers of a service from their primary region to a secondary one,
+// active-passive. The region used is decided by the health of the regions: the outcomes of the calls made to them, and
+// the health checks run in the background(see Failover.Run). Eg.
+//
+//	objects := failover.NewObjects(primary, secondary, failover.WithReplicationHint(queueReplication))
+//	go objects.Run(ctx)
+//	mux.Handle("/recordings/", httpserver.ServeObject(objects, key))
+//
+// The data written to a region is to be replicated to the other by other means(eg. S3 replication, or a job queued by
+// the replication hints), which Reconcile reports the gaps of.
+package failover
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/skit-ai/vcore/log"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+// Region of a target
+type Region int
+
+const (
+	Primary Region = iota
+	Secondary
+)
+
+func (r Region) String() string {
+	if r == Secondary {
+		return "secondary"
+	}
+	return "primary"
+}
+
+// Other returns the other region
+func (r Region) Other() Region {
+	return 1 - r
+}
+
+// HealthCheck returns an error if the target is unhealthy
+type HealthCheck[T any] func(ctx context.Context, target T) error
+
+// Failover holds a target in each region, and decides the region to use. The secondary region is used once the
+// primary has failed threshold times in a row(while the secondary has not), and the primary again once it has
+// succeeded threshold times in a row.
+type Failover[T any] struct {
+	targets    [2]T
+	health     HealthCheck[T]
+	interval   time.Duration
+	threshold  int
+	clock      simulation.Clock
+	onFailover func(from, to Region)
+
+	mutex     sync.Mutex
+	active    Region
+	failures  [2]int
+	successes [2]int
+}
+
+// Option configures a Failover
+type Option func(*options)
+
+type options struct {
+	health     any
+	interval   time.Duration
+	threshold  int
+	clock      simulation.Clock
+	onFailover func(from, to Region)
+	hint       func(ctx context.Context, key string, to Region)
+}
+
+// WithHealthCheck configures the health check of the targets run by Failover.Run, of the type of the targets(eg.
+// HealthCheck[httpserver.ObjectStore] for NewObjects). Without it, the regions are only checked by the outcomes of the
+// calls made to them.
+func WithHealthCheck[T any](check HealthCheck[T]) Option {
+	return func(o *options) {
+		o.health = check
+	}
+}
+
+// WithInterval configures the interval of the health checks, defaults to 10s
+func WithInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// WithThreshold configures the failures(and successes) in a row failing over(and back), defaults to 3
+func WithThreshold(threshold int) Option {
+	return func(o *options) {
+		o.threshold = threshold
+	}
+}
+
+// WithClock configures the clock of the health checks, eg. a simulation.Simulation in tests. Defaults to
+// simulation.Real.
+func WithClock(clock simulation.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithOnFailover configures a function called on every failover(and fail back), eg. to alert the on-call
+func WithOnFailover(onFailover func(from, to Region)) Option {
+	return func(o *options) {
+		o.onFailover = onFailover
+	}
+}
+
+func buildOptions(opts []Option) options {
+	o := options{interval: 10 * time.Second, threshold: 3, clock: simulation.Real}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.threshold <= 0 {
+		o.threshold = 1
+	}
+	return o
+}
+
+// New returns a failover of the targets, the primary being active
+func New[T any](primary, secondary T, opts ...Option) *Failover[T] {
+	return newFailover(primary, secondary, buildOptions(opts))
+}
+
+func newFailover[T any](primary, secondary T, o options) *Failover[T] {
+	health, _ := o.health.(HealthCheck[T])
+	return &Failover[T]{
+		targets:    [2]T{primary, secondary},
+		health:     health,
+		interval:   o.interval,
+		threshold:  o.threshold,
+		clock:      o.clock,
+		onFailover: o.onFailover,
+	}
+}
+
+// Active returns the target of the region in use, along with the region
+func (f *Failover[T]) Active() (T, Region) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.targets[f.active], f.active
+}
+
+// Target returns the target of the region
+func (f *Failover[T]) Target(region Region) T {
+	return f.targets[region]
+}
+
+// Report records the outcome of a call made to the region, failing over(or back) as per the threshold
+func (f *Failover[T]) Report(region Region, err error) {
+	f.mutex.Lock()
+	if err != nil {
+		f.failures[region]++
+		f.successes[region] = 0
+	} else {
+		f.failures[region] = 0
+		f.successes[region]++
+	}
+
+	from := f.active
+	switch {
+	case f.active == Primary && f.failures[Primary] >= f.threshold && f.failures[Secondary] < f.threshold:
+		f.active = Secondary
+	case f.active == Secondary && f.successes[Primary] >= f.threshold:
+		f.active = Primary
+	}
+	to := f.active
+	f.mutex.Unlock()
+
+	if from == to {
+		return
+	}
+	log.Warnf("Failing over from the %s region to the %s region", from, to)
+	if f.onFailover != nil {
+		f.onFailover(from, to)
+	}
+}
+
+// Check runs the health check on the targets of both regions, reporting their outcomes
+func (f *Failover[T]) Check(ctx context.Context) {
+	if f.health == nil {
+		return
+	}
+	for _, region := range []Region{Primary, Secondary} {
+		f.Report(region, f.health(ctx, f.targets[region]))
+	}
+}
+
+// Run runs the health checks every interval until the context is done
+func (f *Failover[T]) Run(ctx context.Context) {
+	for {
+		f.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.clock.After(f.interval):
+		}
+	}
+}
+
+// Calls the function with the target of the active region, and with the target of the other region if it fails with
+// an error which is not healthy(eg. an object not found), reporting the outcomes of the calls
+func (f *Failover[T]) do(ctx context.Context, call func(T) error, healthy func(error) bool) error {
+	target, region := f.Active()
+	err := call(target)
+	f.Report(region, reported(err, healthy))
+	if err == nil || healthy(err) || ctx.Err() != nil {
+		return err
+	}
+
+	other := region.Other()
+	if otherErr := call(f.targets[other]); otherErr != nil {
+		f.Report(other, reported(otherErr, healthy))
+		return err
+	}
+	f.Report(other, nil)
+	return nil
+}
+
+// Returns nil if the error does not make the region unhealthy(eg. an object not found)
+func reported(err error, healthy func(error) bool) error {
+	if err != nil && healthy(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package failover
+
+import (
+	"context"
+	"io"
+	"iter"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/httpserver"
+)
+
+// ObjectWriter writes the objects of a bucket, eg. aws.S3Objects
+type ObjectWriter interface {
+	Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+}
+
+// Objects is an httpserver.ObjectStore failing over from the bucket of the primary region to that of the secondary.
+// Objects are read from the active region, and from the other one if that fails(other than with NotFound). Objects
+// are written to the active region only, hinting their replication to the other(see WithReplicationHint).
+type Objects struct {
+	*Failover[httpserver.ObjectStore]
+	hint func(ctx context.Context, key string, to Region)
+}
+
+// WithReplicationHint configures the function called with the objects written(see Objects.Put) and the region they are
+// to be replicated to, eg. to queue a replication job. The hints are not called if the write fails.
+func WithReplicationHint(hint func(ctx context.Context, key string, to Region)) Option {
+	return func(o *options) {
+		o.hint = hint
+	}
+}
+
+// NewObjects returns the objects of the buckets of the regions, the primary being active
+func NewObjects(primary, secondary httpserver.ObjectStore, opts ...Option) *Objects {
+	o := buildOptions(opts)
+	return &Objects{Failover: newFailover(primary, secondary, o), hint: o.hint}
+}
+
+// Not found objects do not make the bucket unhealthy
+func notFound(err error) bool {
+	return errors.CodeOf(err) == errors.NotFound
+}
+
+// Stat returns the metadata of the object from the active region, or else from the other
+func (o *Objects) Stat(ctx context.Context, key string) (object httpserver.Object, err error) {
+	err = o.do(ctx, func(store httpserver.ObjectStore) error {
+		object, err = store.Stat(ctx, key)
+		return err
+	}, notFound)
+	return object, err
+}
+
+// Open returns the content of the object from the active region, or else from the other
+func (o *Objects) Open(ctx context.Context, key string, offset int64) (content io.ReadCloser, err error) {
+	err = o.do(ctx, func(store httpserver.ObjectStore) error {
+		content, err = store.Open(ctx, key, offset)
+		return err
+	}, notFound)
+	return content, err
+}
+
+// Put writes the object to the bucket of the active region, which is to implement ObjectWriter, and hints its
+// replication to the other region
+func (o *Objects) Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+	store, region := o.Active()
+	writer, ok := store.(ObjectWriter)
+	if !ok {
+		return errors.NewError("The bucket of the "+region.String()+" region cannot be written to", nil, false)
+	}
+
+	err := writer.Put(ctx, key, body, contentType)
+	o.Report(region, err)
+	if err != nil {
+		return errors.NewError("Could not write "+key+" to the "+region.String()+" region", err, false)
+	}
+	if o.hint != nil {
+		o.hint(ctx, key, region.Other())
+	}
+	return nil
+}
+
+// Report of the reconciliation of the buckets of the regions
+type Report struct {
+	// Checked is the number of keys checked
+	Checked int `json:"checked"`
+	// Missing are the keys in only one of the regions, by the region they are missing from
+	Missing map[Region][]string `json:"missing,omitempty"`
+	// Mismatched are the keys whose objects differ(by size or ETag) between the regions
+	Mismatched []string `json:"mismatched,omitempty"`
+	// Failed are the keys which could not be checked, along with the error
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// Reconciled is true if the buckets of the regions have the same objects for the keys checked
+func (r Report) Reconciled() bool {
+	return len(r.Missing) == 0 && len(r.Mismatched) == 0 && len(r.Failed) == 0
+}
+
+// Reconcile compares the objects of the keys in the buckets of both regions, eg. from a periodic job listing the keys
+// written recently. Objects are compared by their size, and by their ETags if both buckets have them. Stops once the
+// context is done.
+func (o *Objects) Reconcile(ctx context.Context, keys iter.Seq[string]) (Report, error) {
+	report := Report{Missing: make(map[Region][]string), Failed: make(map[string]string)}
+	for key := range keys {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.Checked++
+
+		var objects [2]httpserver.Object
+		var missing []Region
+		failed := false
+		for _, region := range []Region{Primary, Secondary} {
+			object, err := o.Target(region).Stat(ctx, key)
+			switch {
+			case notFound(err):
+				missing = append(missing, region)
+			case err != nil:
+				report.Failed[key] = err.Error()
+				failed = true
+			}
+			objects[region] = object
+		}
+
+		switch {
+		case failed || len(missing) == 2:
+		case len(missing) == 1:
+			report.Missing[missing[0]] = append(report.Missing[missing[0]], key)
+		case objects[Primary].Size != objects[Secondary].Size,
+			objects[Primary].ETag != "" && objects[Secondary].ETag != "" && objects[Primary].ETag != objects[Secondary].ETag:
+			report.Mismatched = append(report.Mismatched, key)
+		}
+	}
+
+	if len(report.Missing) == 0 {
+		report.Missing = nil
+	}
+	if len(report.Failed) == 0 {
+		report.Failed = nil
+	}
+	return report, nil
+}
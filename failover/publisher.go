@@ -0,0 +1,30 @@
+package failover
+
+import (
+	"context"
+
+	streadway "github.com/streadway/amqp"
+)
+
+// Publisher publishes to a broker, eg. transport/amqp.Producer
+type Publisher interface {
+	Publish(exchange, exchangeType, routingKey, body string, headers streadway.Table, reliable bool) error
+}
+
+// Publishers is a Publisher failing over from the broker of the primary region to that of the secondary. Events are
+// published to the active region, and to the other one if that fails.
+type Publishers struct {
+	*Failover[Publisher]
+}
+
+// NewPublishers returns a publisher to the brokers of the regions, the primary being active
+func NewPublishers(primary, secondary Publisher, opts ...Option) *Publishers {
+	return &Publishers{Failover: newFailover(primary, secondary, buildOptions(opts))}
+}
+
+// Publish publishes to the broker of the active region, or else to that of the other
+func (p *Publishers) Publish(exchange, exchangeType, routingKey, body string, headers streadway.Table, reliable bool) error {
+	return p.do(context.Background(), func(publisher Publisher) error {
+		return publisher.Publish(exchange, exchangeType, routingKey, body, headers, reliable)
+	}, func(error) bool { return false })
+}
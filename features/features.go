@@ -0,0 +1,162 @@
+// Package features toggles the subsystems of a service(tracing, profiling, crash reports, ...) through a single
+// FEATURES environment variable, eg.
+//
+//	FEATURES=-profiling,+shadowdiff
+//
+// enables shadowdiff and disables profiling, leaving the other features at their defaults. Features can require
+// others(eg. shadowdiff requires mirroring), the set being rejected if a feature is enabled without them.
+// Services register their own features alongside the ones of vcore:
+//
+//	set, err := features.FromEnv(
+//		features.Feature{Name: "mirroring"},
+//		features.Feature{Name: "shadowdiff", Requires: []string{"mirroring"}},
+//	)
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/skit-ai/vcore/env"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+)
+
+// Features of vcore
+const (
+	// OpenTelemetry tracing(instruments.InitProvider)
+	Tracing = "tracing"
+	// Continuous profiling(profile.InitPyroscope)
+	Profiling = "profiling"
+	// Crash reports written to disk(surveillance/crash)
+	CrashReports = "crash-reports"
+	// Runtime monitor of the number of goroutines(leakcheck)
+	GoroutineMonitor = "goroutine-monitor"
+)
+
+type Feature struct {
+	Name        string
+	Description string
+	// Disabled features have to be enabled explicitly
+	Disabled bool
+	// Features which are to be enabled for the feature to be enabled
+	Requires []string
+}
+
+// Builtin features of vcore, enabled unless disabled through FEATURES
+var Builtin = []Feature{
+	{Name: Tracing, Description: "OpenTelemetry tracing"},
+	{Name: Profiling, Description: "Continuous profiling with Pyroscope"},
+	{Name: CrashReports, Description: "Crash reports written to disk"},
+	{Name: GoroutineMonitor, Description: "Alerts on a growing number of goroutines", Disabled: true},
+}
+
+// Set of the features resolved
+type Set struct {
+	features map[string]Feature
+	enabled  map[string]bool
+}
+
+// Resolve resolves the features as per the spec(a comma separated list of the features to enable, prefixed with
+// "-" those to disable) on top of their defaults. Fails if the spec names an unknown feature or a feature is
+// enabled without the features it requires.
+func Resolve(spec string, features ...Feature) (*Set, error) {
+	s := &Set{features: make(map[string]Feature), enabled: make(map[string]bool)}
+	for _, feature := range append(append([]Feature(nil), Builtin...), features...) {
+		s.features[feature.Name] = feature
+		s.enabled[feature.Name] = !feature.Disabled
+	}
+
+	for _, toggle := range strings.Split(spec, ",") {
+		toggle = strings.TrimSpace(toggle)
+		if toggle == "" {
+			continue
+		}
+
+		enable := !strings.HasPrefix(toggle, "-")
+		name := strings.TrimLeft(toggle, "+-")
+		if _, ok := s.features[name]; !ok {
+			return nil, errors.NewError(fmt.Sprintf("Unknown feature %q in FEATURES", name), nil, false)
+		}
+		s.enabled[name] = enable
+	}
+
+	for _, name := range s.names() {
+		if !s.enabled[name] {
+			continue
+		}
+		for _, required := range s.features[name].Requires {
+			if _, ok := s.features[required]; !ok {
+				return nil, errors.NewError(fmt.Sprintf("Feature %s requires the unknown feature %s", name, required), nil, false)
+			}
+			if !s.enabled[required] {
+				return nil, errors.NewError(fmt.Sprintf("Feature %s requires %s, which is disabled", name, required), nil, false)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Enabled is true if the feature is enabled. Unknown features are disabled.
+func (s *Set) Enabled(name string) bool {
+	return s.enabled[name]
+}
+
+// String lists the features with their state, eg. "crash-reports=on profiling=off tracing=on"
+func (s *Set) String() string {
+	toggles := make([]string, 0, len(s.enabled))
+	for _, name := range s.names() {
+		state := "off"
+		if s.enabled[name] {
+			state = "on"
+		}
+		toggles = append(toggles, name+"="+state)
+	}
+	return strings.Join(toggles, " ")
+}
+
+func (s *Set) names() []string {
+	names := make([]string, 0, len(s.features))
+	for name := range s.features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	mutex   sync.RWMutex
+	current *Set
+)
+
+// FromEnv resolves the features as per FEATURES, logs the set resolved and makes it the one Enabled checks
+func FromEnv(features ...Feature) (*Set, error) {
+	s, err := Resolve(env.String("FEATURES", ""), features...)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Features: %s", s)
+
+	mutex.Lock()
+	current = s
+	mutex.Unlock()
+	return s, nil
+}
+
+// Enabled is true if the feature is enabled in the set resolved by FromEnv. Until FromEnv is called, the builtin
+// features are resolved as per FEATURES(or their defaults if it names features of the service).
+func Enabled(name string) bool {
+	mutex.RLock()
+	s := current
+	mutex.RUnlock()
+
+	if s == nil {
+		var err error
+		if s, err = Resolve(env.String("FEATURES", "")); err != nil {
+			s, _ = Resolve("")
+		}
+	}
+	return s.Enabled(name)
+}
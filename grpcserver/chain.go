@@ -0,0 +1,206 @@
+// Package grpcserver builds the interceptors of gRPC servers in a fixed order, so that eg. the recovery of panics by
+// Sentry runs before(around) the auth interceptors instead of after them, losing the context of the panics in them.
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/overrides"
+	sentryWrapper "github.com/skit-ai/vcore/sentry"
+	"github.com/skit-ai/vcore/surveillance"
+	"google.golang.org/grpc"
+)
+
+// Position of an interceptor in a chain. Interceptors run in the order of their positions(the first around all the
+// others), and in the order they were added within a position.
+type Position int
+
+const (
+	// Unspecified is rejected, interceptors must declare their position
+	Unspecified Position = iota
+	// Recovery of panics and reporting of errors(eg. to Sentry), around all the others
+	Recovery
+	// Tracing of the RPCs(eg. OpenTelemetry)
+	Tracing
+	// Logging of the RPCs
+	Logging
+	// Deadlines and the other overrides of the routes
+	Deadline
+	// Authentication and authorization of the callers
+	Auth
+	// Rate limiting of the callers, once they are known
+	RateLimit
+	// Validation of the requests
+	Validation
+	// Application interceptors, closest to the handlers
+	Application
+)
+
+var positionNames = map[Position]string{
+	Recovery:    "recovery",
+	Tracing:     "tracing",
+	Logging:     "logging",
+	Deadline:    "deadline",
+	Auth:        "auth",
+	RateLimit:   "rate_limit",
+	Validation:  "validation",
+	Application: "application",
+}
+
+func (p Position) String() string {
+	if name, ok := positionNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Position(%d)", int(p))
+}
+
+type unaryInterceptor struct {
+	name        string
+	position    Position
+	interceptor grpc.UnaryServerInterceptor
+}
+
+type streamInterceptor struct {
+	name        string
+	position    Position
+	interceptor grpc.StreamServerInterceptor
+}
+
+// InterceptorChain orders the unary and stream interceptors of a server by their positions. Eg.
+//
+//	options, err := grpcserver.NewInterceptorChain().
+//		Sentry(surveillance.SentryClient).
+//		Overrides(routes).
+//		Unary(grpcserver.Auth, "jwt", auth.UnaryServerInterceptor).
+//		ServerOptions()
+type InterceptorChain struct {
+	unary  []unaryInterceptor
+	stream []streamInterceptor
+}
+
+// NewInterceptorChain returns an empty chain
+func NewInterceptorChain() *InterceptorChain {
+	return &InterceptorChain{}
+}
+
+// Unary adds the unary interceptor at the position, with a name identifying it in the errors of the chain
+func (c *InterceptorChain) Unary(position Position, name string, interceptor grpc.UnaryServerInterceptor) *InterceptorChain {
+	c.unary = append(c.unary, unaryInterceptor{name: name, position: position, interceptor: interceptor})
+	return c
+}
+
+// Stream adds the stream interceptor at the position, with a name identifying it in the errors of the chain
+func (c *InterceptorChain) Stream(position Position, name string, interceptor grpc.StreamServerInterceptor) *InterceptorChain {
+	c.stream = append(c.stream, streamInterceptor{name: name, position: position, interceptor: interceptor})
+	return c
+}
+
+// Sentry adds the interceptors of the sentry client at the Recovery position
+func (c *InterceptorChain) Sentry(client *surveillance.Sentry, opts ...sentryWrapper.Option) *InterceptorChain {
+	return c.Unary(Recovery, "sentry", client.UnaryServerInterceptor(opts...)).
+		Stream(Recovery, "sentry", client.StreamServerInterceptor(opts...))
+}
+
+// Overrides adds the interceptors of the overrides of the routes at the Deadline position
+func (c *InterceptorChain) Overrides(routes *overrides.Routes) *InterceptorChain {
+	return c.Unary(Deadline, "overrides", routes.UnaryServerInterceptor()).
+		Stream(Deadline, "overrides", routes.StreamServerInterceptor())
+}
+
+// Returns an error if an interceptor has no(or an unknown) position, if names are repeated or if there is more than an
+// interceptor recovering panics
+func validate(kind string, names []string, at []Position) error {
+	seen := make(map[string]bool)
+	recoveries := 0
+	for i, name := range names {
+		if _, ok := positionNames[at[i]]; !ok {
+			return errors.NewError(fmt.Sprintf("the %s interceptor %q does not declare its position", kind, name), nil, false)
+		}
+		if seen[name] {
+			return errors.NewError(fmt.Sprintf("the %s interceptor %q is added more than once", kind, name), nil, false)
+		}
+		seen[name] = true
+		if at[i] == Recovery {
+			recoveries++
+		}
+	}
+	if recoveries > 1 {
+		return errors.NewError(fmt.Sprintf("%d %s interceptors recover panics, only one can", recoveries, kind), nil, false)
+	}
+	return nil
+}
+
+// Validate returns an error if an interceptor has no(or an unknown) position, if names are repeated within the unary
+// or the stream interceptors, or if more than one of them recovers panics
+func (c *InterceptorChain) Validate() error {
+	names := make([]string, len(c.unary))
+	unaryPositions := make([]Position, len(c.unary))
+	for i, u := range c.unary {
+		names[i], unaryPositions[i] = u.name, u.position
+	}
+	if err := validate("unary", names, unaryPositions); err != nil {
+		return err
+	}
+
+	names = make([]string, len(c.stream))
+	streamPositions := make([]Position, len(c.stream))
+	for i, s := range c.stream {
+		names[i], streamPositions[i] = s.name, s.position
+	}
+	return validate("stream", names, streamPositions)
+}
+
+// UnaryInterceptor returns the unary interceptors chained in the order of their positions
+func (c *InterceptorChain) UnaryInterceptor() (grpc.UnaryServerInterceptor, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	interceptors := append([]unaryInterceptor(nil), c.unary...)
+	sort.SliceStable(interceptors, func(i, j int) bool { return interceptors[i].position < interceptors[j].position })
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		// Wrapping the handler with the innermost interceptor first
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i].interceptor, handler
+			handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}, nil
+}
+
+// StreamInterceptor returns the stream interceptors chained in the order of their positions
+func (c *InterceptorChain) StreamInterceptor() (grpc.StreamServerInterceptor, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	interceptors := append([]streamInterceptor(nil), c.stream...)
+	sort.SliceStable(interceptors, func(i, j int) bool { return interceptors[i].position < interceptors[j].position })
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i].interceptor, handler
+			handler = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, next)
+			}
+		}
+		return handler(srv, ss)
+	}, nil
+}
+
+// ServerOptions returns the options of a server with the chained interceptors, or an error if the chain is invalid
+func (c *InterceptorChain) ServerOptions() ([]grpc.ServerOption, error) {
+	unary, err := c.UnaryInterceptor()
+	if err != nil {
+		return nil, err
+	}
+	stream, err := c.StreamInterceptor()
+	if err != nil {
+		return nil, err
+	}
+	return []grpc.ServerOption{grpc.UnaryInterceptor(unary), grpc.StreamInterceptor(stream)}, nil
+}
@@ -0,0 +1,225 @@
+// Package guardrail tracks the spend of every tenant on the vendors(LLM tokens, TTS characters, call minutes) within
+// a window, and decides whether their non-critical usage goes on, is degraded(eg. to a cheaper model or cached
+// prompts) or is blocked once their thresholds are exceeded.
+//
+//	guard := guardrail.New(map[guardrail.Unit]guardrail.Limit{
+//		guardrail.LLMTokens: {Soft: 800_000, Hard: 1_000_000},
+//	}, guardrail.WithAlerter(page))
+//
+//	if guard.Check(tenant, guardrail.LLMTokens, false) == guardrail.Block {
+//		return errors.WithCode(errors.NewError("LLM budget exhausted", nil, false), errors.ResourceExhausted)
+//	}
+//	...
+//	guard.Record(ctx, tenant, guardrail.LLMTokens, float64(usage.TotalTokens))
+//
+// The spend is tracked in the memory of the process, so every replica enforces the thresholds on its own share.
+package guardrail
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/skit-ai/vcore/log/slog"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+// Unit of the spend on a vendor
+type Unit string
+
+const (
+	LLMTokens     Unit = "llm_tokens"
+	TTSCharacters Unit = "tts_characters"
+	CallMinutes   Unit = "call_minutes"
+)
+
+// Limit of the spend of a tenant in a unit within a window. Usage is degraded past Soft and blocked past Hard, a zero
+// threshold is not checked.
+type Limit struct {
+	Soft float64 `json:"soft" yaml:"soft"`
+	Hard float64 `json:"hard" yaml:"hard"`
+}
+
+// Decision on the usage of a tenant
+type Decision int
+
+const (
+	Allow Decision = iota
+	Degrade
+	Block
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Degrade:
+		return "degrade"
+	case Block:
+		return "block"
+	}
+	return "allow"
+}
+
+// Alert is raised once per window when the spend of a tenant crosses a threshold
+type Alert struct {
+	Tenant    string
+	Unit      Unit
+	Spent     float64
+	Threshold float64
+	// Decision of the usage past the threshold, Degrade or Block
+	Decision Decision
+}
+
+// Alerter delivers the alerts, eg. to a pager or a Slack channel
+type Alerter func(ctx context.Context, alert Alert)
+
+type spend struct {
+	window time.Time
+	spent  float64
+	// Decision of the last alert raised in the window
+	alerted Decision
+}
+
+type key struct {
+	tenant string
+	unit   Unit
+}
+
+// Guardrail tracks the spend of the tenants against their limits
+type Guardrail struct {
+	limits  map[Unit]Limit
+	tenants map[string]map[Unit]Limit
+	window  time.Duration
+	clock   simulation.Clock
+	alerter Alerter
+	logger  slog.Logger
+
+	mutex sync.Mutex
+	spent map[key]*spend
+}
+
+// Option configures a Guardrail
+type Option func(*Guardrail)
+
+// WithWindow configures the window the spend is tracked within(aligned to UTC), defaults to a day
+func WithWindow(window time.Duration) Option {
+	return func(g *Guardrail) {
+		g.window = window
+	}
+}
+
+// WithTenantLimits overrides the limits of a tenant, eg. for the tenants on a larger plan
+func WithTenantLimits(tenant string, limits map[Unit]Limit) Option {
+	return func(g *Guardrail) {
+		g.tenants[tenant] = limits
+	}
+}
+
+// WithAlerter configures the alerter of the thresholds crossed, the alerts are only logged by default
+func WithAlerter(alerter Alerter) Option {
+	return func(g *Guardrail) {
+		g.alerter = alerter
+	}
+}
+
+// WithClock configures the clock of the windows, eg. a simulation.Simulation in tests. Defaults to simulation.Real.
+func WithClock(clock simulation.Clock) Option {
+	return func(g *Guardrail) {
+		g.clock = clock
+	}
+}
+
+// New returns a guardrail enforcing the limits of the units on every tenant
+func New(limits map[Unit]Limit, opts ...Option) *Guardrail {
+	g := &Guardrail{
+		limits:  limits,
+		tenants: make(map[string]map[Unit]Limit),
+		window:  24 * time.Hour,
+		clock:   simulation.Real,
+		logger:  slog.NewLogger(),
+		spent:   make(map[key]*spend),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Returns the limit of the tenant in the unit
+func (g *Guardrail) limit(tenant string, unit Unit) Limit {
+	if limits, ok := g.tenants[tenant]; ok {
+		if limit, ok := limits[unit]; ok {
+			return limit
+		}
+	}
+	return g.limits[unit]
+}
+
+// Returns the spend of the tenant in the current window, reset once the window is over. Must hold the mutex.
+func (g *Guardrail) current(k key) *spend {
+	window := g.clock.Now().UTC().Truncate(g.window)
+	s, ok := g.spent[k]
+	if !ok || !s.window.Equal(window) {
+		s = &spend{window: window}
+		g.spent[k] = s
+	}
+	return s
+}
+
+func (l Limit) decide(spent float64) (Decision, float64) {
+	switch {
+	case l.Hard > 0 && spent >= l.Hard:
+		return Block, l.Hard
+	case l.Soft > 0 && spent >= l.Soft:
+		return Degrade, l.Soft
+	}
+	return Allow, 0
+}
+
+// Record adds the amount to the spend of the tenant in the unit, alerting if it crosses a threshold
+func (g *Guardrail) Record(ctx context.Context, tenant string, unit Unit, amount float64) {
+	g.mutex.Lock()
+	s := g.current(key{tenant, unit})
+	s.spent += amount
+	decision, threshold := g.limit(tenant, unit).decide(s.spent)
+	raise := decision > s.alerted
+	if raise {
+		s.alerted = decision
+	}
+	spent := s.spent
+	g.mutex.Unlock()
+
+	if !raise {
+		return
+	}
+	alert := Alert{Tenant: tenant, Unit: unit, Spent: spent, Threshold: threshold, Decision: decision}
+	g.logger.WithTraceId(ctx).WithFields(map[string]any{
+		"tenant":    tenant,
+		"unit":      unit,
+		"spent":     spent,
+		"threshold": threshold,
+		"decision":  decision.String(),
+	}).Warn("Tenant crossed a spend threshold")
+	if g.alerter != nil {
+		g.alerter(ctx, alert)
+	}
+}
+
+// Check returns the decision on the usage of the tenant in the unit. Critical usage(eg. the turns of a live call) is
+// always allowed, the thresholds only degrade or block the rest.
+func (g *Guardrail) Check(tenant string, unit Unit, critical bool) Decision {
+	if critical {
+		return Allow
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	decision, _ := g.limit(tenant, unit).decide(g.current(key{tenant, unit}).spent)
+	return decision
+}
+
+// Spent returns the spend of the tenant in the unit within the current window
+func (g *Guardrail) Spent(tenant string, unit Unit) float64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.current(key{tenant, unit}).spent
+}
@@ -0,0 +1,282 @@
+// Package hashring assigns keys(eg. calls or tenants) to members(eg. the replicas of a worker) with consistent hashing,
+// so that only the keys of the members added or removed move when a deployment scales. Eg.
+//
+//	ring := hashring.New(hashring.WithLoadFactor(1.25))
+//	go ring.Watch(ctx, replicas) // membership updates from service discovery
+//	...
+//	replica, ok := ring.Acquire(callID)
+//	defer ring.Release(replica)
+//
+// Every member is placed on the ring at many virtual nodes, spreading the keys evenly. With a load factor, the
+// members are bounded to that factor of the average load(see Acquire), as per "Consistent Hashing with Bounded Loads"
+// (Mirrokni et al.), so that hot keys do not overload a member.
+package hashring
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Ring of the members
+type Ring struct {
+	replicas   int
+	loadFactor float64
+	hash       func(key string) uint64
+
+	mutex   sync.RWMutex
+	members map[string]bool
+	// Virtual nodes sorted by their hashes
+	nodes []node
+	loads map[string]int
+	total int
+}
+
+type node struct {
+	hash   uint64
+	member string
+}
+
+// Option configures a Ring
+type Option func(*Ring)
+
+// WithReplicas configures the number of virtual nodes of every member, defaults to 160. More virtual nodes spread the
+// keys more evenly, at the cost of memory and of the time to update the membership.
+func WithReplicas(replicas int) Option {
+	return func(r *Ring) {
+		r.replicas = replicas
+	}
+}
+
+// WithLoadFactor bounds the load of every member to the factor(eg. 1.25) of the average load, for the keys acquired
+// (see Acquire). The loads are not bounded by default.
+func WithLoadFactor(factor float64) Option {
+	return func(r *Ring) {
+		r.loadFactor = factor
+	}
+}
+
+// WithHash configures the hash of the keys and the virtual nodes, defaults to FNV-1a finalized with the mixer of
+// SplitMix64
+func WithHash(hash func(key string) uint64) Option {
+	return func(r *Ring) {
+		r.hash = hash
+	}
+}
+
+// New returns an empty ring
+func New(opts ...Option) *Ring {
+	r := &Ring{
+		replicas: 160,
+		hash:     defaultHash,
+		members:  make(map[string]bool),
+		loads:    make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.replicas <= 0 {
+		r.replicas = 1
+	}
+	return r
+}
+
+func defaultHash(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	x := h.Sum64()
+
+	// FNV does not spread similar keys(eg. "worker-1#1", "worker-1#2") well enough on its own
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Add adds the members to the ring
+func (r *Ring) Add(members ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, member := range members {
+		r.members[member] = true
+	}
+	r.rebuild()
+}
+
+// Remove removes the members from the ring, along with their loads
+func (r *Ring) Remove(members ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, member := range members {
+		delete(r.members, member)
+		r.total -= r.loads[member]
+		delete(r.loads, member)
+	}
+	r.rebuild()
+}
+
+// Set replaces the members of the ring, eg. with the replicas discovered. The loads of the members remaining are kept.
+func (r *Ring) Set(members ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := make(map[string]bool, len(members))
+	for _, member := range members {
+		current[member] = true
+	}
+	for member := range r.members {
+		if !current[member] {
+			r.total -= r.loads[member]
+			delete(r.loads, member)
+		}
+	}
+	r.members = current
+	r.rebuild()
+}
+
+// Watch sets the members of the ring(see Set) to every update of the membership, eg. from service discovery, until the
+// context is done or the updates are closed
+func (r *Ring) Watch(ctx context.Context, updates <-chan []string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case members, ok := <-updates:
+			if !ok {
+				return
+			}
+			r.Set(members...)
+		}
+	}
+}
+
+// Places the virtual nodes of the members on the ring. Must hold the mutex.
+func (r *Ring) rebuild() {
+	nodes := make([]node, 0, len(r.members)*r.replicas)
+	for member := range r.members {
+		for i := 0; i < r.replicas; i++ {
+			nodes = append(nodes, node{hash: r.hash(member + "#" + strconv.Itoa(i)), member: member})
+		}
+	}
+	// Ties are broken by the members, so that every replica builds the same ring
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].hash != nodes[j].hash {
+			return nodes[i].hash < nodes[j].hash
+		}
+		return nodes[i].member < nodes[j].member
+	})
+	r.nodes = nodes
+}
+
+// Members returns the members of the ring, sorted
+func (r *Ring) Members() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	members := make([]string, 0, len(r.members))
+	for member := range r.members {
+		members = append(members, member)
+	}
+	slices.Sort(members)
+	return members
+}
+
+// Returns the index of the first virtual node at or past the hash of the key. Must hold the mutex.
+func (r *Ring) search(key string) int {
+	hash := r.hash(key)
+	i := sort.Search(len(r.nodes), func(i int) bool { return r.nodes[i].hash >= hash })
+	if i == len(r.nodes) {
+		i = 0
+	}
+	return i
+}
+
+// Get returns the member the key is assigned to, regardless of the loads. Returns false if the ring is empty.
+func (r *Ring) Get(key string) (string, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if len(r.nodes) == 0 {
+		return "", false
+	}
+	return r.nodes[r.search(key)].member, true
+}
+
+// GetN returns up to n distinct members for the key, from the member it is assigned to onwards, eg. for the replicas
+// of the state of a key
+func (r *Ring) GetN(key string, n int) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if len(r.nodes) == 0 || n <= 0 {
+		return nil
+	}
+
+	n = min(n, len(r.members))
+	members := make([]string, 0, n)
+	for i, start := 0, r.search(key); len(members) < n; i++ {
+		member := r.nodes[(start+i)%len(r.nodes)].member
+		if !slices.Contains(members, member) {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// Acquire assigns the key to a member, adding to the load of the member until it is released(see Release). With a
+// load factor, the key goes to the first member from the one it is assigned to onwards which is within the bound of
+// the load(see WithLoadFactor). Returns false if the ring is empty.
+func (r *Ring) Acquire(key string) (string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.nodes) == 0 {
+		return "", false
+	}
+
+	start := r.search(key)
+	member := r.nodes[start].member
+	if r.loadFactor > 0 {
+		bound := r.bound()
+		for i := 0; i < len(r.nodes); i++ {
+			if candidate := r.nodes[(start+i)%len(r.nodes)].member; r.loads[candidate]+1 <= bound {
+				member = candidate
+				break
+			}
+		}
+	}
+
+	r.loads[member]++
+	r.total++
+	return member, true
+}
+
+// Returns the bound of the load of the members, including the key being acquired. Must hold the mutex.
+func (r *Ring) bound() int {
+	return int(math.Ceil(float64(r.total+1) / float64(len(r.members)) * r.loadFactor))
+}
+
+// Release releases a key acquired by the member(see Acquire)
+func (r *Ring) Release(member string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.loads[member] > 0 {
+		r.loads[member]--
+		r.total--
+	}
+}
+
+// Loads returns the number of keys acquired by every member
+func (r *Ring) Loads() map[string]int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	loads := make(map[string]int, len(r.members))
+	for member := range r.members {
+		loads[member] = r.loads[member]
+	}
+	return loads
+}
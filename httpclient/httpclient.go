@@ -0,0 +1,192 @@
+// Package httpclient is a JSON client of REST services retrying transient failures, and tracing the requests through
+// the Sentry wrapper if configured to. It is the client the clients generated by openapi.Generate are built on.
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+// StatusError is returned for the responses of a status of 400 or over
+type StatusError struct {
+	Method string
+	URL    string
+	Status int
+	Body   []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s responded with %d %s: %s", e.Method, e.URL, e.Status, http.StatusText(e.Status), bytes.TrimSpace(e.Body))
+}
+
+type Client struct {
+	baseURL string
+	client  *http.Client
+	headers http.Header
+	retries int
+	backoff time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient configures the HTTP client making the requests. Defaults to a client with a timeout of 30s.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithRetries configures the number of retries of the requests failing transiently(network errors, 429, 502, 503 and
+// 504), backing off exponentially from backoff. Only idempotent requests are retried. Defaults to 2 retries from 100ms.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// WithSentry traces the requests through the wrapper(see surveillance.WrapTransport). Apply it after WithHTTPClient.
+func WithSentry(wrapper *surveillance.Sentry) Option {
+	return func(c *Client) {
+		client := *c.client
+		client.Transport = wrapper.WrapTransport(client.Transport)
+		c.client = &client
+	}
+}
+
+// WithHeader configures a header set on all the requests(eg. Authorization)
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(key, value)
+	}
+}
+
+// New returns a client of the service at the base URL
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+		headers: make(http.Header),
+		retries: 2,
+		backoff: 100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RequestOption configures a request
+type RequestOption func(*http.Request)
+
+// WithQuery adds a parameter to the query string of the request
+func WithQuery(key, value string) RequestOption {
+	return func(r *http.Request) {
+		query := r.URL.Query()
+		query.Add(key, value)
+		r.URL.RawQuery = query.Encode()
+	}
+}
+
+// WithRequestHeader sets a header of the request
+func WithRequestHeader(key, value string) RequestOption {
+	return func(r *http.Request) {
+		r.Header.Set(key, value)
+	}
+}
+
+var idempotent = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPut: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// Do sends the body(if not nil) encoded as JSON to the path and decodes the response into out(if not nil).
+// Returns a *StatusError for responses of a status of 400 or over.
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}, opts ...RequestOption) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	retries := 0
+	if idempotent[method] {
+		retries = c.retries
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := c.do(ctx, method, path, payload, out, opts)
+		if err == nil || !retry || attempt >= retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Sends a request, returning true if its failure is to be retried
+func (c *Client) do(ctx context.Context, method, path string, payload []byte, out interface{}, opts []RequestOption) (bool, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return false, err
+	}
+	for key, values := range c.headers {
+		request.Header[key] = values
+	}
+	request.Header.Set("Accept", "application/json")
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	for _, opt := range opts {
+		opt(request)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		data, _ := io.ReadAll(io.LimitReader(response.Body, 64<<10))
+		switch response.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true, &StatusError{Method: method, URL: redact(request.URL), Status: response.StatusCode, Body: data}
+		}
+		return false, &StatusError{Method: method, URL: redact(request.URL), Status: response.StatusCode, Body: data}
+	}
+
+	if out == nil || response.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, response.Body)
+		return false, nil
+	}
+	return false, json.NewDecoder(response.Body).Decode(out)
+}
+
+// Returns the URL sans the query string, which may carry PII
+func redact(u *url.URL) string {
+	redacted := *u
+	redacted.RawQuery, redacted.User = "", nil
+	return redacted.String()
+}
@@ -0,0 +1,68 @@
+package httpserver
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/skit-ai/vcore/realip"
+)
+
+// AllowHosts responds 421(Misdirected Request) to the requests for hosts other than those allowed, eg. for the admin
+// endpoints of a service exposed on a shared ingress. Hosts are matched without their ports and case, and hosts
+// starting with "*." match their subdomains(eg. "*.skit.ai").
+func AllowHosts(hosts ...string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(hosts))
+	var suffixes []string
+	for _, host := range hosts {
+		host = strings.ToLower(host)
+		if strings.HasPrefix(host, "*.") {
+			suffixes = append(suffixes, host[1:])
+		} else {
+			allowed[host] = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host := strings.ToLower(r.Host)
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+
+			ok := allowed[host]
+			for _, suffix := range suffixes {
+				ok = ok || strings.HasSuffix(host, suffix)
+			}
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusMisdirectedRequest), http.StatusMisdirectedRequest)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AllowIPs responds 403 to the requests of clients outside the CIDRs allowed. The IP of the client is that of the
+// connection, or the one forwarded by the proxies within the CIDRs trusted(see realip). Returns an error if a CIDR
+// cannot be parsed.
+func AllowIPs(cidrs, trustedProxies []string) (func(http.Handler) http.Handler, error) {
+	allowed, err := realip.ParsePrefixes(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := realip.New(trustedProxies...)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if addr, ok := resolver.ClientIP(r); !ok || !realip.Contains(allowed, addr) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
@@ -0,0 +1,156 @@
+// Package httpserver runs the HTTP servers of services, draining them on SIGINT/SIGTERM(or once the context is done)
+// instead of dropping the requests in flight. Eg.
+//
+//	if err := httpserver.Run(ctx, router, httpserver.WithH2C()); err != nil {
+//		log.Error(err)
+//	}
+package httpserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/skit-ai/vcore/env"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+type options struct {
+	addr              string
+	listen            listen
+	readHeaderTimeout time.Duration
+	shutdownTimeout   time.Duration
+	// Serves HTTP/2 over cleartext(prior knowledge or upgrades) along with HTTP/1
+	h2c bool
+	// Streams per HTTP/2 connection(0 for the default of x/net, 250), and the duration after which idle connections are
+	// closed(0 for the idle timeout of the server)
+	maxConcurrentStreams uint32
+	idleTimeout          time.Duration
+}
+
+// Option configures Run
+type Option func(*options)
+
+// WithAddr configures the address served, defaults to HTTP_ADDR(":8080")
+func WithAddr(addr string) Option {
+	return func(o *options) {
+		o.addr = addr
+	}
+}
+
+// WithReadHeaderTimeout configures the time allowed to read the headers of a request, defaults to
+// HTTP_READ_HEADER_TIMEOUT(10s)
+func WithReadHeaderTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.readHeaderTimeout = timeout
+	}
+}
+
+// WithShutdownTimeout configures the time the requests in flight are given to complete once the server is shutting
+// down, defaults to HTTP_SHUTDOWN_TIMEOUT(10s)
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.shutdownTimeout = timeout
+	}
+}
+
+// WithH2C serves HTTP/2 over cleartext along with HTTP/1(eg. for gRPC-gateway and streaming endpoints behind load
+// balancers not terminating TLS), defaults to HTTP_H2C(false)
+func WithH2C() Option {
+	return func(o *options) {
+		o.h2c = true
+	}
+}
+
+// WithHTTP2 tunes the HTTP/2 connections with the streams allowed per connection and the duration after which idle
+// connections are closed. Defaults to HTTP2_MAX_CONCURRENT_STREAMS(250) and HTTP2_IDLE_TIMEOUT(the idle timeout of the
+// server).
+func WithHTTP2(maxConcurrentStreams uint32, idleTimeout time.Duration) Option {
+	return func(o *options) {
+		o.maxConcurrentStreams = maxConcurrentStreams
+		o.idleTimeout = idleTimeout
+	}
+}
+
+func defaultOptions() options {
+	listen := listenTCP
+	switch socket := env.String("HTTP_SYSTEMD_SOCKET", ""); socket {
+	case "", "false":
+	case "true":
+		listen = func(string) (net.Listener, error) { return listenSystemd("") }
+	default:
+		listen = func(string) (net.Listener, error) { return listenSystemd(socket) }
+	}
+
+	return options{
+		addr:                 env.String("HTTP_ADDR", ":8080"),
+		listen:               listen,
+		readHeaderTimeout:    env.Duration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+		shutdownTimeout:      env.Duration("HTTP_SHUTDOWN_TIMEOUT", 10*time.Second),
+		h2c:                  env.Bool("HTTP_H2C", false),
+		maxConcurrentStreams: uint32(env.Int("HTTP2_MAX_CONCURRENT_STREAMS", 0)),
+		idleTimeout:          env.Duration("HTTP2_IDLE_TIMEOUT", 0),
+	}
+}
+
+// Run serves the handler until the context is done or the process receives SIGINT/SIGTERM, and then shuts the server
+// down gracefully. Returns nil once the server is shut down, or the error the server failed with.
+func Run(ctx context.Context, handler http.Handler, opts ...Option) error {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	server := &http.Server{
+		Addr:              o.addr,
+		Handler:           handler,
+		ReadHeaderTimeout: o.readHeaderTimeout,
+	}
+	h2 := &http2.Server{MaxConcurrentStreams: o.maxConcurrentStreams, IdleTimeout: o.idleTimeout}
+	if o.h2c {
+		server.Handler = h2c.NewHandler(handler, h2)
+	} else if err := http2.ConfigureServer(server, h2); err != nil {
+		return errors.NewError("Could not configure HTTP/2", err, false)
+	}
+
+	listener, err := o.listen(o.addr)
+	if err != nil {
+		return err
+	}
+	return serve(ctx, server, listener, o.shutdownTimeout)
+}
+
+// Serves on the listener until the context is done or the process is signalled to terminate
+func serve(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	failed := make(chan error, 1)
+	go func() {
+		log.Infof("Serving HTTP on %s", listener.Addr())
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			failed <- err
+		}
+		close(failed)
+	}()
+
+	select {
+	case err := <-failed:
+		return errors.NewError("Could not serve HTTP", err, false)
+	case <-ctx.Done():
+	}
+
+	log.Infof("Shutting down the HTTP server, waiting %s for the requests in flight", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return errors.NewError("Could not shut down the HTTP server gracefully", err, false)
+	}
+	return nil
+}
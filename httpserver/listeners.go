@@ -0,0 +1,102 @@
+package httpserver
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// First file descriptor passed by systemd, see sd_listen_fds(3)
+const listenFDsStart = 3
+
+// Returns the listener of the server
+type listen func(addr string) (net.Listener, error)
+
+func listenTCP(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.NewError("Could not listen on "+addr, err, false)
+	}
+	return listener, nil
+}
+
+// WithListener serves on the listener instead of the address(see WithAddr)
+func WithListener(listener net.Listener) Option {
+	return func(o *options) {
+		o.listen = func(string) (net.Listener, error) {
+			return listener, nil
+		}
+	}
+}
+
+// WithUnixSocket serves on a unix domain socket at the path(eg. for the sidecars on the same host), with the
+// permissions of the socket file(eg. 0660). A stale socket left at the path is removed, and the socket is removed once
+// the server is shut down.
+func WithUnixSocket(path string, mode os.FileMode) Option {
+	return func(o *options) {
+		o.listen = func(string) (net.Listener, error) {
+			return listenUnix(path, mode)
+		}
+	}
+}
+
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, errors.NewError("Could not remove the stale socket "+path, err, false)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.NewError("Could not listen on "+path, err, false)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, errors.NewError("Could not set the permissions of "+path, err, false)
+	}
+	return listener, nil
+}
+
+// WithSystemdSocket serves on a socket passed by systemd socket activation, the socket with the name(FileDescriptorName=
+// of the socket unit) or the first socket if the name is empty. Defaults to HTTP_SYSTEMD_SOCKET, a name or "true" for
+// the first socket.
+func WithSystemdSocket(name string) Option {
+	return func(o *options) {
+		o.listen = func(string) (net.Listener, error) {
+			return listenSystemd(name)
+		}
+	}
+}
+
+func listenSystemd(name string) (net.Listener, error) {
+	// The sockets are passed to the process systemd started, and not to its children
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, errors.NewError("No sockets were passed by systemd", nil, false)
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, errors.NewError("No sockets were passed by systemd", err, false)
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < count; i++ {
+		if name != "" && (i >= len(names) || names[i] != name) {
+			continue
+		}
+
+		file := os.NewFile(uintptr(listenFDsStart+i), fmt.Sprintf("LISTEN_FD_%d", listenFDsStart+i))
+		listener, err := net.FileListener(file)
+		// The listener holds a duplicate of the descriptor
+		file.Close()
+		if err != nil {
+			return nil, errors.NewError("Could not listen on the socket passed by systemd", err, false)
+		}
+		return listener, nil
+	}
+	return nil, errors.NewError(fmt.Sprintf("No socket named %q was passed by systemd", name), nil, false)
+}
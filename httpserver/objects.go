@@ -0,0 +1,202 @@
+package httpserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+)
+
+// Object is the metadata of an object of a storage bucket
+type Object struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+	// ETag is the quoted entity tag of the object, eg. the ETag of S3
+	ETag string
+}
+
+// ObjectStore reads the objects of a storage bucket, eg. aws.S3Objects. Stat returns an error with the code
+// errors.NotFound(see errors.WithCode) for the objects which do not exist.
+type ObjectStore interface {
+	Stat(ctx context.Context, key string) (Object, error)
+	// Open returns the content of the object from the offset to its end
+	Open(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+}
+
+// URLSigner is implemented by the stores which can sign URLs clients download the objects from directly
+type URLSigner interface {
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// Types of the audio formats, as the stores often have them as application/octet-stream
+var audioTypes = map[string]string{
+	".wav":  "audio/wav",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".opus": "audio/ogg",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".webm": "audio/webm",
+}
+
+type objectOptions struct {
+	redirectAbove int64
+	expiry        time.Duration
+}
+
+// ObjectOption configures ServeObject
+type ObjectOption func(*objectOptions)
+
+// WithSignedRedirects redirects the clients to a URL signed for the expiry(if the store is a URLSigner) for the objects
+// larger than the size, so that they do not go through the service. Clients are redirected too if the store cannot be
+// reached. Defaults to serving every object through the service.
+func WithSignedRedirects(size int64, expiry time.Duration) ObjectOption {
+	return func(o *objectOptions) {
+		o.redirectAbove = size
+		o.expiry = expiry
+	}
+}
+
+// ServeObject serves the objects of the store with the keys returned for the requests, eg. for the playback of
+// recordings:
+//
+//	mux.Handle("/recordings/", httpserver.ServeObject(recordings, func(r *http.Request) string {
+//		return strings.TrimPrefix(r.URL.Path, "/recordings/")
+//	}))
+//
+// Range requests are served by reading the objects from the offsets requested, so that clients can seek within long
+// recordings without downloading them. Conditional requests are served through the ETags and modification times of
+// the objects. Audio formats are served with their types when the store does not have them.
+func ServeObject(store ObjectStore, key func(*http.Request) string, opts ...ObjectOption) http.Handler {
+	var options objectOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	signer, _ := store.(URLSigner)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := key(r)
+		if name == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		object, err := store.Stat(ctx, name)
+		switch {
+		case err != nil && errors.CodeOf(err) != errors.NotFound && signer != nil && options.expiry > 0:
+			log.Warnf("Redirecting to a signed URL as %s could not be read: %s", name, err)
+			redirect(w, r, signer, name, options.expiry)
+			return
+		case err != nil:
+			Error(w, r, err)
+			return
+		case signer != nil && options.expiry > 0 && object.Size > options.redirectAbove:
+			redirect(w, r, signer, name, options.expiry)
+			return
+		}
+
+		contentType := object.ContentType
+		if audioType, ok := audioTypes[strings.ToLower(path.Ext(name))]; ok && genericType(contentType) {
+			contentType = audioType
+		}
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		if object.ETag != "" {
+			w.Header().Set("ETag", object.ETag)
+		}
+
+		content := &objectReader{ctx: ctx, store: store, key: name, size: object.Size}
+		defer content.Close()
+		http.ServeContent(w, r, name, object.ModTime, content)
+	})
+}
+
+func redirect(w http.ResponseWriter, r *http.Request, signer URLSigner, key string, expiry time.Duration) {
+	url, err := signer.SignedURL(r.Context(), key, expiry)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	// The URL expires, so the redirect is not to be cached
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// Returns if the type is missing or does not tell the format, as the defaults of the stores
+func genericType(contentType string) bool {
+	switch contentType {
+	case "", "application/octet-stream", "binary/octet-stream":
+		return true
+	}
+	return false
+}
+
+// objectReader is the io.ReadSeeker http.ServeContent serves the ranges from, opening the object from the offset of
+// the first read after a seek
+type objectReader struct {
+	ctx    context.Context
+	store  ObjectStore
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (o *objectReader) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		body, err := o.store.Open(o.ctx, o.key, o.offset)
+		if err != nil {
+			return 0, err
+		}
+		o.body = body
+	}
+
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *objectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	}
+	if offset < 0 {
+		return 0, errors.NewError("Seeking before the start of the object", nil, false)
+	}
+
+	if offset != o.offset {
+		_ = o.Close()
+		o.offset = offset
+	}
+	return offset, nil
+}
+
+func (o *objectReader) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
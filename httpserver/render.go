@@ -0,0 +1,143 @@
+package httpserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// ETag returns the entity tag of the body, a weak one(W/"...") if weak. Strong tags are for bodies identical byte for
+// byte, weak tags for bodies equivalent to clients(eg. the same JSON with its keys in another order).
+func ETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	tag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// Returns true if the entity tags match weakly(RFC 7232), ignoring the W/ prefix
+func weakMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// NotModified sets the ETag and Last-Modified(if not zero) headers of the response, and responds 304(Not Modified) if
+// the request is conditional(If-None-Match, or If-Modified-Since without it) and the client has the representation
+// already. Returns true if it responded, in which case the body is not to be written. Only GET and HEAD requests are
+// conditional.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		// If-None-Match takes precedence over If-Modified-Since
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || (etag != "" && weakMatch(candidate, etag)) {
+				w.WriteHeader(http.StatusNotModified)
+				return true
+			}
+		}
+		return false
+	}
+
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !lastModified.IsZero() {
+		// The precision of the header is of seconds
+		if !lastModified.Truncate(time.Second).After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+type renderOptions struct {
+	weak         bool
+	lastModified time.Time
+}
+
+// RenderOption configures JSON
+type RenderOption func(*renderOptions)
+
+// WithWeakETag tags the response with a weak ETag
+func WithWeakETag() RenderOption {
+	return func(o *renderOptions) {
+		o.weak = true
+	}
+}
+
+// WithLastModified sets the time the resource was last modified, for the requests with If-Modified-Since
+func WithLastModified(lastModified time.Time) RenderOption {
+	return func(o *renderOptions) {
+		o.lastModified = lastModified
+	}
+}
+
+// JSON writes the value as the JSON body of the response with the status. Successful(200) responses are tagged with the
+// ETag of the body, and 304 is responded instead if the request is conditional and the client has the body already
+// (see NotModified), eg. for the endpoints polled by clients. Returns an error if the value cannot be encoded, in which
+// case nothing is written.
+func JSON(w http.ResponseWriter, r *http.Request, status int, v interface{}, opts ...RenderOption) error {
+	var o renderOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return errors.NewError("Could not encode the response", err, false)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if status == http.StatusOK && NotModified(w, r, ETag(body, o.weak), o.lastModified) {
+		return nil
+	}
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_, err = w.Write(body)
+	}
+	return err
+}
+
+// Error writes the body of the error(see errors.ToBody) as the JSON body of the response, with the status of the error
+// (see errors.HTTPStatus), eg.
+//
+//	if err != nil {
+//		httpserver.Error(w, r, err)
+//		return
+//	}
+//
+// The user messages of the error(see errors.WithUserMessages) are picked as per the Accept-Language of the request,
+// unless the options configure the locales.
+func Error(w http.ResponseWriter, r *http.Request, err error, opts ...errors.BodyOption) {
+	opts = append([]errors.BodyOption{errors.WithLocales(acceptedLanguages(r)...)}, opts...)
+	_ = JSON(w, r, errors.HTTPStatus(err), errors.ToBody(r.Context(), err, opts...))
+}
+
+// Returns the languages accepted by the client, in the order of preference
+func acceptedLanguages(r *http.Request) []string {
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil {
+		return nil
+	}
+	languages := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		languages = append(languages, tag.String())
+	}
+	return languages
+}
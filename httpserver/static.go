@@ -0,0 +1,168 @@
+package httpserver
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+type staticOptions struct {
+	index  string
+	spa    bool
+	maxAge time.Duration
+}
+
+// StaticOption configures ServeStatic
+type StaticOption func(*staticOptions)
+
+// WithSPA serves the index for the paths without an extension which are not found, for the routes of single page apps
+func WithSPA() StaticOption {
+	return func(o *staticOptions) {
+		o.spa = true
+	}
+}
+
+// WithIndex configures the file served for the directories(and by WithSPA), defaults to index.html
+func WithIndex(index string) StaticOption {
+	return func(o *staticOptions) {
+		o.index = index
+	}
+}
+
+// WithMaxAge configures the duration the assets are cached for by clients, defaults to an hour. The index is always
+// revalidated, so that the assets it links to(fingerprinted by the bundlers) are picked up once deployed.
+func WithMaxAge(maxAge time.Duration) StaticOption {
+	return func(o *staticOptions) {
+		o.maxAge = maxAge
+	}
+}
+
+// Pre-compressed variants of the files, in the order of preference
+var encodings = []struct {
+	name      string
+	extension string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+type static struct {
+	fsys    fs.FS
+	options staticOptions
+	// ETags of the files, computed once as the files are not expected to change(eg. of an embed.FS)
+	etags sync.Map
+}
+
+// ServeStatic serves the files of the file system(eg. an embed.FS of the assets of a dashboard), with ETags and cache
+// headers. The pre-compressed variants of a file(eg. app.js.br and app.js.gz) are served to the clients accepting them.
+// Paths are cleaned before they are opened so that they cannot escape the file system, and hidden files(starting with
+// a ".") are not served. Eg.
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	assets, _ := fs.Sub(dist, "dist")
+//	mux.Handle("/dashboard/", http.StripPrefix("/dashboard", httpserver.ServeStatic(assets, httpserver.WithSPA())))
+func ServeStatic(fsys fs.FS, opts ...StaticOption) http.Handler {
+	o := staticOptions{index: "index.html", maxAge: time.Hour}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &static{fsys: fsys, options: o}
+}
+
+func (s *static) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Cleaning the path as rooted, so that ".." cannot climb above the root
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	if name == "" {
+		name = s.options.index
+	}
+
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, s.options.index)
+		info, err = fs.Stat(s.fsys, name)
+	}
+	if err != nil && s.options.spa && path.Ext(name) == "" {
+		name = s.options.index
+		info, err = fs.Stat(s.fsys, name)
+	}
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.serveFile(w, r, name, info)
+}
+
+func (s *static) serveFile(w http.ResponseWriter, r *http.Request, name string, info fs.FileInfo) {
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+	if path.Base(name) == s.options.index {
+		header.Set("Cache-Control", "no-cache")
+	} else {
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.options.maxAge.Seconds())))
+	}
+	// Typing by the name of the file, as the pre-compressed variants cannot be sniffed
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	served := name
+	for _, encoding := range encodings {
+		if !accepts(r, encoding.name) {
+			continue
+		}
+		if variant, err := fs.Stat(s.fsys, name+encoding.extension); err == nil && !variant.IsDir() {
+			served = name + encoding.extension
+			header.Set("Content-Encoding", encoding.name)
+			break
+		}
+	}
+
+	content, err := fs.ReadFile(s.fsys, served)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	etag, ok := s.etags.Load(served)
+	if !ok {
+		etag, _ = s.etags.LoadOrStore(served, ETag(content, false))
+	}
+	header.Set("ETag", etag.(string))
+
+	http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(content))
+}
+
+// Returns true if the request accepts the content encoding
+func accepts(r *http.Request, encoding string) bool {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, accepted := range strings.Split(value, ",") {
+			token, params, _ := strings.Cut(strings.TrimSpace(accepted), ";")
+			if !strings.EqualFold(strings.TrimSpace(token), encoding) {
+				continue
+			}
+			q := strings.ReplaceAll(params, " ", "")
+			return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package httpserver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+var (
+	// ErrPartTooLarge is returned for the uploads with a part(or form values) larger than the limit, responded 413
+	ErrPartTooLarge = errors.WithHTTPStatus(errors.NewError("multipart part is too large", nil, false), http.StatusRequestEntityTooLarge)
+	// ErrTooManyParts is returned for the uploads with more parts than the limit, responded 413
+	ErrTooManyParts = errors.WithHTTPStatus(errors.NewError("multipart upload has too many parts", nil, false), http.StatusRequestEntityTooLarge)
+	// ErrTypeNotAllowed is returned for the files whose sniffed type is not allowed, responded 415
+	ErrTypeNotAllowed = errors.WithHTTPStatus(errors.NewError("file type is not allowed", nil, false), http.StatusUnsupportedMediaType)
+)
+
+// Bytes sniffed to detect the type of the files, as many as http.DetectContentType considers
+const sniffLength = 512
+
+// Part is a file uploaded in a multipart form
+type Part struct {
+	// FormName is the name of the form field of the file
+	FormName string
+	// FileName is the name of the file, as sent by the client
+	FileName string
+	// ContentType is the type sniffed from the content of the file, not the one declared by the client
+	ContentType string
+	// Size is the number of bytes of the file, set once it is streamed to the sink
+	Size int64
+}
+
+// Sink stores the content of a file streamed from an upload, eg. to S3. The content is read straight from the
+// request and returns an error from Read if the file is over the limit or rejected by the scanner, which the sink is
+// expected to return(aborting the upload).
+type Sink func(ctx context.Context, part Part, content io.Reader) error
+
+// Scanner wraps the content of the files streamed to the sink, eg. to pipe them through a virus scanner. The reader
+// returned fails the upload by returning an error from Read, eg. once the file is found infected at EOF.
+type Scanner func(ctx context.Context, part Part, content io.Reader) io.Reader
+
+// Uploaded is the result of an upload streamed through Upload
+type Uploaded struct {
+	// Parts are the files streamed to the sink, in the order they were uploaded
+	Parts []Part
+	// Values are the form fields which are not files
+	Values url.Values
+}
+
+type uploadOptions struct {
+	partLimit  int64
+	maxParts   int
+	fieldLimit int64
+	allowed    []string
+	scanner    Scanner
+}
+
+// UploadOption configures Upload
+type UploadOption func(*uploadOptions)
+
+// WithPartLimit configures the bytes of a file past which the upload fails with ErrPartTooLarge, defaults to 32MiB
+func WithPartLimit(limit int64) UploadOption {
+	return func(o *uploadOptions) {
+		o.partLimit = limit
+	}
+}
+
+// WithMaxParts configures the number of parts(files and values) past which the upload fails with ErrTooManyParts,
+// defaults to 16
+func WithMaxParts(parts int) UploadOption {
+	return func(o *uploadOptions) {
+		o.maxParts = parts
+	}
+}
+
+// WithFieldLimit configures the total bytes of the form values past which the upload fails with ErrPartTooLarge, as
+// they are read into memory. Defaults to 64KiB.
+func WithFieldLimit(limit int64) UploadOption {
+	return func(o *uploadOptions) {
+		o.fieldLimit = limit
+	}
+}
+
+// WithAllowedTypes configures the types the files are allowed to be, matched against the type sniffed from their
+// content(see http.DetectContentType). Types ending with "/*" match any subtype, eg. "audio/*". Defaults to any type.
+func WithAllowedTypes(types ...string) UploadOption {
+	return func(o *uploadOptions) {
+		o.allowed = types
+	}
+}
+
+// WithScanner configures the scanner the files are piped through before reaching the sink
+func WithScanner(scanner Scanner) UploadOption {
+	return func(o *uploadOptions) {
+		o.scanner = scanner
+	}
+}
+
+// Upload streams the files of a multipart request to the sink one at a time, without buffering them in memory or on
+// disk as http.Request.ParseMultipartForm does, eg. for audio uploads:
+//
+//	uploaded, err := httpserver.Upload(r, storeInS3, httpserver.WithAllowedTypes("audio/*"))
+//	if err != nil {
+//		httpserver.Error(w, r, err)
+//		return
+//	}
+//
+// The errors of the limits and allowed types respond with the statuses set on them(see errors.HTTPStatus). The files
+// streamed to the sink before an upload fails are not removed, which is up to the sink.
+func Upload(r *http.Request, sink Sink, opts ...UploadOption) (*Uploaded, error) {
+	options := uploadOptions{
+		partLimit:  32 << 20,
+		maxParts:   16,
+		fieldLimit: 64 << 10,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.WithHTTPStatus(errors.NewError("request is not a multipart upload", err, false), http.StatusBadRequest)
+	}
+
+	ctx := r.Context()
+	uploaded := &Uploaded{Values: url.Values{}}
+	fieldBytes := options.fieldLimit
+	for parts := 0; ; parts++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return uploaded, nil
+		}
+		if err != nil {
+			return nil, errors.WithHTTPStatus(errors.NewError("could not read the multipart upload", err, false), http.StatusBadRequest)
+		}
+		if parts == options.maxParts {
+			_ = part.Close()
+			return nil, ErrTooManyParts
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, fieldBytes+1))
+			_ = part.Close()
+			if err != nil {
+				return nil, errors.WithHTTPStatus(errors.NewError("could not read the multipart upload", err, false), http.StatusBadRequest)
+			}
+			if fieldBytes -= int64(len(value)); fieldBytes < 0 {
+				return nil, ErrPartTooLarge
+			}
+			uploaded.Values.Add(part.FormName(), string(value))
+			continue
+		}
+
+		file, err := upload(ctx, part, sink, options)
+		_ = part.Close()
+		if err != nil {
+			return nil, err
+		}
+		uploaded.Parts = append(uploaded.Parts, file)
+	}
+}
+
+// Streams a file to the sink, within the limit and after checking its type
+func upload(ctx context.Context, part *multipart.Part, sink Sink, options uploadOptions) (Part, error) {
+	file := Part{FormName: part.FormName(), FileName: part.FileName()}
+
+	limited := &limitedReader{reader: part, limit: options.partLimit}
+	sniffer := bufio.NewReaderSize(limited, sniffLength)
+	head, err := sniffer.Peek(sniffLength)
+	if err != nil && err != io.EOF {
+		if limited.exceeded {
+			return file, ErrPartTooLarge
+		}
+		return file, errors.WithHTTPStatus(errors.NewError("could not read the multipart upload", err, false), http.StatusBadRequest)
+	}
+
+	file.ContentType = http.DetectContentType(head)
+	if !allowedType(file.ContentType, options.allowed) {
+		return file, ErrTypeNotAllowed
+	}
+
+	var content io.Reader = sniffer
+	if options.scanner != nil {
+		content = options.scanner(ctx, file, content)
+	}
+	err = sink(ctx, file, content)
+	file.Size = limited.read
+	switch {
+	case limited.exceeded:
+		return file, ErrPartTooLarge
+	case err != nil:
+		return file, errors.NewError(fmt.Sprintf("could not store the file %q", file.FileName), err, false)
+	}
+	return file, nil
+}
+
+// Returns if the type(without its parameters) is one of those allowed, or if any type is allowed
+func allowedType(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range allowed {
+		t = strings.ToLower(t)
+		if t == mediaType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedReader fails with ErrPartTooLarge once more than limit bytes are read, unlike io.LimitReader which stops at
+// the limit without an error
+type limitedReader struct {
+	reader   io.Reader
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, ErrPartTooLarge
+	}
+	// Reading a byte past the limit to tell the files of exactly the limit from the larger ones
+	if remaining := l.limit - l.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := l.reader.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		l.exceeded = true
+		l.read = l.limit
+		return n - 1, ErrPartTooLarge
+	}
+	return n, err
+}
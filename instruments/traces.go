@@ -9,6 +9,9 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/skit-ai/vcore/features"
+	"github.com/skit-ai/vcore/log"
+	"github.com/skit-ai/vcore/routes"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
@@ -32,6 +35,11 @@ var (
 // Initializes an OTLP exporter, and configures the corresponding trace and
 // metric providers.
 func InitProvider() (func(context.Context) error, error) {
+	if !features.Enabled(features.Tracing) {
+		log.Infof("Tracing is disabled through FEATURES")
+		return func(context.Context) error { return nil }, nil
+	}
+
 	ctx := context.Background()
 
 	res, err := resource.New(ctx,
@@ -88,7 +96,7 @@ func ExtractTraceID(ctx context.Context) trace.TraceID {
 	return trace.SpanFromContext(ctx).SpanContext().TraceID()
 }
 
-// Set a custom trace span name.
+// Set a custom trace span name, with the IDs in the path replaced by placeholders(see routes.Normalize).
 func SpanNameFormatter(_ string, r *http.Request) string {
-	return fmt.Sprintf("%s %s %s", r.Method, r.Host, r.URL.Path)
+	return fmt.Sprintf("%s %s %s", r.Method, r.Host, routes.Normalize(r.URL.Path))
 }
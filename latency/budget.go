@@ -0,0 +1,216 @@
+// Package latency tracks the time spent by each stage(ASR, NLU, policy, TTS) of a call turn against a budget.
+// Turns exceeding their budget are logged, every turn's breakdown is handed to a metric recorder and repeated
+// breaches can switch the call into a degraded mode for the turns that follow.
+package latency
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skit-ai/vcore/log/slog"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+type Stage string
+
+const (
+	ASR    Stage = "asr"
+	NLU    Stage = "nlu"
+	Policy Stage = "policy"
+	TTS    Stage = "tts"
+)
+
+// Budget of a turn. A zero Total or a stage without a target is not checked.
+type Budget struct {
+	Total  time.Duration           `json:"total" yaml:"total"`
+	Stages map[Stage]time.Duration `json:"stages" yaml:"stages"`
+}
+
+// Breakdown of the time spent by a turn
+type Breakdown struct {
+	Turn   string                  `json:"turn"`
+	Stages map[Stage]time.Duration `json:"stages"`
+	Total  time.Duration           `json:"total"`
+	// Stages which exceeded their targets
+	Over []Stage `json:"over,omitempty"`
+	// True if the turn, or any of its stages, exceeded the budget
+	Exceeded bool `json:"exceeded"`
+}
+
+// Recorder emits the breakdown of every turn as a metric
+type Recorder func(ctx context.Context, breakdown Breakdown)
+
+// Tracker tracks the turns of a call against a budget
+type Tracker struct {
+	budget   Budget
+	recorder Recorder
+	logger   slog.Logger
+	clock    simulation.Clock
+
+	// Consecutive turns over(or within) budget after which the call is degraded(or restored)
+	degradeAfter int
+	onDegrade    func(degraded bool)
+
+	mutex    sync.Mutex
+	degraded bool
+	streak   int
+}
+
+// Option configures a Tracker
+type Option func(*Tracker)
+
+// WithRecorder configures the recorder to which the breakdown of every turn is emitted
+func WithRecorder(recorder Recorder) Option {
+	return func(t *Tracker) {
+		t.recorder = recorder
+	}
+}
+
+// WithLogger configures the logger of the turns exceeding the budget. Defaults to slog's logger.
+func WithLogger(logger slog.Logger) Option {
+	return func(t *Tracker) {
+		t.logger = logger
+	}
+}
+
+// WithDegradation degrades the call once turns consecutive turns exceed the budget, and restores it once as many
+// consecutive turns are within the budget. onChange(if not nil) is called whenever the mode changes.
+func WithDegradation(turns int, onChange func(degraded bool)) Option {
+	return func(t *Tracker) {
+		t.degradeAfter = turns
+		t.onDegrade = onChange
+	}
+}
+
+// WithClock configures the clock timing the turns. Defaults to simulation.Real.
+func WithClock(clock simulation.Clock) Option {
+	return func(t *Tracker) {
+		t.clock = clock
+	}
+}
+
+func NewTracker(budget Budget, opts ...Option) *Tracker {
+	t := &Tracker{budget: budget, logger: slog.NewLogger(), clock: simulation.Real}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Degraded is true if the subsequent turns are to be run in a degraded mode(eg. a faster TTS voice)
+func (t *Tracker) Degraded() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.degraded
+}
+
+// StartTurn starts timing a turn
+func (t *Tracker) StartTurn(id string) *Turn {
+	return &Turn{
+		tracker: t,
+		id:      id,
+		start:   t.clock.Now(),
+		stages:  make(map[Stage]time.Duration),
+	}
+}
+
+// Updates the degraded mode with the outcome of a turn
+func (t *Tracker) observe(exceeded bool) {
+	if t.degradeAfter <= 0 {
+		return
+	}
+
+	t.mutex.Lock()
+	// The streak counts the consecutive turns contradicting the current mode
+	if exceeded != t.degraded {
+		t.streak++
+	} else {
+		t.streak = 0
+	}
+
+	changed := t.streak >= t.degradeAfter
+	if changed {
+		t.degraded = !t.degraded
+		t.streak = 0
+	}
+	degraded := t.degraded
+	t.mutex.Unlock()
+
+	if changed && t.onDegrade != nil {
+		t.onDegrade(degraded)
+	}
+}
+
+// Turn records the time spent by the stages of a turn
+type Turn struct {
+	tracker *Tracker
+	id      string
+	start   time.Time
+
+	mutex  sync.Mutex
+	stages map[Stage]time.Duration
+}
+
+// Start starts timing a stage. Call the returned function once the stage is done.
+func (t *Turn) Start(stage Stage) (stop func()) {
+	clock := t.tracker.clock
+	start := clock.Now()
+	return func() {
+		t.Record(stage, clock.Since(start))
+	}
+}
+
+// Record adds the time spent by a stage. Stages run more than once within a turn are summed up.
+func (t *Turn) Record(stage Stage, elapsed time.Duration) {
+	t.mutex.Lock()
+	t.stages[stage] += elapsed
+	t.mutex.Unlock()
+}
+
+// End finishes the turn, checks it against the budget and returns its breakdown
+func (t *Turn) End(ctx context.Context) Breakdown {
+	t.mutex.Lock()
+	breakdown := Breakdown{
+		Turn:   t.id,
+		Stages: make(map[Stage]time.Duration, len(t.stages)),
+		Total:  t.tracker.clock.Since(t.start),
+	}
+	for stage, elapsed := range t.stages {
+		breakdown.Stages[stage] = elapsed
+	}
+	t.mutex.Unlock()
+
+	budget := t.tracker.budget
+	for stage, elapsed := range breakdown.Stages {
+		if target, ok := budget.Stages[stage]; ok && target > 0 && elapsed > target {
+			breakdown.Over = append(breakdown.Over, stage)
+		}
+	}
+	sort.Slice(breakdown.Over, func(i, j int) bool { return breakdown.Over[i] < breakdown.Over[j] })
+	breakdown.Exceeded = len(breakdown.Over) > 0 || (budget.Total > 0 && breakdown.Total > budget.Total)
+
+	if breakdown.Exceeded {
+		t.tracker.log(ctx, breakdown)
+	}
+	if t.tracker.recorder != nil {
+		t.tracker.recorder(ctx, breakdown)
+	}
+	t.tracker.observe(breakdown.Exceeded)
+	return breakdown
+}
+
+func (t *Tracker) log(ctx context.Context, breakdown Breakdown) {
+	fields := map[string]any{
+		"turn":        breakdown.Turn,
+		"total_ms":    breakdown.Total.Milliseconds(),
+		"budget_ms":   t.budget.Total.Milliseconds(),
+		"over_budget": breakdown.Over,
+		"degraded":    t.Degraded(),
+	}
+	for stage, elapsed := range breakdown.Stages {
+		fields[string(stage)+"_ms"] = elapsed.Milliseconds()
+	}
+	t.logger.WithTraceId(ctx).WithFields(fields).Warn("Turn exceeded its latency budget")
+}
@@ -0,0 +1,138 @@
+// Package leakcheck detects leaked goroutines, in tests with VerifyNone and at runtime with a Monitor alerting
+// when the number of goroutines keeps growing.
+package leakcheck
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Goroutine parsed from a stack dump
+type Goroutine struct {
+	ID    int
+	State string
+	// Functions of the stack, innermost first
+	Functions []string
+	// Function which created the goroutine
+	CreatedBy string
+	Stack     string
+}
+
+// TopFunction returns the function the goroutine is currently running
+func (g Goroutine) TopFunction() string {
+	if len(g.Functions) == 0 {
+		return ""
+	}
+	return g.Functions[0]
+}
+
+// Has is true if any function of the stack(or the creator of the goroutine) starts with the prefix
+func (g Goroutine) Has(prefix string) bool {
+	if strings.HasPrefix(g.CreatedBy, prefix) {
+		return true
+	}
+	for _, function := range g.Functions {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dump returns the stacks of all goroutines
+func Dump() []byte {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// Goroutines returns the goroutines currently running, other than the one calling it
+func Goroutines() []Goroutine {
+	goroutines := Parse(Dump())
+	// The first goroutine of the dump is the one calling runtime.Stack
+	if len(goroutines) > 0 {
+		goroutines = goroutines[1:]
+	}
+	return goroutines
+}
+
+// Parse parses a dump of runtime.Stack
+func Parse(dump []byte) []Goroutine {
+	var goroutines []Goroutine
+	for _, block := range bytes.Split(bytes.TrimSpace(dump), []byte("\n\n")) {
+		lines := strings.Split(string(block), "\n")
+		if len(lines) == 0 || !strings.HasPrefix(lines[0], "goroutine ") {
+			continue
+		}
+
+		g := Goroutine{Stack: string(block)}
+		// goroutine 1 [chan receive, 5 minutes]:
+		header := strings.TrimSuffix(strings.TrimPrefix(lines[0], "goroutine "), ":")
+		if id, state, ok := strings.Cut(header, " "); ok {
+			g.ID, _ = strconv.Atoi(id)
+			g.State = strings.Trim(state, "[]")
+		}
+
+		// Frames are a function call followed by its indented file:line
+		for _, line := range lines[1:] {
+			if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "...") {
+				continue
+			}
+			if strings.HasPrefix(line, "created by ") {
+				g.CreatedBy = strings.TrimPrefix(line, "created by ")
+				// created by main.main in goroutine 1
+				if creator, _, ok := strings.Cut(g.CreatedBy, " in goroutine "); ok {
+					g.CreatedBy = creator
+				}
+				break
+			}
+			g.Functions = append(g.Functions, function(line))
+		}
+		goroutines = append(goroutines, g)
+	}
+	return goroutines
+}
+
+// Strips the arguments of a frame, eg. "main.worker(0xc000010000, 0x1)" to "main.worker"
+func function(frame string) string {
+	if i := strings.LastIndex(frame, "("); i > 0 {
+		return frame[:i]
+	}
+	return frame
+}
+
+// Summarize groups the goroutines by their top function, the most frequent first
+func Summarize(goroutines []Goroutine) string {
+	counts := make(map[string]int)
+	for _, g := range goroutines {
+		counts[g.TopFunction()+" ["+g.State+"]"]++
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	var summary strings.Builder
+	for _, key := range keys {
+		summary.WriteString(strconv.Itoa(counts[key]))
+		summary.WriteString(" x ")
+		summary.WriteString(key)
+		summary.WriteString("\n")
+	}
+	return summary.String()
+}
@@ -0,0 +1,164 @@
+package leakcheck
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/features"
+	"github.com/skit-ai/vcore/log"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+// Maximum size of the stacks attached to an alert
+const maxStacks = 1 << 20
+
+// Monitor samples the number of goroutines and alerts when it keeps growing past a threshold, which usually
+// means goroutines are leaking(eg. a stream per call never closed)
+type Monitor struct {
+	interval  time.Duration
+	threshold int
+	increases int
+	cooldown  time.Duration
+	sentry    *surveillance.Sentry
+	onAlert   func(count int, goroutines []Goroutine)
+	previous  int
+	growing   int
+	lastAlert time.Time
+	samples   int
+}
+
+// MonitorOption configures a Monitor
+type MonitorOption func(*Monitor)
+
+// WithInterval configures the interval at which the goroutines are counted. Defaults to 1m.
+func WithInterval(interval time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		m.interval = interval
+	}
+}
+
+// WithThreshold configures the number of goroutines past which growth is alerted on. Defaults to 10000.
+func WithThreshold(threshold int) MonitorOption {
+	return func(m *Monitor) {
+		m.threshold = threshold
+	}
+}
+
+// WithIncreases configures the number of consecutive samples the count must grow before alerting. Defaults to 5.
+func WithIncreases(increases int) MonitorOption {
+	return func(m *Monitor) {
+		m.increases = increases
+	}
+}
+
+// WithCooldown configures the minimum interval between alerts. Defaults to 1h.
+func WithCooldown(cooldown time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		m.cooldown = cooldown
+	}
+}
+
+// WithSentry configures the client the alerts are captured with. Defaults to surveillance.SentryClient.
+func WithSentry(sentry *surveillance.Sentry) MonitorOption {
+	return func(m *Monitor) {
+		m.sentry = sentry
+	}
+}
+
+// WithAlertHandler configures a handler called on every alert, along with the capture
+func WithAlertHandler(handler func(count int, goroutines []Goroutine)) MonitorOption {
+	return func(m *Monitor) {
+		m.onAlert = handler
+	}
+}
+
+func NewMonitor(opts ...MonitorOption) *Monitor {
+	m := &Monitor{
+		interval:  time.Minute,
+		threshold: 10000,
+		increases: 5,
+		cooldown:  time.Hour,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Start samples the number of goroutines until the context is done. The monitor is disabled unless enabled through
+// FEATURES(eg. FEATURES=goroutine-monitor), in which case Start returns immediately.
+func (m *Monitor) Start(ctx context.Context) {
+	if !features.Enabled(features.GoroutineMonitor) {
+		log.Infof("The goroutine monitor is disabled through FEATURES")
+		return
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Sample(ctx, runtime.NumGoroutine(), time.Now())
+		}
+	}
+}
+
+// Sample records a count of goroutines, alerting if it has grown for the configured number of consecutive samples
+// and is past the threshold. Returns true if an alert was raised.
+func (m *Monitor) Sample(ctx context.Context, count int, now time.Time) bool {
+	if m.samples > 0 && count > m.previous {
+		m.growing++
+	} else {
+		m.growing = 0
+	}
+	m.previous = count
+	m.samples++
+
+	if count < m.threshold || m.growing < m.increases {
+		return false
+	}
+	if !m.lastAlert.IsZero() && now.Sub(m.lastAlert) < m.cooldown {
+		return false
+	}
+	m.lastAlert = now
+	m.alert(ctx, count)
+	return true
+}
+
+// Captures the growth along with a summary and a sample of the stacks of the goroutines
+func (m *Monitor) alert(ctx context.Context, count int) {
+	dump := Dump()
+	goroutines := Parse(dump)
+	if len(dump) > maxStacks {
+		dump = dump[:maxStacks]
+	}
+
+	err := errors.NewErrorWithTags(
+		fmt.Sprintf("Number of goroutines grew to %d over %d consecutive samples", count, m.growing),
+		nil, false, map[string]string{"leakcheck": "goroutines"},
+	)
+	attachments := []surveillance.Attachment{
+		{Filename: "goroutines-summary.txt", ContentType: "text/plain", Bytes: []byte(Summarize(goroutines))},
+		{Filename: "goroutines.txt", ContentType: "text/plain", Bytes: dump},
+	}
+
+	sentry := m.sentry
+	if sentry == nil {
+		sentry = surveillance.SentryClient
+	}
+	if sentry != nil {
+		sentry.CaptureWithAttachments(ctx, err, attachments)
+	} else {
+		log.Error(err)
+	}
+
+	if m.onAlert != nil {
+		m.onAlert(count, goroutines)
+	}
+}
@@ -0,0 +1,120 @@
+package leakcheck
+
+import (
+	"strings"
+	"time"
+)
+
+// TestingT is the subset of testing.TB used by VerifyNone
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Goroutines of the runtime, the testing package and long-lived vcore components(and their dependencies) which
+// are not leaks
+var defaultIgnored = []string{
+	"runtime.",
+	"testing.",
+	"os/signal.",
+	"github.com/getsentry/sentry-go.(*HTTPTransport).worker",
+	"github.com/getsentry/sentry-go.(*HTTPSyncTransport)",
+	"go.opentelemetry.io/otel/sdk/trace.(*batchSpanProcessor).processQueue",
+	"github.com/grafana/pyroscope-go",
+	"github.com/streadway/amqp.(*Connection)",
+	"github.com/mediocregopher/radix",
+	"github.com/skit-ai/vcore/surveillance/crash.Install",
+	"github.com/skit-ai/vcore/transport/amqp.NewConsumer",
+	"google.golang.org/grpc/internal/grpcsync.(*CallbackSerializer)",
+	"go.opencensus.io/stats/view.(*worker).start",
+}
+
+type options struct {
+	ignored    []string
+	ignoredIDs map[int]bool
+	timeout    time.Duration
+}
+
+// Option configures the goroutines ignored by VerifyNone
+type Option func(*options)
+
+// IgnoreFunction ignores the goroutines with a function(or creator) starting with the prefix on their stack,
+// eg. "github.com/skit-ai/vcore/transport/wsbridge.(*session)"
+func IgnoreFunction(prefix string) Option {
+	return func(o *options) {
+		o.ignored = append(o.ignored, prefix)
+	}
+}
+
+// IgnoreCurrent ignores the goroutines running at the time of the call, eg. the ones started before a test
+func IgnoreCurrent() Option {
+	ids := make(map[int]bool)
+	for _, g := range Goroutines() {
+		ids[g.ID] = true
+	}
+	return func(o *options) {
+		for id := range ids {
+			o.ignoredIDs[id] = true
+		}
+	}
+}
+
+// WithTimeout configures how long goroutines are given to exit before they are reported. Defaults to 1s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// VerifyNone reports the goroutines still running, other than the ignored ones, as a test error.
+// Use with defer at the start of a test.
+func VerifyNone(t TestingT, opts ...Option) {
+	t.Helper()
+	if leaks := Find(opts...); len(leaks) > 0 {
+		stacks := make([]string, 0, len(leaks))
+		for _, g := range leaks {
+			stacks = append(stacks, g.Stack)
+		}
+		t.Errorf("Found %d leaked goroutines:\n\n%s", len(leaks), strings.Join(stacks, "\n\n"))
+	}
+}
+
+// Find returns the goroutines still running after the timeout, other than the ignored ones
+func Find(opts ...Option) []Goroutine {
+	o := options{ignored: append([]string(nil), defaultIgnored...), ignoredIDs: make(map[int]bool), timeout: time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	deadline := time.Now().Add(o.timeout)
+	for wait := time.Millisecond; ; wait *= 2 {
+		leaks := leaked(Goroutines(), o)
+		if len(leaks) == 0 || time.Now().After(deadline) {
+			return leaks
+		}
+		if wait > 100*time.Millisecond {
+			wait = 100 * time.Millisecond
+		}
+		time.Sleep(wait)
+	}
+}
+
+func leaked(goroutines []Goroutine, o options) []Goroutine {
+	var leaks []Goroutine
+	for _, g := range goroutines {
+		if !o.ignoredIDs[g.ID] && !ignore(g, o.ignored) {
+			leaks = append(leaks, g)
+		}
+	}
+	return leaks
+}
+
+func ignore(g Goroutine, ignored []string) bool {
+	for _, prefix := range ignored {
+		// Goroutines blocked within the runtime(eg. the GC workers) are recognized by their top function only
+		if strings.HasPrefix(g.TopFunction(), prefix) || (!strings.HasPrefix(prefix, "runtime.") && g.Has(prefix)) {
+			return true
+		}
+	}
+	return false
+}
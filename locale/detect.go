@@ -0,0 +1,170 @@
+// Package locale identifies the language of short utterances and negotiates the locale to be used for a
+// request, so that routing to ASR/NLU models does not rely on hard-coded tenant settings.
+package locale
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Guess is a candidate language for a piece of text along with a score in [0, 1]
+type Guess struct {
+	Language string  `json:"language"`
+	Score    float64 `json:"score"`
+}
+
+// Languages written in a script of their own are identified by the script alone
+var scripts = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Devanagari, "hi"},
+	{unicode.Bengali, "bn"},
+	{unicode.Gurmukhi, "pa"},
+	{unicode.Gujarati, "gu"},
+	{unicode.Oriya, "or"},
+	{unicode.Tamil, "ta"},
+	{unicode.Telugu, "te"},
+	{unicode.Kannada, "kn"},
+	{unicode.Malayalam, "ml"},
+	{unicode.Arabic, "ar"},
+	{unicode.Thai, "th"},
+	{unicode.Han, "zh"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+}
+
+// Detect returns the candidate languages for the text ordered by their score(and by language on ties).
+// Text in a non-latin script is identified by its script. Latin text is scored against character trigram
+// profiles of the supported languages using the out-of-place measure.
+// An empty slice is returned when the text has no letters.
+func Detect(text string) []Guess {
+	counts := make(map[string]int)
+	var letters int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, script := range scripts {
+			if unicode.Is(script.table, r) {
+				counts[script.language]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return nil
+	}
+
+	var guesses []Guess
+	var scripted int
+	for language, count := range counts {
+		scripted += count
+		guesses = append(guesses, Guess{Language: language, Score: float64(count) / float64(letters)})
+	}
+
+	// Remaining letters are latin. Distributing their share among the trigram based guesses
+	if latin := letters - scripted; latin > 0 {
+		share := float64(latin) / float64(letters)
+		for _, guess := range detectLatin(text) {
+			guesses = append(guesses, Guess{Language: guess.Language, Score: guess.Score * share})
+		}
+	}
+
+	// Ordering the ties by language, since the guesses are collected in the random order of the maps
+	sort.Slice(guesses, func(i, j int) bool {
+		if guesses[i].Score == guesses[j].Score {
+			return guesses[i].Language < guesses[j].Language
+		}
+		return guesses[i].Score > guesses[j].Score
+	})
+	return guesses
+}
+
+// DetectBest returns the most likely language of the text or "und" if it could not be determined
+func DetectBest(text string) (string, float64) {
+	if guesses := Detect(text); len(guesses) > 0 {
+		return guesses[0].Language, guesses[0].Score
+	}
+	return "und", 0
+}
+
+func detectLatin(text string) []Guess {
+	ranks := trigramRanks(text)
+	if len(ranks) == 0 {
+		return nil
+	}
+
+	// Out-of-place distance of the text's trigrams from every profile. Trigrams missing from a profile are
+	// penalised with the maximum distance. The similarity to a profile is the fraction of the worst possible
+	// distance it is away from.
+	worst := len(ranks) * maxRank
+	var guesses []Guess
+	var sum float64
+	for language, profile := range profiles {
+		var distance int
+		for trigram, rank := range ranks {
+			if profileRank, ok := profile[trigram]; ok {
+				distance += abs(rank - profileRank)
+			} else {
+				distance += maxRank
+			}
+		}
+		similarity := float64(worst-distance) / float64(worst)
+		guesses = append(guesses, Guess{Language: language, Score: similarity})
+		sum += similarity
+	}
+
+	// Converting similarities into scores which sum up to one
+	for i := range guesses {
+		if sum > 0 {
+			guesses[i].Score /= sum
+		}
+	}
+	return guesses
+}
+
+// Ranks the most frequent trigrams of the text. Words are padded with spaces so that the trigrams capture
+// the beginning and end of words.
+func trigramRanks(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.In(r, unicode.Latin)
+	}) {
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			counts[string(padded[i:i+3])]++
+		}
+	}
+
+	trigrams := make([]string, 0, len(counts))
+	for trigram := range counts {
+		trigrams = append(trigrams, trigram)
+	}
+	sort.Slice(trigrams, func(i, j int) bool {
+		if counts[trigrams[i]] == counts[trigrams[j]] {
+			return trigrams[i] < trigrams[j]
+		}
+		return counts[trigrams[i]] > counts[trigrams[j]]
+	})
+	if len(trigrams) > maxRank {
+		trigrams = trigrams[:maxRank]
+	}
+
+	ranks := make(map[string]int, len(trigrams))
+	for rank, trigram := range trigrams {
+		ranks[trigram] = rank
+	}
+	return ranks
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
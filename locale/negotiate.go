@@ -0,0 +1,77 @@
+package locale
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Negotiator picks the best supported locale given the preferences of a caller
+type Negotiator struct {
+	supported []language.Tag
+	matcher   language.Matcher
+}
+
+// NewNegotiator returns a negotiator for the supported locales(BCP 47 tags like "en-IN", "hi").
+// The first supported locale is the fallback when none of the preferences match.
+func NewNegotiator(supported ...string) (*Negotiator, error) {
+	tags := make([]language.Tag, 0, len(supported))
+	for _, s := range supported {
+		tag, err := language.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		tags = append(tags, language.English)
+	}
+
+	return &Negotiator{
+		supported: tags,
+		matcher:   language.NewMatcher(tags),
+	}, nil
+}
+
+// Negotiate returns the supported locale which best matches the preferences along with the confidence of
+// the match. Each preference is either an Accept-Language header value or a comma separated list of tags,
+// and preferences are considered in the order given. Eg.
+//
+//	negotiator.Negotiate(r.Header.Get("Accept-Language"), strings.Join(tenant.Locales, ","), detected)
+//
+// Invalid or empty preferences are skipped.
+func (n *Negotiator) Negotiate(preferences ...string) (string, language.Confidence) {
+	var desired []language.Tag
+	for _, preference := range preferences {
+		if strings.TrimSpace(preference) == "" {
+			continue
+		}
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err != nil {
+			continue
+		}
+		desired = append(desired, tags...)
+	}
+
+	_, index, confidence := n.matcher.Match(desired...)
+	return n.supported[index].String(), confidence
+}
+
+// Supported returns the locales supported by the negotiator
+func (n *Negotiator) Supported() []string {
+	supported := make([]string, len(n.supported))
+	for i, tag := range n.supported {
+		supported[i] = tag.String()
+	}
+	return supported
+}
+
+// Base returns the base language of a locale. Eg. "hi" for "hi-IN" and "hi-Latn"
+func Base(locale string) string {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return locale
+	}
+	base, _ := tag.Base()
+	return base.String()
+}
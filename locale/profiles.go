@@ -0,0 +1,61 @@
+package locale
+
+// Trigram profiles are limited to the most frequent trigrams of a language.
+// Distances are capped at this rank.
+const maxRank = 40
+
+// Most frequent trigrams(in order) of the latin script languages supported.
+// "hi-Latn" is romanized Hindi(Hinglish) which is common in ASR output and chat.
+var rawProfiles = map[string][]string{
+	"en": {
+		" th", "the", "he ", " an", "and", "nd ", " to", "ing", "ng ", " in", "to ", "ed ", " of", "of ", "er ",
+		" is", "is ", "ion", "tio", " it", "it ", " a ", "re ", "on ", "at ", "es ", "ent", " be", "hat", "tha",
+		" yo", "you", "ou ", " my", "my ", "for", " fo", "or ", " wh", "ll ",
+	},
+	"hi-Latn": {
+		" ha", "hai", "ai ", " ke", "ke ", " ka", "ka ", " ki", "ki ", "hi ", " ho", "aa ", "na ", " me", "mei",
+		"ein", " ye", " ky", "kya", "ya ", "nah", "ahi", " na", "ta ", "ha ", " ko", "ko ", " se", "se ", "rah",
+		"aha", " mu", "muj", "ujh", "jhe", "he ", "ega", "ar ", "kar", " ba",
+	},
+	"es": {
+		" de", "de ", "os ", " la", "la ", " el", "el ", "es ", " qu", "que", "ue ", " en", "en ", "as ", " co",
+		"ado", "ra ", "ent", " se", "on ", "nte", " lo", "los", "er ", "ar ", " un", "una", " po", "por", "ta ",
+		"ien", "ión", "ón ", "cio", " es", "est", "ero", " me", "con", "ro ",
+	},
+	"fr": {
+		" de", "es ", "de ", " le", "le ", "ent", " la", "la ", "nt ", " et", "et ", "les", "ion", "re ", " pa",
+		" qu", "que", "ue ", " co", "ne ", "ous", " vo", "vou", "on ", " un", "tio", "ait", " en", "est", " es",
+		"ont", "ais", "eux", " je", "je ", "our", "ez ", "pou", " po", "ur ",
+	},
+	"de": {
+		"en ", "er ", " de", "der", "ie ", "ich", "die", " di", "ein", "sch", "che", "ch ", " un", "und", "nd ",
+		"den", " ei", "ine", "cht", "te ", " ge", "ist", " is", "st ", " zu", "ne ", "gen", "es ", " da", "das",
+		"ben", " ic", "nic", "ht ", " ni", "mit", " mi", "ver", " si", "sie",
+	},
+	"pt": {
+		" de", "de ", "os ", " qu", "que", "ue ", "do ", " do", " co", "as ", " a ", "ão ", "ent", " se", "da ",
+		" da", " pa", "es ", "nte", "com", "ar ", " é ", "er ", "ra ", " no", "não", "ção", "açã", " um", "um ",
+		"uma", " em", "em ", "ado", "par", "ara", "est", " es", "ha ", "sta",
+	},
+	"id": {
+		"an ", "ang", " me", "ng ", " da", "kan", "yan", " ya", "dan", " di", "nya", "ya ", "ak ", " be", "ada",
+		" ke", "men", "ah ", " se", "ara", "ka ", "ran", "ini", " in", "ni ", "aka", "apa", " ap", "ber", " sa",
+		"aya", "ata", "tid", " ti", "ida", "dak", "uk ", "at ", "eng", "ena",
+	},
+}
+
+var profiles = buildProfiles(rawProfiles)
+
+func buildProfiles(raw map[string][]string) map[string]map[string]int {
+	built := make(map[string]map[string]int, len(raw))
+	for language, trigrams := range raw {
+		profile := make(map[string]int, len(trigrams))
+		for rank, trigram := range trigrams {
+			if _, exists := profile[trigram]; !exists && rank < maxRank {
+				profile[trigram] = rank
+			}
+		}
+		built[language] = profile
+	}
+	return built
+}
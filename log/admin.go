@@ -0,0 +1,93 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// ReloadOnSIGHUP sets the levels(see SetLevels) to the spec in the file on every SIGHUP, until the context is done. Eg.
+// with the file mounted from a ConfigMap:
+//
+//	go log.ReloadOnSIGHUP(ctx, "/etc/config/log-levels")
+//	...
+//	$ echo "info,asr=debug" > /etc/config/log-levels && kill -HUP 1
+//
+// The levels are not changed if the file cannot be read or the spec is invalid.
+func ReloadOnSIGHUP(ctx context.Context, path string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := ReloadLevels(path); err != nil {
+				Unhooked().Warnf("Could not reload the log levels from %s: %s", path, err)
+				continue
+			}
+			Unhooked().Warnf("Reloaded the log levels from %s: %s", path, Levels())
+		}
+	}
+}
+
+// ReloadLevels sets the levels(see SetLevels) to the spec in the file
+func ReloadLevels(path string) error {
+	spec, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return SetLevels(strings.TrimSpace(string(spec)))
+}
+
+// Levels of the loggers served by Handler
+type levels struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+func currentLevels() levels {
+	current := levels{Level: LevelName(int(globalLevel.Load())), Modules: make(map[string]string)}
+	for module, level := range ModuleLevels() {
+		current.Modules[module] = LevelName(level)
+	}
+	return current
+}
+
+// Handler serves the levels of the loggers on an admin endpoint: GET returns them as JSON, and PUT sets them to the
+// spec of the body(see SetLevels). Eg.
+//
+//	$ curl -X PUT -d "info,asr=debug" localhost:8080/admin/log-levels
+//
+// The handler is to be guarded as the other admin endpoints are, as it can flood the logs.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			spec, err := io.ReadAll(io.LimitReader(r.Body, 4<<10))
+			if err == nil {
+				err = SetLevels(strings.TrimSpace(string(spec)))
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			Unhooked().Warnf("Set the log levels to %s", Levels())
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(currentLevels())
+	})
+}
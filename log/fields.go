@@ -0,0 +1,96 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Key of the logger on a context. The logger is not one of the keys of ctxkeys, as ctxkeys depends on this package.
+type contextKey struct{}
+
+// WithFields returns a copy of the logger logging the fields with every entry, along with the fields of the logger.
+// The fields of the same keys replace those of the logger. Eg. for the attributes of a request:
+//
+//	logger := log.WithFields(map[string]interface{}{"call_id": callID, "tenant": tenant})
+//	logger.Infof("Transferring the call to %s", agent)
+//
+// The fields follow the messages of the text entries(eg. "[INFO] Transferring the call to 42 call_id=c-1 tenant=acme"),
+// and are fields of the JSON entries(see FormatJSON).
+func (logger *Logger) WithFields(fields map[string]interface{}) *Logger {
+	merged := make(map[string]interface{}, len(logger.fields)+len(fields))
+	for key, value := range logger.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return &Logger{module: logger.module, unhooked: logger.unhooked, fields: merged}
+}
+
+// Fields returns the fields of the logger(see WithFields)
+func (logger *Logger) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(logger.fields))
+	for key, value := range logger.fields {
+		fields[key] = value
+	}
+	return fields
+}
+
+// WithFields returns a copy of the default logger logging the fields with every entry
+func WithFields(fields map[string]interface{}) *Logger {
+	return defaultLogger.WithFields(fields)
+}
+
+// ToContext returns a copy of the context carrying the logger, eg. for a middleware to attach the attributes of the
+// requests once:
+//
+//	ctx := log.ToContext(r.Context(), log.WithFields(map[string]interface{}{"request_id": id}))
+//	next.ServeHTTP(w, r.WithContext(ctx))
+func ToContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger on the context(see ToContext), or else the default logger. The IDs of the span active
+// on the context(of OTEL, or else of Sentry) are logged as the fields trace_id and span_id, so that the entries can be
+// joined with the traces and the Sentry transactions they were logged within.
+func FromContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return &defaultLogger
+	}
+	logger, ok := ctx.Value(contextKey{}).(*Logger)
+	if !ok || logger == nil {
+		logger = &defaultLogger
+	}
+	// Taking the span of the context rather than the fields of the logger, as spans can be started after the logger
+	// was put on the context
+	if fields := traceFields(ctx); fields != nil {
+		return logger.WithFields(fields)
+	}
+	return logger
+}
+
+// Formats the fields for the text entries, as key=value pairs sorted by their keys. Values with spaces or quotes are
+// quoted.
+func textFields(fields map[string]interface{}) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	for _, key := range keys {
+		value := fmt.Sprint(fields[key])
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		builder.WriteString(" " + key + "=" + value)
+	}
+	return builder.String()
+}
@@ -0,0 +1,63 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Hook receives the entries logged(eg. to forward errors to Sentry)
+type Hook func(level int, err error, message string)
+
+type hook struct {
+	level int
+	fn    Hook
+}
+
+var (
+	hooksMutex sync.RWMutex
+	hooks      = make(map[int]hook)
+	hookID     int
+)
+
+// The default logger sans the hooks
+var unhookedLogger = Logger{unhooked: true}
+
+// AddHook registers a hook receiving the entries logged at the level or a more severe one(eg. WARN for warnings and
+// errors), irrespective of the level of the logger. Call the returned function to remove the hook.
+func AddHook(level int, fn Hook) (remove func()) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	hookID++
+	id := hookID
+	hooks[id] = hook{level: level, fn: fn}
+	return func() {
+		hooksMutex.Lock()
+		delete(hooks, id)
+		hooksMutex.Unlock()
+	}
+}
+
+// Unhooked returns the default logger without the hooks, for the code run by hooks(eg. the Sentry wrapper) to log
+// without feeding the hooks again
+func Unhooked() *Logger {
+	return &unhookedLogger
+}
+
+// Hands the entry to the hooks of its level
+func runHooks(level int, err error, format string, args ...interface{}) {
+	hooksMutex.RLock()
+	defer hooksMutex.RUnlock()
+
+	var message *string
+	for _, h := range hooks {
+		if level > h.level {
+			continue
+		}
+		if message == nil {
+			formatted := fmt.Sprintf(format, args...)
+			message = &formatted
+		}
+		h.fn(level, err, *message)
+	}
+}
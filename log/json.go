@@ -0,0 +1,124 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/skit-ai/vcore/env"
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Format of the entries logged
+type Format string
+
+const (
+	// FormatText logs the entries as lines prefixed by their levels, eg. "[WARN] Falling back to the default voice"
+	FormatText Format = "text"
+	// FormatJSON logs the entries as JSON objects, one per line, eg. for Loki or Elasticsearch:
+	//
+	//	{"caller":"tts/client.go:42","level":"warn","message":"Falling back to the default voice","timestamp":"2024-01-01T00:00:00.000Z"}
+	FormatJSON Format = "json"
+)
+
+// Keys of the JSON entries. Fields with the same keys are logged with the prefix "fields.".
+const (
+	timestampKey  = "timestamp"
+	levelKey      = "level"
+	messageKey    = "message"
+	callerKey     = "caller"
+	errorKey      = "error"
+	stacktraceKey = "stacktrace"
+)
+
+var logFormat atomic.Value
+
+func init() {
+	logFormat.Store(Format(strings.ToLower(env.String("LOG_FORMAT", string(FormatText)))))
+}
+
+// SetFormat configures the format of the entries logged, defaults to the format of the environment variable LOG_FORMAT
+// ("text" or "json"), or else FormatText. Entries are written to the output of the standard logger(see log.SetOutput)
+// in either format.
+func SetFormat(f Format) {
+	logFormat.Store(f)
+}
+
+// Name of the level in the JSON entries
+func levelName(level int, err error) string {
+	// Telling outages from the other errors, as the text entries do
+	if level == ERROR && err != nil && errors.Severity(err) == errors.Critical {
+		return "critical"
+	}
+	return LevelName(level)
+}
+
+// Returns the file and the line of the call logging the entry, outside of the package
+func caller() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/skit-ai/vcore/log.") {
+			// Keeping the directory of the file, as file names(eg. client.go) are rarely unique
+			file := frame.File
+			if i := strings.LastIndex(file, "/"); i >= 0 {
+				if j := strings.LastIndex(file[:i], "/"); j >= 0 {
+					file = file[j+1:]
+				}
+			}
+			return file + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// Writes the entry as a JSON object to the output of the standard logger. The tags of the error are logged as fields.
+func logJSON(level int, err error, message string, fields map[string]interface{}) {
+	entry := make(map[string]interface{}, len(fields)+6)
+	set := func(key string, value interface{}) {
+		switch key {
+		case timestampKey, levelKey, messageKey, callerKey, errorKey, stacktraceKey:
+			key = "fields." + key
+		}
+		entry[key] = value
+	}
+	for key, value := range fields {
+		set(key, value)
+	}
+
+	entry[timestampKey] = time.Now().UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	entry[levelKey] = levelName(level, err)
+	entry[messageKey] = message
+	if c := caller(); c != "" {
+		entry[callerKey] = c
+	}
+	if err != nil {
+		for key, value := range errors.Tags(err) {
+			set(key, value)
+		}
+		entry[errorKey] = err.Error()
+		if stacktrace := errors.Stacktrace(err); stacktrace != "" {
+			entry[stacktraceKey] = stacktrace
+		}
+	}
+
+	line, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		// Fields which cannot be encoded(eg. channels) are logged as their values formatted
+		for key, value := range entry {
+			if _, err := json.Marshal(value); err != nil {
+				entry[key] = fmt.Sprintf("%+v", value)
+			}
+		}
+		line, _ = json.Marshal(entry)
+	}
+	_, _ = log.Writer().Write(append(line, '\n'))
+}
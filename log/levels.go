@@ -17,10 +17,15 @@ const (
 )
 
 type Logger struct {
-	level int
+	// Module of the logger(see Module), whose level(if set) overrides the level of the default logger
+	module string
+	// True if the entries are not handed to the hooks
+	unhooked bool
+	// Fields logged with every entry(see WithFields)
+	fields map[string]interface{}
 }
 
-var defaultLogger = Logger{WARN}
+var defaultLogger = Logger{}
 
 // Prefix based on the log level to be added to every log statement
 func levelPrefix(level int) string {
@@ -39,14 +44,37 @@ func levelPrefix(level int) string {
 	return ""
 }
 
+// Level the error is logged at as per its severity(see errors.WithSeverity): WARN for warnings, ERROR otherwise
+func errorLevel(err error) int {
+	if err != nil && errors.Severity(err) == errors.Warning {
+		return WARN
+	}
+	return ERROR
+}
+
 // Logs using stdlib logger based on the log level set
 func (logger *Logger) log(LEVEL int, err error, format string, args ...interface{}) {
+	if !logger.unhooked {
+		runHooks(LEVEL, err, format, args...)
+	}
+
 	if logger.isLevel(LEVEL) {
+		if logFormat.Load() == FormatJSON {
+			logJSON(LEVEL, err, fmt.Sprintf(format, args...), logger.fields)
+			return
+		}
+
+		prefix := levelPrefix(LEVEL)
+		message := fmt.Sprintf(format, args...) + textFields(logger.fields)
 		if err == nil {
-			log.Printf("%s %s\n", levelPrefix(LEVEL), fmt.Sprintf(format, args...))
+			log.Printf("%s %s\n", prefix, message)
 		} else {
+			// Telling outages from the other errors
+			if LEVEL == ERROR && errors.Severity(err) == errors.Critical {
+				prefix = "[CRITICAL]"
+			}
 			// Do not use log.Fatalf since it will call os.Exit and terminate the program
-			log.Printf("%s %s:\n%s\n", levelPrefix(LEVEL), fmt.Sprintf(format, args...), errors.Stacktrace(err))
+			log.Printf("%s %s:\n%s\n", prefix, message, errors.Stacktrace(err))
 		}
 
 	}
@@ -54,13 +82,29 @@ func (logger *Logger) log(LEVEL int, err error, format string, args ...interface
 
 // Checks if the logger has the ability to log at a given log level
 func (logger *Logger) isLevel(LEVEL int) bool {
-	return logger.level >= LEVEL
+	return logger.Level() >= LEVEL
 }
 
-// Set the level of the logger
+// Level returns the level of the logger: the level of its module if set(see SetModuleLevel), or else that of the
+// default logger
+func (logger *Logger) Level() int {
+	if logger.module != "" {
+		if level, ok := moduleLevel(logger.module); ok {
+			return level
+		}
+	}
+	return int(globalLevel.Load())
+}
+
+// Set the level of the logger. The level of a module logger(see Module) is the level of its module, and that of any
+// other logger the level of the default logger. Safe to call at runtime, eg. to turn on debug logs in production.
 func (logger *Logger) SetLevel(level int) {
 	if level <= TRACE && level >= ERROR {
-		defaultLogger.level = level
+		if logger.module != "" {
+			SetModuleLevel(logger.module, level)
+		} else {
+			globalLevel.Store(int32(level))
+		}
 	} else {
 		_format := "Cannot set log level to %d. Log levels allowed are %s. Default log level is %d(WARN)"
 		logger.Warnf(_format, level, joinInt(",", []int{TRACE, DEBUG, INFO, WARN, ERROR}), WARN)
@@ -138,8 +182,9 @@ func (logger *Logger) Warnf(format string, args ...interface{}) {
 	logger.log(WARN, nil, format, args...)
 }
 
+// Errorf logs the error at the level of its severity(WARN for warnings, ERROR otherwise)
 func (logger *Logger) Errorf(err error, format string, args ...interface{}) {
-	logger.log(ERROR, err, format, args...)
+	logger.log(errorLevel(err), err, format, args...)
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -161,8 +206,9 @@ func (logger *Logger) Warn(args ...interface{}) {
 	logger.log(WARN, nil, repeat("%v", len(args)), args...)
 }
 
+// Error logs the error at the level of its severity(WARN for warnings, ERROR otherwise)
 func (logger *Logger) Error(err error, args ...interface{}) {
-	logger.log(ERROR, err, repeat("%v", len(args)), args...)
+	logger.log(errorLevel(err), err, repeat("%v", len(args)), args...)
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
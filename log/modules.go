@@ -0,0 +1,159 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// Level of the default logger, and of the modules without a level of their own
+	globalLevel atomic.Int32
+
+	modulesMutex sync.RWMutex
+	moduleLevels = make(map[string]int)
+	modules      = make(map[string]*Logger)
+)
+
+func init() {
+	globalLevel.Store(WARN)
+}
+
+// Module returns the logger of the module(eg. "asr" or "transport/amqp"), logging at the level of the module if set
+// (see SetModuleLevel), or else at the level of the default logger. Eg. to log the details of a component only:
+//
+//	var logger = log.Module("asr")
+//	...
+//	logger.Debugf("Streaming %d bytes of audio", len(chunk))
+func Module(name string) *Logger {
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+	logger, ok := modules[name]
+	if !ok {
+		logger = &Logger{module: name}
+		modules[name] = logger
+	}
+	return logger
+}
+
+// SetModuleLevel sets the level of the module, overriding the level of the default logger for its logger(see Module)
+func SetModuleLevel(module string, level int) {
+	if level < ERROR || level > TRACE {
+		Warnf("Cannot set the log level of %s to %d", module, level)
+		return
+	}
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+	moduleLevels[module] = level
+}
+
+// ClearModuleLevel clears the level of the module, its logger logging at the level of the default logger again
+func ClearModuleLevel(module string) {
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+	delete(moduleLevels, module)
+}
+
+// ModuleLevels returns the levels set for the modules
+func ModuleLevels() map[string]int {
+	modulesMutex.RLock()
+	defer modulesMutex.RUnlock()
+	levels := make(map[string]int, len(moduleLevels))
+	for module, level := range moduleLevels {
+		levels[module] = level
+	}
+	return levels
+}
+
+func moduleLevel(module string) (int, bool) {
+	modulesMutex.RLock()
+	defer modulesMutex.RUnlock()
+	level, ok := moduleLevels[module]
+	return level, ok
+}
+
+// LevelName returns the name of the level, eg. "debug" for DEBUG
+func LevelName(level int) string {
+	switch level {
+	case ERROR:
+		return "error"
+	case WARN:
+		return "warn"
+	case INFO:
+		return "info"
+	case DEBUG:
+		return "debug"
+	case TRACE:
+		return "trace"
+	}
+	return strconv.Itoa(level)
+}
+
+// ParseLevel returns the level of the name(eg. "debug", case insensitive) or of the number(eg. "3")
+func ParseLevel(name string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "error":
+		return ERROR, nil
+	case "warn", "warning":
+		return WARN, nil
+	case "info":
+		return INFO, nil
+	case "debug":
+		return DEBUG, nil
+	case "trace":
+		return TRACE, nil
+	}
+	if level, err := strconv.Atoi(strings.TrimSpace(name)); err == nil && level >= ERROR && level <= TRACE {
+		return level, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q, the levels are error, warn, info, debug and trace", name)
+}
+
+// SetLevels sets the level of the default logger and the levels of the modules as per the spec, a level and the
+// levels of modules separated by commas, eg. "warn,asr=debug,transport/amqp=trace". The levels of the modules not in
+// the spec are cleared, so that a spec replaces the levels set before, while the level of the default logger is kept if
+// the spec has none. Nothing is set if the spec is invalid.
+func SetLevels(spec string) error {
+	global := -1
+	levels := make(map[string]int)
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		module, name, ok := strings.Cut(part, "=")
+		if !ok {
+			module, name = "", part
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			return err
+		}
+		if module = strings.TrimSpace(module); module == "" {
+			global = level
+		} else {
+			levels[module] = level
+		}
+	}
+
+	if global >= 0 {
+		globalLevel.Store(int32(global))
+	}
+	modulesMutex.Lock()
+	defer modulesMutex.Unlock()
+	moduleLevels = levels
+	return nil
+}
+
+// Levels returns the spec of the levels set(see SetLevels), eg. "warn,asr=debug"
+func Levels() string {
+	levels := ModuleLevels()
+	parts := make([]string, 0, len(levels)+1)
+	for module, level := range levels {
+		parts = append(parts, module+"="+LevelName(level))
+	}
+	sort.Strings(parts)
+	return strings.Join(append([]string{LevelName(int(globalLevel.Load()))}, parts...), ",")
+}
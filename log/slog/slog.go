@@ -9,6 +9,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/skit-ai/vcore/env"
+	"github.com/skit-ai/vcore/errors"
 	"github.com/skit-ai/vcore/instruments"
 )
 
@@ -20,6 +21,7 @@ type loggerWrapper struct {
 
 const defaultMsgKey = "msg"
 const defaultErrKey = "error"
+const severityKey = "severity"
 
 var (
 	defaultLoggerWrapper *loggerWrapper
@@ -68,6 +70,18 @@ func levelFilter(logLevel string) level.Option {
 	}
 }
 
+// Levels the line of an error as per its severity(see errors.WithSeverity): warn for warnings and error otherwise,
+// critical errors being marked with their severity
+func leveled(logger log.Logger, err error) log.Logger {
+	switch errors.Severity(err) {
+	case errors.Warning:
+		return level.Warn(logger)
+	case errors.Critical:
+		return level.Error(log.With(logger, severityKey, errors.Critical))
+	}
+	return level.Error(logger)
+}
+
 func mapToSlice(m map[string]any) []any {
 	var args []any
 	for k, v := range m {
@@ -115,11 +129,11 @@ func (l *loggerWrapper) Error(err error, msg string, args ...any) {
 	}
 
 	if msg == "" {
-		level.Error(log.With(l.logger, defaultErrKey, err.Error())).Log(args...)
+		leveled(log.With(l.logger, defaultErrKey, err.Error()), err).Log(args...)
 		return
 	}
 
-	level.Error(log.With(l.logger, defaultMsgKey, msg, defaultErrKey, err.Error())).Log(args...)
+	leveled(log.With(l.logger, defaultMsgKey, msg, defaultErrKey, err.Error()), err).Log(args...)
 }
 
 // Infof logs a format line with level info using the loggerWrapper instance.
@@ -162,11 +176,11 @@ func (l *loggerWrapper) Errorf(err error, format string, args ...any) {
 	}
 
 	if format == "" {
-		level.Error(l.logger).Log(defaultErrKey, err.Error())
+		leveled(l.logger, err).Log(defaultErrKey, err.Error())
 		return
 	}
 
-	level.Error(l.logger).Log(defaultMsgKey, fmt.Sprintf(format, args...), defaultErrKey, err.Error())
+	leveled(l.logger, err).Log(defaultMsgKey, fmt.Sprintf(format, args...), defaultErrKey, err.Error())
 }
 
 // WithTraceId returns a pointer to updated loggerWrapper with trace_id attached to the logger.
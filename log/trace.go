@@ -0,0 +1,34 @@
+package log
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Keys of the fields of the trace of a context(see FromContext), eg. for the derived fields of Loki linking the
+// entries to their traces in Grafana
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// Returns the fields of the span active on the context, the OTEL span taking precedence over the Sentry span
+func traceFields(ctx context.Context) map[string]interface{} {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		fields := map[string]interface{}{TraceIDKey: spanContext.TraceID().String()}
+		if spanContext.HasSpanID() {
+			fields[SpanIDKey] = spanContext.SpanID().String()
+		}
+		return fields
+	}
+	if span := sentry.SpanFromContext(ctx); span != nil && span.TraceID != (sentry.TraceID{}) {
+		fields := map[string]interface{}{TraceIDKey: span.TraceID.String()}
+		if span.SpanID != (sentry.SpanID{}) {
+			fields[SpanIDKey] = span.SpanID.String()
+		}
+		return fields
+	}
+	return nil
+}
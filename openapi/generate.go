@@ -0,0 +1,254 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Generate returns the source of a typed Go client of the package pkg for the operations registered, built on
+// httpclient(retries, tracing). Eg. from a go:generate program of the service:
+//
+//	source, err := openapi.Generate(api.Spec(), "callsclient")
+//	os.WriteFile("callsclient/client.go", source, 0o644)
+//
+// Every operation is to have an ID, which names its method. The client uses the request and response types of the
+// operations, which are to be exported types of importable packages(ie. not main).
+func Generate(spec *Spec, pkg string) ([]byte, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	spec.mutex.RLock()
+	operations := append([]Operation(nil), spec.operations...)
+	spec.mutex.RUnlock()
+	sort.SliceStable(operations, func(i, j int) bool { return operations[i].ID < operations[j].ID })
+
+	g := &codegen{imports: map[string]string{"context": "context", "github.com/skit-ai/vcore/httpclient": "httpclient"}}
+	var methods bytes.Buffer
+	for _, operation := range operations {
+		if operation.ID == "" {
+			return nil, fmt.Errorf("%s %s: the operation has no ID to name its method", operation.Method, operation.Path)
+		}
+		if err := g.method(&methods, operation); err != nil {
+			return nil, fmt.Errorf("%s %s: %s", operation.Method, operation.Path, err)
+		}
+	}
+
+	var source bytes.Buffer
+	fmt.Fprintf(&source, "// Code generated by openapi.Generate. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	paths := make([]string, 0, len(g.imports))
+	for importPath := range g.imports {
+		paths = append(paths, importPath)
+	}
+	sort.Strings(paths)
+	for _, importPath := range paths {
+		if alias := g.imports[importPath]; alias != path.Base(importPath) {
+			fmt.Fprintf(&source, "\t%s %q\n", alias, importPath)
+		} else {
+			fmt.Fprintf(&source, "\t%q\n", importPath)
+		}
+	}
+	fmt.Fprintf(&source, `)
+
+// Client of %[1]s %[2]s
+type Client struct {
+	*httpclient.Client
+}
+
+// New returns a client of %[1]s at the base URL
+func New(baseURL string, opts ...httpclient.Option) *Client {
+	return &Client{Client: httpclient.New(baseURL, opts...)}
+}
+`, spec.title, spec.version)
+	source.Write(methods.Bytes())
+
+	return format.Source(source.Bytes())
+}
+
+type codegen struct {
+	// Aliases of the packages imported, by their paths
+	imports map[string]string
+}
+
+// Returns the alias of the package, importing it
+func (g *codegen) use(importPath string) string {
+	if alias, ok := g.imports[importPath]; ok {
+		return alias
+	}
+
+	base := identifier(path.Base(importPath), false)
+	alias := base
+	for i := 2; g.taken(alias); i++ {
+		alias = fmt.Sprintf("%s%d", base, i)
+	}
+	g.imports[importPath] = alias
+	return alias
+}
+
+func (g *codegen) taken(alias string) bool {
+	for _, a := range g.imports {
+		if a == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the Go expression of the type
+func (g *codegen) expr(t reflect.Type) (string, error) {
+	if t.Name() != "" {
+		switch {
+		case t.PkgPath() == "":
+			return t.Name(), nil
+		case t.PkgPath() == "main" || strings.Contains(t.Name(), "["):
+			return "", fmt.Errorf("the type %s can not be referenced by a client", t)
+		case !unicode.IsUpper(rune(t.Name()[0])):
+			return "", fmt.Errorf("the type %s is not exported", t)
+		}
+		return g.use(t.PkgPath()) + "." + t.Name(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		elem, err := g.expr(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		switch t.Kind() {
+		case reflect.Ptr:
+			return "*" + elem, nil
+		case reflect.Slice:
+			return "[]" + elem, nil
+		case reflect.Array:
+			return fmt.Sprintf("[%d]%s", t.Len(), elem), nil
+		}
+		key, err := g.expr(t.Key())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("map[%s]%s", key, elem), nil
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", nil
+		}
+	}
+	return "", fmt.Errorf("the type %s can not be referenced by a client, name it", t)
+}
+
+var reserved = map[string]bool{"c": true, "ctx": true, "body": true, "out": true, "opts": true, "err": true}
+
+func (g *codegen) method(b *bytes.Buffer, operation Operation) error {
+	params, _ := pathParameters(operation.Path)
+	args := []string{"ctx context.Context"}
+	names := make(map[string]string, len(params))
+	for _, param := range params {
+		name := identifier(param, false)
+		if reserved[name] || shadows(name) {
+			name += "Param"
+		}
+		names[param] = name
+		args = append(args, name+" string")
+	}
+
+	bodyArg := "nil"
+	if operation.Request != nil {
+		request, err := g.expr(reflect.TypeOf(operation.Request))
+		if err != nil {
+			return err
+		}
+		args = append(args, "body "+request)
+		bodyArg = "body"
+	}
+	args = append(args, "opts ...httpclient.RequestOption")
+
+	// The path as a concatenation of its literals and escaped parameters
+	var parts []string
+	literal := ""
+	for _, segment := range strings.Split(operation.Path, "/")[1:] {
+		if strings.HasPrefix(segment, "{") {
+			g.use("net/url")
+			parts = append(parts, fmt.Sprintf("%q", literal+"/"), "url.PathEscape("+names[segment[1:len(segment)-1]]+")")
+			literal = ""
+			continue
+		}
+		literal += "/" + segment
+	}
+	if literal != "" || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%q", literal))
+	}
+	pathExpr := strings.Join(parts, " + ")
+
+	name := identifier(operation.ID, true)
+	fmt.Fprintf(b, "\n// %s calls %s %s", name, operation.Method, operation.Path)
+	if operation.Summary != "" {
+		fmt.Fprintf(b, ": %s", operation.Summary)
+	}
+	b.WriteString("\n")
+
+	if operation.Response == nil {
+		fmt.Fprintf(b, "func (c *Client) %s(%s) error {\n", name, strings.Join(args, ", "))
+		fmt.Fprintf(b, "\treturn c.Do(ctx, %q, %s, %s, nil, opts...)\n}\n", operation.Method, pathExpr, bodyArg)
+		return nil
+	}
+
+	response, err := g.expr(reflect.TypeOf(operation.Response))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(%s) (*%s, error) {\n", name, strings.Join(args, ", "), response)
+	fmt.Fprintf(b, "\tout := new(%s)\n", response)
+	fmt.Fprintf(b, "\tif err := c.Do(ctx, %q, %s, %s, out, opts...); err != nil {\n\t\treturn nil, err\n\t}\n", operation.Method, pathExpr, bodyArg)
+	fmt.Fprintf(b, "\treturn out, nil\n}\n")
+	return nil
+}
+
+// Returns the camel cased identifier of a name(eg. call_id to callID, getCall to GetCall if exported)
+func identifier(name string, exported bool) string {
+	var b strings.Builder
+	upper := exported
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = b.Len() > 0 || exported
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteByte('_')
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+		} else if b.Len() == 0 {
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+		upper = false
+	}
+
+	id := b.String()
+	// Initialisms as per the Go style(eg. callId to callID)
+	for _, initialism := range []string{"Id", "Url", "Http", "Api"} {
+		if strings.HasSuffix(id, initialism) && len(id) > len(initialism) {
+			id = strings.TrimSuffix(id, initialism) + strings.ToUpper(initialism)
+		}
+	}
+	if id == "" {
+		id = "param"
+	}
+	return id
+}
+
+// Returns true if the name is a keyword, or shadows a package used by the client
+func shadows(name string) bool {
+	switch name {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func", "go",
+		"goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct", "switch", "type",
+		"var", "url", "httpclient", "context":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+// Package openapi generates the OpenAPI 3 document of a REST service from the registrations of its handlers, so that
+// the spec is kept in sync with the code. Eg.
+//
+//	spec := openapi.New("calls", "1.4.0")
+//	mux.Handle("/v1/calls/", spec.Handle(openapi.Operation{
+//		Method:   http.MethodGet,
+//		Path:     "/v1/calls/{id}",
+//		Summary:  "Get a call",
+//		Response: Call{},
+//		Errors:   map[int]interface{}{http.StatusNotFound: Error{}},
+//	}, getCall))
+//	mux.Handle("/openapi.json", spec)
+//
+//	if err := spec.Validate(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// The schemas of the request and response types are generated from their json tags, named structs being shared
+// through the components of the document.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/skit-ai/vcore/httpserver"
+)
+
+// Parameter of an operation(https://spec.openapis.org/oas/v3.0.3#parameter-object). In is one of "path",
+// "query", "header" or "cookie". The parameters of the path are declared from it unless declared explicitly.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// Operation registered by a handler
+type Operation struct {
+	Method string
+	// Path with its parameters in braces, eg. /v1/calls/{id}
+	Path        string
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	Parameters  []Parameter
+	// Value(or nil pointer) of the type of the body of the request, nil if it has none
+	Request interface{}
+	// Value of the type of the body of the response, nil if it has none
+	Response interface{}
+	// Status of the response. Defaults to 200.
+	Status int
+	// Types of the bodies of the error responses by their status(nil values for responses with no body)
+	Errors map[int]interface{}
+}
+
+// Spec collects the operations registered, and serves them as a document
+type Spec struct {
+	title   string
+	version string
+
+	mutex      sync.RWMutex
+	operations []Operation
+}
+
+// New returns the spec of a service
+func New(title, version string) *Spec {
+	return &Spec{title: title, version: version}
+}
+
+// Register registers an operation. Registrations are checked by Validate.
+func (s *Spec) Register(operation Operation) {
+	operation.Method = strings.ToUpper(operation.Method)
+	s.mutex.Lock()
+	s.operations = append(s.operations, operation)
+	s.mutex.Unlock()
+}
+
+// Handle registers the operation of the handler and returns the handler
+func (s *Spec) Handle(operation Operation, handler http.Handler) http.Handler {
+	s.Register(operation)
+	return handler
+}
+
+// HandleFunc registers the operation of the handler and returns the handler
+func (s *Spec) HandleFunc(operation Operation, handler http.HandlerFunc) http.HandlerFunc {
+	s.Register(operation)
+	return handler
+}
+
+// Validate checks the operations registered, call it at startup once all the handlers are registered.
+// Fails on invalid methods and paths, operations registered twice, duplicate operation IDs and parameters of the
+// path not in it.
+func (s *Spec) Validate() error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var problems []string
+	routes := make(map[string]bool)
+	ids := make(map[string]bool)
+	for _, operation := range s.operations {
+		route := operation.Method + " " + operation.Path
+		if !validMethods[operation.Method] {
+			problems = append(problems, fmt.Sprintf("%s: invalid method", route))
+		}
+		params, err := pathParameters(operation.Path)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", route, err))
+		}
+		if routes[route] {
+			problems = append(problems, fmt.Sprintf("%s: registered more than once", route))
+		}
+		routes[route] = true
+
+		if operation.ID != "" {
+			if ids[operation.ID] {
+				problems = append(problems, fmt.Sprintf("%s: the operation ID %q is not unique", route, operation.ID))
+			}
+			ids[operation.ID] = true
+		}
+
+		for _, param := range operation.Parameters {
+			if param.In == "path" && !contains(params, param.Name) {
+				problems = append(problems, fmt.Sprintf("%s: the path parameter %q is not in the path", route, param.Name))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid OpenAPI spec:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+var validMethods = map[string]bool{
+	http.MethodGet: true, http.MethodPut: true, http.MethodPost: true, http.MethodDelete: true,
+	http.MethodOptions: true, http.MethodHead: true, http.MethodPatch: true, http.MethodTrace: true,
+}
+
+// Returns the names of the parameters of the path
+func pathParameters(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("the path is to start with /")
+	}
+
+	var params []string
+	for _, segment := range strings.Split(path, "/") {
+		open, close := strings.Count(segment, "{"), strings.Count(segment, "}")
+		if open == 0 && close == 0 {
+			continue
+		}
+		if open != 1 || close != 1 || !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") || len(segment) == 2 {
+			return params, fmt.Errorf("the segment %q is to be a single parameter in braces", segment)
+		}
+		params = append(params, segment[1:len(segment)-1])
+	}
+	return params, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Document returns the OpenAPI document of the operations registered
+func (s *Spec) Document() map[string]interface{} {
+	s.mutex.RLock()
+	operations := append([]Operation(nil), s.operations...)
+	s.mutex.RUnlock()
+
+	g := &generator{schemas: make(map[string]*Schema)}
+	paths := make(map[string]map[string]interface{})
+	for _, operation := range operations {
+		item, ok := paths[operation.Path]
+		if !ok {
+			item = make(map[string]interface{})
+			paths[operation.Path] = item
+		}
+		item[strings.ToLower(operation.Method)] = g.operation(operation)
+	}
+
+	document := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]string{"title": s.title, "version": s.version},
+		"paths":   paths,
+	}
+	if len(g.schemas) > 0 {
+		document["components"] = map[string]interface{}{"schemas": g.schemas}
+	}
+	return document
+}
+
+func (g *generator) operation(operation Operation) map[string]interface{} {
+	doc := make(map[string]interface{})
+	if operation.ID != "" {
+		doc["operationId"] = operation.ID
+	}
+	if operation.Summary != "" {
+		doc["summary"] = operation.Summary
+	}
+	if operation.Description != "" {
+		doc["description"] = operation.Description
+	}
+	if len(operation.Tags) > 0 {
+		doc["tags"] = operation.Tags
+	}
+
+	parameters := append([]Parameter(nil), operation.Parameters...)
+	params, _ := pathParameters(operation.Path)
+	for _, name := range params {
+		declared := false
+		for _, param := range parameters {
+			declared = declared || (param.In == "path" && param.Name == name)
+		}
+		if !declared {
+			parameters = append(parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	for i := range parameters {
+		// Parameters of the path are always required
+		if parameters[i].In == "path" {
+			parameters[i].Required = true
+		}
+		if parameters[i].Schema == nil {
+			parameters[i].Schema = &Schema{Type: "string"}
+		}
+	}
+	if len(parameters) > 0 {
+		doc["parameters"] = parameters
+	}
+
+	if operation.Request != nil {
+		doc["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  g.content(operation.Request),
+		}
+	}
+
+	status := operation.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	responses := map[string]interface{}{
+		fmt.Sprint(status): g.response(status, operation.Response),
+	}
+	codes := make([]int, 0, len(operation.Errors))
+	for code := range operation.Errors {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		responses[fmt.Sprint(code)] = g.response(code, operation.Errors[code])
+	}
+	doc["responses"] = responses
+	return doc
+}
+
+func (g *generator) response(status int, body interface{}) map[string]interface{} {
+	response := map[string]interface{}{"description": http.StatusText(status)}
+	if body != nil {
+		response["content"] = g.content(body)
+	}
+	return response
+}
+
+func (g *generator) content(body interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(body))},
+	}
+}
+
+// ServeHTTP serves the document as JSON(eg. at /openapi.json), responding 304 to the clients which have it already
+func (s *Spec) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := httpserver.JSON(w, r, http.StatusOK, s.Document()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,287 @@
+// Package overrides declares the overrides of the defaults of the middleware(timeouts, body limits, rate limits, auth
+// scopes and sampling rates) for specific routes or RPCs in a single config, instead of a bespoke middleware stack per
+// route. Eg.
+//
+//	"*":
+//	  timeout: 10s
+//	"/v1/calls/*":
+//	  timeout: 30s
+//	  scopes: [calls]
+//	"POST /v1/calls/*/recordings":
+//	  body_limit: 52428800
+//	"/skit.Dialogue/Stream":
+//	  timeout: 0s
+//	  sample_rate: 0.1
+//
+// Patterns are "*", paths(or full RPC methods) and paths ending with "/*" matching the paths under them, optionally
+// prefixed by an HTTP method. A "*" segment within a path matches any single segment. The overrides of all the
+// patterns matching a request are merged, the more specific patterns(exact over prefixes, longer over shorter, with
+// a method over without) overriding the less specific ones field by field.
+package overrides
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skit-ai/vcore/ctxkeys"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v2"
+)
+
+// Override of a route. Unset(nil) fields fall back to the less specific patterns, and then to the defaults of the
+// middleware consuming them.
+type Override struct {
+	// Deadline of the request, zero to not have one
+	Timeout *time.Duration `yaml:"timeout" json:"timeout"`
+	// Maximum bytes of the body of a request, zero to not limit it
+	BodyLimit *int64 `yaml:"body_limit" json:"body_limit"`
+	// Requests per second(and the burst above them) allowed, for the rate limiting middleware
+	RateLimit *float64 `yaml:"rate_limit" json:"rate_limit"`
+	Burst     *int     `yaml:"burst" json:"burst"`
+	// Scopes the caller must be granted, for the auth middleware
+	Scopes []string `yaml:"scopes" json:"scopes"`
+	// Rate(0 to 1) at which the errors of the route are reported to Sentry
+	SampleRate *float64 `yaml:"sample_rate" json:"sample_rate"`
+}
+
+// Returns the override with the fields set on other replacing those of o
+func (o Override) merge(other Override) Override {
+	if other.Timeout != nil {
+		o.Timeout = other.Timeout
+	}
+	if other.BodyLimit != nil {
+		o.BodyLimit = other.BodyLimit
+	}
+	if other.RateLimit != nil {
+		o.RateLimit = other.RateLimit
+	}
+	if other.Burst != nil {
+		o.Burst = other.Burst
+	}
+	if other.Scopes != nil {
+		o.Scopes = other.Scopes
+	}
+	if other.SampleRate != nil {
+		o.SampleRate = other.SampleRate
+	}
+	return o
+}
+
+// Allows is true if the granted scopes include all the scopes of the override
+func (o Override) Allows(granted ...string) bool {
+	for _, scope := range o.Scopes {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Sampled is true if an error of the route is to be reported, as per its sample rate(all are if it has none)
+func (o Override) Sampled(random func() float64) bool {
+	if o.SampleRate == nil {
+		return true
+	}
+	if random == nil {
+		random = rand.Float64
+	}
+	return random() < *o.SampleRate
+}
+
+// Config maps the patterns of routes to their overrides
+type Config map[string]Override
+
+type pattern struct {
+	method   string
+	segments []string
+	// True if the pattern matches the paths under it
+	prefix   bool
+	override Override
+}
+
+// Returns true if the pattern matches the segments of the path
+func (p pattern) matches(method string, segments []string) bool {
+	if p.method != "" && p.method != method {
+		return false
+	}
+	if len(segments) < len(p.segments) || (!p.prefix && len(segments) != len(p.segments)) {
+		return false
+	}
+	for i, segment := range p.segments {
+		if segment != "*" && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if the pattern is less specific than the other
+func (p pattern) less(other pattern) bool {
+	if p.prefix != other.prefix {
+		return p.prefix
+	}
+	if len(p.segments) != len(other.segments) {
+		return len(p.segments) < len(other.segments)
+	}
+	return p.method == "" && other.method != ""
+}
+
+// Routes resolves the overrides of requests
+type Routes struct {
+	// Sorted from the least to the most specific
+	patterns []pattern
+}
+
+// New compiles the patterns of the config
+func New(config Config) (*Routes, error) {
+	routes := &Routes{}
+	for key, override := range config {
+		p, err := parse(key)
+		if err != nil {
+			return nil, err
+		}
+		if override.SampleRate != nil && (*override.SampleRate < 0 || *override.SampleRate > 1) {
+			return nil, fmt.Errorf("pattern %q has an invalid sample rate %v", key, *override.SampleRate)
+		}
+		p.override = override
+		routes.patterns = append(routes.patterns, p)
+	}
+	sort.SliceStable(routes.patterns, func(i, j int) bool { return routes.patterns[i].less(routes.patterns[j]) })
+	return routes, nil
+}
+
+// LoadFile compiles the config of a YAML(or JSON) file
+func LoadFile(path string) (*Routes, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return New(config)
+}
+
+func parse(key string) (p pattern, err error) {
+	path := strings.TrimSpace(key)
+	if method, rest, found := strings.Cut(path, " "); found {
+		p.method = strings.ToUpper(method)
+		path = strings.TrimSpace(rest)
+	}
+
+	if path == "*" {
+		p.prefix = true
+		return
+	}
+	if !strings.HasPrefix(path, "/") {
+		return p, fmt.Errorf("pattern %q is to be \"*\" or start with \"/\"", key)
+	}
+	if strings.HasSuffix(path, "/*") {
+		p.prefix = true
+		path = strings.TrimSuffix(path, "/*")
+	}
+	p.segments = split(path)
+	return
+}
+
+func split(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+}
+
+// Resolve merges the overrides of the patterns matching the method and path(or the full method of an RPC, with an
+// empty method)
+func (r *Routes) Resolve(method, path string) Override {
+	var resolved Override
+	if r == nil {
+		return resolved
+	}
+
+	segments := split(path)
+	for _, p := range r.patterns {
+		if p.matches(method, segments) {
+			resolved = resolved.merge(p.override)
+		}
+	}
+	return resolved
+}
+
+var key = ctxkeys.NewKey[Override]("overrides.route")
+
+// FromContext returns the override of the request of the context(an empty one if there is none)
+func FromContext(ctx context.Context) Override {
+	return key.Value(ctx)
+}
+
+// WithOverride returns a copy of the context carrying the override
+func WithOverride(ctx context.Context, override Override) context.Context {
+	return key.With(ctx, override)
+}
+
+// Attaches the override to the context, along with its deadline
+func apply(ctx context.Context, override Override) (context.Context, context.CancelFunc) {
+	ctx = WithOverride(ctx, override)
+	if override.Timeout != nil && *override.Timeout > 0 {
+		return context.WithTimeout(ctx, *override.Timeout)
+	}
+	return ctx, func() {}
+}
+
+// Middleware attaches the override of every request to its context, applying its timeout and body limit
+func (r *Routes) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		override := r.Resolve(req.Method, req.URL.Path)
+		ctx, cancel := apply(req.Context(), override)
+		defer cancel()
+
+		if override.BodyLimit != nil && *override.BodyLimit > 0 {
+			if req.ContentLength > *override.BodyLimit {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			req.Body = http.MaxBytesReader(w, req.Body, *override.BodyLimit)
+		}
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// UnaryServerInterceptor attaches the override of every call to its context, applying its timeout
+func (r *Routes) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := apply(ctx, r.Resolve("", info.FullMethod))
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamServerInterceptor attaches the override of every stream to its context, applying its timeout
+func (r *Routes) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := apply(ss.Context(), r.Resolve("", info.FullMethod))
+		defer cancel()
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
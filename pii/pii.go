@@ -0,0 +1,252 @@
+// Package pii detects personally identifiable information in transcript text and scrubs it before the
+// transcripts are persisted.
+package pii
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type EntityType string
+
+const (
+	Phone   EntityType = "PHONE"
+	Card    EntityType = "CARD"
+	OTP     EntityType = "OTP"
+	Email   EntityType = "EMAIL"
+	Address EntityType = "ADDRESS"
+)
+
+// Entity is a span of text identified as PII.
+// Start and End are byte offsets into the original text such that text[Start:End] == Text.
+type Entity struct {
+	Type  EntityType `json:"type"`
+	Start int        `json:"start"`
+	End   int        `json:"end"`
+	Text  string     `json:"text"`
+	Score float64    `json:"score"`
+}
+
+// Detector finds PII entities in a piece of text.
+// Regex based detectors are provided by this package. Model based detectors(eg. NER served over gRPC) can be
+// plugged in by implementing this interface.
+type Detector interface {
+	Detect(text string) []Entity
+}
+
+// DetectorFunc allows the use of ordinary functions as a Detector
+type DetectorFunc func(text string) []Entity
+
+func (f DetectorFunc) Detect(text string) []Entity {
+	return f(text)
+}
+
+// RegexDetector detects entities of a type using a regular expression.
+// Validate(if set) is called on every match to weed out false positives.
+type RegexDetector struct {
+	Type     EntityType
+	Pattern  *regexp.Regexp
+	Validate func(match string) bool
+	Score    float64
+	// Index of the sub-match to be used as the entity. 0 uses the complete match
+	Group int
+}
+
+// NewRegexDetector returns a detector for the pattern. Panics if the pattern does not compile.
+func NewRegexDetector(entityType EntityType, pattern string, validate func(string) bool) *RegexDetector {
+	return &RegexDetector{
+		Type:     entityType,
+		Pattern:  regexp.MustCompile(pattern),
+		Validate: validate,
+		Score:    1,
+	}
+}
+
+func (d *RegexDetector) Detect(text string) (entities []Entity) {
+	for _, match := range d.Pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2*d.Group], match[2*d.Group+1]
+		if start < 0 {
+			continue
+		}
+		if d.Validate != nil && !d.Validate(text[start:end]) {
+			continue
+		}
+		entities = append(entities, Entity{
+			Type:  d.Type,
+			Start: start,
+			End:   end,
+			Text:  text[start:end],
+			Score: d.Score,
+		})
+	}
+	return
+}
+
+var (
+	EmailDetector = NewRegexDetector(Email, `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`, nil)
+	CardDetector  = NewRegexDetector(Card, `\b(?:\d[ \-]?){12,18}\d\b`, Luhn)
+	PhoneDetector = NewRegexDetector(Phone, `(?:\+\d{1,3}[ \-]?)?\b\d(?:[ \-]?\d){9,11}\b`, nil)
+	OTPDetector   = &RegexDetector{
+		Type:    OTP,
+		Pattern: regexp.MustCompile(`(?i)\b(?:otp|code|pin|password)\b\D{0,20}?\b(\d{4,8})\b`),
+		Score:   0.9,
+		Group:   1,
+	}
+	AddressDetector = &RegexDetector{
+		Type: Address,
+		Pattern: regexp.MustCompile(`(?i)\b\d{1,5}(?:[ ,/\-]+[A-Za-z0-9.]+){1,6}?[ ,]+` +
+			`(?:street|st|road|rd|lane|ln|avenue|ave|nagar|colony|sector|block|cross|main|layout|marg)\b`),
+		Score: 0.7,
+	}
+)
+
+// DefaultDetectors are the detectors used when none are configured
+func DefaultDetectors() []Detector {
+	return []Detector{EmailDetector, CardDetector, PhoneDetector, OTPDetector, AddressDetector}
+}
+
+// Luhn checks if the digits in the string(ignoring separators) pass the Luhn checksum used by card numbers
+func Luhn(number string) bool {
+	var sum, count int
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		count++
+		double = !double
+	}
+	return count > 1 && sum%10 == 0
+}
+
+// Scrubber detects and replaces PII entities in text
+type Scrubber struct {
+	detectors  []Detector
+	strategy   Strategy
+	strategies map[EntityType]Strategy
+}
+
+// Option configures a Scrubber
+type Option func(*Scrubber)
+
+// WithDetectors replaces the detectors used by the scrubber
+func WithDetectors(detectors ...Detector) Option {
+	return func(s *Scrubber) {
+		s.detectors = detectors
+	}
+}
+
+// WithDetector appends a detector to the ones used by the scrubber
+func WithDetector(detector Detector) Option {
+	return func(s *Scrubber) {
+		s.detectors = append(s.detectors, detector)
+	}
+}
+
+// WithStrategy sets the replacement strategy used for all entity types
+func WithStrategy(strategy Strategy) Option {
+	return func(s *Scrubber) {
+		s.strategy = strategy
+	}
+}
+
+// WithTypeStrategy sets the replacement strategy used for an entity type
+func WithTypeStrategy(entityType EntityType, strategy Strategy) Option {
+	return func(s *Scrubber) {
+		s.strategies[entityType] = strategy
+	}
+}
+
+// New returns a scrubber using the default detectors and the Redact strategy unless configured otherwise
+func New(opts ...Option) *Scrubber {
+	s := &Scrubber{
+		detectors:  DefaultDetectors(),
+		strategy:   Redact,
+		strategies: make(map[EntityType]Strategy),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Detect returns the non-overlapping entities found in the text ordered by their offsets.
+// When entities overlap, the longer one is retained. Ties are broken by the order of the detectors.
+func (s *Scrubber) Detect(text string) []Entity {
+	var candidates []Entity
+	for _, detector := range s.detectors {
+		candidates = append(candidates, detector.Detect(text)...)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].End-candidates[i].Start > candidates[j].End-candidates[j].Start
+	})
+
+	var entities []Entity
+	for _, candidate := range candidates {
+		overlaps := false
+		for _, entity := range entities {
+			if candidate.Start < entity.End && entity.Start < candidate.End {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			entities = append(entities, candidate)
+		}
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].Start < entities[j].Start
+	})
+	return entities
+}
+
+// Scrub replaces the entities found in the text as per the configured strategies.
+// The entities returned carry offsets into the original text.
+func (s *Scrubber) Scrub(text string) (string, []Entity) {
+	entities := s.Detect(text)
+	if len(entities) == 0 {
+		return text, nil
+	}
+
+	var builder strings.Builder
+	last := 0
+	for _, entity := range entities {
+		strategy, ok := s.strategies[entity.Type]
+		if !ok {
+			strategy = s.strategy
+		}
+		builder.WriteString(text[last:entity.Start])
+		builder.WriteString(strategy.Replace(entity))
+		last = entity.End
+	}
+	builder.WriteString(text[last:])
+
+	return builder.String(), entities
+}
+
+var defaultScrubber = New()
+
+// Detect finds PII entities in the text using the default detectors
+func Detect(text string) []Entity {
+	return defaultScrubber.Detect(text)
+}
+
+// Scrub redacts PII entities in the text using the default detectors
+func Scrub(text string) (string, []Entity) {
+	return defaultScrubber.Scrub(text)
+}
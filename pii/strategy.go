@@ -0,0 +1,66 @@
+package pii
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"unicode"
+)
+
+// Strategy decides what an entity is replaced with when scrubbing
+type Strategy interface {
+	Replace(entity Entity) string
+}
+
+// StrategyFunc allows the use of ordinary functions as a Strategy
+type StrategyFunc func(entity Entity) string
+
+func (f StrategyFunc) Replace(entity Entity) string {
+	return f(entity)
+}
+
+var (
+	// Redact replaces the entity with its type. Eg. "[PHONE]"
+	Redact = StrategyFunc(func(entity Entity) string {
+		return "[" + string(entity.Type) + "]"
+	})
+
+	// Mask replaces every alphanumeric character of the entity with a '*' retaining separators
+	Mask = MaskKeepLast(0)
+
+	// Hash replaces the entity with a truncated SHA-256 hash so that occurrences of the same value
+	// can be correlated without revealing it. Eg. "[PHONE:1f2e3d4c]"
+	Hash = StrategyFunc(func(entity Entity) string {
+		sum := sha256.Sum256([]byte(entity.Text))
+		return "[" + string(entity.Type) + ":" + hex.EncodeToString(sum[:4]) + "]"
+	})
+)
+
+// MaskKeepLast masks every alphanumeric character of the entity except the last n. Eg. "******1234"
+func MaskKeepLast(n int) Strategy {
+	return StrategyFunc(func(entity Entity) string {
+		runes := []rune(entity.Text)
+		kept := 0
+		for i := len(runes) - 1; i >= 0; i-- {
+			if !isAlphaNumeric(runes[i]) {
+				continue
+			}
+			if kept < n {
+				kept++
+				continue
+			}
+			runes[i] = '*'
+		}
+		return string(runes)
+	})
+}
+
+// Replace replaces every entity with a fixed string
+func Replace(with string) Strategy {
+	return StrategyFunc(func(Entity) string {
+		return with
+	})
+}
+
+func isAlphaNumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
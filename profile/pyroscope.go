@@ -4,6 +4,8 @@ import (
 	"github.com/grafana/pyroscope-go"
 	"github.com/pkg/errors"
 	"github.com/skit-ai/vcore/env"
+	"github.com/skit-ai/vcore/features"
+	"github.com/skit-ai/vcore/log"
 )
 
 var (
@@ -46,6 +48,11 @@ func InitPyroscopeWithProfiles(profileTypes []pyroscope.ProfileType) error {
 }
 
 func initPyroscope(profileTypes []pyroscope.ProfileType) error {
+	if !features.Enabled(features.Profiling) {
+		log.Infof("Profiling is disabled through FEATURES")
+		return nil
+	}
+
 	_, err := pyroscope.Start(
 		pyroscope.Config{
 			ApplicationName: appName,
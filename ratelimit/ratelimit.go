@@ -0,0 +1,248 @@
+// Package ratelimit limits the rate of the requests of every tenant(or client) with token buckets, eg.
+//
+//	limiter := ratelimit.New(10, 20, ratelimit.WithStore(ratelimit.NewRedisStore(redis.Client)))
+//	handler = routes.Middleware(limiter.Middleware(handler))
+//
+// The requests are limited by tenant(ctxkeys.Tenant), or else by the IP of the client set by realip.Middleware, which
+// is to run before the limiter so that the clients behind proxies get buckets of their own. Without either, the
+// requests are limited by the address of the connection.
+//
+// The buckets are kept in the memory of the process by default, so every replica has its own and they are refilled
+// on restarts. A RedisStore shares the buckets across the replicas and restarts. The rates of the routes overridden
+// (see overrides.Override) replace the default rate of the limiter for their requests.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/skit-ai/vcore/ctxkeys"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+	"github.com/skit-ai/vcore/overrides"
+	"github.com/skit-ai/vcore/realip"
+	"github.com/skit-ai/vcore/routes"
+	"github.com/skit-ai/vcore/simulation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// Result of taking tokens from a bucket
+type Result struct {
+	Allowed bool
+	// Remaining tokens in the bucket
+	Remaining int
+	// RetryAfter is the duration after which the tokens taken would be allowed, if they were not
+	RetryAfter time.Duration
+}
+
+// Store holds the token buckets of the keys. Buckets start full, hold up to burst tokens and are refilled at rate
+// tokens per second.
+type Store interface {
+	Take(ctx context.Context, key string, rate float64, burst int, tokens int) (Result, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	rate    float64
+	burst   int
+}
+
+// Takes between sweeps of the buckets refilled, which are dropped as they are the same as new ones
+const sweepInterval = 1024
+
+// MemoryStore holds the buckets in the memory of the process
+type MemoryStore struct {
+	clock   simulation.Clock
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+// NewMemoryStore returns a store holding the buckets in memory, refilled as per the clock(eg. a simulation.Simulation
+// in tests, defaults to simulation.Real if nil)
+func NewMemoryStore(clock simulation.Clock) *MemoryStore {
+	if clock == nil {
+		clock = simulation.Real
+	}
+	return &MemoryStore{clock: clock, buckets: make(map[string]*bucket)}
+}
+
+// Take takes the tokens from the bucket of the key if it has as many
+func (s *MemoryStore) Take(_ context.Context, key string, rate float64, burst int, tokens int) (Result, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.clock.Now()
+	if s.takes++; s.takes%sweepInterval == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated, b.rate, b.burst = now, rate, burst
+
+	if b.tokens < float64(tokens) {
+		return Result{
+			Remaining:  int(b.tokens),
+			RetryAfter: time.Duration((float64(tokens) - b.tokens) / rate * float64(time.Second)),
+		}, nil
+	}
+	b.tokens -= float64(tokens)
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+// Drops the buckets which would have been refilled by now
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.rate >= float64(b.burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Limiter limits the rate of the requests of the keys
+type Limiter struct {
+	rate  float64
+	burst int
+	store Store
+	key   func(ctx context.Context) string
+}
+
+// Option configures a Limiter
+type Option func(*Limiter)
+
+// WithStore configures the store of the buckets, defaults to a MemoryStore
+func WithStore(store Store) Option {
+	return func(l *Limiter) {
+		l.store = store
+	}
+}
+
+// WithKey configures the key of the bucket of the requests of a context. Defaults to the tenant(ctxkeys.Tenant), or
+// else the IP of the client(ctxkeys.ClientIP, see realip.Middleware). Requests without a key are limited by the
+// address of their connection, or not at all if it has none(eg. on a unix socket), rather than sharing a bucket.
+func WithKey(key func(ctx context.Context) string) Option {
+	return func(l *Limiter) {
+		l.key = key
+	}
+}
+
+// New returns a limiter allowing rate requests per second to every key, and bursts of up to burst requests
+func New(rate float64, burst int, opts ...Option) *Limiter {
+	l := &Limiter{rate: rate, burst: burst, key: defaultKey}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.store == nil {
+		l.store = NewMemoryStore(nil)
+	}
+	return l
+}
+
+func defaultKey(ctx context.Context) string {
+	if tenant := ctxkeys.Tenant.Value(ctx); tenant != "" {
+		return "tenant:" + tenant
+	}
+	if ip, ok := realip.FromContext(ctx); ok {
+		return "ip:" + ip.String()
+	}
+	return ""
+}
+
+// Returns the key of the address of a connection, empty if it has none
+func addrKey(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	if addr == "" || addr == "@" {
+		return ""
+	}
+	return "addr:" + addr
+}
+
+// Allow takes a token from the bucket of the key, at the rate overridden for the route of the context(if any).
+// Requests are allowed if the store fails, so that an outage of the store does not take the service down with it.
+func (l *Limiter) Allow(ctx context.Context, key string) Result {
+	rate, burst := l.rate, l.burst
+	override := overrides.FromContext(ctx)
+	if override.RateLimit != nil {
+		rate = *override.RateLimit
+	}
+	if override.Burst != nil {
+		burst = *override.Burst
+	}
+	if rate <= 0 {
+		return Result{Allowed: true}
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+
+	result, err := l.store.Take(ctx, key, rate, burst, 1)
+	if err != nil {
+		log.Warnf("Allowing the request of %s as the rate limit could not be checked: %s", key, err)
+		return Result{Allowed: true}
+	}
+	return result
+}
+
+// Middleware responds 429 to the requests over the rate of their keys, with the Retry-After header
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		key := l.key(ctx)
+		if key == "" {
+			key = addrKey(r.RemoteAddr)
+		}
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if overrides.FromContext(ctx).RateLimit != nil {
+			// Routes with their own rates have their own buckets
+			key += "|" + routes.Name(r)
+		}
+
+		result := l.Allow(ctx, key)
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UnaryServerInterceptor fails the calls over the rate of their keys with ResourceExhausted, eg. at the
+// grpcserver.RateLimit position of an interceptor chain
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key := l.key(ctx)
+		if p, ok := peer.FromContext(ctx); key == "" && ok && p.Addr != nil {
+			key = addrKey(p.Addr.String())
+		}
+		if key == "" {
+			return handler(ctx, req)
+		}
+		if overrides.FromContext(ctx).RateLimit != nil {
+			key += "|" + info.FullMethod
+		}
+
+		if result := l.Allow(ctx, key); !result.Allowed {
+			return nil, errors.ToGRPCStatus(errors.WithCode(errors.NewError("rate limit exceeded", nil, false), errors.ResourceExhausted)).Err()
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/mediocregopher/radix/v3"
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Refills and takes from the bucket(a hash of its tokens and the time it was updated at) atomically, with the time of
+// redis so that the clocks of the replicas do not matter. Buckets expire once they would have been refilled.
+// The numbers are returned as strings, as redis truncates the numbers returned by scripts to integers.
+var takeScript = radix.NewEvalScript(1, `
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local requested = tonumber(ARGV[3])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1])
+local updated = tonumber(bucket[2])
+if tokens == nil or updated == nil then
+	tokens = burst
+	updated = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= requested then
+	tokens = tokens - requested
+	allowed = 1
+else
+	retry = (requested - tokens) / rate
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {tostring(allowed), tostring(tokens), tostring(retry)}
+`)
+
+// RedisStore holds the buckets in redis, so that they are shared by the replicas of a service and survive restarts
+type RedisStore struct {
+	client radix.Client
+	prefix string
+}
+
+// NewRedisStore returns a store holding the buckets in redis(eg. the pool of transport/redisv3), under keys prefixed
+// with "ratelimit:"
+func NewRedisStore(client radix.Client) *RedisStore {
+	return &RedisStore{client: client, prefix: "ratelimit:"}
+}
+
+// Take takes the tokens from the bucket of the key if it has as many
+func (s *RedisStore) Take(_ context.Context, key string, rate float64, burst int, tokens int) (Result, error) {
+	var reply []string
+	err := s.client.Do(takeScript.Cmd(&reply, s.prefix+key,
+		strconv.FormatFloat(rate, 'f', -1, 64), strconv.Itoa(burst), strconv.Itoa(tokens)))
+	if err != nil {
+		return Result{}, errors.NewError("Could not take tokens from the bucket of "+key, err, false)
+	}
+	if len(reply) != 3 {
+		return Result{}, errors.NewError("Unexpected reply of the token bucket script", nil, false)
+	}
+
+	remaining, err := strconv.ParseFloat(reply[1], 64)
+	if err != nil {
+		return Result{}, errors.NewError("Unexpected tokens in the bucket of "+key, err, false)
+	}
+	retry, err := strconv.ParseFloat(reply[2], 64)
+	if err != nil {
+		return Result{}, errors.NewError("Unexpected retry of the bucket of "+key, err, false)
+	}
+	return Result{
+		Allowed:    reply[0] == "1",
+		Remaining:  int(remaining),
+		RetryAfter: time.Duration(retry * float64(time.Second)),
+	}, nil
+}
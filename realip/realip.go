@@ -0,0 +1,171 @@
+// Package realip resolves the IP of the client of a request behind proxies, for the rate limiters, access logs and
+// audit logs to agree on it. The header set by the proxies(X-Forwarded-For by default, see WithHeader) is followed only
+// through the proxies trusted, as clients can set it to anything. The other headers are ignored, as a proxy which does
+// not set them passes on those set by the clients.
+package realip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/skit-ai/vcore/ctxkeys"
+	"github.com/skit-ai/vcore/env"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+)
+
+// Resolver resolves the IPs of clients through the proxies trusted
+type Resolver struct {
+	trusted []netip.Prefix
+	header  string
+}
+
+// Header set by the proxies trusted, defaults to the header of REALIP_HEADER or else X-Forwarded-For
+var defaultHeader = env.String("REALIP_HEADER", "X-Forwarded-For")
+
+// New returns a resolver trusting the proxies within the CIDRs(or IPs), eg. "10.0.0.0/8". Returns an error if a CIDR
+// cannot be parsed.
+func New(trustedProxies ...string) (*Resolver, error) {
+	trusted, err := ParsePrefixes(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{trusted: trusted, header: http.CanonicalHeaderKey(defaultHeader)}, nil
+}
+
+// WithHeader returns a copy of the resolver following the header set by the proxies trusted, ie. Forwarded(RFC 7239),
+// X-Forwarded-For, or a header of a single IP(eg. X-Real-IP). Only the header the proxies set is to be followed.
+func (r *Resolver) WithHeader(header string) *Resolver {
+	return &Resolver{trusted: r.trusted, header: http.CanonicalHeaderKey(header)}
+}
+
+// ParsePrefixes parses CIDRs and single IPs(as prefixes of their length)
+func ParsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, errors.NewError("Could not parse the IP "+cidr, err, false)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, errors.NewError("Could not parse the CIDR "+cidr, err, false)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Contains is true if the IP is within any of the prefixes
+func Contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP of the client of the request: the IP of the connection, or the IP forwarded by the proxies
+// trusted. Returns false if the IP cannot be resolved(eg. for a unix socket).
+func (r *Resolver) ClientIP(req *http.Request) (netip.Addr, bool) {
+	addr, ok := parseHost(req.RemoteAddr)
+	if !ok || !Contains(r.trusted, addr) {
+		return addr, ok
+	}
+
+	// The hops appended by each proxy, followed from the nearest one until a hop is not trusted
+	var hops []string
+	if r.header == "Forwarded" {
+		hops = forwardedFor(req.Header.Values(r.header))
+	} else {
+		for _, value := range req.Header.Values(r.header) {
+			hops = append(hops, strings.Split(value, ",")...)
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0 && Contains(r.trusted, addr); i-- {
+		hop, ok := parseHost(strings.TrimSpace(hops[i]))
+		if !ok {
+			// Obfuscated(or unknown) hops end the chain, the last proxy trusted being the client as far as it is known
+			break
+		}
+		addr = hop
+	}
+	return addr, true
+}
+
+// Returns the for parameters of the Forwarded headers(RFC 7239), eg. `for=192.0.2.60;proto=http, for="[2001:db8::1]"`
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, strings.Trim(value, `"`))
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// Parses an IP, optionally with a port and in brackets(for IPv6)
+func parseHost(host string) (netip.Addr, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// Middleware sets the IP of the client of the request on its context(see FromContext)
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if addr, ok := r.ClientIP(req); ok {
+			req = req.WithContext(ctxkeys.ClientIP.With(req.Context(), addr))
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// Default resolver, trusting the proxies of REALIP_TRUSTED_PROXIES(comma separated CIDRs) to set the header of
+// REALIP_HEADER(defaults to X-Forwarded-For)
+var Default = defaultResolver()
+
+func defaultResolver() *Resolver {
+	resolver, err := New(strings.Split(env.String("REALIP_TRUSTED_PROXIES", ""), ",")...)
+	if err != nil {
+		log.Warnf("Ignoring REALIP_TRUSTED_PROXIES: %s", err)
+		return &Resolver{header: http.CanonicalHeaderKey(defaultHeader)}
+	}
+	return resolver
+}
+
+// ClientIP returns the IP of the client of the request using the default resolver
+func ClientIP(req *http.Request) (netip.Addr, bool) {
+	return Default.ClientIP(req)
+}
+
+// FromContext returns the IP of the client set on the context by the middleware, and false if there is none
+func FromContext(ctx context.Context) (netip.Addr, bool) {
+	return ctxkeys.ClientIP.Get(ctx)
+}
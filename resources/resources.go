@@ -0,0 +1,173 @@
+// Package resources tracks the resources opened during a request(transactions, temp files, locks, streams) on its
+// context, so that the ones left open(eg. a transaction never rolled back on an early return) are closed and
+// logged with the stack which opened them once the request ends.
+//
+//	tx, _ := db.BeginTx(ctx, nil)
+//	release := resources.Track(ctx, "sql.Tx", "update call", tx.Rollback)
+//	defer release()
+package resources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skit-ai/vcore/ctxkeys"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+	"google.golang.org/grpc"
+)
+
+// Leak of a resource left open when its request ended
+type Leak struct {
+	Kind   string
+	Name   string
+	Opened time.Time
+	// Stack of the goroutine which opened the resource
+	Stack string
+}
+
+func (l Leak) String() string {
+	return fmt.Sprintf("%s %q opened at %s and not released", l.Kind, l.Name, l.Opened.Format(time.RFC3339Nano))
+}
+
+type resource struct {
+	Leak
+	close func() error
+}
+
+// Tracker of the resources of a request
+type Tracker struct {
+	mutex     sync.Mutex
+	resources map[uint64]*resource
+	next      uint64
+	finished  bool
+}
+
+func New() *Tracker {
+	return &Tracker{resources: make(map[uint64]*resource)}
+}
+
+var trackerKey = ctxkeys.NewKey[*Tracker]("resources.tracker")
+
+// WithTracker returns a copy of the context carrying a new tracker
+func WithTracker(ctx context.Context) (context.Context, *Tracker) {
+	t := New()
+	return trackerKey.With(ctx, t), t
+}
+
+// FromContext returns the tracker of the context(nil if there is none)
+func FromContext(ctx context.Context) *Tracker {
+	return trackerKey.Value(ctx)
+}
+
+// Track records a resource opened on the tracker of the context, along with the function closing it if it leaks.
+// Call the returned function once the resource is released. Resources are not tracked if the context carries no
+// tracker.
+func Track(ctx context.Context, kind, name string, close func() error) (release func()) {
+	return FromContext(ctx).Track(kind, name, close)
+}
+
+// Track records a resource opened, along with the function closing it if it leaks. Call the returned function once
+// the resource is released.
+func (t *Tracker) Track(kind, name string, close func() error) (release func()) {
+	if t == nil {
+		return func() {}
+	}
+
+	r := &resource{
+		Leak:  Leak{Kind: kind, Name: name, Opened: time.Now(), Stack: string(debug.Stack())},
+		close: close,
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.finished {
+		log.Warnf("Tracking %s %q after the end of its request", kind, name)
+	}
+	t.next++
+	id := t.next
+	t.resources[id] = r
+
+	return func() {
+		t.mutex.Lock()
+		delete(t.resources, id)
+		t.mutex.Unlock()
+	}
+}
+
+// Open returns the resources not released yet, the oldest first
+func (t *Tracker) Open() []Leak {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.open()
+}
+
+func (t *Tracker) open() []Leak {
+	leaks := make([]Leak, 0, len(t.resources))
+	for _, r := range t.resources {
+		leaks = append(leaks, r.Leak)
+	}
+	sort.Slice(leaks, func(i, j int) bool { return leaks[i].Opened.Before(leaks[j].Opened) })
+	return leaks
+}
+
+// Finish closes the resources not released, logging them with the stacks which opened them, and returns them.
+// Resources are closed in the reverse order they were opened in.
+func (t *Tracker) Finish() []Leak {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	t.finished = true
+	resources := make([]*resource, 0, len(t.resources))
+	for _, r := range t.resources {
+		resources = append(resources, r)
+	}
+	t.resources = make(map[uint64]*resource)
+	t.mutex.Unlock()
+
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Opened.After(resources[j].Opened) })
+
+	leaks := make([]Leak, 0, len(resources))
+	for _, r := range resources {
+		leaks = append(leaks, r.Leak)
+		log.Warnf("Leaked %s\n%s", r.Leak, r.Stack)
+
+		if r.close != nil {
+			if err := r.close(); err != nil {
+				log.Error(errors.NewError(fmt.Sprintf("Could not close the leaked %s %q", r.Kind, r.Name), err, false))
+			}
+		}
+	}
+	return leaks
+}
+
+// Middleware attaches a tracker to the context of every request, finishing it once the request is served
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, t := WithTracker(r.Context())
+		defer t.Finish()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// UnaryServerInterceptor attaches a tracker to the context of every call, finishing it once the call is handled
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, t := WithTracker(ctx)
+		defer t.Finish()
+		return handler(ctx, req)
+	}
+}
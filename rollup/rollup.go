@@ -0,0 +1,255 @@
+// Package rollup aggregates high-frequency events(eg. the metrics of the calls every second) into rollups of time
+// windows within the service, flushed to a broker or a database for near-real-time dashboards. Eg.
+//
+//	latencies := rollup.New("turn_latency", time.Minute, func(ctx context.Context, rollups []rollup.Rollup[string]) error {
+//		return writeRollups(ctx, db, rollups)
+//	}, rollup.WithAllowedLateness[string](10*time.Second))
+//	go latencies.Run(ctx)
+//	defer latencies.Shutdown(ctx)
+//	...
+//	latencies.Observe(tenant, turn.EndedAt, turn.Latency.Seconds())
+//
+// Events are assigned to the windows by their own time, not the time they are observed at. A window is closed(and
+// flushed) once the watermark passes its end, the watermark trailing the latest time of the events(and the clock) by
+// the allowed lateness. Events of the windows closed already are late, and are dropped.
+package rollup
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/skit-ai/vcore/log"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+var (
+	lateEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "rollup",
+		Name:      "late_events_total",
+		Help:      "Events dropped for arriving after their windows were closed, by rollup",
+	}, []string{"rollup"})
+	flushFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "rollup",
+		Name:      "flush_failures_total",
+		Help:      "Flushes of the rollups which failed(and are to be retried), by rollup",
+	}, []string{"rollup"})
+)
+
+// Collectors returns the metrics of the rollups, to be registered by the service
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{lateEvents, flushFailures}
+}
+
+// Rollup of the events of a key within a window
+type Rollup[K comparable] struct {
+	Key K `json:"key"`
+	// Start and End of the window, the end being exclusive
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Count int64     `json:"count"`
+	Sum   float64   `json:"sum"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+}
+
+// Mean of the values of the events
+func (r Rollup[K]) Mean() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+	return r.Sum / float64(r.Count)
+}
+
+func (r *Rollup[K]) add(value float64) {
+	r.Count++
+	r.Sum += value
+	r.Min = math.Min(r.Min, value)
+	r.Max = math.Max(r.Max, value)
+}
+
+// Flusher writes the rollups of the windows closed, eg. to a topic or a table. The rollups are flushed again(along with
+// those closed since) if it fails, so writes are to be idempotent on the key and the start of the window.
+type Flusher[K comparable] func(ctx context.Context, rollups []Rollup[K]) error
+
+type window[K comparable] struct {
+	key   K
+	start int64
+}
+
+// Aggregator of the events into rollups
+type Aggregator[K comparable] struct {
+	name     string
+	window   time.Duration
+	flush    Flusher[K]
+	lateness time.Duration
+	interval time.Duration
+	clock    simulation.Clock
+
+	mutex     sync.Mutex
+	open      map[window[K]]*Rollup[K]
+	watermark time.Time
+	// Rollups closed, not flushed yet
+	closed []Rollup[K]
+	// Serializes the flushes, so that the rollups are flushed in the order of their windows
+	flushing sync.Mutex
+}
+
+// Option configures an Aggregator
+type Option[K comparable] func(*Aggregator[K])
+
+// WithAllowedLateness configures how long after the latest event(or the clock) the events of a window are still
+// aggregated, eg. for the events buffered by the clients. Defaults to no lateness.
+func WithAllowedLateness[K comparable](lateness time.Duration) Option[K] {
+	return func(a *Aggregator[K]) {
+		a.lateness = lateness
+	}
+}
+
+// WithFlushInterval configures how often Run advances the watermark by the clock and flushes the windows closed.
+// Defaults to the window.
+func WithFlushInterval[K comparable](interval time.Duration) Option[K] {
+	return func(a *Aggregator[K]) {
+		a.interval = interval
+	}
+}
+
+// WithClock configures the clock of the watermark and the flushes, eg. a simulation.Simulation in tests
+func WithClock[K comparable](clock simulation.Clock) Option[K] {
+	return func(a *Aggregator[K]) {
+		a.clock = clock
+	}
+}
+
+// New returns an aggregator of the events into rollups of windows of the duration, flushed by the function. The name
+// labels the metrics of the aggregator.
+func New[K comparable](name string, size time.Duration, flush Flusher[K], opts ...Option[K]) *Aggregator[K] {
+	a := &Aggregator[K]{
+		name:   name,
+		window: size,
+		flush:  flush,
+		clock:  simulation.Real,
+		open:   make(map[window[K]]*Rollup[K]),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.interval <= 0 {
+		a.interval = a.window
+	}
+	return a
+}
+
+// Observe aggregates the value of an event of the key at the time. Returns false if the event is late, ie. its window
+// was closed already.
+func (a *Aggregator[K]) Observe(key K, at time.Time, value float64) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	start := at.Truncate(a.window)
+	if !start.Add(a.window).After(a.watermark) {
+		lateEvents.WithLabelValues(a.name).Inc()
+		return false
+	}
+
+	w := window[K]{key: key, start: start.UnixNano()}
+	r, ok := a.open[w]
+	if !ok {
+		r = &Rollup[K]{Key: key, Start: start, End: start.Add(a.window), Min: math.Inf(1), Max: math.Inf(-1)}
+		a.open[w] = r
+	}
+	r.add(value)
+
+	a.advance(at.Add(-a.lateness))
+	return true
+}
+
+// Advances the watermark to the time, closing the windows ending at or before it. Must hold the mutex.
+func (a *Aggregator[K]) advance(watermark time.Time) {
+	if !watermark.After(a.watermark) {
+		return
+	}
+	a.watermark = watermark
+
+	for w, r := range a.open {
+		if !r.End.After(watermark) {
+			a.closed = append(a.closed, *r)
+			delete(a.open, w)
+		}
+	}
+}
+
+// Watermark returns the time up to which the windows are closed
+func (a *Aggregator[K]) Watermark() time.Time {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.watermark
+}
+
+// Flush advances the watermark by the clock, and flushes the rollups of the windows closed. The rollups are kept to be
+// flushed again if the flusher fails.
+func (a *Aggregator[K]) Flush(ctx context.Context) error {
+	a.mutex.Lock()
+	a.advance(a.clock.Now().Add(-a.lateness))
+	a.mutex.Unlock()
+	return a.flushClosed(ctx)
+}
+
+func (a *Aggregator[K]) flushClosed(ctx context.Context) error {
+	a.flushing.Lock()
+	defer a.flushing.Unlock()
+
+	a.mutex.Lock()
+	rollups := a.closed
+	a.closed = nil
+	a.mutex.Unlock()
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(rollups, func(i, j int) bool { return rollups[i].Start.Before(rollups[j].Start) })
+	if err := a.flush(ctx, rollups); err != nil {
+		flushFailures.WithLabelValues(a.name).Inc()
+		a.mutex.Lock()
+		a.closed = append(rollups, a.closed...)
+		a.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run flushes the windows closed every interval(see WithFlushInterval), until the context is done
+func (a *Aggregator[K]) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.clock.After(a.interval):
+			if err := a.Flush(ctx); err != nil {
+				log.Warnf("Could not flush the rollups of %s: %s", a.name, err)
+			}
+		}
+	}
+}
+
+// Shutdown closes every window, including the ones still open, and flushes them. It has the same signature as the
+// shutdown function returned by instruments.InitProvider so that both can be registered with the same shutdown
+// routine.
+func (a *Aggregator[K]) Shutdown(ctx context.Context) error {
+	a.mutex.Lock()
+	for w, r := range a.open {
+		a.closed = append(a.closed, *r)
+		delete(a.open, w)
+		if r.End.After(a.watermark) {
+			a.watermark = r.End
+		}
+	}
+	a.mutex.Unlock()
+	return a.flushClosed(ctx)
+}
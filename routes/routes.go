@@ -0,0 +1,101 @@
+// Package routes normalizes the paths of requests into route patterns(eg. "/calls/{id}" for
+// "/calls/3f2b1c9e-8d4a-4b6e-9f1a-2c3d4e5f6a7b"), so that the Sentry transactions, the span names, the metric labels
+// and the access logs of a route are named the same, instead of once per ID.
+package routes
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// Placeholder replacing the IDs in the paths
+const Placeholder = "{id}"
+
+type rule struct {
+	pattern     *regexp.Regexp
+	placeholder string
+	// Segments shorter than it are kept
+	minLength int
+}
+
+// Normalizer replaces the segments of paths matching its rules with placeholders. Segments matching no rule are kept.
+type Normalizer struct {
+	rules []rule
+}
+
+// Option configures a Normalizer
+type Option func(*Normalizer)
+
+// WithPattern replaces the segments matching the pattern(the whole segment) with the placeholder, before the default
+// rules(eg. `^CA[0-9a-f]{32}$` for the call SIDs of Twilio)
+func WithPattern(pattern, placeholder string) Option {
+	return func(n *Normalizer) {
+		n.rules = append(n.rules, rule{pattern: regexp.MustCompile(pattern), placeholder: placeholder})
+	}
+}
+
+var defaultRules = []rule{
+	// UUIDs
+	{pattern: regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`), placeholder: Placeholder},
+	// Numeric IDs
+	{pattern: regexp.MustCompile(`^[0-9]+$`), placeholder: Placeholder},
+	// Hashes and object IDs(eg. of mongo), with at least a digit so that words are not taken for them
+	{pattern: regexp.MustCompile(`^[0-9a-fA-F]*[0-9][0-9a-fA-F]*$`), placeholder: Placeholder, minLength: 16},
+	// ULIDs
+	{pattern: regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`), placeholder: Placeholder},
+}
+
+// New returns a normalizer with the rules of the options followed by the default rules
+func New(opts ...Option) *Normalizer {
+	n := &Normalizer{}
+	for _, opt := range opts {
+		opt(n)
+	}
+	n.rules = append(n.rules, defaultRules...)
+	return n
+}
+
+// Normalize returns the path with the segments matching the rules replaced by their placeholders
+func (n *Normalizer) Normalize(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		for _, r := range n.rules {
+			if len(segment) >= r.minLength && r.pattern.MatchString(segment) {
+				segments[i] = r.placeholder
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Name returns the method and the normalized path of the request(eg. "GET /calls/{id}")
+func (n *Normalizer) Name(r *http.Request) string {
+	return r.Method + " " + n.Normalize(r.URL.Path)
+}
+
+var normalizer atomic.Pointer[Normalizer]
+
+func init() {
+	normalizer.Store(New())
+}
+
+// SetDefault replaces the normalizer used by Normalize and Name(and so by the middleware of vcore)
+func SetDefault(n *Normalizer) {
+	normalizer.Store(n)
+}
+
+// Normalize normalizes the path with the default normalizer
+func Normalize(path string) string {
+	return normalizer.Load().Normalize(path)
+}
+
+// Name returns the method and the path of the request normalized with the default normalizer
+func Name(r *http.Request) string {
+	return normalizer.Load().Name(r)
+}
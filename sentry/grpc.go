@@ -2,11 +2,12 @@ package sentry
 
 import (
 	"context"
-	"errors"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/skit-ai/vcore/errors"
 )
 
 type options struct {
@@ -47,24 +48,10 @@ func WithReportOn(r ReportOn) Option {
 // ReportOn decides error should be reported to sentry.
 type ReportOn func(error) bool
 
-// isContextCanceledError checks if an error is context.Canceled or derived from it
-func isContextCanceledError(err error) bool {
-	if err == context.Canceled {
-		return true
-	}
-
-	// Check if it's a gRPC Canceled error
-	if st, ok := status.FromError(err); ok && st.Code() == codes.Canceled {
-		return true
-	}
-
-	// Check if it wraps context.Canceled
-	return errors.Is(err, context.Canceled)
-}
-
-// ReportAlways returns true if err is non-nil and not a context canceled error.
+// ReportAlways returns true if err is non-nil and not due to a cancellation(see errors.IsCanceled), eg. of the
+// clients hanging up.
 func ReportAlways(err error) bool {
-	return err != nil && !isContextCanceledError(err)
+	return err != nil && !errors.IsCanceled(err)
 }
 
 // ReportOnCodes returns true if error code matches on of the given codes.
@@ -78,6 +65,25 @@ func ReportOnCodes(cc ...codes.Code) ReportOn {
 	}
 }
 
+// SkipCodes returns true if err is to be reported as per ReportAlways and its code is not one of the given codes
+// (eg. codes.NotFound for lookups expected to miss).
+func SkipCodes(cc ...codes.Code) ReportOn {
+	cm := make(map[codes.Code]bool)
+	for _, c := range cc {
+		cm[c] = true
+	}
+	return func(err error) bool {
+		return ReportAlways(err) && !cm[status.Code(err)]
+	}
+}
+
+// ReportIf returns true if err is to be reported as per ReportAlways and satisfies the predicate.
+func ReportIf(predicate func(error) bool) ReportOn {
+	return func(err error) bool {
+		return ReportAlways(err) && predicate(err)
+	}
+}
+
 // WrappedServerStream is a thin wrapper around grpc.ServerStream that allows modifying context.
 type WrappedServerStream struct {
 	grpc.ServerStream
@@ -1,14 +1,18 @@
 package sentry
 
 import (
+	"bufio"
 	"context"
-	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/getsentry/sentry-go"
 	sentryhttp "github.com/getsentry/sentry-go/http"
 	"github.com/julienschmidt/httprouter"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/routes"
 )
 
 type Handler struct {
@@ -27,21 +31,17 @@ func (h *Handler) HandleFunc(handler http.HandlerFunc) http.HandlerFunc {
 			ctx = sentry.SetHubOnContext(ctx, hub)
 		}
 		span := sentry.StartSpan(ctx, "http.server",
-			sentry.WithTransactionName(fmt.Sprintf("%s %s", r.Method, r.URL.Path)),
+			sentry.WithTransactionName(routes.Name(r)),
+			sentry.WithTransactionSource(sentry.SourceRoute),
 			sentry.ContinueFromRequest(r),
 		)
-		defer span.Finish()
-		// TODO(tracing): if the next handler.ServeHTTP panics, store
-		// information on the transaction accordingly (status, tag,
-		// level?, ...).
+		writer := &statusWriter{ResponseWriter: rw}
+		defer finishTransaction(span, writer)
 		r = r.WithContext(span.Context())
 		hub.Scope().SetRequest(r)
-		defer h.recoverWithSentry(hub, r)
+		defer h.recoverWithSentry(hub, r, writer)
 
-		// TODO(tracing): use custom response writer to intercept
-		// response. Use HTTP status to add tag to transaction; set span
-		// status.
-		handler.ServeHTTP(rw, r)
+		handler.ServeHTTP(writer.withInterfaces(), r)
 	}
 }
 
@@ -79,27 +79,33 @@ func (h *Handler) HandleHttpRouter(handler httprouter.Handle) httprouter.Handle
 			ctx = sentry.SetHubOnContext(ctx, hub)
 		}
 		span := sentry.StartSpan(ctx, "http.server",
-			sentry.WithTransactionName(fmt.Sprintf("%s %s", r.Method, r.URL.Path)),
+			sentry.WithTransactionName(routes.Name(r)),
+			sentry.WithTransactionSource(sentry.SourceRoute),
 			sentry.ContinueFromRequest(r),
 		)
-		defer span.Finish()
-		// TODO(tracing): if the next handler.ServeHTTP panics, store
-		// information on the transaction accordingly (status, tag,
-		// level?, ...).
+		writer := &statusWriter{ResponseWriter: rw}
+		defer finishTransaction(span, writer)
 		r = r.WithContext(span.Context())
 		hub.Scope().SetRequest(r)
-		defer h.recoverWithSentry(hub, r)
+		defer h.recoverWithSentry(hub, r, writer)
 
-		handler(rw, r, params)
+		handler(writer.withInterfaces(), r, params)
 	}
 }
 
-func (h *Handler) recoverWithSentry(hub *sentry.Hub, r *http.Request) {
+func (h *Handler) recoverWithSentry(hub *sentry.Hub, r *http.Request, writer *statusWriter) {
 	if err := recover(); err != nil {
-		eventID := hub.RecoverWithContext(
-			context.WithValue(r.Context(), sentry.RequestContextKey, r),
-			err,
-		)
+		writer.panicked = true
+		// Reporting the panic as an error, with the stack of the panic and the tags of the error
+		panicErr := errors.FromPanic(err)
+		var eventID *sentry.EventID
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetTags(errors.Tags(panicErr))
+			eventID = hub.RecoverWithContext(
+				context.WithValue(r.Context(), sentry.RequestContextKey, r),
+				panicErr,
+			)
+		})
 		if eventID != nil && h.waitForDelivery {
 			hub.Flush(h.timeout)
 		}
@@ -108,3 +114,195 @@ func (h *Handler) recoverWithSentry(hub *sentry.Hub, r *http.Request) {
 		}
 	}
 }
+
+// Finishes the transaction of a request with the status of its response
+func finishTransaction(span *sentry.Span, writer *statusWriter) {
+	status := writer.status
+	if writer.panicked {
+		status = http.StatusInternalServerError
+	} else if status == 0 {
+		status = http.StatusOK
+	}
+	span.Status = sentry.HTTPtoSpanStatus(status)
+	span.SetData("http.response.status_code", status)
+	span.Finish()
+}
+
+// statusWriter records the status code of the response
+type statusWriter struct {
+	http.ResponseWriter
+	status   int
+	panicked bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Returns the writer along with the optional interfaces(http.Flusher, http.Hijacker, io.ReaderFrom and http.Pusher)
+// of the underlying writer, and only those, so that the handlers checking for them(eg. for streaming and WebSockets)
+// behave as they would without the wrapper
+func (w *statusWriter) withInterfaces() http.ResponseWriter {
+	var flags int
+	if _, ok := w.ResponseWriter.(http.Flusher); ok {
+		flags |= 1
+	}
+	if _, ok := w.ResponseWriter.(http.Hijacker); ok {
+		flags |= 2
+	}
+	if _, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		flags |= 4
+	}
+	pusher, ok := w.ResponseWriter.(http.Pusher)
+	if ok {
+		flags |= 8
+	}
+
+	f, h, r := flusher{w}, hijacker{w}, readerFrom{w}
+	switch flags {
+	case 1:
+		return struct {
+			*statusWriter
+			http.Flusher
+		}{w, f}
+	case 2:
+		return struct {
+			*statusWriter
+			http.Hijacker
+		}{w, h}
+	case 3:
+		return struct {
+			*statusWriter
+			http.Flusher
+			http.Hijacker
+		}{w, f, h}
+	case 4:
+		return struct {
+			*statusWriter
+			io.ReaderFrom
+		}{w, r}
+	case 5:
+		return struct {
+			*statusWriter
+			http.Flusher
+			io.ReaderFrom
+		}{w, f, r}
+	case 6:
+		return struct {
+			*statusWriter
+			http.Hijacker
+			io.ReaderFrom
+		}{w, h, r}
+	case 7:
+		return struct {
+			*statusWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{w, f, h, r}
+	case 8:
+		return struct {
+			*statusWriter
+			http.Pusher
+		}{w, pusher}
+	case 9:
+		return struct {
+			*statusWriter
+			http.Flusher
+			http.Pusher
+		}{w, f, pusher}
+	case 10:
+		return struct {
+			*statusWriter
+			http.Hijacker
+			http.Pusher
+		}{w, h, pusher}
+	case 11:
+		return struct {
+			*statusWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, f, h, pusher}
+	case 12:
+		return struct {
+			*statusWriter
+			io.ReaderFrom
+			http.Pusher
+		}{w, r, pusher}
+	case 13:
+		return struct {
+			*statusWriter
+			http.Flusher
+			io.ReaderFrom
+			http.Pusher
+		}{w, f, r, pusher}
+	case 14:
+		return struct {
+			*statusWriter
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{w, h, r, pusher}
+	case 15:
+		return struct {
+			*statusWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{w, f, h, r, pusher}
+	}
+	return w
+}
+
+// flusher flushes the underlying writer, which writes the status of the response if it is not written yet
+type flusher struct {
+	writer *statusWriter
+}
+
+func (f flusher) Flush() {
+	if f.writer.status == 0 {
+		f.writer.status = http.StatusOK
+	}
+	f.writer.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijacker hijacks the connection of the underlying writer, eg. to switch to WebSockets
+type hijacker struct {
+	writer *statusWriter
+}
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h.writer.status == 0 {
+		h.writer.status = http.StatusSwitchingProtocols
+	}
+	return h.writer.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// readerFrom copies to the underlying writer(eg. with sendfile), as io.Copy does
+type readerFrom struct {
+	writer *statusWriter
+}
+
+func (r readerFrom) ReadFrom(src io.Reader) (int64, error) {
+	if r.writer.status == 0 {
+		r.writer.status = http.StatusOK
+	}
+	return r.writer.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
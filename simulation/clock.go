@@ -0,0 +1,46 @@
+// Package simulation lets tests drive time and randomness deterministically across vcore components, eg. to
+// reproduce a storm of retries backing off in lockstep without waiting for the backoff.
+//
+// Components take a Clock(and a random source where they sample) through their options, defaulting to Real.
+// In tests, a Simulation stands in for both and is advanced explicitly:
+//
+//	sim := simulation.New(42, time.Now())
+//	tracker := latency.NewTracker(budget, latency.WithClock(sim))
+//	turn := tracker.StartTurn("1")
+//	sim.Advance(3 * time.Second)
+//	turn.End(ctx) // Total is exactly 3s
+package simulation
+
+import "time"
+
+// Clock is the source of time of a component
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	// After returns a channel which receives the time once the duration has elapsed
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc calls f in its own goroutine(synchronously within Advance for a Simulation) once the duration
+	// has elapsed
+	AfterFunc(d time.Duration, f func()) Timer
+	Sleep(d time.Duration)
+}
+
+// Timer scheduled by a Clock
+type Timer interface {
+	// Stop prevents the timer from firing, returning false if it has already fired or been stopped
+	Stop() bool
+}
+
+type realClock struct{}
+
+// Real is the clock of the wall time
+var Real Clock = realClock{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
@@ -0,0 +1,187 @@
+package simulation
+
+import (
+	"container/heap"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Simulation is a Clock which only moves when advanced, and a seeded random source. Timers fire in the order
+// of their deadlines(and of their scheduling for the same deadline), so that runs with the same seed and the same
+// steps are reproducible.
+type Simulation struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers timers
+	seq    uint64
+	random *rand.Rand
+}
+
+// New returns a simulation starting at the time, with a random source seeded with the seed
+func New(seed int64, start time.Time) *Simulation {
+	return &Simulation{now: start, random: rand.New(rand.NewSource(seed))}
+}
+
+func (s *Simulation) Now() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.now
+}
+
+func (s *Simulation) Since(t time.Time) time.Duration {
+	return s.Now().Sub(t)
+}
+
+func (s *Simulation) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	s.schedule(d, nil, ch)
+	return ch
+}
+
+func (s *Simulation) AfterFunc(d time.Duration, f func()) Timer {
+	return s.schedule(d, f, nil)
+}
+
+// Sleep blocks the goroutine until the simulation is advanced past the duration
+func (s *Simulation) Sleep(d time.Duration) {
+	<-s.After(d)
+}
+
+func (s *Simulation) schedule(d time.Duration, f func(), ch chan time.Time) *timer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.seq++
+	t := &timer{sim: s, at: s.now.Add(d), seq: s.seq, f: f, ch: ch}
+	heap.Push(&s.timers, t)
+	return t
+}
+
+// Advance moves the time forward by the duration, firing the timers due on the way in order. Timers scheduled by
+// the timers fired are fired too if they are due within the duration.
+func (s *Simulation) Advance(d time.Duration) {
+	s.mutex.Lock()
+	until := s.now.Add(d)
+	s.mutex.Unlock()
+
+	for s.fireNext(until) {
+	}
+
+	s.mutex.Lock()
+	if s.now.Before(until) {
+		s.now = until
+	}
+	s.mutex.Unlock()
+}
+
+// Next moves the time forward to the next timer and fires it. Returns false if no timer is pending.
+func (s *Simulation) Next() bool {
+	s.mutex.Lock()
+	if len(s.timers) == 0 {
+		s.mutex.Unlock()
+		return false
+	}
+	at := s.timers[0].at
+	s.mutex.Unlock()
+
+	return s.fireNext(at)
+}
+
+// Fires the earliest timer if it is due by the time
+func (s *Simulation) fireNext(until time.Time) bool {
+	s.mutex.Lock()
+	if len(s.timers) == 0 || s.timers[0].at.After(until) {
+		s.mutex.Unlock()
+		return false
+	}
+	t := heap.Pop(&s.timers).(*timer)
+	if t.at.After(s.now) {
+		s.now = t.at
+	}
+	now := s.now
+	s.mutex.Unlock()
+
+	// Fired without holding the lock, as timers usually read the clock or schedule other timers
+	if t.f != nil {
+		t.f()
+	} else {
+		t.ch <- now
+	}
+	return true
+}
+
+// Pending returns the number of timers yet to fire
+func (s *Simulation) Pending() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.timers)
+}
+
+// Float64 returns a random number in [0, 1) from the seeded source. It has the signature of rand.Float64, for the
+// components sampling with it.
+func (s *Simulation) Float64() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.random.Float64()
+}
+
+// Int63n returns a random number in [0, n) from the seeded source, eg. for jitter
+func (s *Simulation) Int63n(n int64) int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.random.Int63n(n)
+}
+
+type timer struct {
+	sim   *Simulation
+	at    time.Time
+	seq   uint64
+	f     func()
+	ch    chan time.Time
+	index int
+}
+
+func (t *timer) Stop() bool {
+	t.sim.mutex.Lock()
+	defer t.sim.mutex.Unlock()
+
+	if t.index < 0 {
+		return false
+	}
+	heap.Remove(&t.sim.timers, t.index)
+	return true
+}
+
+// timers is a heap of the pending timers, the earliest first
+type timers []*timer
+
+func (h timers) Len() int { return len(h) }
+
+func (h timers) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].at.Before(h[j].at)
+}
+
+func (h timers) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timers) Push(x interface{}) {
+	t := x.(*timer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *timers) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	t.index = -1
+	*h = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,121 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Bloom tells whether an item was added before. Items added are always found, while items not added are found with
+// the false positive rate the filter is sized for, once it holds the items it is sized for.
+type Bloom struct {
+	bits   []uint64
+	size   uint64
+	hashes uint64
+}
+
+// NewBloom returns an empty Bloom filter sized for the number of items and the false positive rate(eg. 0.01). Uses
+// about 1.2 bytes per item for a rate of 1%.
+func NewBloom(items uint64, rate float64) *Bloom {
+	items = max(items, 1)
+	rate = min(max(rate, 1e-9), 0.5)
+	size := uint64(math.Ceil(-float64(items) * math.Log(rate) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(1, math.Round(float64(size)/float64(items)*math.Ln2)))
+	return newBloom(size, hashes)
+}
+
+func newBloom(size, hashes uint64) *Bloom {
+	size = max(size, 64)
+	return &Bloom{bits: make([]uint64, (size+63)/64), size: size, hashes: hashes}
+}
+
+// Calls the function with the positions of the bits of the item, by double hashing
+func (b *Bloom) positions(item []byte, f func(position uint64) bool) bool {
+	h1 := hash(item)
+	h2 := mix(h1) | 1
+	for i := uint64(0); i < b.hashes; i++ {
+		if !f((h1 + i*h2) % b.size) {
+			return false
+		}
+	}
+	return true
+}
+
+// Add adds the item
+func (b *Bloom) Add(item []byte) {
+	b.positions(item, func(position uint64) bool {
+		b.bits[position/64] |= 1 << (position % 64)
+		return true
+	})
+}
+
+// AddString adds the item
+func (b *Bloom) AddString(item string) {
+	b.Add([]byte(item))
+}
+
+// Contains is true if the item may have been added, and false if it was not
+func (b *Bloom) Contains(item []byte) bool {
+	return b.positions(item, func(position uint64) bool {
+		return b.bits[position/64]&(1<<(position%64)) != 0
+	})
+}
+
+// ContainsString is true if the item may have been added, and false if it was not
+func (b *Bloom) ContainsString(item string) bool {
+	return b.Contains([]byte(item))
+}
+
+// TestAndAdd adds the item, returning whether it may have been added before, eg. to suppress the duplicates of events:
+//
+//	if seen.TestAndAdd([]byte(event.ID)) {
+//		return nil
+//	}
+func (b *Bloom) TestAndAdd(item []byte) bool {
+	found := b.Contains(item)
+	b.Add(item)
+	return found
+}
+
+// Merge adds the items of the other Bloom filter, of the same size
+func (b *Bloom) Merge(other *Bloom) error {
+	if b.size != other.size || b.hashes != other.hashes {
+		return mismatched("Bloom filter")
+	}
+	for i, word := range other.bits {
+		b.bits[i] |= word
+	}
+	return nil
+}
+
+// MarshalBinary serializes the Bloom filter
+func (b *Bloom) MarshalBinary() ([]byte, error) {
+	data := binary.AppendUvarint(header(kindBloom), b.size)
+	data = binary.AppendUvarint(data, b.hashes)
+	for _, word := range b.bits {
+		data = binary.BigEndian.AppendUint64(data, word)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the Bloom filter with the one serialized
+func (b *Bloom) UnmarshalBinary(data []byte) error {
+	data, err := readHeader(data, kindBloom, "Bloom filter")
+	if err != nil {
+		return err
+	}
+	var size, hashes uint64
+	if data, err = readUints(data, "Bloom filter", &size, &hashes); err != nil {
+		return err
+	}
+	if size < 64 || hashes == 0 || uint64(len(data)) != (size+63)/64*8 {
+		return errors.NewError("The Bloom filter is corrupted", nil, false)
+	}
+
+	*b = *newBloom(size, hashes)
+	for i := range b.bits {
+		b.bits[i] = binary.BigEndian.Uint64(data[i*8:])
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// CountMin estimates the number of times every item was added. The estimates are never below the counts, and are
+// above them by at most the error(a fraction of the total count) with the confidence the sketch is sized for.
+type CountMin struct {
+	width    uint64
+	depth    uint64
+	counters []uint64
+	total    uint64
+}
+
+// NewCountMin returns an empty Count-Min sketch overestimating the counts by at most the fraction of the total
+// count(eg. 0.001) with the probability 1-delta(eg. delta 0.01 for 99%)
+func NewCountMin(epsilon, delta float64) *CountMin {
+	epsilon = min(max(epsilon, 1e-6), 1)
+	delta = min(max(delta, 1e-9), 0.5)
+	width := uint64(math.Ceil(math.E / epsilon))
+	depth := uint64(math.Ceil(math.Log(1 / delta)))
+	return newCountMin(width, depth)
+}
+
+func newCountMin(width, depth uint64) *CountMin {
+	return &CountMin{width: width, depth: depth, counters: make([]uint64, width*depth)}
+}
+
+// Calls the function with the index of the counter of the item in every row, by double hashing
+func (c *CountMin) each(item []byte, f func(index uint64)) {
+	h1 := hash(item)
+	h2 := mix(h1) | 1
+	for row := uint64(0); row < c.depth; row++ {
+		f(row*c.width + (h1+row*h2)%c.width)
+	}
+}
+
+// Add adds the item the number of times
+func (c *CountMin) Add(item []byte, count uint64) {
+	c.each(item, func(index uint64) {
+		c.counters[index] += count
+	})
+	c.total += count
+}
+
+// AddString adds the item the number of times
+func (c *CountMin) AddString(item string, count uint64) {
+	c.Add([]byte(item), count)
+}
+
+// Count returns the estimate of the number of times the item was added
+func (c *CountMin) Count(item []byte) uint64 {
+	count := uint64(math.MaxUint64)
+	c.each(item, func(index uint64) {
+		count = min(count, c.counters[index])
+	})
+	return count
+}
+
+// CountString returns the estimate of the number of times the item was added
+func (c *CountMin) CountString(item string) uint64 {
+	return c.Count([]byte(item))
+}
+
+// Total returns the number of times any item was added
+func (c *CountMin) Total() uint64 {
+	return c.total
+}
+
+// Merge adds the counts of the other Count-Min sketch, of the same width and depth
+func (c *CountMin) Merge(other *CountMin) error {
+	if c.width != other.width || c.depth != other.depth {
+		return mismatched("Count-Min sketch")
+	}
+	for i, counter := range other.counters {
+		c.counters[i] += counter
+	}
+	c.total += other.total
+	return nil
+}
+
+// MarshalBinary serializes the Count-Min sketch
+func (c *CountMin) MarshalBinary() ([]byte, error) {
+	data := binary.AppendUvarint(header(kindCountMin), c.width)
+	data = binary.AppendUvarint(data, c.depth)
+	data = binary.AppendUvarint(data, c.total)
+	for _, counter := range c.counters {
+		data = binary.AppendUvarint(data, counter)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the Count-Min sketch with the one serialized
+func (c *CountMin) UnmarshalBinary(data []byte) error {
+	data, err := readHeader(data, kindCountMin, "Count-Min sketch")
+	if err != nil {
+		return err
+	}
+	var width, depth, total uint64
+	if data, err = readUints(data, "Count-Min sketch", &width, &depth, &total); err != nil {
+		return err
+	}
+	if width == 0 || depth == 0 || width > uint64(len(data)) || width*depth > uint64(len(data)) {
+		return errors.NewError("The Count-Min sketch is corrupted", nil, false)
+	}
+
+	sketch := newCountMin(width, depth)
+	sketch.total = total
+	for i := range sketch.counters {
+		if data, err = readUints(data, "Count-Min sketch", &sketch.counters[i]); err != nil {
+			return err
+		}
+	}
+	if len(data) != 0 {
+		return errors.NewError("The Count-Min sketch is corrupted", nil, false)
+	}
+	*c = *sketch
+	return nil
+}
@@ -0,0 +1,82 @@
+// Package sketch has approximate data structures, counting and deduplicating the items of large streams(eg. the
+// callers of a campaign) in a fixed amount of memory:
+//
+//   - HyperLogLog estimates the number of distinct items, eg. the unique callers
+//   - Bloom tells whether an item was added before, eg. to suppress duplicate events
+//   - CountMin estimates the number of times every item was added, eg. the calls of every caller
+//   - TopK tracks the items added the most, eg. the noisiest tenants
+//   - TDigest estimates the quantiles of the values added, eg. the p95 of the latencies of every tenant
+//
+// The sketches of different replicas(or partitions) are merged into the sketch of the whole stream, and are
+// serialized(see encoding.BinaryMarshaler) to be stored, eg. in Redis or a storage bucket. Sketches can only be merged
+// with sketches of the same parameters. The sketches are not safe for concurrent use.
+package sketch
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Version of the serialization of the sketches
+const version = 1
+
+// Kinds of the sketches, the first byte of their serialization
+const (
+	kindHyperLogLog byte = iota + 1
+	kindBloom
+	kindCountMin
+	kindTopK
+	kindTDigest
+)
+
+// Returns the hash of the item, FNV-1a finalized with the mixer of SplitMix64, as in hashring
+func hash(item []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(item)
+	return mix(h.Sum64())
+}
+
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Returns the header of the serialization of a sketch of the kind
+func header(kind byte) []byte {
+	return []byte{kind, version}
+}
+
+// Checks the header of the serialization of a sketch of the kind, returning the rest of the data
+func readHeader(data []byte, kind byte, name string) ([]byte, error) {
+	if len(data) < 2 || data[0] != kind {
+		return nil, errors.NewError("The data is not of a "+name, nil, false)
+	}
+	if data[1] != version {
+		return nil, errors.NewError("The version of the "+name+" is not supported", nil, false)
+	}
+	return data[2:], nil
+}
+
+// Reads the unsigned integers at the start of the data, returning the rest of the data
+func readUints(data []byte, name string, values ...*uint64) ([]byte, error) {
+	for _, value := range values {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.NewError("The "+name+" is truncated", nil, false)
+		}
+		*value = v
+		data = data[n:]
+	}
+	return data, nil
+}
+
+// Errors of the sketches merged with sketches of other parameters
+func mismatched(name string) error {
+	return errors.NewError("Cannot merge a "+name+" with one of other parameters", nil, false)
+}
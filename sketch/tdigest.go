@@ -0,0 +1,221 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// TDigest estimates the quantiles of the values added(eg. the p95 of the latencies of a tenant), accurately at the
+// extreme quantiles, in the memory of its compression. Unlike the buckets of histograms, the quantiles need no bounds
+// known beforehand.
+type TDigest struct {
+	compression float64
+	// Centroids merged, by their means
+	centroids []centroid
+	// Values added since the last merge
+	buffer []centroid
+	count  float64
+	min    float64
+	max    float64
+}
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// NewTDigest returns an empty t-digest of the compression(eg. 100), bounding the number of centroids(about 2 times the
+// compression). More compression estimates the quantiles more accurately at the cost of memory.
+func NewTDigest(compression float64) *TDigest {
+	compression = math.Max(compression, 10)
+	return &TDigest{compression: compression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+// Add adds the value
+func (t *TDigest) Add(value float64) {
+	t.AddWeighted(value, 1)
+}
+
+// AddWeighted adds the value the number of times. NaNs and non-positive weights are ignored.
+func (t *TDigest) AddWeighted(value, weight float64) {
+	if math.IsNaN(value) || weight <= 0 {
+		return
+	}
+	t.buffer = append(t.buffer, centroid{mean: value, weight: weight})
+	t.count += weight
+	t.min = math.Min(t.min, value)
+	t.max = math.Max(t.max, value)
+	if len(t.buffer) >= int(5*t.compression) {
+		t.compress()
+	}
+}
+
+// Scale function k1 of the t-digest, mapping the quantile q to the index of its centroid
+func (t *TDigest) scale(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// Merges the buffer into the centroids, merging adjacent centroids while they stay within one index of the scale
+func (t *TDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.centroids, t.buffer...)
+	t.buffer = t.buffer[:0]
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	current := all[0]
+	before := 0.0
+	for _, next := range all[1:] {
+		if t.scale((before+current.weight+next.weight)/t.count)-t.scale(before/t.count) <= 1 {
+			current.weight += next.weight
+			current.mean += (next.mean - current.mean) * next.weight / current.weight
+			continue
+		}
+		merged = append(merged, current)
+		before += current.weight
+		current = next
+	}
+	t.centroids = append(merged, current)
+}
+
+// Count returns the number of values added
+func (t *TDigest) Count() float64 {
+	return t.count
+}
+
+// Quantile returns the estimate of the quantile q(eg. 0.95) of the values, or NaN without values
+func (t *TDigest) Quantile(q float64) float64 {
+	t.compress()
+	if len(t.centroids) == 0 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+
+	// Interpolating between the centers of the centroids, and the extremes at either end
+	index := q * t.count
+	first, last := t.centroids[0], t.centroids[len(t.centroids)-1]
+	if index < first.weight/2 {
+		return t.min + (first.mean-t.min)*index/(first.weight/2)
+	}
+	cumulative := 0.0
+	for i := 0; i < len(t.centroids)-1; i++ {
+		c, next := t.centroids[i], t.centroids[i+1]
+		center, nextCenter := cumulative+c.weight/2, cumulative+c.weight+next.weight/2
+		if index < nextCenter {
+			return c.mean + (next.mean-c.mean)*(index-center)/(nextCenter-center)
+		}
+		cumulative += c.weight
+	}
+	if remaining := t.count - index; remaining < last.weight/2 {
+		return t.max - (t.max-last.mean)*remaining/(last.weight/2)
+	}
+	return last.mean
+}
+
+// CDF returns the estimate of the fraction of the values at or below the value, eg. to tell the share of the turns
+// within an SLO. Returns NaN without values.
+func (t *TDigest) CDF(value float64) float64 {
+	t.compress()
+	if len(t.centroids) == 0 {
+		return math.NaN()
+	}
+	if value < t.min {
+		return 0
+	}
+	if value >= t.max {
+		return 1
+	}
+
+	first, last := t.centroids[0], t.centroids[len(t.centroids)-1]
+	if value < first.mean {
+		if first.mean == t.min {
+			return 0
+		}
+		return first.weight / 2 * (value - t.min) / (first.mean - t.min) / t.count
+	}
+	cumulative := 0.0
+	for i := 0; i < len(t.centroids)-1; i++ {
+		c, next := t.centroids[i], t.centroids[i+1]
+		if value < next.mean {
+			center := cumulative + c.weight/2
+			span := c.weight/2 + next.weight/2
+			return (center + span*(value-c.mean)/(next.mean-c.mean)) / t.count
+		}
+		cumulative += c.weight
+	}
+	return (t.count - last.weight/2*(t.max-value)/(t.max-last.mean)) / t.count
+}
+
+// Merge adds the values of the other t-digest, eg. of another replica. The t-digests can be of other compressions.
+func (t *TDigest) Merge(other *TDigest) error {
+	other.compress()
+	if len(other.centroids) == 0 {
+		return nil
+	}
+	t.buffer = append(t.buffer, other.centroids...)
+	t.count += other.count
+	t.min = math.Min(t.min, other.min)
+	t.max = math.Max(t.max, other.max)
+	t.compress()
+	return nil
+}
+
+// MarshalBinary serializes the t-digest
+func (t *TDigest) MarshalBinary() ([]byte, error) {
+	t.compress()
+	data := binary.BigEndian.AppendUint64(header(kindTDigest), math.Float64bits(t.compression))
+	data = binary.BigEndian.AppendUint64(data, math.Float64bits(t.min))
+	data = binary.BigEndian.AppendUint64(data, math.Float64bits(t.max))
+	data = binary.AppendUvarint(data, uint64(len(t.centroids)))
+	for _, c := range t.centroids {
+		data = binary.BigEndian.AppendUint64(data, math.Float64bits(c.mean))
+		data = binary.BigEndian.AppendUint64(data, math.Float64bits(c.weight))
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the t-digest with the one serialized
+func (t *TDigest) UnmarshalBinary(data []byte) error {
+	data, err := readHeader(data, kindTDigest, "t-digest")
+	if err != nil {
+		return err
+	}
+	if len(data) < 24 {
+		return errors.NewError("The t-digest is truncated", nil, false)
+	}
+	digest := NewTDigest(readFloat(data, 0))
+	digest.min, digest.max = readFloat(data, 1), readFloat(data, 2)
+
+	var size uint64
+	if data, err = readUints(data[24:], "t-digest", &size); err != nil {
+		return err
+	}
+	if uint64(len(data)) != size*16 {
+		return errors.NewError("The t-digest is corrupted", nil, false)
+	}
+	for i := 0; i < int(size); i++ {
+		c := centroid{mean: readFloat(data, 2*i), weight: readFloat(data, 2*i+1)}
+		if c.weight <= 0 || math.IsNaN(c.mean) {
+			return errors.NewError("The t-digest is corrupted", nil, false)
+		}
+		digest.centroids = append(digest.centroids, c)
+		digest.count += c.weight
+	}
+	*t = *digest
+	return nil
+}
+
+// Reads the i-th float of the data
+func readFloat(data []byte, i int) float64 {
+	return math.Float64frombits(binary.BigEndian.Uint64(data[i*8:]))
+}
@@ -0,0 +1,224 @@
+package sketch
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"sort"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Item of a TopK, counted Count times of which at most Error are of other items
+type Item struct {
+	Key   string `json:"key"`
+	Count uint64 `json:"count"`
+	Error uint64 `json:"error,omitempty"`
+}
+
+// TopK tracks the items added the most(eg. the noisiest tenants) with the Space-Saving algorithm, in the memory of its
+// capacity. Every item added more than 1/capacity of the times is tracked, and the count of an item tracked is above
+// its true count by at most its error.
+type TopK struct {
+	capacity int
+	items    map[string]*counter
+	// Min-heap of the counters, by their counts
+	heap counters
+}
+
+type counter struct {
+	Item
+	index int
+}
+
+type counters []*counter
+
+func (c counters) Len() int           { return len(c) }
+func (c counters) Less(i, j int) bool { return c[i].Count < c[j].Count }
+func (c counters) Swap(i, j int) {
+	c[i], c[j] = c[j], c[i]
+	c[i].index, c[j].index = i, j
+}
+func (c *counters) Push(x any) {
+	item := x.(*counter)
+	item.index = len(*c)
+	*c = append(*c, item)
+}
+func (c *counters) Pop() any {
+	old := *c
+	item := old[len(old)-1]
+	*c = old[:len(old)-1]
+	return item
+}
+
+// NewTopK returns an empty TopK tracking up to capacity items, eg. a few times the number of items to be reported for
+// accurate counts
+func NewTopK(capacity int) *TopK {
+	capacity = max(capacity, 1)
+	return &TopK{capacity: capacity, items: make(map[string]*counter, capacity)}
+}
+
+// Add adds the item the number of times
+func (t *TopK) Add(key string, count uint64) {
+	if c, ok := t.items[key]; ok {
+		c.Count += count
+		heap.Fix(&t.heap, c.index)
+		return
+	}
+	if len(t.heap) < t.capacity {
+		c := &counter{Item: Item{Key: key, Count: count}}
+		t.items[key] = c
+		heap.Push(&t.heap, c)
+		return
+	}
+
+	// Replacing the item counted the least, whose count is the most the new item could have been counted
+	c := t.heap[0]
+	delete(t.items, c.Key)
+	c.Key, c.Error, c.Count = key, c.Count, c.Count+count
+	t.items[key] = c
+	heap.Fix(&t.heap, 0)
+}
+
+// Top returns the n items counted the most, by their counts
+func (t *TopK) Top(n int) []Item {
+	items := make([]Item, 0, len(t.heap))
+	for _, c := range t.heap {
+		items = append(items, c.Item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Key < items[j].Key
+	})
+	if n >= 0 && n < len(items) {
+		items = items[:n]
+	}
+	return items
+}
+
+// Count returns the count of the item, and whether it is tracked
+func (t *TopK) Count(key string) (Item, bool) {
+	if c, ok := t.items[key]; ok {
+		return c.Item, true
+	}
+	return Item{}, false
+}
+
+// Merge adds the items of the other TopK, of the same capacity. The items not tracked by one of the TopKs may have been
+// counted up to its least count, which is added to their errors.
+func (t *TopK) Merge(other *TopK) error {
+	if t.capacity != other.capacity {
+		return mismatched("TopK")
+	}
+
+	floor := func(s *TopK) uint64 {
+		if len(s.heap) < s.capacity {
+			return 0
+		}
+		return s.heap[0].Count
+	}
+	ours, theirs := floor(t), floor(other)
+
+	merged := make(map[string]Item, len(t.items)+len(other.items))
+	for key, c := range t.items {
+		item := c.Item
+		if o, ok := other.items[key]; ok {
+			item.Count += o.Count
+			item.Error += o.Error
+		} else {
+			item.Count += theirs
+			item.Error += theirs
+		}
+		merged[key] = item
+	}
+	for key, o := range other.items {
+		if _, ok := t.items[key]; !ok {
+			merged[key] = Item{Key: key, Count: o.Count + ours, Error: o.Error + ours}
+		}
+	}
+
+	items := make([]Item, 0, len(merged))
+	for _, item := range merged {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Key < items[j].Key
+	})
+	if len(items) > t.capacity {
+		items = items[:t.capacity]
+	}
+
+	t.items = make(map[string]*counter, t.capacity)
+	t.heap = t.heap[:0]
+	for _, item := range items {
+		c := &counter{Item: item}
+		t.items[item.Key] = c
+		heap.Push(&t.heap, c)
+	}
+	return nil
+}
+
+// Clone returns a copy of the TopK
+func (t *TopK) Clone() *TopK {
+	clone := NewTopK(t.capacity)
+	for _, c := range t.heap {
+		copied := &counter{Item: c.Item}
+		clone.items[c.Key] = copied
+		heap.Push(&clone.heap, copied)
+	}
+	return clone
+}
+
+// MarshalBinary serializes the TopK
+func (t *TopK) MarshalBinary() ([]byte, error) {
+	data := binary.AppendUvarint(header(kindTopK), uint64(t.capacity))
+	data = binary.AppendUvarint(data, uint64(len(t.heap)))
+	for _, c := range t.heap {
+		data = binary.AppendUvarint(data, uint64(len(c.Key)))
+		data = append(data, c.Key...)
+		data = binary.AppendUvarint(data, c.Count)
+		data = binary.AppendUvarint(data, c.Error)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the TopK with the one serialized
+func (t *TopK) UnmarshalBinary(data []byte) error {
+	data, err := readHeader(data, kindTopK, "TopK")
+	if err != nil {
+		return err
+	}
+	var capacity, size uint64
+	if data, err = readUints(data, "TopK", &capacity, &size); err != nil {
+		return err
+	}
+	if capacity == 0 || size > capacity || size > uint64(len(data)) {
+		return errors.NewError("The TopK is corrupted", nil, false)
+	}
+
+	topK := NewTopK(int(capacity))
+	for i := uint64(0); i < size; i++ {
+		var length uint64
+		if data, err = readUints(data, "TopK", &length); err != nil {
+			return err
+		}
+		if length > uint64(len(data)) {
+			return errors.NewError("The TopK is truncated", nil, false)
+		}
+		c := &counter{Item: Item{Key: string(data[:length])}}
+		if data, err = readUints(data[length:], "TopK", &c.Count, &c.Error); err != nil {
+			return err
+		}
+		topK.items[c.Key] = c
+		heap.Push(&topK.heap, c)
+	}
+	if len(data) != 0 {
+		return errors.NewError("The TopK is corrupted", nil, false)
+	}
+	*t = *topK
+	return nil
+}
@@ -0,0 +1,251 @@
+// Package snapshot carries the in-memory state of a service(eg. caches, aggregators) across deploys, by snapshotting
+// it to a storage bucket on shutdown and restoring it on startup, so that the new replicas do not start cold. Eg.
+//
+//	snapshots := snapshot.New(bucket, "transcripts/"+replica, snapshot.WithMaxAge(time.Hour))
+//	snapshots.Register("cache", 2, cache)
+//	if err := snapshots.Restore(ctx); err != nil {
+//		log.Warn("Starting cold", err)
+//	}
+//	...
+//	defer snapshots.Shutdown(ctx)
+//
+// Every snapshot is stamped with the version of the state of its component, and is not restored by a component of
+// another version, so that the format of the state can change between deploys.
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/httpserver"
+	"github.com/skit-ai/vcore/log"
+	"github.com/skit-ai/vcore/simulation"
+	"github.com/skit-ai/vcore/transport/compression"
+)
+
+// Stateful is a component holding in-memory state
+type Stateful interface {
+	// Snapshot writes the state of the component
+	Snapshot(w io.Writer) error
+	// Restore reads the state written by Snapshot, replacing the state of the component
+	Restore(r io.Reader) error
+}
+
+// Bucket reads and writes the snapshots, eg. aws.S3Objects
+type Bucket interface {
+	httpserver.ObjectStore
+	Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+}
+
+// Results of the snapshots and the restores, as labels of the metrics
+const (
+	resultOK = "ok"
+	// The snapshot did not exist, eg. on the first deploy
+	resultMissing = "missing"
+	// The snapshot was of another version, or older than the maximum age
+	resultStale  = "stale"
+	resultFailed = "failed"
+)
+
+var (
+	snapshots = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "snapshot",
+		Name:      "snapshots_total",
+		Help:      "Snapshots of the state of the components, by component and result",
+	}, []string{"component", "result"})
+	restores = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "snapshot",
+		Name:      "restores_total",
+		Help:      "Restores of the state of the components, by component and result",
+	}, []string{"component", "result"})
+)
+
+// Collectors returns the metrics of the snapshots, to be registered by the service
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{snapshots, restores}
+}
+
+// Header of a snapshot, the first line of its object
+type header struct {
+	Component string    `json:"component"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type component struct {
+	name    string
+	version int
+	state   Stateful
+}
+
+// Snapshots of the components of a service
+type Snapshots struct {
+	bucket Bucket
+	prefix string
+	maxAge time.Duration
+	clock  simulation.Clock
+
+	mutex      sync.Mutex
+	components []component
+}
+
+// Option configures Snapshots
+type Option func(*Snapshots)
+
+// WithMaxAge configures the age past which the snapshots are not restored, eg. as the state would be too outdated to
+// be of use. Defaults to restoring snapshots of any age.
+func WithMaxAge(age time.Duration) Option {
+	return func(s *Snapshots) {
+		s.maxAge = age
+	}
+}
+
+// WithClock configures the clock the snapshots are stamped(and aged) with, eg. a simulation.Simulation in tests
+func WithClock(clock simulation.Clock) Option {
+	return func(s *Snapshots) {
+		s.clock = clock
+	}
+}
+
+// New returns the snapshots of the components to the bucket, under the prefix. Replicas holding state of their own(eg.
+// partitioned by a hashring.Ring) are to use prefixes of their own.
+func New(bucket Bucket, prefix string, opts ...Option) *Snapshots {
+	s := &Snapshots{bucket: bucket, prefix: prefix, clock: simulation.Real}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register registers the component with the name and the version of its state. The version is to be bumped whenever
+// the format of the state changes.
+func (s *Snapshots) Register(name string, version int, state Stateful) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.components = append(s.components, component{name: name, version: version, state: state})
+}
+
+// Key of the object of the snapshot of the component
+func (s *Snapshots) key(name string) string {
+	return path.Join(s.prefix, name+".snapshot")
+}
+
+func (s *Snapshots) registered() []component {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]component(nil), s.components...)
+}
+
+// Snapshot writes the snapshots of the components to the bucket. The components failing to snapshot do not stop the
+// others, their errors being combined.
+func (s *Snapshots) Snapshot(ctx context.Context) error {
+	var errs []error
+	for _, c := range s.registered() {
+		if err := s.snapshot(ctx, c); err != nil {
+			snapshots.WithLabelValues(c.name, resultFailed).Inc()
+			errs = append(errs, err)
+			continue
+		}
+		snapshots.WithLabelValues(c.name, resultOK).Inc()
+	}
+	return errors.Combine(errs...)
+}
+
+func (s *Snapshots) snapshot(ctx context.Context, c component) error {
+	var state bytes.Buffer
+	if err := c.state.Snapshot(&state); err != nil {
+		return errors.NewError("Could not snapshot "+c.name, err, false)
+	}
+	compressed, err := compression.Compress(compression.Zstd, state.Bytes())
+	if err != nil {
+		return errors.NewError("Could not compress the snapshot of "+c.name, err, false)
+	}
+
+	var object bytes.Buffer
+	if err := json.NewEncoder(&object).Encode(header{Component: c.name, Version: c.version, CreatedAt: s.clock.Now()}); err != nil {
+		return errors.NewError("Could not encode the header of the snapshot of "+c.name, err, false)
+	}
+	object.Write(compressed)
+
+	if err := s.bucket.Put(ctx, s.key(c.name), bytes.NewReader(object.Bytes()), "application/octet-stream"); err != nil {
+		return errors.NewError("Could not write the snapshot of "+c.name, err, false)
+	}
+	return nil
+}
+
+// Shutdown snapshots the components(see Snapshot). It has the same signature as the shutdown function returned by
+// instruments.InitProvider so that both can be registered with the same shutdown routine.
+func (s *Snapshots) Shutdown(ctx context.Context) error {
+	return s.Snapshot(ctx)
+}
+
+// Restore restores the components from their snapshots. The components whose snapshots are missing, of another version
+// or too old(see WithMaxAge) are left as they are, ie. start cold. The components failing to restore do not stop the
+// others, their errors being combined.
+func (s *Snapshots) Restore(ctx context.Context) error {
+	var errs []error
+	for _, c := range s.registered() {
+		result, err := s.restore(ctx, c)
+		restores.WithLabelValues(c.name, result).Inc()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Combine(errs...)
+}
+
+func (s *Snapshots) restore(ctx context.Context, c component) (string, error) {
+	content, err := s.bucket.Open(ctx, s.key(c.name), 0)
+	if errors.CodeOf(err) == errors.NotFound {
+		return resultMissing, nil
+	}
+	if err != nil {
+		return resultFailed, errors.NewError("Could not read the snapshot of "+c.name, err, false)
+	}
+	defer content.Close()
+
+	reader := bufio.NewReader(content)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return resultFailed, errors.NewError("Could not read the header of the snapshot of "+c.name, err, false)
+	}
+	var h header
+	if err := json.Unmarshal(line, &h); err != nil {
+		return resultFailed, errors.NewError("Could not decode the header of the snapshot of "+c.name, err, false)
+	}
+
+	if h.Version != c.version {
+		log.Infof("Not restoring %s from a snapshot of version %d, as it is of version %d", c.name, h.Version, c.version)
+		return resultStale, nil
+	}
+	if age := s.clock.Now().Sub(h.CreatedAt); s.maxAge > 0 && age > s.maxAge {
+		log.Infof("Not restoring %s from a snapshot taken %s ago", c.name, age.Round(time.Second))
+		return resultStale, nil
+	}
+
+	compressed, err := io.ReadAll(reader)
+	if err != nil {
+		return resultFailed, errors.NewError("Could not read the snapshot of "+c.name, err, false)
+	}
+	state, err := compression.Decompress(compressed)
+	if err != nil {
+		return resultFailed, errors.NewError("Could not decompress the snapshot of "+c.name, err, false)
+	}
+	if err := c.state.Restore(bytes.NewReader(state)); err != nil {
+		return resultFailed, errors.NewErrorWithExtras("Could not restore "+c.name, err, false, map[string]interface{}{
+			"version": h.Version,
+		})
+	}
+	return resultOK, nil
+}
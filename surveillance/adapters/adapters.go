@@ -0,0 +1,87 @@
+// Package adapters installs the Sentry wrapper's HTTP middleware on Gin and Echo routers. Chi(and the other net/http
+// routers) can use Sentry.SentryMiddleware as it is.
+// The adapters run the framework's handlers within the wrapper's HandleFunc, so they set the hub(and user) on the
+// request's context, start the request's transaction and recover panics the same way, respecting the Repanic and
+// WaitForDelivery options of the wrapper's handler.
+package adapters
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+// GinMiddleware returns a Gin middleware for the wrapper(the default client if nil)
+//
+//	router.Use(adapters.GinMiddleware(nil))
+func GinMiddleware(wrapper *surveillance.Sentry) gin.HandlerFunc {
+	wrapper = orDefault(wrapper)
+
+	return func(c *gin.Context) {
+		writer := c.Writer
+		defer func() {
+			c.Writer = writer
+		}()
+
+		wrapper.HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The request now carries the hub on its context, and the writer records the status of the response
+			c.Request = r
+			c.Writer = &ginWriter{ResponseWriter: writer, writer: w}
+			c.Next()
+		})(writer, c.Request)
+	}
+}
+
+// Writer of a Gin context writing through the writer of the wrapper, for the transaction to be finished with the
+// status of the response
+type ginWriter struct {
+	gin.ResponseWriter
+	writer http.ResponseWriter
+}
+
+func (w *ginWriter) WriteHeader(status int) {
+	w.writer.WriteHeader(status)
+}
+
+func (w *ginWriter) Write(b []byte) (int, error) {
+	return w.writer.Write(b)
+}
+
+func (w *ginWriter) WriteString(s string) (int, error) {
+	return w.writer.Write([]byte(s))
+}
+
+// EchoMiddleware returns an Echo middleware for the wrapper(the default client if nil)
+//
+//	e.Use(adapters.EchoMiddleware(nil))
+func EchoMiddleware(wrapper *surveillance.Sentry) echo.MiddlewareFunc {
+	wrapper = orDefault(wrapper)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			writer := c.Response().Writer
+			defer func() {
+				c.Response().Writer = writer
+			}()
+
+			wrapper.HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				c.Response().Writer = w
+				// Responding the errors of the handlers within the transaction, rather than once Echo handles them
+				if err := next(c); err != nil {
+					c.Error(err)
+				}
+			})(writer, c.Request())
+			return nil
+		}
+	}
+}
+
+func orDefault(wrapper *surveillance.Sentry) *surveillance.Sentry {
+	if wrapper == nil {
+		return surveillance.SentryClient
+	}
+	return wrapper
+}
@@ -0,0 +1,100 @@
+package surveillance
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/skit-ai/vcore/simulation"
+)
+
+// adaptiveSampler reduces the rate at which errors are sent once their volume spikes past a threshold per minute,
+// and ramps the rate back up(doubling it every minute) once the volume subsides. This keeps the quota under control
+// during incidents while a sample of the errors still gets through.
+type adaptiveSampler struct {
+	// Errors per minute past which the rate is reduced
+	threshold int
+	// Lowest rate the errors are sampled at
+	floor  float64
+	clock  simulation.Clock
+	random func() float64
+
+	mutex sync.Mutex
+	// Start of the current minute and the number of errors seen(sent or not) within it
+	minute time.Time
+	count  int
+	rate   float64
+}
+
+func newAdaptiveSampler(threshold int, floor float64) *adaptiveSampler {
+	if threshold <= 0 {
+		return nil
+	}
+	return &adaptiveSampler{threshold: threshold, floor: floor, clock: simulation.Real, random: rand.Float64, rate: 1}
+}
+
+// sample returns true if the error is to be sent
+func (s *adaptiveSampler) sample() bool {
+	if s == nil {
+		return true
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.roll(s.clock.Now())
+	s.count++
+	// Reacting to a spike within the minute it starts
+	if s.count > s.threshold {
+		s.rate = max(min(s.rate, float64(s.threshold)/float64(s.count)), s.floor)
+	}
+	return s.rate >= 1 || s.random() < s.rate
+}
+
+// Starts a new minute once the current one has elapsed, setting the rate for it from the volume of the last one
+func (s *adaptiveSampler) roll(now time.Time) {
+	if s.minute.IsZero() {
+		s.minute = now
+		return
+	}
+	elapsed := now.Sub(s.minute)
+	if elapsed < time.Minute {
+		return
+	}
+
+	target := 1.0
+	// Minutes without any error in between count as quiet ones
+	if elapsed < 2*time.Minute && s.count > s.threshold {
+		target = float64(s.threshold) / float64(s.count)
+	}
+	if target < s.rate {
+		s.rate = target
+	} else {
+		for i := time.Duration(0); i < elapsed/time.Minute && s.rate < target; i++ {
+			s.rate = min(2*s.rate, target)
+		}
+	}
+	s.rate = max(s.rate, s.floor)
+
+	s.minute = now
+	s.count = 0
+}
+
+// Returns the rate errors are currently sent at
+func (s *adaptiveSampler) effectiveRate() float64 {
+	if s == nil {
+		return 1
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.roll(s.clock.Now())
+	return s.rate
+}
+
+// EffectiveSampleRate returns the rate errors are currently sent at by the adaptive sampling(1 if it is disabled or
+// the volume of errors is below the threshold)
+func (wrapper *Sentry) EffectiveSampleRate() float64 {
+	return wrapper.adaptive.effectiveRate()
+}
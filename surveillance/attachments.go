@@ -0,0 +1,35 @@
+package surveillance
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Attachment is a payload(eg. the offending request body or model output) shipped along with a captured error
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Bytes       []byte
+}
+
+// CaptureWithAttachments captures the error on the hub of the context(or the global hub) along with the attachments.
+// Attachments are not scrubbed, redact any PII in them before capturing.
+func (wrapper *Sentry) CaptureWithAttachments(ctx context.Context, err error, attachments []Attachment) sentry.EventID {
+	// Admitted as the errors captured without attachments
+	eventID, _ := wrapper.handle(ctx, hubFromContext(ctx), err, false, func(scope *sentry.Scope) {
+		for _, attachment := range attachments {
+			scope.AddAttachment(&sentry.Attachment{
+				Filename:    attachment.Filename,
+				ContentType: attachment.ContentType,
+				Payload:     attachment.Bytes,
+			})
+		}
+	})
+	return eventID
+}
+
+// CaptureWithAttachments captures the error along with the attachments using the default sentry client
+func CaptureWithAttachments(ctx context.Context, err error, attachments []Attachment) sentry.EventID {
+	return SentryClient.CaptureWithAttachments(ctx, err, attachments)
+}
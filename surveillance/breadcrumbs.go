@@ -0,0 +1,103 @@
+package surveillance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/surveillance/crash"
+)
+
+// Categories of breadcrumbs commonly recorded by request handlers
+const (
+	BreadcrumbDB         = "db"
+	BreadcrumbHTTP       = "http"
+	BreadcrumbGRPC       = "grpc"
+	BreadcrumbTransition = "state"
+)
+
+// hubFromContext returns the request scoped hub set on the context by the middlewares/interceptors.
+// Falls back to the global hub when the context does not carry one.
+func hubFromContext(ctx context.Context) *sentry.Hub {
+	if ctx != nil {
+		if hub := sentry.GetHubFromContext(ctx); hub != nil {
+			return hub
+		}
+	}
+	return sentry.CurrentHub()
+}
+
+// AddBreadcrumb records a step on the hub of the context(or the global hub if there is none).
+// The breadcrumbs recorded show up alongside any exception captured later on the same hub.
+func (wrapper *Sentry) AddBreadcrumb(ctx context.Context, category, message string, data map[string]interface{}) {
+	wrapper.AddBreadcrumbWithLevel(ctx, sentry.LevelInfo, category, message, data)
+}
+
+// AddBreadcrumbWithLevel records a step with the given level on the hub of the context
+func (wrapper *Sentry) AddBreadcrumbWithLevel(ctx context.Context, level sentry.Level, category, message string, data map[string]interface{}) {
+	// Retained for the crash report(if a reporter is installed), even when sentry is not initialized
+	crash.AddBreadcrumb(category, message)
+	if wrapper.client == nil {
+		return
+	}
+
+	hubFromContext(ctx).AddBreadcrumb(&sentry.Breadcrumb{
+		Type:      "default",
+		Category:  category,
+		Message:   message,
+		Data:      data,
+		Level:     level,
+		Timestamp: time.Now(),
+	}, nil)
+}
+
+// AddDBBreadcrumb records a database call made while serving the request
+func (wrapper *Sentry) AddDBBreadcrumb(ctx context.Context, query string, data map[string]interface{}) {
+	wrapper.AddBreadcrumb(ctx, BreadcrumbDB, query, data)
+}
+
+// AddHTTPBreadcrumb records a call made to an external API while serving the request.
+// Responses with a status code of 400 and above are recorded as warnings.
+func (wrapper *Sentry) AddHTTPBreadcrumb(ctx context.Context, method, url string, statusCode int) {
+	crash.AddBreadcrumb(BreadcrumbHTTP, fmt.Sprintf("%s %s %d", method, url, statusCode))
+	if wrapper.client == nil {
+		return
+	}
+
+	level := sentry.LevelInfo
+	if statusCode >= 400 {
+		level = sentry.LevelWarning
+	}
+
+	hubFromContext(ctx).AddBreadcrumb(&sentry.Breadcrumb{
+		Type:     "http",
+		Category: BreadcrumbHTTP,
+		Data: map[string]interface{}{
+			"method":      method,
+			"url":         url,
+			"status_code": statusCode,
+		},
+		Level:     level,
+		Timestamp: time.Now(),
+	}, nil)
+}
+
+// AddTransitionBreadcrumb records a state transition(eg. of an FSM) made while serving the request
+func (wrapper *Sentry) AddTransitionBreadcrumb(ctx context.Context, from, to string, data map[string]interface{}) {
+	wrapper.AddBreadcrumb(ctx, BreadcrumbTransition, fmt.Sprintf("%s -> %s", from, to), data)
+}
+
+// ClearBreadcrumbs removes all the breadcrumbs recorded on the hub of the context
+func (wrapper *Sentry) ClearBreadcrumbs(ctx context.Context) {
+	if wrapper.client == nil {
+		return
+	}
+
+	hubFromContext(ctx).Scope().ClearBreadcrumbs()
+}
+
+// AddBreadcrumb records a step using the default sentry client
+func AddBreadcrumb(ctx context.Context, category, message string, data map[string]interface{}) {
+	SentryClient.AddBreadcrumb(ctx, category, message, data)
+}
@@ -0,0 +1,90 @@
+package surveillance
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/env"
+	"github.com/skit-ai/vcore/errors"
+	vlog "github.com/skit-ai/vcore/log"
+)
+
+// The logger of the wrapper, which does not feed the log bridge(see BridgeLogs) with what it logs itself
+var log = vlog.Unhooked()
+
+type bridgeConfig struct {
+	level int
+	dedup *deduplicator
+}
+
+// BridgeOption configures the bridge of the logs to Sentry
+type BridgeOption func(*bridgeConfig)
+
+// WithBridgeLevel configures the least severe level of the entries captured(eg. log.WARN for warnings and errors).
+// Defaults to log.ERROR.
+func WithBridgeLevel(level int) BridgeOption {
+	return func(c *bridgeConfig) {
+		c.level = level
+	}
+}
+
+// WithBridgeDedupWindow configures the window within which identical entries are captured only once, on top of the
+// deduplication of the wrapper(see WithDedupWindow). Defaults to SENTRY_BRIDGE_DEDUP_WINDOW(1m).
+func WithBridgeDedupWindow(window time.Duration) BridgeOption {
+	return func(c *bridgeConfig) {
+		c.dedup = newDeduplicator(window)
+	}
+}
+
+// BridgeLogs captures the errors logged through the log package(eg. log.Error, log.Errorf) on Sentry, so that code
+// which only logs its errors gets them reported without calling Capture. Entries logged without an error are
+// captured as errors of their message. Call the returned function to stop bridging.
+func (wrapper *Sentry) BridgeLogs(opts ...BridgeOption) (stop func()) {
+	config := &bridgeConfig{level: vlog.ERROR}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.dedup == nil {
+		config.dedup = newDeduplicator(env.Duration("SENTRY_BRIDGE_DEDUP_WINDOW", time.Minute))
+	}
+	config.dedup.summarize = wrapper.sendSummary
+	if wrapper.clock != nil {
+		config.dedup.clock = wrapper.clock
+	}
+
+	return vlog.AddHook(config.level, func(level int, err error, message string) {
+		wrapper.bridge(config, level, err, message)
+	})
+}
+
+// BridgeLogs captures the errors logged through the log package on Sentry using the global client(see
+// Sentry.BridgeLogs)
+func BridgeLogs(opts ...BridgeOption) (stop func()) {
+	return SentryClient.BridgeLogs(opts...)
+}
+
+func (wrapper *Sentry) bridge(config *bridgeConfig, level int, err error, message string) {
+	if wrapper.client == nil || wrapper.closed.Load() {
+		return
+	}
+
+	if err == nil {
+		if message == "" {
+			return
+		}
+		err = errors.NewError(message, nil, false)
+	}
+	if level != vlog.ERROR {
+		err = errors.WithSeverity(err, errors.Warning)
+	}
+
+	if wrapper.ignored(err) || !config.dedup.allow(err) || !wrapper.admit(err) {
+		return
+	}
+	wrapper.captureOnHub(wrapper.hub(), err, func(scope *sentry.Scope) {
+		scope.SetTag("source", "log")
+		if message != "" && message != err.Error() {
+			scope.SetExtra("log.message", message)
+		}
+	})
+}
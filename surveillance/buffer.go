@@ -0,0 +1,395 @@
+package surveillance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+)
+
+const (
+	minBackoff = time.Second
+	maxBackoff = 5 * time.Minute
+)
+
+// BufferStats of the events buffered while Sentry is unreachable
+type BufferStats struct {
+	// Number of events currently waiting to be sent
+	Queued int
+	Sent   uint64
+	// Events dropped as the buffer was full or rejected by Sentry
+	Dropped uint64
+	// Attempts to send an event which failed and were retried
+	Retries uint64
+}
+
+type bufferConfig struct {
+	size int
+	dir  string
+}
+
+type envelope struct {
+	id   uint64
+	body []byte
+	// File the envelope is persisted to(if the buffer is persistent)
+	path string
+}
+
+// bufferedTransport queues events in a bounded buffer(optionally persisted to a directory) and retries them with an
+// exponential backoff while Sentry is unreachable, so that transient network issues do not lose error reports.
+// The oldest events are dropped once the buffer is full.
+type bufferedTransport struct {
+	size int
+	dir  string
+
+	dsn    *sentry.Dsn
+	client *http.Client
+
+	mutex   sync.Mutex
+	queue   []envelope
+	nextID  uint64
+	wake    chan struct{}
+	idle    *sync.Cond
+	sending bool
+	closed  bool
+	done    chan struct{}
+	close   sync.Once
+
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+	retries atomic.Uint64
+}
+
+func newBufferedTransport(config bufferConfig) *bufferedTransport {
+	t := &bufferedTransport{
+		size: config.size,
+		dir:  config.dir,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	t.idle = sync.NewCond(&t.mutex)
+	return t
+}
+
+func (t *bufferedTransport) Configure(options sentry.ClientOptions) {
+	dsn, err := sentry.NewDsn(options.Dsn)
+	if err != nil {
+		log.Warnf("Could not parse the sentry DSN, events will not be sent: %s", err)
+		return
+	}
+	t.dsn = dsn
+
+	t.client = options.HTTPClient
+	if t.client == nil {
+		t.client = &http.Client{Timeout: 30 * time.Second, Transport: options.HTTPTransport}
+	}
+
+	if t.dir != "" {
+		// Each project persists to a directory of its own, so that the clients routing to other projects(see
+		// NewSentry) sharing SENTRY_BUFFER_DIR do not send(and delete) its envelopes on a restart
+		t.dir = filepath.Join(t.dir, dsn.GetProjectID())
+		// Envelopes carry the PII of the events(unless scrubbed), so only the process' user can read them
+		if err := os.MkdirAll(t.dir, 0o700); err != nil {
+			log.Warnf("Could not create the directory of the sentry buffer, events will only be buffered in memory: %s", err)
+			t.dir = ""
+		} else {
+			t.load()
+		}
+	}
+
+	go t.worker()
+}
+
+// Loads the envelopes persisted by a previous run, the oldest first
+func (t *bufferedTransport) load() {
+	paths, _ := filepath.Glob(filepath.Join(t.dir, "*.envelope"))
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		t.push(envelope{body: body, path: path})
+	}
+}
+
+func (t *bufferedTransport) SendEvent(event *sentry.Event) {
+	if t.dsn == nil {
+		return
+	}
+
+	body, err := encodeEnvelope(event, t.dsn)
+	if err != nil {
+		log.Warnf("Could not encode the sentry event %s: %s", event.EventID, err)
+		t.drop()
+		return
+	}
+
+	e := envelope{body: body}
+	if t.dir != "" {
+		e.path = filepath.Join(t.dir, fmt.Sprintf("%020d-%s.envelope", time.Now().UnixNano(), event.EventID))
+		if err := os.WriteFile(e.path, body, 0o600); err != nil {
+			e.path = ""
+		}
+	}
+	t.push(e)
+}
+
+// Queues the envelope, dropping the oldest one if the buffer is full
+func (t *bufferedTransport) push(e envelope) {
+	t.mutex.Lock()
+	t.nextID++
+	e.id = t.nextID
+	if len(t.queue) >= t.size {
+		t.remove(t.queue[0])
+		t.queue = t.queue[1:]
+		t.drop()
+		sendFailures.WithLabelValues("buffer_full").Inc()
+	} else {
+		bufferQueued.Inc()
+	}
+	t.queue = append(t.queue, e)
+	t.mutex.Unlock()
+
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Counts an event dropped from(or before reaching) the buffer
+func (t *bufferedTransport) drop() {
+	t.dropped.Add(1)
+	bufferDropped.Inc()
+}
+
+func (t *bufferedTransport) remove(e envelope) {
+	if e.path != "" {
+		os.Remove(e.path)
+	}
+}
+
+func (t *bufferedTransport) worker() {
+	backoff := minBackoff
+	for {
+		t.mutex.Lock()
+		if len(t.queue) == 0 {
+			t.sending = false
+			t.idle.Broadcast()
+			t.mutex.Unlock()
+
+			select {
+			case <-t.done:
+				return
+			case <-t.wake:
+				continue
+			}
+		}
+		t.sending = true
+		e := t.queue[0]
+		t.mutex.Unlock()
+
+		retry, after, err := t.send(e.body)
+		if err != nil && retry {
+			t.retries.Add(1)
+			sendFailures.WithLabelValues("retried").Inc()
+			// Rate limited requests are retried once Sentry asks to(if it does), rather than as per the backoff
+			wait := backoff
+			if after > 0 {
+				wait = after
+			} else {
+				backoff = min(2*backoff, maxBackoff)
+			}
+			select {
+			case <-t.done:
+				return
+			case <-time.After(wait):
+			}
+			continue
+		}
+		backoff = minBackoff
+
+		if err != nil {
+			log.Warnf("Dropping the sentry event rejected by the server: %s", err)
+			t.drop()
+			sendFailures.WithLabelValues("rejected").Inc()
+		} else {
+			t.sent.Add(1)
+		}
+
+		t.mutex.Lock()
+		// The envelope might have been dropped from a full buffer while it was being sent
+		if len(t.queue) > 0 && t.queue[0].id == e.id {
+			t.queue = t.queue[1:]
+			bufferQueued.Dec()
+		}
+		t.mutex.Unlock()
+		t.remove(e)
+	}
+}
+
+// Sends the envelope, returning true if a failure is to be retried(network errors, rate limits and server errors)
+// along with the time to retry it after as per the Retry-After header(0 if there is none)
+func (t *bufferedTransport) send(body []byte) (retry bool, after time.Duration, err error) {
+	request, err := http.NewRequest(http.MethodPost, t.dsn.GetAPIURL().String(), bytes.NewReader(body))
+	if err != nil {
+		return false, 0, err
+	}
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=sentry.go/%s, sentry_key=%s", sentry.SDKVersion, t.dsn.GetPublicKey())
+	if secret := t.dsn.GetSecretKey(); secret != "" {
+		auth += ", sentry_secret=" + secret
+	}
+	request.Header.Set("X-Sentry-Auth", auth)
+	request.Header.Set("Content-Type", "application/x-sentry-envelope")
+
+	response, err := t.client.Do(request)
+	if err != nil {
+		return true, 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	switch {
+	case response.StatusCode < 300:
+		return false, 0, nil
+	case response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500:
+		err = errors.NewError(fmt.Sprintf("sentry responded with %s", response.Status), nil, false)
+		return true, retryAfter(response.Header.Get("Retry-After"), time.Now()), err
+	default:
+		return false, 0, errors.NewError(fmt.Sprintf("sentry responded with %s", response.Status), nil, false)
+	}
+}
+
+// Parses the Retry-After header, either in seconds or as an HTTP date. Returns 0 if it is missing or invalid.
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
+
+// Flush waits until the buffer is empty or the timeout is reached. Events still buffered on timeout are sent by the
+// next run of the process if the buffer is persistent.
+func (t *bufferedTransport) Flush(timeout time.Duration) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return waitFor(t.idle, timeout, func() bool {
+		return (len(t.queue) == 0 && !t.sending) || t.closed
+	})
+}
+
+// Waits on the condition, whose lock is held, until done or until the timeout is reached. A timer broadcasts on the
+// condition at the deadline, so that no goroutine is left waiting once the timeout is reached.
+func waitFor(cond *sync.Cond, timeout time.Duration, done func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	timer := time.AfterFunc(timeout, func() {
+		cond.L.Lock()
+		cond.Broadcast()
+		cond.L.Unlock()
+	})
+	defer timer.Stop()
+
+	for !done() {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		cond.Wait()
+	}
+	return true
+}
+
+func (t *bufferedTransport) Close() {
+	t.close.Do(func() {
+		close(t.done)
+		t.mutex.Lock()
+		t.closed = true
+		t.idle.Broadcast()
+		t.mutex.Unlock()
+	})
+}
+
+func (t *bufferedTransport) stats() BufferStats {
+	t.mutex.Lock()
+	queued := len(t.queue)
+	t.mutex.Unlock()
+
+	return BufferStats{
+		Queued:  queued,
+		Sent:    t.sent.Load(),
+		Dropped: t.dropped.Load(),
+		Retries: t.retries.Load(),
+	}
+}
+
+// Encodes the event as an envelope(https://develop.sentry.dev/sdk/envelopes/) along with its attachments
+func encodeEnvelope(event *sentry.Event, dsn *sentry.Dsn) ([]byte, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	if err := enc.Encode(map[string]interface{}{
+		"event_id": event.EventID,
+		"sent_at":  time.Now(),
+		"dsn":      dsn.String(),
+		"sdk":      map[string]string{"name": event.Sdk.Name, "version": event.Sdk.Version},
+	}); err != nil {
+		return nil, err
+	}
+
+	itemType := "event"
+	if event.Type == "transaction" || event.Type == "check_in" {
+		itemType = event.Type
+	}
+	if err := enc.Encode(map[string]interface{}{"type": itemType, "length": len(body)}); err != nil {
+		return nil, err
+	}
+	b.Write(body)
+	b.WriteString("\n")
+
+	for _, attachment := range event.Attachments {
+		header := map[string]interface{}{
+			"type":     "attachment",
+			"length":   len(attachment.Payload),
+			"filename": attachment.Filename,
+		}
+		if attachment.ContentType != "" {
+			header["content_type"] = attachment.ContentType
+		}
+		if err := enc.Encode(header); err != nil {
+			return nil, err
+		}
+		b.Write(attachment.Payload)
+		b.WriteString("\n")
+	}
+	return b.Bytes(), nil
+}
+
+// BufferStats returns the stats of the buffer of events(zero if buffering is disabled)
+func (wrapper *Sentry) BufferStats() BufferStats {
+	if wrapper.buffer == nil {
+		return BufferStats{}
+	}
+	return wrapper.buffer.stats()
+}
@@ -0,0 +1,63 @@
+package surveillance
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+)
+
+var (
+	// ErrNotInitialized is returned for the errors captured by a client which could not be initialized(eg. without
+	// a DSN)
+	ErrNotInitialized = errors.NewError("sentry is not initialized", nil, false)
+	// ErrClosed is returned for the errors captured once the client is closed, as the transport drops them
+	ErrClosed = errors.NewError("sentry is closed", nil, false)
+	// ErrDropped matches the DropError of the errors which were not sent(with errors.Is)
+	ErrDropped = errors.NewError("the error was not sent to sentry", nil, false)
+)
+
+// DropError is returned for the errors which were not sent, with the reason they were not(eg. "duplicate")
+type DropError struct {
+	Reason string
+}
+
+func (e *DropError) Error() string {
+	return "the error was not sent to sentry: " + e.Reason
+}
+
+func (e *DropError) Is(target error) bool {
+	return target == ErrDropped
+}
+
+// Reason of the errors ignored through errors.Ignore
+const dropIgnored = "ignored"
+
+// Captures the error on the hub, returning the ID of the event or why it was not sent. The sample rate of the route
+// of the context is applied if the context is not nil. The scope of the event can be configured(see captureOnHub).
+func (wrapper *Sentry) capture(ctx context.Context, hub *sentry.Hub, err error, configure ...func(scope *sentry.Scope)) (sentry.EventID, error) {
+	switch {
+	case wrapper.client == nil:
+		return "", ErrNotInitialized
+	case wrapper.closed.Load():
+		return "", ErrClosed
+	case wrapper.ignored(err):
+		return "", &DropError{Reason: dropIgnored}
+	case ctx != nil && !wrapper.sampleRoute(ctx):
+		return "", &DropError{Reason: dropRoute}
+	}
+	if reason := wrapper.admission(err); reason != "" {
+		return "", &DropError{Reason: reason}
+	}
+
+	eventID := wrapper.captureOnHub(hub, err, configure...)
+	if eventID == nil {
+		// Dropped by the client as per SENTRY_SAMPLING(or BeforeSend), or as the capture queue was full
+		reason := dropSampleRate
+		if wrapper.queue != nil {
+			reason = dropQueueFull
+		}
+		return "", &DropError{Reason: reason}
+	}
+	return *eventID, nil
+}
@@ -0,0 +1,325 @@
+// Package crash writes structured crash reports to disk when a process dies of a panic or a fatal signal, so that
+// post-mortems have data even when the crash could not be reported to Sentry.
+//
+// Reports carry the stacks of all goroutines, the build info, the most recent breadcrumbs and the requests in flight.
+// Panics are reported where Recover is deferred(eg. in main). Panics of other goroutines, and fatal runtime errors,
+// are written by the runtime to a crash output file alongside, as they cannot be intercepted in Go.
+package crash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/skit-ai/vcore/features"
+	"github.com/skit-ai/vcore/routes"
+)
+
+// Uploader ships a report(eg. to S3) from the previous run of the process
+type Uploader func(ctx context.Context, path string) error
+
+type Breadcrumb struct {
+	Time     time.Time `json:"time"`
+	Category string    `json:"category"`
+	Message  string    `json:"message"`
+}
+
+type Request struct {
+	Summary string    `json:"summary"`
+	Started time.Time `json:"started"`
+}
+
+type Build struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Settings  map[string]string `json:"settings,omitempty"`
+}
+
+type Report struct {
+	Time        time.Time    `json:"time"`
+	Reason      string       `json:"reason"`
+	PID         int          `json:"pid"`
+	Build       Build        `json:"build"`
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs"`
+	Requests    []Request    `json:"requests"`
+	Goroutines  string       `json:"goroutines"`
+}
+
+type Reporter struct {
+	disabled    bool
+	dir         string
+	breadcrumbs int
+	uploader    Uploader
+	signals     []os.Signal
+
+	mutex     sync.Mutex
+	crumbs    []Breadcrumb
+	requests  map[uint64]Request
+	requestID uint64
+	output    *os.File
+}
+
+// Option configures a Reporter
+type Option func(*Reporter)
+
+// WithBreadcrumbs configures the number of recent breadcrumbs retained for the report. Defaults to 100.
+func WithBreadcrumbs(breadcrumbs int) Option {
+	return func(r *Reporter) {
+		r.breadcrumbs = breadcrumbs
+	}
+}
+
+// WithUploader configures the uploader of the reports left by previous runs, which are removed once uploaded
+func WithUploader(uploader Uploader) Option {
+	return func(r *Reporter) {
+		r.uploader = uploader
+	}
+}
+
+// WithSignals configures the fatal signals on which a report is written before exiting. Defaults to SIGQUIT and
+// SIGABRT. Pass no signals to not handle any.
+func WithSignals(signals ...os.Signal) Option {
+	return func(r *Reporter) {
+		r.signals = signals
+	}
+}
+
+var installed atomic.Pointer[Reporter]
+
+// Install installs the reporter writing to the directory, which is created if it does not exist.
+// Reports left by previous runs are uploaded if an uploader is configured. If crash reports are disabled through
+// FEATURES, the reporter returned writes nothing.
+func Install(dir string, opts ...Option) (*Reporter, error) {
+	if !features.Enabled(features.CrashReports) {
+		fmt.Fprintln(os.Stderr, "Crash reports are disabled through FEATURES")
+		return &Reporter{disabled: true}, nil
+	}
+
+	r := &Reporter{
+		dir:         dir,
+		breadcrumbs: 100,
+		signals:     []os.Signal{syscall.SIGQUIT, syscall.SIGABRT},
+		requests:    make(map[uint64]Request),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	// Reports carry the stacks of every goroutine and the requests in flight, so only the process' user can read them
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	r.collect()
+
+	// The runtime writes the panics and fatal errors it cannot hand to Recover to this file
+	path := filepath.Join(dir, fmt.Sprintf("runtime-%d-%d.log", os.Getpid(), time.Now().Unix()))
+	output, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	debug.SetTraceback("all")
+	if err := debug.SetCrashOutput(output, debug.CrashOptions{}); err != nil {
+		output.Close()
+		return nil, err
+	}
+	r.output = output
+
+	if len(r.signals) > 0 {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, r.signals...)
+		go func() {
+			sig := <-signals
+			r.Write(fmt.Sprintf("signal: %s", sig))
+			os.Exit(2)
+		}()
+	}
+
+	installed.Store(r)
+	return r, nil
+}
+
+// Removes the empty runtime output of clean exits and uploads the reports left by previous runs. Files of the
+// directory other than the reports are left as is.
+func (r *Reporter) collect() {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isReport(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(r.dir, entry.Name())
+		if info, err := entry.Info(); err == nil && info.Size() == 0 && strings.HasPrefix(entry.Name(), "runtime-") {
+			os.Remove(path)
+			continue
+		}
+
+		if r.uploader != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := r.uploader(ctx, path); err == nil {
+				os.Remove(path)
+			} else {
+				fmt.Fprintf(os.Stderr, "Could not upload crash report %s: %s\n", path, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Returns true for the names of the files written by the reporter: the reports and the runtime output
+func isReport(name string) bool {
+	return (strings.HasPrefix(name, "crash-") && strings.HasSuffix(name, ".json")) ||
+		(strings.HasPrefix(name, "runtime-") && strings.HasSuffix(name, ".log"))
+}
+
+// AddBreadcrumb records a step for the report of the installed reporter(if any)
+func AddBreadcrumb(category, message string) {
+	if r := installed.Load(); r != nil {
+		r.AddBreadcrumb(category, message)
+	}
+}
+
+// AddBreadcrumb records a step, retaining only the most recent ones
+func (r *Reporter) AddBreadcrumb(category, message string) {
+	if r.disabled {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.crumbs = append(r.crumbs, Breadcrumb{Time: time.Now(), Category: category, Message: message})
+	if len(r.crumbs) > r.breadcrumbs {
+		r.crumbs = r.crumbs[len(r.crumbs)-r.breadcrumbs:]
+	}
+}
+
+// TrackRequest records a request in flight with the installed reporter(if any). Call the returned function once
+// the request is done.
+func TrackRequest(summary string) (done func()) {
+	r := installed.Load()
+	if r == nil {
+		return func() {}
+	}
+
+	r.mutex.Lock()
+	r.requestID++
+	id := r.requestID
+	r.requests[id] = Request{Summary: summary, Started: time.Now()}
+	r.mutex.Unlock()
+
+	return func() {
+		r.mutex.Lock()
+		delete(r.requests, id)
+		r.mutex.Unlock()
+	}
+}
+
+// Middleware tracks the requests served as in flight for the report
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done := TrackRequest(routes.Name(r))
+		defer done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Recover writes a report of a panic and panics again. Defer it at the top of main and of long-lived goroutines.
+func (r *Reporter) Recover() {
+	if recovered := recover(); recovered != nil {
+		r.Write(fmt.Sprintf("panic: %v", recovered))
+		panic(recovered)
+	}
+}
+
+// Recover writes a report of a panic using the installed reporter(if any) and panics again
+func Recover() {
+	if recovered := recover(); recovered != nil {
+		if r := installed.Load(); r != nil {
+			r.Write(fmt.Sprintf("panic: %v", recovered))
+		}
+		panic(recovered)
+	}
+}
+
+// Write writes a report with the reason and returns its path, which is empty if crash reports are disabled
+func (r *Reporter) Write(reason string) string {
+	if r.disabled {
+		return ""
+	}
+	report := r.report(reason)
+	path := filepath.Join(r.dir, fmt.Sprintf("crash-%d-%s.json", report.PID, report.Time.Format("20060102T150405.000")))
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, data, 0o600)
+	}
+	if err != nil {
+		// Last resort, the report goes to STDERR
+		fmt.Fprintf(os.Stderr, "Could not write crash report %s: %s\n%s\n", path, err, report.Goroutines)
+	}
+	return path
+}
+
+func (r *Reporter) report(reason string) Report {
+	report := Report{
+		Time:       time.Now(),
+		Reason:     reason,
+		PID:        os.Getpid(),
+		Build:      build(),
+		Goroutines: stacks(),
+	}
+
+	r.mutex.Lock()
+	report.Breadcrumbs = append([]Breadcrumb(nil), r.crumbs...)
+	for _, request := range r.requests {
+		report.Requests = append(report.Requests, request)
+	}
+	r.mutex.Unlock()
+
+	sort.Slice(report.Requests, func(i, j int) bool {
+		return report.Requests[i].Started.Before(report.Requests[j].Started)
+	})
+	return report
+}
+
+func build() Build {
+	b := Build{GoVersion: runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		b.Path = info.Main.Path
+		b.Version = info.Main.Version
+		b.Settings = make(map[string]string)
+		for _, setting := range info.Settings {
+			if strings.HasPrefix(setting.Key, "vcs.") || setting.Key == "GOOS" || setting.Key == "GOARCH" {
+				b.Settings[setting.Key] = setting.Value
+			}
+		}
+	}
+	return b
+}
+
+// Returns the stacks of all goroutines, growing the buffer until they fit
+func stacks() string {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 64<<20 {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
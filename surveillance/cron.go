@@ -0,0 +1,116 @@
+package surveillance
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+)
+
+// MonitorOption configures the monitor of a job on Sentry Crons(https://docs.sentry.io/product/crons/), which is
+// created(or updated) with the configuration on the first check-in
+type MonitorOption func(*sentry.MonitorConfig)
+
+// WithCrontab configures the schedule of the job as a crontab, eg. "*/5 * * * *"
+func WithCrontab(crontab string) MonitorOption {
+	return func(c *sentry.MonitorConfig) {
+		c.Schedule = sentry.CrontabSchedule(crontab)
+	}
+}
+
+// WithSchedule configures the schedule of the job as an interval, eg. WithSchedule(1, sentry.MonitorScheduleUnitHour)
+func WithSchedule(value int64, unit sentry.MonitorScheduleUnit) MonitorOption {
+	return func(c *sentry.MonitorConfig) {
+		c.Schedule = sentry.IntervalSchedule(value, unit)
+	}
+}
+
+// WithCheckInMargin configures the minutes after the scheduled time within which a run is not considered missed
+func WithCheckInMargin(minutes int64) MonitorOption {
+	return func(c *sentry.MonitorConfig) {
+		c.CheckInMargin = minutes
+	}
+}
+
+// WithMaxRuntime configures the minutes after which a run still in progress is considered failed
+func WithMaxRuntime(minutes int64) MonitorOption {
+	return func(c *sentry.MonitorConfig) {
+		c.MaxRuntime = minutes
+	}
+}
+
+// WithTimezone configures the timezone of the schedule, eg. "Asia/Kolkata"
+func WithTimezone(timezone string) MonitorOption {
+	return func(c *sentry.MonitorConfig) {
+		c.Timezone = timezone
+	}
+}
+
+// Monitor runs a periodic job, checking in on Sentry Crons as it starts and once it succeeds or fails, so that
+// missed and failed runs are alerted on. The error(or panic, which is repanicked) of a failed run is also captured,
+// tagged with the slug of the monitor. Returns the error of the job.
+//
+//	err := surveillance.SentryClient.Monitor("reconcile-calls", reconcile, surveillance.WithCrontab("0 * * * *"))
+func (wrapper *Sentry) Monitor(slug string, fn func() error, opts ...MonitorOption) (err error) {
+	if wrapper.client == nil || !wrapper.reporting {
+		if err = fn(); err != nil {
+			log.Error(err)
+		}
+		return err
+	}
+
+	var config *sentry.MonitorConfig
+	if len(opts) > 0 {
+		config = &sentry.MonitorConfig{}
+		for _, opt := range opts {
+			opt(config)
+		}
+	}
+
+	hub := sentry.CurrentHub().Clone()
+	if hub.Client() != wrapper.client {
+		hub.BindClient(wrapper.client)
+	}
+	hub.Scope().SetTag("monitor.slug", slug)
+
+	checkInID := hub.CaptureCheckIn(&sentry.CheckIn{MonitorSlug: slug, Status: sentry.CheckInStatusInProgress}, config)
+	start := time.Now()
+
+	finish := func(status sentry.CheckInStatus) {
+		checkIn := &sentry.CheckIn{MonitorSlug: slug, Status: status, Duration: time.Since(start)}
+		if checkInID != nil {
+			checkIn.ID = *checkInID
+		}
+		hub.CaptureCheckIn(checkIn, config)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			finish(sentry.CheckInStatusError)
+			wrapper.captureOnHub(hub, errors.FromPanic(r))
+			log.Error(errors.NewError(fmt.Sprintf("Job %s panicked: %v", slug, r), nil, false))
+			panic(r)
+		}
+	}()
+
+	if err = fn(); err != nil {
+		finish(sentry.CheckInStatusError)
+		if !wrapper.ignored(err) && wrapper.admit(err) {
+			if eventID := wrapper.captureOnHub(hub, err); eventID != nil {
+				log.Errorf(err, "Error captured in sentry with the event ID `%s`", *eventID)
+				return err
+			}
+		}
+		log.Error(err)
+		return err
+	}
+
+	finish(sentry.CheckInStatusOK)
+	return nil
+}
+
+// Monitor runs a periodic job checking in on Sentry Crons using the default sentry client
+func Monitor(slug string, fn func() error, opts ...MonitorOption) error {
+	return SentryClient.Monitor(slug, fn, opts...)
+}
@@ -0,0 +1,98 @@
+package surveillance
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+// deduplicator suppresses identical errors captured within a window of the first occurrence.
+// Once the window elapses, a summary event carrying the number of occurrences suppressed is sent.
+type deduplicator struct {
+	mutex   sync.Mutex
+	window  time.Duration
+	clock   simulation.Clock
+	entries map[string]*occurrence
+	// Sends the summary of an error which was suppressed, through the client deduplicating it(see Sentry.sendSummary)
+	summarize func(err error, suppressed int, window time.Duration)
+}
+
+type occurrence struct {
+	err        error
+	suppressed int
+}
+
+func newDeduplicator(window time.Duration) *deduplicator {
+	return &deduplicator{
+		window:  window,
+		clock:   simulation.Real,
+		entries: make(map[string]*occurrence),
+	}
+}
+
+// Errors are identical if they have the same fingerprint, or the same root cause type and message
+func dedupKey(err error) string {
+	if fingerprint := errors.Fingerprint(err); fingerprint != nil {
+		return "fingerprint:" + strings.Join(fingerprint, ":")
+	}
+	return fmt.Sprintf("%T:%s", errors.DeepestCause(err), err.Error())
+}
+
+// allow returns true if the error is the first of its kind within the window
+func (d *deduplicator) allow(err error) bool {
+	if d == nil || d.window <= 0 {
+		return true
+	}
+
+	key := dedupKey(err)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if entry, exists := d.entries[key]; exists {
+		entry.suppressed++
+		return false
+	}
+
+	d.entries[key] = &occurrence{err: err}
+	d.clock.AfterFunc(d.window, func() {
+		d.expire(key)
+	})
+	return true
+}
+
+func (d *deduplicator) expire(key string) {
+	d.mutex.Lock()
+	entry := d.entries[key]
+	delete(d.entries, key)
+	d.mutex.Unlock()
+
+	if entry != nil && entry.suppressed > 0 && d.summarize != nil {
+		d.summarize(entry.err, entry.suppressed, d.window)
+	}
+}
+
+// Sends an event summarising the occurrences of an error which were suppressed, unless the client is closed
+func (wrapper *Sentry) sendSummary(err error, suppressed int, window time.Duration) {
+	if wrapper.client == nil || wrapper.closed.Load() {
+		return
+	}
+	wrapper.captureOnHub(wrapper.hub(), err, func(scope *sentry.Scope) {
+		scope.SetTag("deduplicated", "true")
+		scope.SetExtra("suppressed_occurrences", suppressed)
+		scope.SetExtra("dedup_window", window.String())
+	})
+}
+
+// Returns the hub of the client: the global hub if the client is bound to it, or else a hub of its own(see NewSentry)
+func (wrapper *Sentry) hub() *sentry.Hub {
+	if hub := sentry.CurrentHub(); hub.Client() == wrapper.client {
+		return hub
+	}
+	return sentry.NewHub(wrapper.client, sentry.NewScope())
+}
@@ -0,0 +1,34 @@
+package surveillance
+
+import (
+	"slices"
+	"strings"
+)
+
+// environments decides whether events are reported from the environment(ENVIRONMENT) of the process, so that
+// local/dev/test runs exercise the same code paths without sending events, and without unsetting the DSN
+type environments struct {
+	// Environments reported from. Any environment not denied is reported from if empty.
+	allow []string
+	// Environments never reported from
+	deny []string
+}
+
+// Parses a comma separated list of environments, eg. "staging,production"
+func parseEnvironments(list string) []string {
+	var parsed []string
+	for _, environment := range strings.Split(list, ",") {
+		if environment = strings.ToLower(strings.TrimSpace(environment)); environment != "" {
+			parsed = append(parsed, environment)
+		}
+	}
+	return parsed
+}
+
+func (e environments) reports(environment string) bool {
+	environment = strings.ToLower(environment)
+	if slices.Contains(e.deny, environment) {
+		return false
+	}
+	return len(e.allow) == 0 || slices.Contains(e.allow, environment)
+}
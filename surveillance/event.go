@@ -0,0 +1,173 @@
+package surveillance
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Depth of the chain of causes unwrapped for every exception of an event
+const maxErrorDepth = 10
+
+// EventBuilder builds a custom event, eg. one carrying several exceptions or contexts of its own, without reaching
+// into sentry-go:
+//
+//	surveillance.NewEvent().
+//		WithMessage("Reconciliation found mismatches").
+//		WithLevel(sentry.LevelWarning).
+//		WithTag("job", "reconcile").
+//		WithException(err1).
+//		WithException(err2).
+//		Send(ctx)
+type EventBuilder struct {
+	wrapper *Sentry
+	event   *sentry.Event
+	errs    []error
+}
+
+// NewEvent starts building an event captured with the client
+func (wrapper *Sentry) NewEvent() *EventBuilder {
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	return &EventBuilder{wrapper: wrapper, event: event}
+}
+
+// NewEvent starts building an event captured with the default sentry client
+func NewEvent() *EventBuilder {
+	return SentryClient.NewEvent()
+}
+
+func (b *EventBuilder) WithMessage(message string) *EventBuilder {
+	b.event.Message = message
+	return b
+}
+
+// WithLevel sets the level of the event. Defaults to error.
+func (b *EventBuilder) WithLevel(level sentry.Level) *EventBuilder {
+	b.event.Level = level
+	return b
+}
+
+func (b *EventBuilder) WithTag(key, value string) *EventBuilder {
+	b.event.Tags[key] = value
+	return b
+}
+
+func (b *EventBuilder) WithTags(tags map[string]string) *EventBuilder {
+	for key, value := range tags {
+		b.event.Tags[key] = value
+	}
+	return b
+}
+
+func (b *EventBuilder) WithExtra(key string, value interface{}) *EventBuilder {
+	b.event.Extra[key] = value
+	return b
+}
+
+// WithContext adds a custom context(shown as a section of the event), eg. WithContext("call", sentry.Context{"id": id})
+func (b *EventBuilder) WithContext(name string, context sentry.Context) *EventBuilder {
+	b.event.Contexts[name] = context
+	return b
+}
+
+// WithException adds the error(and its causes) to the exceptions of the event, along with the tags and extras set
+// on it. Can be called more than once for an event carrying several errors.
+func (b *EventBuilder) WithException(err error) *EventBuilder {
+	if err != nil {
+		b.errs = append(b.errs, err)
+	}
+	return b
+}
+
+// WithRequest adds the data of the request(method, URL, headers) to the event
+func (b *EventBuilder) WithRequest(r *http.Request) *EventBuilder {
+	b.event.Request = sentry.NewRequest(r)
+	return b
+}
+
+func (b *EventBuilder) WithUser(user sentry.User) *EventBuilder {
+	b.event.User = user
+	return b
+}
+
+// WithFingerprint sets the fingerprint grouping the event
+func (b *EventBuilder) WithFingerprint(fingerprint ...string) *EventBuilder {
+	b.event.Fingerprint = fingerprint
+	return b
+}
+
+// Build returns the event built
+func (b *EventBuilder) Build() *sentry.Event {
+	event := b.event
+	event.Exception = nil
+	for _, err := range b.errs {
+		// Exceptions are built one error at a time, as sentry-go orders them for a single chain
+		single := &sentry.Event{}
+		single.SetException(err, maxErrorDepth)
+		event.Exception = append(event.Exception, single.Exception...)
+
+		for key, value := range errors.Tags(err) {
+			if _, set := event.Tags[key]; !set {
+				event.Tags[key] = value
+			}
+		}
+		for key, value := range errors.TruncatedExtras(err) {
+			if _, set := event.Extra[key]; !set {
+				event.Extra[key] = value
+			}
+		}
+		if event.Fingerprint == nil {
+			event.Fingerprint = b.wrapper.fingerprint(err)
+		}
+	}
+
+	if len(b.errs) > 1 {
+		// Several errors are sent as an exception group
+		for i := range event.Exception {
+			event.Exception[i].Mechanism = &sentry.Mechanism{IsExceptionGroup: true, ExceptionID: i, Type: "generic"}
+			if i > 0 {
+				event.Exception[i].Mechanism.ParentID = sentry.Pointer(0)
+			}
+		}
+	}
+	return event
+}
+
+// Send captures the event on the hub of the context(or the global hub), returning its ID("" if it was not sent).
+// Events are not sampled or deduplicated, but are not sent once the client is closed, from environments which are
+// not reported from, or if any of their errors is to be ignored(see errors.Ignore).
+func (b *EventBuilder) Send(ctx context.Context) sentry.EventID {
+	event := b.Build()
+	if b.wrapper.client == nil || !b.wrapper.reporting || b.wrapper.closed.Load() || b.ignored() {
+		for _, err := range b.errs {
+			log.Error(err)
+		}
+		if len(b.errs) == 0 {
+			log.Warnf("Not sending the sentry event: %s", event.Message)
+		}
+		return ""
+	}
+
+	hub := b.wrapper.bind(hubFromContext(ctx))
+	var eventID *sentry.EventID
+	hub.WithScope(func(scope *sentry.Scope) {
+		eventID = hub.CaptureEvent(event)
+	})
+	if eventID == nil {
+		return ""
+	}
+	return *eventID
+}
+
+// Returns true if any of the errors of the event is to be ignored, as Capture would ignore it
+func (b *EventBuilder) ignored() bool {
+	for _, err := range b.errs {
+		if b.wrapper.ignored(err) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package surveillance
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// FingerprintRule groups the errors matching it into a single Sentry issue, so that the errors wrapped at different
+// call sites(or with different messages) from the same root cause stop creating separate issues.
+// An error matches if it carries the code Code(see errors.Code), the tag Tag(with the value Value, if set) or if the
+// type of its deepest cause is ErrorType. Fingerprint defaults to the selector, the code and the type of the deepest
+// cause. Fingerprints set on the error itself(errors.WithFingerprint) take precedence over the rules.
+type FingerprintRule struct {
+	Code        int
+	Tag         string
+	Value       string
+	ErrorType   string
+	Fingerprint []string
+}
+
+// Sentinel returned by errors.Code for errors without a code, as it returns 500 for non-positive defaults
+const noCode = math.MaxInt32
+
+func (r FingerprintRule) matches(err error) bool {
+	if r.Code != 0 && errors.Code(err, noCode) != r.Code {
+		return false
+	}
+	if r.Tag != "" {
+		value, ok := errors.Tags(err)[r.Tag]
+		if !ok || (r.Value != "" && value != r.Value) {
+			return false
+		}
+	}
+	if r.ErrorType != "" && fmt.Sprintf("%T", errors.DeepestCause(err)) != r.ErrorType {
+		return false
+	}
+	return r.Code != 0 || r.Tag != "" || r.ErrorType != ""
+}
+
+func (r FingerprintRule) fingerprint(err error) []string {
+	if len(r.Fingerprint) > 0 {
+		return r.Fingerprint
+	}
+
+	var fingerprint []string
+	if r.Code != 0 {
+		fingerprint = append(fingerprint, "code", strconv.Itoa(r.Code))
+	}
+	if r.Tag != "" {
+		fingerprint = append(fingerprint, "tag", r.Tag, errors.Tags(err)[r.Tag])
+	}
+	return append(fingerprint, fmt.Sprintf("%T", errors.DeepestCause(err)))
+}
+
+// ParseFingerprintRules parses comma separated rules of the form `<selector>[=<part>:<part>...]`, where the selector
+// is one of `code:<code>`, `tag:<key>`, `tag:<key>:<value>` or `type:<error type>`.
+// Eg. "code:503=slu:unavailable,type:*net.OpError"
+func ParseFingerprintRules(rules string) ([]FingerprintRule, error) {
+	var parsed []FingerprintRule
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		selector, parts, _ := strings.Cut(rule, "=")
+		var r FingerprintRule
+		if parts != "" {
+			r.Fingerprint = strings.Split(parts, ":")
+		}
+
+		switch {
+		case strings.HasPrefix(selector, "code:"):
+			code, err := strconv.Atoi(strings.TrimPrefix(selector, "code:"))
+			if err != nil || code == 0 {
+				return nil, errors.NewError(fmt.Sprintf("fingerprint rule %q has an invalid code", rule), err, false)
+			}
+			r.Code = code
+		case strings.HasPrefix(selector, "tag:"):
+			r.Tag, r.Value, _ = strings.Cut(strings.TrimPrefix(selector, "tag:"), ":")
+		case strings.HasPrefix(selector, "type:"):
+			r.ErrorType = strings.TrimPrefix(selector, "type:")
+		default:
+			return nil, errors.NewError(fmt.Sprintf("fingerprint rule %q has an unknown selector", rule), nil, false)
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+// Reads the rules from SENTRY_FINGERPRINT_RULES
+func fingerprintRulesFromEnv(rules string) []FingerprintRule {
+	parsed, err := ParseFingerprintRules(rules)
+	if err != nil {
+		log.Warnf("Ignoring SENTRY_FINGERPRINT_RULES: %s", err)
+	}
+	return parsed
+}
+
+// Returns the fingerprint of the error, set on it or by the first rule matching it(nil if there is none)
+func (wrapper *Sentry) fingerprint(err error) []string {
+	if fingerprint := errors.Fingerprint(err); fingerprint != nil {
+		return fingerprint
+	}
+	for _, rule := range wrapper.fingerprintRules {
+		if rule.matches(err) {
+			return rule.fingerprint(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package surveillance
+
+import (
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Drops the internal frames(see errors.SkipFrames) from the top of the stack traces of the exceptions of the event, eg.
+// the constructors of the errors and the Capture helpers, so that the frame the error originated in is the culprit.
+// The frames filtered(see errors.FilterFrames) are dropped from anywhere in the stack traces, and the outermost frames
+// past the depth of the stacks(see errors.SetStackDepth) are omitted.
+func trimFrames(event *sentry.Event) *sentry.Event {
+	if event == nil {
+		return event
+	}
+
+	for i := range event.Exception {
+		stacktrace := event.Exception[i].Stacktrace
+		if stacktrace == nil {
+			continue
+		}
+
+		// Frames are ordered from the outermost caller, keeping at least a frame
+		frames := stacktrace.Frames
+		for len(frames) > 1 && errors.InternalFrame(frames[len(frames)-1].Module+"."+frames[len(frames)-1].Function) {
+			frames = frames[:len(frames)-1]
+		}
+		frames = filterFrames(frames)
+		if len(frames) != len(stacktrace.Frames) {
+			// Stack traces might be shared with the other events of the error
+			event.Exception[i].Stacktrace = &sentry.Stacktrace{Frames: frames, FramesOmitted: stacktrace.FramesOmitted}
+		}
+	}
+	return event
+}
+
+// Returns the frames(from the outermost caller) without those filtered, up to the depth of the stacks. The frames are
+// copied if any is dropped.
+func filterFrames(frames []sentry.Frame) []sentry.Frame {
+	depth := errors.StackDepth()
+	filtered := false
+	for i := range frames[:max(len(frames)-1, 0)] {
+		if errors.FilteredFrame(frames[i].Module + "." + frames[i].Function) {
+			filtered = true
+			break
+		}
+	}
+	if !filtered && len(frames) <= depth {
+		return frames
+	}
+
+	kept := make([]sentry.Frame, 0, min(len(frames), depth))
+	for i, frame := range frames {
+		// Keeping the top frame, the frame the error originated in
+		if i < len(frames)-1 && errors.FilteredFrame(frame.Module+"."+frame.Function) {
+			continue
+		}
+		kept = append(kept, frame)
+	}
+	if len(kept) > depth {
+		kept = kept[len(kept)-depth:]
+	}
+	return kept
+}
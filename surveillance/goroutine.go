@@ -0,0 +1,101 @@
+package surveillance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+)
+
+// PanicHandler is called with the value recovered from a panic of a goroutine and the ID of the event
+// capturing it(nil if it was not captured)
+type PanicHandler func(ctx context.Context, recovered interface{}, eventID *sentry.EventID)
+
+type goOptions struct {
+	restarts int
+	backoff  time.Duration
+	onPanic  PanicHandler
+}
+
+// GoOption configures the goroutines launched by Go
+type GoOption func(*goOptions)
+
+// WithRestarts restarts the goroutine up to restarts times after it panics, waiting for backoff before each restart.
+// Goroutines are not restarted once their context is done.
+func WithRestarts(restarts int, backoff time.Duration) GoOption {
+	return func(o *goOptions) {
+		o.restarts = restarts
+		o.backoff = backoff
+	}
+}
+
+// WithPanicHandler configures a handler called after every panic of the goroutine
+func WithPanicHandler(handler PanicHandler) GoOption {
+	return func(o *goOptions) {
+		o.onPanic = handler
+	}
+}
+
+// Go runs fn in a goroutine with a clone of the hub of the context, so that a panic of the goroutine is recovered
+// and captured with the scope(request, user, tags, breadcrumbs) of the request which launched it, instead of
+// crashing the process.
+// The goroutine gets the context as is, use context.WithoutCancel for it to outlive the request.
+func (wrapper *Sentry) Go(ctx context.Context, fn func(ctx context.Context), opts ...GoOption) {
+	options := goOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	hub := hubFromContext(ctx).Clone()
+	if wrapper.client != nil && hub.Client() != wrapper.client {
+		hub.BindClient(wrapper.client)
+	}
+	ctx = sentry.SetHubOnContext(ctx, hub)
+
+	go func() {
+		for attempt := 0; ; attempt++ {
+			if !wrapper.runRecovered(ctx, hub, fn, options.onPanic) {
+				return
+			}
+			if attempt >= options.restarts {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(options.backoff):
+			}
+			log.Warnf("Restarting goroutine after a panic (restart %d of %d)", attempt+1, options.restarts)
+		}
+	}()
+}
+
+// Runs fn, returning true if it panicked
+func (wrapper *Sentry) runRecovered(ctx context.Context, hub *sentry.Hub, fn func(ctx context.Context), onPanic PanicHandler) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+
+			var eventID *sentry.EventID
+			if wrapper.client != nil {
+				eventID = wrapper.captureOnHub(hub, errors.FromPanic(r))
+			}
+			log.Error(errors.NewError(fmt.Sprintf("Recovered from a panic in a goroutine: %v", r), nil, false))
+
+			if onPanic != nil {
+				onPanic(ctx, r, eventID)
+			}
+		}
+	}()
+
+	fn(ctx)
+	return false
+}
+
+// Go runs fn in a goroutine recovering its panics using the default sentry client
+func Go(ctx context.Context, fn func(ctx context.Context), opts ...GoOption) {
+	SentryClient.Go(ctx, fn, opts...)
+}
@@ -0,0 +1,150 @@
+package surveillance
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+	sentryWrapper "github.com/skit-ai/vcore/sentry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Client interceptors do not capture errors unless configured to, the server being the one to report them
+func reportNever(error) bool {
+	return false
+}
+
+// Returns the context carrying a hub(the one already on it or a clone of the global hub)
+func withHub(ctx context.Context) (context.Context, *sentry.Hub) {
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		return ctx, hub
+	}
+	hub := sentry.CurrentHub().Clone()
+	return sentry.SetHubOnContext(ctx, hub), hub
+}
+
+// Starts a span for the outbound call(only if the context is part of a transaction) and continues the trace
+// on the server through the sentry-trace and baggage metadata
+func startClientSpan(ctx context.Context, hub *sentry.Hub, method string) (context.Context, *sentry.Span) {
+	var span *sentry.Span
+	traceparent, baggage := hub.GetTraceparent(), hub.GetBaggage()
+	if sentry.SpanFromContext(ctx) != nil {
+		span = sentry.StartSpan(ctx, "grpc.client", sentry.WithDescription(method))
+		ctx = span.Context()
+		traceparent, baggage = span.ToSentryTrace(), span.ToBaggage()
+	}
+
+	if traceparent != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, sentry.SentryTraceHeader, traceparent)
+	}
+	if baggage != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, sentry.SentryBaggageHeader, baggage)
+	}
+	return ctx, span
+}
+
+// Finishes the span(if any) of an outbound call, records the call as a breadcrumb and captures the error if
+// it is to be reported
+func (wrapper *Sentry) finishClientCall(ctx context.Context, hub *sentry.Hub, span *sentry.Span, method string, err error, reportOn sentryWrapper.ReportOn) {
+	code := status.Code(err)
+	if span != nil {
+		finishRPCSpan(span, code)
+	}
+
+	level := sentry.LevelInfo
+	if code != codes.OK {
+		level = sentry.LevelWarning
+	}
+	wrapper.AddBreadcrumbWithLevel(ctx, level, BreadcrumbGRPC, method, map[string]interface{}{
+		"status_code": code.String(),
+	})
+
+	if reportOn(err) && !wrapper.ignored(err) && wrapper.admit(err) {
+		wrapper.captureOnHub(hub, err)
+	}
+}
+
+// UnaryClientInterceptor is a grpc interceptor recording outbound calls as breadcrumbs(and spans within
+// transactions) on the hub of the context and continuing the trace on the server.
+// Errors are captured only if configured with sentryWrapper.WithReportOn.
+func (wrapper *Sentry) UnaryClientInterceptor(opts ...sentryWrapper.Option) grpc.UnaryClientInterceptor {
+	options := sentryWrapper.BuildOptions(append([]sentryWrapper.Option{sentryWrapper.WithReportOn(reportNever)}, opts...)...)
+
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		callOpts ...grpc.CallOption,
+	) error {
+		ctx, hub := withHub(ctx)
+		ctx, span := startClientSpan(ctx, hub, method)
+
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		wrapper.finishClientCall(ctx, hub, span, method, err, options.ReportOn)
+		return err
+	}
+}
+
+// StreamClientInterceptor is a grpc interceptor recording outbound streams as breadcrumbs(and spans within
+// transactions) on the hub of the context once they end, and continuing the trace on the server.
+// Errors are captured only if configured with sentryWrapper.WithReportOn.
+func (wrapper *Sentry) StreamClientInterceptor(opts ...sentryWrapper.Option) grpc.StreamClientInterceptor {
+	options := sentryWrapper.BuildOptions(append([]sentryWrapper.Option{sentryWrapper.WithReportOn(reportNever)}, opts...)...)
+
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		callOpts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		ctx, hub := withHub(ctx)
+		ctx, span := startClientSpan(ctx, hub, method)
+
+		stream, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			wrapper.finishClientCall(ctx, hub, span, method, err, options.ReportOn)
+			return nil, err
+		}
+
+		return &clientStream{
+			ClientStream: stream,
+			finish: func(err error) {
+				wrapper.finishClientCall(ctx, hub, span, method, err, options.ReportOn)
+			},
+		}, nil
+	}
+}
+
+// clientStream finishes the call once the stream ends
+type clientStream struct {
+	grpc.ClientStream
+	once   sync.Once
+	finish func(err error)
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		s.once.Do(func() { s.finish(nil) })
+	} else if err != nil {
+		s.once.Do(func() { s.finish(err) })
+	}
+	return err
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	// io.EOF on send means the stream has failed, the error being surfaced by RecvMsg
+	if err != nil && err != io.EOF {
+		s.once.Do(func() { s.finish(err) })
+	}
+	return err
+}
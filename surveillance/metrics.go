@@ -0,0 +1,137 @@
+package surveillance
+
+import (
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Reasons errors are not sent
+const (
+	dropEnvironment  = "environment"
+	dropSamplingRule = "sampling_rule"
+	dropDuplicate    = "duplicate"
+	dropAdaptive     = "adaptive"
+	// Sampled out as per the sample rate of the route(see overrides)
+	dropRoute = "route"
+	// Dropped as the capture queue was full
+	dropQueueFull = "queue_full"
+	// Sampled out by the client as per SENTRY_SAMPLING
+	dropSampleRate = "sample_rate"
+	// Not sent by a client which was never initialized(eg. without a DSN), which is not counted as a drop
+	dropNotInitialized = "not_initialized"
+)
+
+var (
+	eventsCaptured = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "sentry",
+		Name:      "events_captured_total",
+		Help:      "Errors captured and queued to be sent to Sentry, by level",
+	}, []string{"level"})
+	eventsIgnored = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "sentry",
+		Name:      "events_ignored_total",
+		Help:      "Errors not captured as they are to be ignored(errors.Ignore)",
+	})
+	eventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "sentry",
+		Name:      "events_dropped_total",
+		Help:      "Errors not sent to Sentry, by reason(environment, sampling_rule, duplicate, adaptive, route, queue_full, sample_rate)",
+	}, []string{"reason"})
+	sendFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "sentry",
+		Name:      "send_failures_total",
+		Help:      "Failures to deliver events to Sentry, by reason(retried, rejected, rate_limited, network, buffer_full, flush_timeout)",
+	}, []string{"reason"})
+	bufferQueued = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "vcore",
+		Subsystem: "sentry",
+		Name:      "buffer_queued",
+		Help:      "Events waiting in the buffer(see SENTRY_BUFFER_SIZE) to be sent to Sentry",
+	})
+	bufferDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "sentry",
+		Name:      "buffer_dropped_total",
+		Help:      "Events dropped by the buffer as it was full or they were rejected by Sentry",
+	})
+	errorsSeen = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "errors",
+		Name:      "seen_total",
+		Help:      "Errors captured or returned by the handlers of the interceptors, by category(see errors.CategoryOf)",
+	}, []string{"category"})
+)
+
+// Collectors returns the metrics of the wrapper, to be registered with a registry of its own
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{eventsCaptured, eventsIgnored, eventsDropped, sendFailures, bufferQueued, bufferDropped, errorsSeen}
+}
+
+// RegisterMetrics registers the metrics of the wrapper(events captured, ignored, dropped, failures to send them, the
+// events buffered and errors by category), eg. RegisterMetrics(prometheus.DefaultRegisterer)
+func RegisterMetrics(registerer prometheus.Registerer) error {
+	for _, collector := range Collectors() {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Counts(and logs) the failures of the requests delivering the events, for the default transport of the client which
+// drops the events it fails to deliver silently
+type failureCounter struct {
+	http.RoundTripper
+}
+
+func (c failureCounter) RoundTrip(r *http.Request) (*http.Response, error) {
+	response, err := c.RoundTripper.RoundTrip(r)
+	switch {
+	case err != nil:
+		sendFailures.WithLabelValues("network").Inc()
+		log.Warnf("Could not deliver an event to sentry: %s", err)
+	case response.StatusCode == http.StatusTooManyRequests:
+		// Sentry rate limits the events over the quota of the project, the client backing off
+		sendFailures.WithLabelValues("rate_limited").Inc()
+	case response.StatusCode >= http.StatusBadRequest:
+		sendFailures.WithLabelValues("rejected").Inc()
+		log.Warnf("The event delivered to sentry was rejected with %s", response.Status)
+	}
+	return response, err
+}
+
+// CountError counts the error by its category(see errors.CategoryOf), unless it is nil or due to a cancellation(eg.
+// of the clients hanging up). Errors captured(see Sentry.Capture) or returned by the handlers of the interceptors are
+// counted already, the rest(eg. of the HTTP handlers rendering their errors) can be counted with it.
+func CountError(err error) {
+	if err == nil || errors.IsCanceled(err) {
+		return
+	}
+	errorsSeen.WithLabelValues(string(errors.CategoryOf(err))).Inc()
+}
+
+// Returns true(counting the error) if the error is to be ignored, or is due to a cancellation(eg. of the clients
+// hanging up)
+func (wrapper *Sentry) ignored(err error) bool {
+	if errors.Ignore(err) || errors.IsCanceled(err) {
+		eventsIgnored.Inc()
+		return true
+	}
+	return false
+}
+
+// Counts an event captured, or sampled out by the client if it has no ID
+func countCaptured(eventID *sentry.EventID, level sentry.Level) {
+	if eventID == nil {
+		eventsDropped.WithLabelValues(dropSampleRate).Inc()
+		return
+	}
+	eventsCaptured.WithLabelValues(string(level)).Inc()
+}
@@ -0,0 +1,126 @@
+package surveillance
+
+import (
+	"strings"
+	"time"
+
+	"github.com/skit-ai/vcore/simulation"
+)
+
+// Option configures the Sentry wrapper. Options override the configuration read from environment variables.
+type Option func(*Sentry)
+
+// WithDedupWindow configures the window within which identical errors are captured only once.
+// A window of 0 disables deduplication. Defaults to SENTRY_DEDUP_WINDOW(disabled if unset).
+func WithDedupWindow(window time.Duration) Option {
+	return func(s *Sentry) {
+		s.dedup = newDeduplicator(window)
+	}
+}
+
+// WithSamplingRules configures the rules sampling errors by their tags or types, overriding SENTRY_SAMPLING_RULES.
+// The first rule matching an error decides its rate, errors matching no rule are always sent.
+func WithSamplingRules(rules ...SamplingRule) Option {
+	return func(s *Sentry) {
+		s.sampler = newSampler(rules)
+	}
+}
+
+// WithUserKeys configures the HTTP headers/gRPC metadata keys carrying the ID and email of the user of a request,
+// used when the user is not set on the context through SetUser. Defaults to SENTRY_USER_ID_KEY and
+// SENTRY_USER_EMAIL_KEY.
+func WithUserKeys(id, email string) Option {
+	return func(s *Sentry) {
+		s.userKeys = userKeys{id: id, email: email}
+	}
+}
+
+// WithBuffer buffers up to size events(persisted to a directory of the project within dir, unless empty) while
+// Sentry is unreachable, retrying them with an exponential backoff. The oldest events are dropped once the buffer is
+// full. A size of 0 disables buffering. Defaults to SENTRY_BUFFER_SIZE and SENTRY_BUFFER_DIR(disabled if unset).
+func WithBuffer(size int, dir string) Option {
+	return func(s *Sentry) {
+		s.bufferConfig = bufferConfig{size: size, dir: dir}
+	}
+}
+
+// WithClock configures the clock expiring the dedup window, eg. a simulation.Simulation in tests.
+// Defaults to simulation.Real.
+func WithClock(clock simulation.Clock) Option {
+	return func(s *Sentry) {
+		s.clock = clock
+	}
+}
+
+// WithRandom configures the random source(returning numbers in [0, 1)) of the sampling rules, eg. the Float64 of a
+// simulation.Simulation in tests. Defaults to rand.Float64.
+func WithRandom(random func() float64) Option {
+	return func(s *Sentry) {
+		s.random = random
+	}
+}
+
+// WithEnvironments configures the environments(ENVIRONMENT) events are reported from, and the ones they are never
+// reported from. Errors captured in other environments are only logged. An empty allow list allows any environment
+// not denied. Defaults to SENTRY_REPORT_ENVIRONMENTS and SENTRY_SKIP_ENVIRONMENTS(comma separated).
+func WithEnvironments(allow, deny []string) Option {
+	return func(s *Sentry) {
+		s.environments = environments{
+			allow: parseEnvironments(strings.Join(allow, ",")),
+			deny:  parseEnvironments(strings.Join(deny, ",")),
+		}
+	}
+}
+
+// WithAdaptiveSampling reduces the rate errors are sent at(down to the floor) once more than threshold errors are
+// captured within a minute, ramping it back up once the volume subsides. A threshold of 0 disables it. Defaults to
+// SENTRY_ADAPTIVE_THRESHOLD(disabled if unset) and SENTRY_ADAPTIVE_FLOOR(0.01).
+func WithAdaptiveSampling(threshold int, floor float64) Option {
+	return func(s *Sentry) {
+		s.adaptive = newAdaptiveSampler(threshold, floor)
+	}
+}
+
+// WithPayloadLimits configures the bytes of an extra(breadcrumb data or request body) past which it is truncated, and
+// of an event past which its oldest breadcrumbs are dropped. Truncated events are tagged with payload.truncated and
+// their original size. A limit of 0 disables truncation. Defaults to SENTRY_MAX_EXTRA_SIZE(16KiB) and
+// SENTRY_MAX_EVENT_SIZE(900KiB).
+func WithPayloadLimits(extra, event int) Option {
+	return func(s *Sentry) {
+		s.limits = payloadLimits{extra: extra, event: event}
+	}
+}
+
+// WithCaptureQueue queues up to size errors captured to be sent by a worker, so that they are not sent on the
+// goroutines capturing them. The policy decides what happens to the errors captured while the queue is full. Event IDs
+// are returned before the events are sampled(SENTRY_SAMPLING) when queued. Defaults to SENTRY_CAPTURE_QUEUE_SIZE and
+// SENTRY_CAPTURE_DROP_POLICY(synchronous if unset).
+func WithCaptureQueue(size int, policy DropPolicy) Option {
+	return func(s *Sentry) {
+		s.queueConfig = queueConfig{size: size, policy: policy}
+	}
+}
+
+// WithSyncCapture sends the errors captured synchronously on the goroutines capturing them, eg. in tests
+func WithSyncCapture() Option {
+	return func(s *Sentry) {
+		s.queueConfig = queueConfig{}
+	}
+}
+
+// WithFingerprintRules configures the rules grouping the errors without a fingerprint(see errors.WithFingerprint),
+// overriding SENTRY_FINGERPRINT_RULES. The first rule matching an error decides its fingerprint.
+func WithFingerprintRules(rules ...FingerprintRule) Option {
+	return func(s *Sentry) {
+		s.fingerprintRules = rules
+	}
+}
+
+// WithProfiling CPU profiles the regions(see StartProfile) of sampled transactions at the rate(0 to 1), attaching the
+// profiles of the regions longer than the threshold to their transactions. Defaults to SENTRY_PROFILES_SAMPLE_RATE(0)
+// and SENTRY_PROFILES_THRESHOLD(1s).
+func WithProfiling(rate float64, threshold time.Duration) Option {
+	return func(s *Sentry) {
+		s.profiling = profiling{rate: rate, threshold: threshold}
+	}
+}
@@ -0,0 +1,160 @@
+package surveillance
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+// Tag set on the transactions a region of which is profiled, keying the profiles attached to them
+const TagProfileID = "profile.id"
+
+// Transactions with profiles yet to be sent, past which the profiles of the oldest are dropped
+const maxPendingProfiles = 32
+
+// Time after which the profiles of a transaction which was not sent(eg. dropped, or never finished) are dropped
+const pendingProfileTTL = 10 * time.Minute
+
+// CPU profiles are of the whole process, only a region is profiled at a time
+var cpuProfile sync.Mutex
+
+type profiling struct {
+	// Rate(0 to 1) at which regions are profiled
+	rate float64
+	// Duration of a region past which its profile is attached to its transaction
+	threshold time.Duration
+}
+
+// profiles holds the profiles of the regions until the transactions they are part of are sent
+type profiles struct {
+	clock   simulation.Clock
+	mutex   sync.Mutex
+	pending map[string]*pendingProfiles
+}
+
+// Profiles of a transaction, and the time the first of them was added
+type pendingProfiles struct {
+	attachments []*sentry.Attachment
+	added       time.Time
+}
+
+func (p *profiles) add(id string, attachment *sentry.Attachment) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	clock := p.clock
+	if clock == nil {
+		clock = simulation.Real
+	}
+	now := clock.Now()
+	if p.pending == nil {
+		p.pending = make(map[string]*pendingProfiles)
+	}
+
+	entry, ok := p.pending[id]
+	if !ok {
+		// Making room for the profiles, as those of the transactions which are not sent are never taken
+		oldest := ""
+		for key, other := range p.pending {
+			if now.Sub(other.added) >= pendingProfileTTL {
+				delete(p.pending, key)
+			} else if oldest == "" || other.added.Before(p.pending[oldest].added) {
+				oldest = key
+			}
+		}
+		if len(p.pending) >= maxPendingProfiles {
+			delete(p.pending, oldest)
+		}
+		entry = &pendingProfiles{added: now}
+		p.pending[id] = entry
+	}
+	entry.attachments = append(entry.attachments, attachment)
+}
+
+func (p *profiles) take(id string) []*sentry.Attachment {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	entry, ok := p.pending[id]
+	if !ok {
+		return nil
+	}
+	delete(p.pending, id)
+	return entry.attachments
+}
+
+// Attaches the profiles of the regions of the transaction to its event
+func (p *profiles) attach(event *sentry.Event) *sentry.Event {
+	if id := event.Tags[TagProfileID]; id != "" {
+		event.Attachments = append(event.Attachments, p.take(id)...)
+	}
+	return event
+}
+
+// StartProfile starts a span for the region within the transaction of the context(a new transaction if it has none),
+// CPU profiling the process during the region as per SENTRY_PROFILES_SAMPLE_RATE. Call the returned function once the
+// region is done. The profile(a pprof file) is attached to the transaction if the region took longer than
+// SENTRY_PROFILES_THRESHOLD. Only the regions of sampled transactions are profiled, and only a region at a time as
+// the profile is of the whole process.
+func (wrapper *Sentry) StartProfile(ctx context.Context, name string) (context.Context, func()) {
+	span := sentry.StartSpan(ctx, "profile", sentry.WithDescription(name))
+	ctx = span.Context()
+
+	transaction := span.GetTransaction()
+	random := wrapper.random
+	if random == nil {
+		random = rand.Float64
+	}
+	if wrapper.client == nil || transaction == nil || !transaction.Sampled.Bool() ||
+		random() >= wrapper.profiling.rate || !cpuProfile.TryLock() {
+		return ctx, span.Finish
+	}
+
+	var profile bytes.Buffer
+	if err := pprof.StartCPUProfile(&profile); err != nil {
+		cpuProfile.Unlock()
+		log.Warnf("Could not profile %s: %s", name, err)
+		return ctx, span.Finish
+	}
+
+	start := time.Now()
+	return ctx, func() {
+		pprof.StopCPUProfile()
+		cpuProfile.Unlock()
+
+		if time.Since(start) >= wrapper.profiling.threshold {
+			id := transaction.SpanID.String()
+			wrapper.profiles.add(id, &sentry.Attachment{
+				Filename:    name + ".pprof",
+				ContentType: "application/octet-stream",
+				Payload:     profile.Bytes(),
+			})
+			transaction.SetTag(TagProfileID, id)
+			span.SetData("profile", name+".pprof")
+		}
+		span.Finish()
+	}
+}
+
+// ProfileRegion runs the function within a profiled region(see StartProfile)
+func (wrapper *Sentry) ProfileRegion(ctx context.Context, name string, fn func(ctx context.Context)) {
+	ctx, done := wrapper.StartProfile(ctx, name)
+	defer done()
+	fn(ctx)
+}
+
+// StartProfile starts a profiled region using the default sentry client
+func StartProfile(ctx context.Context, name string) (context.Context, func()) {
+	return SentryClient.StartProfile(ctx, name)
+}
+
+// ProfileRegion runs the function within a profiled region using the default sentry client
+func ProfileRegion(ctx context.Context, name string, fn func(ctx context.Context)) {
+	SentryClient.ProfileRegion(ctx, name, fn)
+}
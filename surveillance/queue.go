@@ -0,0 +1,179 @@
+package surveillance
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// DropPolicy decides what happens to the errors captured while the capture queue is full
+type DropPolicy int
+
+const (
+	// DropNewest drops the errors captured while the queue is full
+	DropNewest DropPolicy = iota
+	// DropOldest drops the oldest error queued to make room for the one captured
+	DropOldest
+	// Block blocks the capture until there is room, pushing back on the callers
+	Block
+)
+
+func (p DropPolicy) String() string {
+	switch p {
+	case DropOldest:
+		return "oldest"
+	case Block:
+		return "block"
+	}
+	return "newest"
+}
+
+// ParseDropPolicy parses the policy of SENTRY_CAPTURE_DROP_POLICY, one of "newest", "oldest" or "block"
+func ParseDropPolicy(policy string) (DropPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case "", "newest":
+		return DropNewest, nil
+	case "oldest":
+		return DropOldest, nil
+	case "block":
+		return Block, nil
+	}
+	return DropNewest, fmt.Errorf("unknown drop policy %q", policy)
+}
+
+type queueConfig struct {
+	size   int
+	policy DropPolicy
+}
+
+// captureQueue hands the events captured to a worker sending them, so that the scrubbing, truncation and encoding of
+// events are not on the goroutines capturing them
+type captureQueue struct {
+	policy DropPolicy
+
+	mutex    sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond
+	jobs     []func()
+	size     int
+	running  bool
+	closed   bool
+}
+
+func newCaptureQueue(config queueConfig) *captureQueue {
+	q := &captureQueue{policy: config.policy, size: config.size}
+	q.notEmpty = sync.NewCond(&q.mutex)
+	q.notFull = sync.NewCond(&q.mutex)
+	q.idle = sync.NewCond(&q.mutex)
+	go q.worker()
+	return q
+}
+
+// Queues the job as per the drop policy, returning false if it was dropped
+func (q *captureQueue) push(job func()) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for !q.closed && len(q.jobs) >= q.size {
+		switch q.policy {
+		case Block:
+			q.notFull.Wait()
+			continue
+		case DropOldest:
+			q.jobs = q.jobs[1:]
+			eventsDropped.WithLabelValues(dropQueueFull).Inc()
+			continue
+		}
+		eventsDropped.WithLabelValues(dropQueueFull).Inc()
+		return false
+	}
+	if q.closed {
+		return false
+	}
+
+	q.jobs = append(q.jobs, job)
+	q.notEmpty.Signal()
+	return true
+}
+
+func (q *captureQueue) worker() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for {
+		for len(q.jobs) == 0 && !q.closed {
+			q.running = false
+			q.idle.Broadcast()
+			q.notEmpty.Wait()
+		}
+		if len(q.jobs) == 0 {
+			q.running = false
+			q.idle.Broadcast()
+			return
+		}
+
+		job := q.jobs[0]
+		q.jobs = q.jobs[1:]
+		q.running = true
+		q.notFull.Signal()
+
+		q.mutex.Unlock()
+		job()
+		q.mutex.Lock()
+	}
+}
+
+// Waits until the queue is drained or the timeout is reached
+func (q *captureQueue) flush(timeout time.Duration) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return waitFor(q.idle, timeout, func() bool {
+		return len(q.jobs) == 0 && !q.running
+	})
+}
+
+// Stops the worker once the queued jobs are done, dropping the jobs pushed after
+func (q *captureQueue) close() {
+	q.mutex.Lock()
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.mutex.Unlock()
+}
+
+// Captures the error on the queue, returning the ID the event will be sent with(nil if it was dropped). The event is
+// built from the error and scope on the caller's goroutine, so that it carries the state at the time of the capture.
+func (wrapper *Sentry) enqueue(hub *sentry.Hub, scope *sentry.Scope, err error, eventLevel sentry.Level) *sentry.EventID {
+	client := hub.Client()
+	if client == nil {
+		return nil
+	}
+
+	event := client.EventFromException(err, eventLevel)
+	event.EventID = newEventID()
+	event.Timestamp = time.Now()
+	scope = scope.Clone()
+
+	if !wrapper.queue.push(func() {
+		countCaptured(client.CaptureEvent(event, &sentry.EventHint{OriginalException: err}, scope), eventLevel)
+	}) {
+		return nil
+	}
+	return &event.EventID
+}
+
+// Returns a random(version 4) UUID as sentry-go does
+func newEventID() sentry.EventID {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return sentry.EventID(hex.EncodeToString(id))
+}
@@ -0,0 +1,122 @@
+package surveillance
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Route returns the key of the client an error is to be captured with, or "" for the fallback client
+type Route func(err error) string
+
+// RouteByTag routes errors by the value of a tag set on them, eg. RouteByTag("service")
+func RouteByTag(tag string) Route {
+	return func(err error) string {
+		return errors.Tags(err)[tag]
+	}
+}
+
+// Router captures errors with one of multiple clients(eg. one per logical service running in the binary, each
+// with its own DSN) as per a routing function. Errors routed to no registered client are captured with the
+// fallback client.
+//
+//	router := surveillance.NewRouter(surveillance.SentryClient, surveillance.RouteByTag("service"))
+//	router.Register("billing", surveillance.NewSentry(billingDSN, ""))
+//	router.Capture(errors.NewErrorWithTags("Could not charge", err, false, map[string]string{"service": "billing"}), false)
+type Router struct {
+	fallback *Sentry
+	route    Route
+
+	mutex   sync.RWMutex
+	clients map[string]*Sentry
+}
+
+// NewRouter returns a router using the fallback client(defaults to SentryClient) for the errors not routed to a
+// registered client
+func NewRouter(fallback *Sentry, route Route) *Router {
+	if fallback == nil {
+		fallback = SentryClient
+	}
+	return &Router{fallback: fallback, route: route, clients: make(map[string]*Sentry)}
+}
+
+// Register registers the client the errors routed to the key are captured with
+func (r *Router) Register(key string, client *Sentry) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.clients[key] = client
+}
+
+// Client returns the client the error is routed to
+func (r *Router) Client(err error) *Sentry {
+	if err == nil || r.route == nil {
+		return r.fallback
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if client, ok := r.clients[r.route(err)]; ok {
+		return client
+	}
+	return r.fallback
+}
+
+// Capture captures the error with the client it is routed to
+func (r *Router) Capture(err error, _panic bool) sentry.EventID {
+	return r.Client(err).Capture(err, _panic)
+}
+
+// CaptureWithContext captures the error on the hub of the context with the client it is routed to
+func (r *Router) CaptureWithContext(ctx context.Context, err error, _panic bool) sentry.EventID {
+	return r.Client(err).CaptureWithContext(ctx, err, _panic)
+}
+
+// CaptureWithContextErr captures the error on the hub of the context with the client it is routed to, returning why
+// it was not sent(see Sentry.CaptureWithContextErr)
+func (r *Router) CaptureWithContextErr(ctx context.Context, err error, _panic bool) (sentry.EventID, error) {
+	return r.Client(err).CaptureWithContextErr(ctx, err, _panic)
+}
+
+// Close flushes and shuts down the registered clients(but not the fallback client), waiting for at most the flush
+// timeout of each. A client registered with multiple keys is closed once.
+func (r *Router) Close() {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	clients := make(map[*Sentry]struct{}, len(r.clients))
+	for _, client := range r.clients {
+		clients[client] = struct{}{}
+	}
+
+	var wg sync.WaitGroup
+	for client := range clients {
+		wg.Add(1)
+		go func(client *Sentry) {
+			defer wg.Done()
+			client.Close()
+		}(client)
+	}
+	wg.Wait()
+}
+
+// Flush waits until the events of the registered clients and of the fallback client are delivered, or the timeout is
+// reached
+func (r *Router) Flush(timeout time.Duration) bool {
+	r.mutex.RLock()
+	clients := append([]*Sentry{r.fallback}, slices.Collect(maps.Values(r.clients))...)
+	r.mutex.RUnlock()
+
+	flushed := true
+	deadline := time.Now().Add(timeout)
+	for _, client := range clients {
+		flushed = client.Flush(time.Until(deadline)) && flushed
+	}
+	return flushed
+}
@@ -0,0 +1,160 @@
+package surveillance
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/overrides"
+)
+
+// SamplingRule samples the errors matching it at Rate(0 to 1).
+// An error matches if it carries the tag Tag(with the value Value, if set) or if the type of its deepest cause
+// is ErrorType(eg. "*net.OpError").
+type SamplingRule struct {
+	Tag       string
+	Value     string
+	ErrorType string
+	Rate      float64
+}
+
+func (r SamplingRule) matches(err error) bool {
+	if r.Tag != "" {
+		value, ok := errors.Tags(err)[r.Tag]
+		if !ok || (r.Value != "" && value != r.Value) {
+			return false
+		}
+	}
+	if r.ErrorType != "" && fmt.Sprintf("%T", errors.DeepestCause(err)) != r.ErrorType {
+		return false
+	}
+	return r.Tag != "" || r.ErrorType != ""
+}
+
+// String returns the rule in the format accepted by SENTRY_SAMPLING_RULES
+func (r SamplingRule) String() string {
+	var selector string
+	switch {
+	case r.Tag != "" && r.Value != "":
+		selector = "tag:" + r.Tag + ":" + r.Value
+	case r.Tag != "":
+		selector = "tag:" + r.Tag
+	default:
+		selector = "type:" + r.ErrorType
+	}
+	return selector + "=" + strconv.FormatFloat(r.Rate, 'f', -1, 64)
+}
+
+// ParseSamplingRules parses comma separated rules of the form `<selector>=<rate>`, where the selector is one of
+// `tag:<key>`, `tag:<key>:<value>` or `type:<error type>`. Eg. "tag:timeout=0.05,type:*net.OpError=0.5"
+func ParseSamplingRules(rules string) ([]SamplingRule, error) {
+	var parsed []SamplingRule
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		separator := strings.LastIndex(rule, "=")
+		if separator < 0 {
+			return nil, errors.NewError(fmt.Sprintf("sampling rule %q has no rate", rule), nil, false)
+		}
+		rate, err := strconv.ParseFloat(rule[separator+1:], 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, errors.NewError(fmt.Sprintf("sampling rule %q has an invalid rate", rule), err, false)
+		}
+
+		selector := rule[:separator]
+		switch {
+		case strings.HasPrefix(selector, "tag:"):
+			tag, value, _ := strings.Cut(strings.TrimPrefix(selector, "tag:"), ":")
+			parsed = append(parsed, SamplingRule{Tag: tag, Value: value, Rate: rate})
+		case strings.HasPrefix(selector, "type:"):
+			parsed = append(parsed, SamplingRule{ErrorType: strings.TrimPrefix(selector, "type:"), Rate: rate})
+		default:
+			return nil, errors.NewError(fmt.Sprintf("sampling rule %q has an unknown selector", rule), nil, false)
+		}
+	}
+	return parsed, nil
+}
+
+// sampler decides if an error is to be sent as per the first rule matching it.
+// Errors matching no rule are always sent.
+type sampler struct {
+	rules      []SamplingRule
+	mutex      sync.Mutex
+	sampledOut map[string]uint64
+	// Returns a random number in [0, 1)
+	random func() float64
+}
+
+func newSampler(rules []SamplingRule) *sampler {
+	return &sampler{
+		rules:      rules,
+		sampledOut: make(map[string]uint64),
+		random:     rand.Float64,
+	}
+}
+
+// Reads the rules from SENTRY_SAMPLING_RULES
+func samplerFromEnv(rules string) *sampler {
+	parsed, err := ParseSamplingRules(rules)
+	if err != nil {
+		log.Warnf("Ignoring SENTRY_SAMPLING_RULES: %s", err)
+	}
+	return newSampler(parsed)
+}
+
+// sample returns true if the error is to be sent
+func (s *sampler) sample(err error) bool {
+	if s == nil {
+		return true
+	}
+
+	for _, rule := range s.rules {
+		if !rule.matches(err) {
+			continue
+		}
+		if s.random() < rule.Rate {
+			return true
+		}
+
+		s.mutex.Lock()
+		s.sampledOut[rule.String()]++
+		s.mutex.Unlock()
+		return false
+	}
+	return true
+}
+
+func (s *sampler) counters() map[string]uint64 {
+	counters := make(map[string]uint64)
+	if s == nil {
+		return counters
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for rule, count := range s.sampledOut {
+		counters[rule] = count
+	}
+	return counters
+}
+
+// SampledOut returns the number of events dropped by each sampling rule, keyed by the rule
+func (wrapper *Sentry) SampledOut() map[string]uint64 {
+	return wrapper.sampler.counters()
+}
+
+// Returns true if the error of the request survives the sample rate of its route(see overrides)
+func (wrapper *Sentry) sampleRoute(ctx context.Context) bool {
+	if overrides.FromContext(ctx).Sampled(wrapper.random) {
+		return true
+	}
+	eventsDropped.WithLabelValues(dropRoute).Inc()
+	return false
+}
@@ -0,0 +1,256 @@
+package surveillance
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Filtered replaces values redacted from an event before it is sent to Sentry
+const Filtered = "[Filtered]"
+
+// Field names whose values are redacted irrespective of their content. Short words are anchored, so that the fields
+// which merely contain them(eg. "footprint", "spinner", "otp_attempts_left") are kept.
+var defaultScrubFields = []string{
+	`passw(or)?d`, `secret`, `token`, `api[-_]?key`, `authori[sz]ation`, `cookie`,
+	`(^|[-_.])otp$`, `(^|[-_.])pin($|[-_.])`, `cvv`, `card[-_]?(number|no)`, `phone`, `mobile`, `email`,
+}
+
+// Values which are redacted wherever they appear
+var defaultScrubPatterns = []string{
+	// emails
+	`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	// bearer/basic credentials
+	`(?i)\b(bearer|basic)\s+[A-Za-z0-9\-._~+/]+=*`,
+	// JWTs
+	`\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`,
+	// phone numbers with an optional country code
+	`(?:\+\d{1,3}[ \-]?)?\b[6-9]\d{9}\b`,
+}
+
+// scrubber redacts PII from events before they leave the process.
+// Field rules are matched against keys of extras, contexts, tags, user data, breadcrumb data, headers, cookies and
+// query params. Value rules are matched against string values in all of the above, the messages of the exceptions, the
+// user and the request body.
+type scrubber struct {
+	mutex    sync.RWMutex
+	fields   []*regexp.Regexp
+	patterns []*regexp.Regexp
+}
+
+var eventScrubber = newScrubber()
+
+func newScrubber() *scrubber {
+	s := &scrubber{}
+	for _, field := range defaultScrubFields {
+		s.fields = append(s.fields, regexp.MustCompile(`(?i)`+field))
+	}
+	for _, pattern := range defaultScrubPatterns {
+		s.patterns = append(s.patterns, regexp.MustCompile(pattern))
+	}
+	return s
+}
+
+// AddScrubField registers a pattern(case-insensitive) for field names whose values are to be redacted
+func AddScrubField(pattern string) error {
+	compiled, err := regexp.Compile(`(?i)` + pattern)
+	if err != nil {
+		return err
+	}
+
+	eventScrubber.mutex.Lock()
+	defer eventScrubber.mutex.Unlock()
+	eventScrubber.fields = append(eventScrubber.fields, compiled)
+	return nil
+}
+
+// AddScrubPattern registers a pattern for values that are to be redacted wherever they appear in an event
+func AddScrubPattern(pattern string) error {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	eventScrubber.mutex.Lock()
+	defer eventScrubber.mutex.Unlock()
+	eventScrubber.patterns = append(eventScrubber.patterns, compiled)
+	return nil
+}
+
+// ScrubEvent redacts PII from the message, exceptions, extras, contexts, tags, user, breadcrumbs and request of the
+// event as per the registered rules. Maps and breadcrumbs shared with the scope or the error are copied rather than modified.
+func ScrubEvent(event *sentry.Event) *sentry.Event {
+	return eventScrubber.scrubEvent(event)
+}
+
+func (s *scrubber) scrubEvent(event *sentry.Event) *sentry.Event {
+	if event == nil {
+		return nil
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	event.Message = s.scrubString(event.Message)
+	// The messages of the errors captured, eg. "could not reach ...?token=..."
+	if event.Exception != nil {
+		exceptions := make([]sentry.Exception, len(event.Exception))
+		for i, exception := range event.Exception {
+			exception.Value = s.scrubString(exception.Value)
+			exceptions[i] = exception
+		}
+		event.Exception = exceptions
+	}
+	if event.Tags != nil {
+		event.Tags = s.scrubStringMap(event.Tags)
+	}
+	event.User = s.scrubUser(event.User)
+	if event.Extra != nil {
+		event.Extra = s.scrubMap(event.Extra)
+	}
+	for key, c := range event.Contexts {
+		event.Contexts[key] = s.scrubMap(c)
+	}
+
+	for i, breadcrumb := range event.Breadcrumbs {
+		scrubbed := *breadcrumb
+		scrubbed.Message = s.scrubString(breadcrumb.Message)
+		if breadcrumb.Data != nil {
+			scrubbed.Data = s.scrubMap(breadcrumb.Data)
+		}
+		event.Breadcrumbs[i] = &scrubbed
+	}
+
+	if request := event.Request; request != nil {
+		scrubbed := *request
+		scrubbed.Data = s.scrubBody(request.Data)
+		scrubbed.QueryString = s.scrubQuery(request.QueryString)
+		if request.Cookies != "" && s.isSensitiveField("cookie") {
+			scrubbed.Cookies = Filtered
+		}
+		if request.Headers != nil {
+			scrubbed.Headers = s.scrubStringMap(request.Headers)
+		}
+		event.Request = &scrubbed
+	}
+
+	return event
+}
+
+func (s *scrubber) scrubUser(user sentry.User) sentry.User {
+	if user.Email != "" && s.isSensitiveField("email") {
+		user.Email = Filtered
+	} else {
+		user.Email = s.scrubString(user.Email)
+	}
+	user.Username = s.scrubString(user.Username)
+	user.Name = s.scrubString(user.Name)
+	if user.Data != nil {
+		user.Data = s.scrubStringMap(user.Data)
+	}
+	return user
+}
+
+func (s *scrubber) isSensitiveField(key string) bool {
+	for _, field := range s.fields {
+		if field.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *scrubber) scrubString(value string) string {
+	for _, pattern := range s.patterns {
+		value = pattern.ReplaceAllString(value, Filtered)
+	}
+	return value
+}
+
+func (s *scrubber) scrubMap(m map[string]interface{}) map[string]interface{} {
+	scrubbed := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if s.isSensitiveField(key) {
+			scrubbed[key] = Filtered
+		} else {
+			scrubbed[key] = s.scrubValue(value)
+		}
+	}
+	return scrubbed
+}
+
+func (s *scrubber) scrubStringMap(m map[string]string) map[string]string {
+	scrubbed := make(map[string]string, len(m))
+	for key, value := range m {
+		if s.isSensitiveField(key) {
+			scrubbed[key] = Filtered
+		} else {
+			scrubbed[key] = s.scrubString(value)
+		}
+	}
+	return scrubbed
+}
+
+func (s *scrubber) scrubValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return s.scrubString(v)
+	case map[string]interface{}:
+		return s.scrubMap(v)
+	case map[string]string:
+		return s.scrubStringMap(v)
+	case []interface{}:
+		scrubbed := make([]interface{}, len(v))
+		for i := range v {
+			scrubbed[i] = s.scrubValue(v[i])
+		}
+		return scrubbed
+	case []string:
+		scrubbed := make([]string, len(v))
+		for i := range v {
+			scrubbed[i] = s.scrubString(v[i])
+		}
+		return scrubbed
+	}
+	return value
+}
+
+// Request bodies which are JSON are scrubbed field by field. Others are scrubbed as plain strings.
+func (s *scrubber) scrubBody(body string) string {
+	if body == "" {
+		return body
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(body), &decoded); err == nil {
+		if encoded, err := json.Marshal(s.scrubValue(decoded)); err == nil {
+			return string(encoded)
+		}
+	}
+	return s.scrubString(body)
+}
+
+func (s *scrubber) scrubQuery(query string) string {
+	if query == "" {
+		return query
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return s.scrubString(query)
+	}
+
+	for key, vals := range values {
+		for i := range vals {
+			if s.isSensitiveField(key) {
+				vals[i] = Filtered
+			} else {
+				vals[i] = s.scrubString(vals[i])
+			}
+		}
+	}
+	return values.Encode()
+}
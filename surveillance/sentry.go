@@ -4,26 +4,62 @@ import (
 	"context"
 	"net/http"
 	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/getsentry/sentry-go"
 	sentryhttp "github.com/getsentry/sentry-go/http"
 	"github.com/julienschmidt/httprouter"
 	"github.com/skit-ai/vcore/env"
 	"github.com/skit-ai/vcore/errors"
-	"github.com/skit-ai/vcore/log"
 	sentryWrapper "github.com/skit-ai/vcore/sentry"
+	"github.com/skit-ai/vcore/simulation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 type Sentry struct {
-	client  *sentry.Client
-	handler *sentryWrapper.Handler
+	client       *sentry.Client
+	handler      *sentryWrapper.Handler
+	flushTimeout time.Duration
+	dedup        *deduplicator
+	sampler      *sampler
+	userKeys     userKeys
+	bufferConfig bufferConfig
+	buffer       *bufferedTransport
+	clock        simulation.Clock
+	random       func() float64
+	environments environments
+	adaptive     *adaptiveSampler
+	limits       payloadLimits
+	queueConfig  queueConfig
+	queue        *captureQueue
+	// Rules grouping the errors without a fingerprint of their own
+	fingerprintRules []FingerprintRule
+	// Profiling of regions, and their profiles until their transactions are sent
+	profiling profiling
+	profiles  *profiles
+	// True once the client is closed, after which events are dropped
+	closed atomic.Bool
+	// False if events are not reported from the environment of the process
+	reporting bool
 }
 
-func InitSentry(release string) (client *Sentry) {
-	dsn := env.String("SENTRY_DSN", "")             // Retrieve the Sentry DSN from environment variables
+func InitSentry(release string, opts ...Option) (client *Sentry) {
+	dsn := env.String("SENTRY_DSN", "") // Retrieve the Sentry DSN from environment variables
+	return initSentry(dsn, release, true, opts...)
+}
+
+// NewSentry returns a client of the DSN which is not bound to the global hub, eg. for the errors of a logical
+// service to be captured in its own project through a Router. It is configured from the same environment variables
+// as InitSentry, other than SENTRY_DSN.
+func NewSentry(dsn, release string, opts ...Option) *Sentry {
+	return initSentry(dsn, release, false, opts...)
+}
+
+// Initializes a client of the DSN, binding it to the global hub if global is true
+func initSentry(dsn, release string, global bool, opts ...Option) (client *Sentry) {
 	sampleRate := env.Float("SENTRY_SAMPLING", 1.0) // Retrieve the Sentry sampling rate from environment variables, defaulting to 1.0
 	if release == "" {
 		release = env.String("SENTRY_RELEASE", "") // Retrieve the Sentry release version from environment variables if not provided
@@ -31,34 +67,152 @@ func InitSentry(release string) (client *Sentry) {
 	// Parse SENTRY_TRACING environment variable using vcore/env to determine if tracing is enabled
 	enableTracing := env.Bool("SENTRY_TRACING", false)
 	tracesSampleRate := env.Float("SENTRY_TRACES_SAMPLE_RATE", 0.0)
+	// Rate at which the regions of sampled transactions are CPU profiled, and the duration of a region past which its
+	// profile is attached to its transaction
+	profilesSampleRate := env.Float("SENTRY_PROFILES_SAMPLE_RATE", 0.0)
+	profilesThreshold := env.Duration("SENTRY_PROFILES_THRESHOLD", time.Second)
+	// Time to wait for buffered events to be delivered on Close
+	flushTimeout := env.Duration("SENTRY_FLUSH_TIMEOUT", 2*time.Second)
+	// Redact PII from events before they are sent
+	scrub := env.Bool("SENTRY_SCRUB", true)
+	// Window within which identical errors are captured only once
+	dedupWindow := env.Duration("SENTRY_DEDUP_WINDOW", 0)
+	// Rules sampling the errors by their tags or types
+	samplingRules := env.String("SENTRY_SAMPLING_RULES", "")
+	// Rules grouping errors(eg. by their codes) into issues irrespective of their messages
+	fingerprintRules := env.String("SENTRY_FINGERPRINT_RULES", "")
+	// Headers/gRPC metadata keys identifying the user of a request
+	userIDKey := env.String("SENTRY_USER_ID_KEY", "")
+	userEmailKey := env.String("SENTRY_USER_EMAIL_KEY", "")
+	// Number of events buffered(and retried) while Sentry is unreachable, and the directory persisting them
+	bufferSize := env.Int("SENTRY_BUFFER_SIZE", 0)
+	bufferDir := env.String("SENTRY_BUFFER_DIR", "")
+	// Environments events are(or are not) reported from, eg. staging,production
+	reportEnvironments := env.String("SENTRY_REPORT_ENVIRONMENTS", "")
+	skipEnvironments := env.String("SENTRY_SKIP_ENVIRONMENTS", "")
+	environment := os.Getenv("ENVIRONMENT")
+	// Errors per minute past which the rate errors are sent at is reduced, and the lowest rate it is reduced to
+	adaptiveThreshold := env.Int("SENTRY_ADAPTIVE_THRESHOLD", 0)
+	adaptiveFloor := env.Float("SENTRY_ADAPTIVE_FLOOR", 0.01)
+	// Bytes of an extra(or breadcrumb data) past which it is truncated, and of an event past which breadcrumbs are
+	// dropped, as Sentry drops events over its limits
+	maxExtraSize := env.Int("SENTRY_MAX_EXTRA_SIZE", 16<<10)
+	maxEventSize := env.Int("SENTRY_MAX_EVENT_SIZE", 900<<10)
+	// Errors queued to be sent by a worker rather than on the goroutines capturing them(0 to send them synchronously),
+	// and what happens to the errors captured while the queue is full
+	queueSize := env.Int("SENTRY_CAPTURE_QUEUE_SIZE", 0)
+	dropPolicy, err := ParseDropPolicy(env.String("SENTRY_CAPTURE_DROP_POLICY", ""))
+	if err != nil {
+		log.Warnf("Dropping the newest errors while the capture queue is full: %s", err)
+	}
 
 	if dsn != "" {
-		if err := sentry.Init(sentry.ClientOptions{
+		client = &Sentry{
+			handler:      sentryWrapper.New(sentryhttp.Options{Repanic: true}),
+			flushTimeout: flushTimeout,
+			dedup:        newDeduplicator(dedupWindow),
+			sampler:      samplerFromEnv(samplingRules),
+			userKeys:     userKeys{id: userIDKey, email: userEmailKey},
+			bufferConfig: bufferConfig{size: bufferSize, dir: bufferDir},
+			environments: environments{
+				allow: parseEnvironments(reportEnvironments),
+				deny:  parseEnvironments(skipEnvironments),
+			},
+			adaptive:    newAdaptiveSampler(adaptiveThreshold, adaptiveFloor),
+			limits:      payloadLimits{extra: maxExtraSize, event: maxEventSize},
+			queueConfig: queueConfig{size: queueSize, policy: dropPolicy},
+			profiling:   profiling{rate: profilesSampleRate, threshold: profilesThreshold},
+			profiles:    &profiles{},
+
+			fingerprintRules: fingerprintRulesFromEnv(fingerprintRules),
+		}
+		for _, opt := range opts {
+			opt(client)
+		}
+		client.dedup.summarize = client.sendSummary
+		if client.clock != nil {
+			client.profiles.clock = client.clock
+			client.dedup.clock = client.clock
+			if client.adaptive != nil {
+				client.adaptive.clock = client.clock
+			}
+		}
+		if client.random != nil {
+			client.sampler.random = client.random
+			if client.adaptive != nil {
+				client.adaptive.random = client.random
+			}
+		}
+		client.reporting = client.environments.reports(environment)
+		if !client.reporting {
+			log.Infof("Not reporting to sentry from the environment %q, errors will only be logged", environment)
+		}
+		reporting := client.reporting
+		limits := client.limits
+		profiles := client.profiles
+
+		// Events are buffered and retried while Sentry is unreachable only if a buffer is configured
+		var transport sentry.Transport
+		var httpTransport http.RoundTripper
+		if client.bufferConfig.size > 0 {
+			client.buffer = newBufferedTransport(client.bufferConfig)
+			transport = client.buffer
+		} else {
+			// The default transport of the client drops the events it fails to deliver silently
+			httpTransport = failureCounter{http.DefaultTransport}
+		}
+
+		options := sentry.ClientOptions{
 			Dsn:              dsn,
 			AttachStacktrace: true,
 			EnableTracing:    enableTracing,
 			TracesSampleRate: tracesSampleRate,
 			// Use async transport. Which is set by default. Use Sync transport for testing.
 			//Transport: sentry.NewHTTPSyncTransport(),
+			Transport:     transport,
+			HTTPTransport: httpTransport,
 
 			// Enable debugging to check connectivity
 			//Debug: true,
 			Release:    release,
 			SampleRate: sampleRate,
 
-			Environment: os.Getenv("ENVIRONMENT"),
-		}); err != nil {
-			log.Warnf("Could not initialize sentry with DSN: %s", dsn)
-			client = &Sentry{nil, nil}
-		} else {
-			client = &Sentry{
-				sentry.CurrentHub().Client(),
-				sentryWrapper.New(sentryhttp.Options{Repanic: true}),
+			Environment: environment,
+
+			BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+				// Panics recovered by the handlers and interceptors are not admitted through Capture
+				if !reporting {
+					return nil
+				}
+				if scrub {
+					event = ScrubEvent(event)
+				}
+				return truncateEvent(trimFrames(event), limits)
+			},
+			BeforeSendTransaction: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+				if !reporting {
+					return nil
+				}
+				return profiles.attach(event)
+			},
+		}
+
+		if global {
+			if err = sentry.Init(options); err == nil {
+				client.client = sentry.CurrentHub().Client()
 			}
+		} else {
+			client.client, err = sentry.NewClient(options)
+		}
+		if err != nil {
+			log.Warnf("Could not initialize sentry with DSN: %s", dsn)
+			client = &Sentry{}
+		} else if client.queueConfig.size > 0 {
+			client.queue = newCaptureQueue(client.queueConfig)
 		}
 	} else {
 		log.Warnf("Could not initialize sentry with DSN: %s", dsn)
-		client = &Sentry{nil, nil}
+		client = &Sentry{}
 	}
 	return
 }
@@ -67,105 +221,169 @@ var (
 	SentryClient = InitSentry("")
 )
 
-// Handles an error by capturing it on Sentry and logging the same on STDOUT
-func (wrapper *Sentry) Capture(err error, _panic bool) sentry.EventID {
-	eventID := new(sentry.EventID)
-	if err != nil {
-		// Do not log to sentry if the error is ignorable.
-		// However, do log it to stdout
-		if wrapper.client != nil && !errors.Ignore(err) {
-			// Capture error asynchronously
-			sentry.WithScope(func(scope *sentry.Scope) {
-
-				// Setting the stacktrace of the error as an extra along with any other extras set in the error
-				if extras := errors.Extras(err); extras != nil {
-					scope.SetContext("extras", extras)
-
-					// setExtras is deprecated
-					// adding it for backward compatibility with vernacular's sentry
-					scope.SetExtras(extras)
-				}
+// Returns true if the error is reported from the environment, survives the sampling rules and is not a duplicate.
+// The adaptive sampling counts the errors which are not duplicates, whether or not they are sent.
+func (wrapper *Sentry) admit(err error) bool {
+	return wrapper.admission(err) == ""
+}
 
-				// Determining the tags(if any) set on the error
-				scope.SetTags(errors.Tags(err))
+// Returns the reason the error is not to be sent, empty if it is to be sent
+func (wrapper *Sentry) admission(err error) (reason string) {
+	switch {
+	case wrapper.client == nil:
+		// The errors of the services without sentry are not dropped, as they were never to be sent
+		return dropNotInitialized
+	case !wrapper.reporting:
+		reason = dropEnvironment
+	case !wrapper.sampler.sample(err):
+		reason = dropSamplingRule
+	case !wrapper.dedup.allow(err):
+		reason = dropDuplicate
+	case !wrapper.adaptive.sample():
+		reason = dropAdaptive
+	default:
+		return ""
+	}
 
-				// Capturing the error on Sentry
-				// eventID can be nil when sample rate is used
-				eventID = sentry.CaptureException(err)
-				if eventID != nil {
-					log.Errorf(err, "Error captured in sentry with the event ID `%s`", *eventID)
-				}
+	eventsDropped.WithLabelValues(reason).Inc()
+	return reason
+}
 
-				return
-			})
-		} else {
-			// Log the error sans sentry's event ID information
-			log.Error(err)
+// Captures the error on the hub within a scope carrying the extras, tags and fingerprint set on the error.
+// The scope can be configured further(eg. with attachments) before the error is captured.
+func (wrapper *Sentry) captureOnHub(hub *sentry.Hub, err error, configure ...func(scope *sentry.Scope)) (eventID *sentry.EventID) {
+	hub = wrapper.bind(hub)
+	hub.WithScope(func(scope *sentry.Scope) {
+		// Setting the stacktrace of the error as an extra along with any other extras set in the error
+		if extras := errors.TruncatedExtras(err); extras != nil {
+			scope.SetContext("extras", extras)
+
+			// setExtras is deprecated
+			// adding it for backward compatibility with vernacular's sentry
+			scope.SetExtras(extras)
 		}
 
-		if _panic {
-			panic(err)
+		// Determining the tags(if any) set on the error
+		scope.SetTags(errors.Tags(err))
+
+		// Grouping the error as per the fingerprint set on the error, or the rule matching it(if any)
+		if fingerprint := wrapper.fingerprint(err); fingerprint != nil {
+			scope.SetFingerprint(fingerprint)
+		}
+
+		// Leveling the event as per the severity of the error, so that alerts can tell degradations from outages
+		eventLevel := level(errors.Severity(err))
+		scope.SetLevel(eventLevel)
+
+		for _, f := range configure {
+			f(scope)
 		}
+
+		if wrapper.queue != nil {
+			eventID = wrapper.enqueue(hub, scope, err, eventLevel)
+			return
+		}
+		eventID = hub.CaptureException(err)
+		countCaptured(eventID, eventLevel)
+	})
+	return
+}
+
+// Returns the hub if it is bound to the client, or else a clone of it bound to the client. Clients not bound to the
+// global hub(see NewSentry) are never bound to a shared hub, as events captured concurrently on it would be sent to
+// the project of the client.
+func (wrapper *Sentry) bind(hub *sentry.Hub) *sentry.Hub {
+	if wrapper.client == nil || hub.Client() == wrapper.client {
+		return hub
 	}
+	hub = hub.Clone()
+	hub.BindClient(wrapper.client)
+	return hub
+}
 
-	if eventID != nil {
-		return *eventID
+// Returns the context carrying a hub of the request bound to the client: the hub of the context if it is bound to
+// the client, or else a clone of it(or of the global hub if there is none)
+func (wrapper *Sentry) requestHub(ctx context.Context) (context.Context, *sentry.Hub) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = wrapper.bind(sentry.CurrentHub().Clone())
+		return sentry.SetHubOnContext(ctx, hub), hub
+	}
+	if bound := wrapper.bind(hub); bound != hub {
+		return sentry.SetHubOnContext(ctx, bound), bound
 	}
+	return ctx, hub
+}
 
-	return ""
+// Maps the severity of an error to the level of its event
+func level(severity errors.SeverityLevel) sentry.Level {
+	switch severity {
+	case errors.Warning:
+		return sentry.LevelWarning
+	case errors.Critical:
+		return sentry.LevelFatal
+	default:
+		return sentry.LevelError
+	}
 }
 
 // Handles an error by capturing it on Sentry and logging the same on STDOUT
+func (wrapper *Sentry) Capture(err error, _panic bool) sentry.EventID {
+	eventID, _ := wrapper.handle(nil, sentry.CurrentHub(), err, _panic)
+	return eventID
+}
+
+// CaptureWithContext handles an error by capturing it on the hub of the context(the global hub if it has none) and
+// logging the same on STDOUT. Returns the ID of the event, empty if the error was not sent(see CaptureWithContextErr).
 func (wrapper *Sentry) CaptureWithContext(c context.Context, err error, _panic bool) sentry.EventID {
-	eventID := new(sentry.EventID)
-	if err != nil {
-		// Do not log to sentry if the error is ignorable.
-		// However, do log it to stdout
-		if wrapper.client != nil && !errors.Ignore(err) {
-			// Capture error asynchronously
-			if hub := sentry.GetHubFromContext(c); hub != nil {
-				sentry.WithScope(func(scope *sentry.Scope) {
-					// Setting the stacktrace of the error as an extra along with any other extras set in the error
-					if extras := errors.Extras(err); extras != nil {
-						scope.SetContext("extras", extras)
-
-						// setExtras is deprecated
-						// adding it for backward compatibility with vernacular's sentry
-						scope.SetExtras(extras)
-					}
-
-					// Determining the tags(if any) set on the error
-					scope.SetTags(errors.Tags(err))
-				})
-
-				// Capturing the error on Sentry
-				eventID = hub.CaptureException(err)
-				if eventID != nil {
-					log.Errorf(err, "Error captured in sentry with the event ID `%s`", *eventID)
-				}
-				// NOTE: logging nil events was causing logs to be cluttered with warning logs, hence skipping.
-			} else {
-				wrapper.Capture(err, _panic)
-			}
-		} else {
-			// Log the error sans sentry's event ID information
-			log.Error(err)
-		}
+	eventID, _ := wrapper.CaptureWithContextErr(c, err, _panic)
+	return eventID
+}
 
-		if _panic {
-			panic(err)
-		}
+// CaptureWithContextErr captures the error like CaptureWithContext, and returns why the error was not sent: a
+// *DropError(matching ErrDropped) for the errors ignored, sampled out or deduplicated, ErrNotInitialized if sentry
+// could not be initialized and ErrClosed once the client is closed. Events are delivered asynchronously, failures to
+// deliver them are logged and counted by the send_failures_total metric(see RegisterMetrics).
+func (wrapper *Sentry) CaptureWithContextErr(c context.Context, err error, _panic bool) (sentry.EventID, error) {
+	hub := sentry.GetHubFromContext(c)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	return wrapper.handle(c, hub, err, _panic)
+}
+
+func (wrapper *Sentry) handle(ctx context.Context, hub *sentry.Hub, err error, _panic bool, configure ...func(scope *sentry.Scope)) (sentry.EventID, error) {
+	if err == nil {
+		return "", nil
+	}
+	CountError(err)
+
+	// Do not log to sentry if the error is ignorable.
+	// However, do log it to stdout
+	eventID, captureErr := wrapper.capture(ctx, hub, err, configure...)
+	if captureErr == nil {
+		log.Errorf(err, "Error captured in sentry with the event ID `%s`", eventID)
+	} else {
+		// Log the error sans sentry's event ID information
+		log.Error(err)
 	}
 
-	return *eventID
+	if _panic {
+		panic(err)
+	}
+	return eventID, captureErr
 }
 
 // Wrapper over sentry-go/http#HandleFunc
 // Only calls the sentry handler if sentry was successfully initialized
 func (wrapper *Sentry) HandleFunc(handler http.HandlerFunc) http.HandlerFunc {
 	if wrapper.handler != nil {
-		// If the sentry handler was initialized, call it's HandleFunc function
-		return wrapper.handler.HandleFunc(handler)
+		// If the sentry handler was initialized, call it's HandleFunc function on a hub bound to the client
+		handle := wrapper.handler.HandleFunc(wrapper.withUser(handler))
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, _ := wrapper.requestHub(r.Context())
+			handle(w, r.WithContext(ctx))
+		}
 	} else {
 		// Simply return the handler in case the sentry handler was not initialized
 		return handler
@@ -176,8 +394,12 @@ func (wrapper *Sentry) HandleFunc(handler http.HandlerFunc) http.HandlerFunc {
 // Only calls the sentry handler if sentry was successfully initialized
 func (wrapper *Sentry) HandleHttpRouter(handler httprouter.Handle) httprouter.Handle {
 	if wrapper.handler != nil {
-		// If the sentry handler was initialized, call it's HandleFunc function
-		return wrapper.handler.HandleHttpRouter(handler)
+		// If the sentry handler was initialized, call it's HandleFunc function on a hub bound to the client
+		handle := wrapper.handler.HandleHttpRouter(wrapper.withUserHttpRouter(handler))
+		return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+			ctx, _ := wrapper.requestHub(r.Context())
+			handle(w, r.WithContext(ctx), params)
+		}
 	} else {
 		// Simply return the handler in case the sentry handler was not initialized
 		return handler
@@ -194,8 +416,10 @@ func (wrapper *Sentry) SentryMiddleware(next http.Handler) http.Handler {
 
 // UnaryServerInterceptor is a grpc interceptor that reports errors and panics
 // to sentry. It also sets *sentry.Hub to context.
-func (wrapper *Sentry) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
-	opts := sentryWrapper.BuildOptions(sentryWrapper.WithRepanic(false))
+// The errors reported can be tuned with sentryWrapper.WithReportOn(eg. sentryWrapper.SkipCodes(codes.NotFound)),
+// all but cancellations are by default. Errors to be ignored(see errors.Ignore) are never reported.
+func (wrapper *Sentry) UnaryServerInterceptor(opts ...sentryWrapper.Option) grpc.UnaryServerInterceptor {
+	options := sentryWrapper.BuildOptions(append([]sentryWrapper.Option{sentryWrapper.WithRepanic(false)}, opts...)...)
 
 	return func(
 		ctx context.Context,
@@ -203,17 +427,23 @@ func (wrapper *Sentry) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (resp interface{}, err error) {
-		hub := sentry.GetHubFromContext(ctx)
-		if hub == nil {
-			hub = sentry.CurrentHub().Clone()
-			ctx = sentry.SetHubOnContext(ctx, hub)
-		}
+		ctx, hub := wrapper.requestHub(ctx)
+		wrapper.setUserFromMetadata(ctx, hub)
+
+		transaction := startRPCTransaction(ctx, info.FullMethod)
+		ctx = transaction.Context()
+		// Deferred before recovering, so that the transaction finishes with the status of a recovered panic
+		defer func() {
+			finishRPCSpan(transaction, status.Code(err))
+		}()
 
 		defer func() {
 			if r := recover(); r != nil {
-				hub.RecoverWithContext(ctx, r)
+				panicErr := errors.FromPanic(r)
+				CountError(panicErr)
+				wrapper.captureOnHub(hub, panicErr)
 
-				if opts.Repanic {
+				if options.Repanic {
 					panic(r)
 				}
 
@@ -222,9 +452,10 @@ func (wrapper *Sentry) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 		}()
 
 		resp, err = handler(ctx, req)
+		CountError(err)
 
-		if opts.ReportOn(err) {
-			hub.CaptureException(err)
+		if options.ReportOn(err) && !wrapper.ignored(err) && wrapper.admit(err) {
+			wrapper.captureOnHub(hub, err)
 		}
 
 		return resp, err
@@ -233,40 +464,47 @@ func (wrapper *Sentry) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 
 // StreamServerInterceptor returns a grpc interceptor that reports errors and panics
 // to sentry. It also sets *sentry.Hub to context.
-func (wrapper *Sentry) StreamServerInterceptor() grpc.StreamServerInterceptor {
-	opts := sentryWrapper.BuildOptions(sentryWrapper.WithRepanic(false))
+// The errors reported can be tuned as with UnaryServerInterceptor.
+func (wrapper *Sentry) StreamServerInterceptor(opts ...sentryWrapper.Option) grpc.StreamServerInterceptor {
+	options := sentryWrapper.BuildOptions(append([]sentryWrapper.Option{sentryWrapper.WithRepanic(false)}, opts...)...)
 
 	return func(
 		srv interface{},
 		stream grpc.ServerStream,
 		info *grpc.StreamServerInfo,
 		handler grpc.StreamHandler,
-	) error {
-		ctx := stream.Context()
-		hub := sentry.GetHubFromContext(ctx)
-		if hub == nil {
-			hub = sentry.CurrentHub().Clone()
-			ctx = sentry.SetHubOnContext(ctx, hub)
-		}
+	) (err error) {
+		ctx, hub := wrapper.requestHub(stream.Context())
+		wrapper.setUserFromMetadata(ctx, hub)
+
+		transaction := startRPCTransaction(ctx, info.FullMethod)
+		ctx = transaction.Context()
+		// Deferred before recovering, so that the transaction finishes with the status of a recovered panic
+		defer func() {
+			finishRPCSpan(transaction, status.Code(err))
+		}()
 
 		defer func() {
 			if r := recover(); r != nil {
-				hub.RecoverWithContext(ctx, r)
+				panicErr := errors.FromPanic(r)
+				CountError(panicErr)
+				wrapper.captureOnHub(hub, panicErr)
 
-				if opts.Repanic {
+				// The client is to see the panic as an error, as with unary calls
+				err = status.Errorf(codes.Internal, "%s", r)
+				if options.Repanic {
 					panic(r)
 				}
-
-				_ = status.Errorf(codes.Internal, "%s", r)
 			}
 		}()
 
 		wrapped := sentryWrapper.WrapServerStream(stream)
 		wrapped.WrappedContext = ctx
-		err := handler(srv, wrapped)
+		err = handler(srv, wrapped)
+		CountError(err)
 
-		if opts.ReportOn(err) {
-			hub.CaptureException(err)
+		if options.ReportOn(err) && !wrapper.ignored(err) && wrapper.admit(err) {
+			wrapper.captureOnHub(hub, err)
 		}
 
 		return err
@@ -0,0 +1,76 @@
+package surveillance
+
+import (
+	"context"
+	"time"
+)
+
+// Flush waits until the buffered events are sent to Sentry or the timeout is reached.
+// Returns false if the timeout was reached before all the events could be delivered.
+func (wrapper *Sentry) Flush(timeout time.Duration) bool {
+	if wrapper.client == nil {
+		return true
+	}
+
+	start := time.Now()
+	if wrapper.queue != nil && !wrapper.queue.flush(timeout) {
+		return false
+	}
+	return wrapper.client.Flush(timeout - time.Since(start))
+}
+
+// Close flushes the buffered events(waiting for at most SENTRY_FLUSH_TIMEOUT) and shuts down the transport.
+// Events captured after Close are dropped, and closing the client again(or once shut down) is a no-op. Meant to be
+// deferred in main or called from a SIGTERM handler:
+//
+//	defer surveillance.SentryClient.Close()
+func (wrapper *Sentry) Close() {
+	// The transport of the client panics if it is closed twice
+	if wrapper.client == nil || !wrapper.closed.CompareAndSwap(false, true) {
+		return
+	}
+
+	if wrapper.queue != nil {
+		wrapper.queue.close()
+	}
+	if !wrapper.Flush(wrapper.flushTimeout) {
+		sendFailures.WithLabelValues("flush_timeout").Inc()
+		log.Warnf("Timed out after %s while flushing events to sentry", wrapper.flushTimeout)
+	}
+	wrapper.client.Close()
+}
+
+// Shutdown flushes the buffered events until the context is done and shuts down the transport.
+// It has the same signature as the shutdown function returned by instruments.InitProvider so that both
+// can be registered with the same shutdown routine. Shutting down a client which is closed already is a no-op.
+func (wrapper *Sentry) Shutdown(ctx context.Context) error {
+	if wrapper.client == nil || !wrapper.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	timeout := wrapper.flushTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	if wrapper.queue != nil {
+		wrapper.queue.close()
+	}
+	flushed := wrapper.Flush(timeout)
+	wrapper.client.Close()
+	if !flushed {
+		sendFailures.WithLabelValues("flush_timeout").Inc()
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// Flush waits for the events buffered by the default sentry client to be delivered
+func Flush(timeout time.Duration) bool {
+	return SentryClient.Flush(timeout)
+}
+
+// Close flushes and shuts down the default sentry client
+func Close() {
+	SentryClient.Close()
+}
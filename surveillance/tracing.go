@@ -0,0 +1,48 @@
+package surveillance
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// StartTransaction starts a transaction(eg. for a job or a message consumed) on the hub of the context.
+// Use the context of the returned span for the spans within the transaction, and call Finish once it is done.
+// Transactions are sent only if tracing is enabled(SENTRY_TRACING) and as per SENTRY_TRACES_SAMPLE_RATE.
+func StartTransaction(ctx context.Context, name, op string, opts ...sentry.SpanOption) *sentry.Span {
+	ctx, _ = withHub(ctx)
+	return sentry.StartTransaction(ctx, name, append([]sentry.SpanOption{sentry.WithOpName(op)}, opts...)...)
+}
+
+// StartSpan starts a span(eg. for a database query) within the transaction of the context.
+// Starts a new transaction if the context is not part of one.
+func StartSpan(ctx context.Context, op string, opts ...sentry.SpanOption) *sentry.Span {
+	return sentry.StartSpan(ctx, op, opts...)
+}
+
+// Starts the transaction of an RPC, continuing the trace of the client from the sentry-trace and baggage metadata
+func startRPCTransaction(ctx context.Context, method string) *sentry.Span {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	return sentry.StartTransaction(ctx, method,
+		sentry.WithOpName("grpc.server"),
+		sentry.WithTransactionSource(sentry.SourceRoute),
+		sentry.ContinueFromHeaders(first(sentry.SentryTraceHeader), first(sentry.SentryBaggageHeader)),
+	)
+}
+
+// Finishes the span(or transaction) of an RPC with its status code
+func finishRPCSpan(span *sentry.Span, code codes.Code) {
+	// Span statuses are ordered as gRPC codes, offset by the undefined status
+	span.Status = sentry.SpanStatus(code + 1)
+	span.SetTag("rpc.grpc.status_code", code.String())
+	span.Finish()
+}
@@ -0,0 +1,87 @@
+package surveillance
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// roundTripper records the requests made through it on the hub of their context
+type roundTripper struct {
+	wrapper *Sentry
+	next    http.RoundTripper
+}
+
+// WrapTransport returns a round tripper recording each outbound request as a breadcrumb on the hub of the request's
+// context. When tracing is enabled, it also creates child spans(within transactions) for the requests and continues
+// the trace on the server through the sentry-trace and baggage headers. Defaults to http.DefaultTransport if rt is nil.
+//
+//	client := &http.Client{Transport: surveillance.WrapTransport(nil)}
+func (wrapper *Sentry) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &roundTripper{wrapper: wrapper, next: rt}
+}
+
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	hub := hubFromContext(ctx)
+
+	// URL sans the query string, fragment and userinfo, which may carry PII
+	target := *req.URL
+	target.RawQuery, target.Fragment, target.User = "", "", nil
+
+	var span *sentry.Span
+	if client := hub.Client(); client != nil && client.Options().EnableTracing {
+		traceparent, baggage := hub.GetTraceparent(), hub.GetBaggage()
+		if sentry.SpanFromContext(ctx) != nil {
+			span = sentry.StartSpan(ctx, "http.client", sentry.WithDescription(req.Method+" "+target.String()))
+			ctx = span.Context()
+			traceparent, baggage = span.ToSentryTrace(), span.ToBaggage()
+		}
+
+		// Round trippers must not modify the request
+		req = req.Clone(ctx)
+		if traceparent != "" {
+			req.Header.Set(sentry.SentryTraceHeader, traceparent)
+		}
+		if baggage != "" {
+			req.Header.Set(sentry.SentryBaggageHeader, baggage)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+
+	if err != nil {
+		if span != nil {
+			span.Status = sentry.SpanStatusInternalError
+			span.Finish()
+		}
+		// The errors of the clients(*url.Error) carry the full URL of the request
+		cause := err
+		for urlErr, ok := cause.(*url.Error); ok; urlErr, ok = cause.(*url.Error) {
+			cause = urlErr.Err
+		}
+		t.wrapper.AddBreadcrumbWithLevel(ctx, sentry.LevelError, BreadcrumbHTTP, fmt.Sprintf("%s %s: %s", req.Method, target.String(), cause), map[string]interface{}{
+			"method": req.Method,
+			"url":    target.String(),
+		})
+		return resp, err
+	}
+
+	if span != nil {
+		span.Status = sentry.HTTPtoSpanStatus(resp.StatusCode)
+		span.SetData("http.response.status_code", resp.StatusCode)
+		span.Finish()
+	}
+	t.wrapper.AddHTTPBreadcrumb(ctx, req.Method, target.String(), resp.StatusCode)
+	return resp, nil
+}
+
+// WrapTransport wraps the round tripper using the default sentry client
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return SentryClient.WrapTransport(rt)
+}
@@ -0,0 +1,134 @@
+package surveillance
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Tags set on the events truncated to fit the payload limits
+const (
+	TagTruncated    = "payload.truncated"
+	TagOriginalSize = "payload.original_size"
+)
+
+// Limits of the size of the events sent, past which Sentry drops them
+type payloadLimits struct {
+	// Bytes of the JSON of an extra(or breadcrumb data, or request body) past which it is summarized
+	extra int
+	// Bytes of the JSON of an event past which its oldest breadcrumbs are dropped
+	event int
+}
+
+// Truncates the extras, breadcrumb data and request body over the limit and drops the oldest breadcrumbs of events
+// still over the limit, tagging the event with its original size if it was truncated
+func truncateEvent(event *sentry.Event, limits payloadLimits) *sentry.Event {
+	if event == nil || limits.extra <= 0 {
+		return event
+	}
+
+	original := encodedSize(event)
+	truncated := false
+	if extra, ok := truncateValues(event.Extra, limits.extra); ok {
+		event.Extra = extra
+		truncated = true
+	}
+	for name, context := range event.Contexts {
+		if values, ok := truncateValues(context, limits.extra); ok {
+			// Contexts are shared with the scope, and are not to be modified in place
+			contexts := make(map[string]sentry.Context, len(event.Contexts))
+			for k, v := range event.Contexts {
+				contexts[k] = v
+			}
+			contexts[name] = values
+			event.Contexts = contexts
+			truncated = true
+		}
+	}
+	for i, breadcrumb := range event.Breadcrumbs {
+		if data, ok := truncateValues(breadcrumb.Data, limits.extra); ok {
+			copied := *breadcrumb
+			copied.Data = data
+			event.Breadcrumbs[i] = &copied
+			truncated = true
+		}
+	}
+	if event.Request != nil && len(event.Request.Data) > limits.extra {
+		event.Request.Data = cut(event.Request.Data, limits.extra, len(event.Request.Data))
+		truncated = true
+	}
+
+	// Halving the breadcrumbs(the oldest first) until the event fits
+	if limits.event > 0 {
+		for len(event.Breadcrumbs) > 0 && encodedSize(event) > limits.event {
+			event.Breadcrumbs = event.Breadcrumbs[len(event.Breadcrumbs)/2+len(event.Breadcrumbs)%2:]
+			truncated = true
+		}
+	}
+
+	if truncated {
+		if event.Tags == nil {
+			event.Tags = make(map[string]string)
+		}
+		event.Tags[TagTruncated] = "true"
+		event.Tags[TagOriginalSize] = strconv.Itoa(original)
+	}
+	return event
+}
+
+func encodedSize(event *sentry.Event) int {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// Returns a copy of the values with those over the limit summarized, and false if none are over the limit. The values
+// are not modified, as they are shared with the error(or scope) they were set on.
+func truncateValues(values map[string]interface{}, limit int) (map[string]interface{}, bool) {
+	var truncated map[string]interface{}
+	for key, value := range values {
+		if summary, ok := summarize(value, limit); ok {
+			if truncated == nil {
+				truncated = make(map[string]interface{}, len(values))
+				for k, v := range values {
+					truncated[k] = v
+				}
+			}
+			truncated[key] = summary
+		}
+	}
+	return truncated, truncated != nil
+}
+
+// Returns the summary of the value if its JSON is over the limit
+func summarize(value interface{}, limit int) (string, bool) {
+	if s, ok := value.(string); ok {
+		if len(s) <= limit {
+			return "", false
+		}
+		return cut(s, limit, len(s)), true
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil || len(data) <= limit {
+		return "", false
+	}
+	return cut(string(data), limit, len(data)), true
+}
+
+// Returns the prefix of the value within the limit, noting the size of the value
+func cut(value string, limit, size int) string {
+	suffix := fmt.Sprintf("...[truncated, %d bytes]", size)
+	if keep := limit - len(suffix); keep > 0 {
+		value = value[:keep]
+	} else {
+		value = ""
+	}
+	// The cut might split a multi-byte character
+	return strings.ToValidUTF8(value, "") + suffix
+}
@@ -0,0 +1,93 @@
+package surveillance
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/julienschmidt/httprouter"
+	"github.com/skit-ai/vcore/ctxkeys"
+	"google.golang.org/grpc/metadata"
+)
+
+// userKeys are the HTTP headers/gRPC metadata keys identifying the caller of a request
+type userKeys struct {
+	id    string
+	email string
+}
+
+// SetUser attaches the user affected by the request to the context and to the scope of the context's hub,
+// so that events captured with the context identify the user
+func SetUser(ctx context.Context, id, email string, extras map[string]string) context.Context {
+	user := sentry.User{ID: id, Email: email, Data: extras}
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		hub.Scope().SetUser(user)
+	}
+	return ctxkeys.Principal.With(ctx, ctxkeys.Identity{ID: id, Email: email, Extras: extras})
+}
+
+// UserFromContext returns the user set on the context by SetUser(or as the ctxkeys.Principal)
+func UserFromContext(ctx context.Context) (sentry.User, bool) {
+	principal, ok := ctxkeys.Principal.Get(ctx)
+	if !ok {
+		return sentry.User{}, false
+	}
+	return sentry.User{ID: principal.ID, Email: principal.Email, Data: principal.Extras}, true
+}
+
+// Sets the user of the request on the hub from the context, falling back to the configured headers
+func (wrapper *Sentry) setUserFromRequest(hub *sentry.Hub, r *http.Request) {
+	user, ok := UserFromContext(r.Context())
+	if !ok {
+		user, ok = wrapper.userKeys.fromLookup(r.Header.Get)
+	}
+	if ok {
+		hub.Scope().SetUser(user)
+	}
+}
+
+// Sets the user of the call on the hub from the context, falling back to the configured metadata keys
+func (wrapper *Sentry) setUserFromMetadata(ctx context.Context, hub *sentry.Hub) {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		md, _ := metadata.FromIncomingContext(ctx)
+		user, ok = wrapper.userKeys.fromLookup(func(key string) string {
+			if values := md.Get(key); len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		})
+	}
+	if ok {
+		hub.Scope().SetUser(user)
+	}
+}
+
+func (k userKeys) fromLookup(lookup func(key string) string) (user sentry.User, ok bool) {
+	if k.id != "" {
+		user.ID = lookup(k.id)
+	}
+	if k.email != "" {
+		user.Email = lookup(k.email)
+	}
+	return user, user.ID != "" || user.Email != ""
+}
+
+// Runs within the sentry handler, once the hub has been set on the request's context
+func (wrapper *Sentry) withUser(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if hub := sentry.GetHubFromContext(r.Context()); hub != nil {
+			wrapper.setUserFromRequest(hub, r)
+		}
+		handler(w, r)
+	}
+}
+
+func (wrapper *Sentry) withUserHttpRouter(handler httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		if hub := sentry.GetHubFromContext(r.Context()); hub != nil {
+			wrapper.setUserFromRequest(hub, r)
+		}
+		handler(w, r, params)
+	}
+}
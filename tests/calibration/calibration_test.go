@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/skit-ai/vcore/calibration"
+)
+
+const config = `
+models:
+  slu-v3:
+    default:
+      type: platt
+      a: -6
+      b: 3
+    intents:
+      book_appointment:
+        type: piecewise
+        points: [{raw: 1, calibrated: 0.95}, {raw: 0, calibrated: 0}, {raw: 0.6, calibrated: 0.4}]
+`
+
+func TestCalibrator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calibration.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	calibrator, err := calibration.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The points are sorted and interpolated
+	if calibrated := calibrator.Calibrate("slu-v3", "book_appointment", 0.8); math.Abs(calibrated-0.675) > 1e-9 {
+		t.Errorf("Expected the piecewise curve of the intent to be interpolated, got %f", calibrated)
+	}
+	if calibrated := calibrator.Calibrate("slu-v3", "book_appointment", 1.5); calibrated != 0.95 {
+		t.Errorf("Expected confidences past the last point to be mapped to it, got %f", calibrated)
+	}
+	// The other intents fall back to the curve of the model
+	if calibrated := calibrator.Calibrate("slu-v3", "cancel", 0.5); calibrated != 0.5 {
+		t.Errorf("Expected the platt curve of the model, got %f", calibrated)
+	}
+	if calibrated := calibrator.Calibrate("slu-v4", "cancel", 1.2); calibrated != 1 {
+		t.Errorf("Expected the unknown models to be left uncalibrated(but clamped), got %f", calibrated)
+	}
+
+	// The curves are retained when the config reloaded is invalid
+	if err := calibrator.Reload(calibration.Config{Models: map[string]calibration.ModelConfig{
+		"slu-v3": {Default: calibration.CurveConfig{Type: "piecewise", Points: []calibration.Point{{Raw: 0}}}},
+	}}); err == nil {
+		t.Fatal("Expected a piecewise curve with a single point to be invalid")
+	}
+	if calibrated := calibrator.Calibrate("slu-v3", "cancel", 0.5); calibrated != 0.5 {
+		t.Errorf("Expected the curves to be retained, got %f", calibrated)
+	}
+	if _, err := (calibration.CurveConfig{Type: "spline"}).Build(); err == nil {
+		t.Error("Expected an unknown curve type to be invalid")
+	}
+}
+
+func TestPolicyHysteresis(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(calibration.Collectors()...)
+	policy := calibration.NewPolicy("slu-v3", calibration.Thresholds{Accept: 0.8, Reject: 0.4, Hysteresis: 0.1})
+
+	decisions := []struct {
+		confidence float64
+		decision   calibration.Decision
+	}{
+		{0.85, calibration.Accept},
+		// Stays accepted until below 0.7
+		{0.75, calibration.Accept},
+		{0.65, calibration.Confirm},
+		{0.35, calibration.Reject},
+		// Stays rejected until 0.5
+		{0.45, calibration.Reject},
+		{0.5, calibration.Confirm},
+	}
+	for _, d := range decisions {
+		if decision := policy.DecideFor("c-1", "book_appointment", d.confidence); decision != d.decision {
+			t.Errorf("Expected %s for %.2f, got %s", d.decision, d.confidence, decision)
+		}
+	}
+	// Without hysteresis
+	if decision := policy.Decide("book_appointment", 0.75); decision != calibration.Confirm {
+		t.Errorf("Expected %s without hysteresis, got %s", calibration.Confirm, decision)
+	}
+
+	stats := policy.Stats().Snapshot()["book_appointment"]
+	if stats.Decisions[calibration.Accept] != 2 || stats.Decisions[calibration.Confirm] != 3 || stats.Decisions[calibration.Reject] != 2 {
+		t.Errorf("Expected the decisions to be counted, got %v", stats.Decisions)
+	}
+	if stats.Confidences[8] != 1 || stats.Confidences[7] != 2 {
+		t.Errorf("Expected the confidences to be bucketed, got %v", stats.Confidences)
+	}
+	policy.Stats().Reset()
+	if snapshot := policy.Stats().Snapshot(); len(snapshot) != 0 {
+		t.Errorf("Expected the stats to be reset, got %v", snapshot)
+	}
+
+	// The metrics are not reset with the stats
+	expected := `
+		# HELP vcore_calibration_decisions_total Decisions taken by the policies on calibrated confidences, by intent, model and decision
+		# TYPE vcore_calibration_decisions_total counter
+		vcore_calibration_decisions_total{decision="accept",intent="book_appointment",model="slu-v3"} 2
+		vcore_calibration_decisions_total{decision="confirm",intent="book_appointment",model="slu-v3"} 3
+		vcore_calibration_decisions_total{decision="reject",intent="book_appointment",model="slu-v3"} 2
+	`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "vcore_calibration_decisions_total"); err != nil {
+		t.Error(err)
+	}
+}
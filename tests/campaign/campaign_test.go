@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/campaign"
+)
+
+func TestChunk(t *testing.T) {
+	var contacts []campaign.Contact
+	for i := 0; i < 5; i++ {
+		contacts = append(contacts, campaign.Contact{ID: fmt.Sprintf("c-%d", i)})
+	}
+
+	chunks := campaign.Chunk(contacts, 2)
+	if len(chunks) != 3 || len(chunks[2]) != 1 || chunks[1][0].ID != "c-2" {
+		t.Errorf("Expected 3 chunks in order, got %v", chunks)
+	}
+	if chunks := campaign.Chunk(contacts, 0); len(chunks) != 1 || len(chunks[0]) != 5 {
+		t.Errorf("Expected a single chunk without a size, got %v", chunks)
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	quiet, err := campaign.NewQuietHours("21:00", "09:00", ist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !quiet.Contains(time.Date(2024, 1, 1, 22, 0, 0, 0, ist)) || !quiet.Contains(time.Date(2024, 1, 1, 8, 59, 0, 0, ist)) {
+		t.Error("Expected the quiet hours to cross midnight")
+	}
+	// 10:00 in UTC is 15:30 in IST
+	if quiet.Contains(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Error("Expected the quiet hours to be in their location")
+	}
+	if next := quiet.NextAllowed(time.Date(2024, 1, 1, 22, 0, 0, 0, ist)); !next.Equal(time.Date(2024, 1, 2, 9, 0, 0, 0, ist)) {
+		t.Errorf("Expected the next call to be allowed at 09:00 of the next day, got %s", next)
+	}
+	if _, err := campaign.NewQuietHours("9pm", "09:00", ist); err == nil {
+		t.Error("Expected an error for an invalid time")
+	}
+}
+
+func TestPacer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	pacer := campaign.NewPacer(campaign.Slot{Start: start, End: start.Add(time.Hour), Target: 60})
+
+	if allowance := pacer.Allowance(start); allowance != 1 {
+		t.Fatalf("Expected the first call to be allowed at the start of the slot, got %d", allowance)
+	}
+	pacer.Record(start, 1)
+	if allowance := pacer.Allowance(start.Add(30 * time.Second)); allowance != 0 {
+		t.Errorf("Expected no burst at the start of the slot, got %d", allowance)
+	}
+	// Half way through the slot, the calls due are allowed
+	if allowance := pacer.Allowance(start.Add(30 * time.Minute)); allowance != 30 {
+		t.Errorf("Expected 30 calls to be allowed half way through, got %d", allowance)
+	}
+	pacer.Record(start.Add(30*time.Minute), 30)
+	if allowance := pacer.Allowance(start.Add(59*time.Minute + 59*time.Second)); allowance != 29 {
+		t.Errorf("Expected the rest of the target to be allowed by the end of the slot, got %d", allowance)
+	}
+	if allowance := pacer.Allowance(start.Add(time.Hour)); allowance != 0 {
+		t.Errorf("Expected no calls outside the slots, got %d", allowance)
+	}
+	if dispatched := pacer.Dispatched(); dispatched[0] != 31 {
+		t.Errorf("Expected 31 calls to be dispatched, got %v", dispatched)
+	}
+}
+
+func TestRetryQueue(t *testing.T) {
+	now := time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC)
+	quiet, _ := campaign.NewQuietHours("21:00", "09:00", time.UTC)
+	queue := campaign.NewRetryQueue(campaign.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     []time.Duration{time.Hour, 4 * time.Hour},
+		QuietHours:  quiet,
+	})
+	contact := campaign.Contact{ID: "c-1", Phone: "+919876543210"}
+
+	if !queue.Schedule(contact, "no_answer", now) {
+		t.Fatal("Expected the contact to be retried")
+	}
+	if due := queue.Due(now.Add(59*time.Minute), 0); len(due) != 0 {
+		t.Errorf("Expected the retry not to be due before its backoff, got %v", due)
+	}
+	due := queue.Due(now.Add(time.Hour), 0)
+	if len(due) != 1 || due[0].Attempts != 1 || due[0].LastOutcome != "no_answer" {
+		t.Fatalf("Expected the retry to be due after its backoff, got %v", due)
+	}
+
+	// The second backoff ends within the quiet hours, the retry is pushed to their end
+	if !queue.Schedule(contact, "busy", now.Add(time.Hour)) {
+		t.Fatal("Expected the contact to be retried")
+	}
+	if due := queue.Due(now.Add(5*time.Hour), 0); len(due) != 0 {
+		t.Errorf("Expected nothing to be due during quiet hours, got %v", due)
+	}
+	morning := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	if due := queue.Due(morning, 0); len(due) != 1 || due[0].Attempts != 2 {
+		t.Errorf("Expected the retry to be due once the quiet hours end, got %v", due)
+	}
+
+	// The contact has exhausted its attempts
+	if queue.Schedule(contact, "no_answer", morning) || queue.Len() != 0 {
+		t.Error("Expected the contact not to be retried after 3 attempts")
+	}
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/crypto"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+func keyring(t *testing.T, opts ...crypto.KeyringOption) *crypto.Keyring {
+	keys, err := crypto.ParseKeys("2024-01:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=@2024-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := crypto.NewKeyring(keys, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring
+}
+
+func TestKeyringSigning(t *testing.T) {
+	ring := keyring(t)
+	body := []byte(`{"event":"call.ended"}`)
+	oldID, oldSignature := ring.Sign(body)
+
+	if err := ring.Add(crypto.Key{ID: "2024-04", Secret: bytes.Repeat([]byte("k"), 32)}); err != nil {
+		t.Fatal(err)
+	}
+	id, signature := ring.Sign(body)
+	if id != "2024-04" || bytes.Equal(signature, oldSignature) {
+		t.Errorf("Expected the latest key to sign, got %s", id)
+	}
+	if !ring.Verify(oldID, body, oldSignature) || !ring.Verify("", body, oldSignature) || !ring.Verify(id, body, signature) {
+		t.Error("Expected the signatures of every key to be accepted")
+	}
+	if ring.Verify(id, body, oldSignature) || ring.Verify(id, []byte("tampered"), signature) {
+		t.Error("Expected the signatures of another key or message to be rejected")
+	}
+
+	if err := ring.Retire(oldID); err != nil {
+		t.Fatal(err)
+	}
+	if ring.Verify(oldID, body, oldSignature) {
+		t.Error("Expected the signatures of a retired key to be rejected")
+	}
+	if ring.Retire(id) == nil {
+		t.Error("Expected the latest key not to be retired")
+	}
+	if ring.Add(crypto.Key{ID: id, Secret: []byte("again")}) == nil {
+		t.Error("Expected a duplicate ID to be rejected")
+	}
+}
+
+func TestKeyringEncryption(t *testing.T) {
+	ring := keyring(t)
+	state := map[string][]byte{}
+	for _, id := range []string{"call-1", "call-2"} {
+		encrypted, err := ring.Encrypt([]byte("caller " + id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		state[id] = encrypted
+	}
+
+	if err := ring.Add(crypto.Key{ID: "2024-04", Secret: bytes.Repeat([]byte("k"), 32)}); err != nil {
+		t.Fatal(err)
+	}
+	fresh, _ := ring.Encrypt([]byte("caller call-3"))
+	state["call-3"] = fresh
+
+	migrated, err := ring.Reencrypt(context.Background(), maps.All(maps.Clone(state)), func(ctx context.Context, id string, value []byte) error {
+		state[id] = value
+		return nil
+	})
+	if err != nil || migrated != 2 {
+		t.Fatalf("Expected the values of the old key to be re-encrypted, got %d %v", migrated, err)
+	}
+	if err := ring.Retire("2024-01"); err != nil {
+		t.Fatal(err)
+	}
+	for id, value := range state {
+		if keyID, _ := crypto.EncryptedWith(value); keyID != "2024-04" {
+			t.Errorf("Expected %s to be encrypted with the latest key, got %s", id, keyID)
+		}
+		if decrypted, err := ring.Decrypt(value); err != nil || string(decrypted) != "caller "+id {
+			t.Errorf("Expected %s to decrypt, got %q %v", id, decrypted, err)
+		}
+	}
+
+	if _, err := ring.Decrypt([]byte{3, 'a'}); err == nil {
+		t.Error("Expected truncated data to be rejected")
+	}
+}
+
+func TestRotationReminders(t *testing.T) {
+	sim := simulation.New(42, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC))
+	ring := keyring(t, crypto.WithClock(sim))
+	if ring.Due(90 * 24 * time.Hour) {
+		t.Error("Expected a key younger than the age not to be due")
+	}
+
+	reminders := make(chan crypto.Key, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ring.RemindRotation(ctx, 90*24*time.Hour, 24*time.Hour, func(latest crypto.Key) {
+		reminders <- latest
+	})
+
+	// Advancing a day at a time, once the reminder is waiting for the next interval
+	for day := 0; day < 30; day++ {
+		for sim.Pending() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		select {
+		case latest := <-reminders:
+			if latest.ID != "2024-01" {
+				t.Errorf("Expected a reminder of the latest key, got %s", latest.ID)
+			}
+			if due := sim.Now(); due.Before(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("Expected no reminder before the key is due, got one on %s", due)
+			}
+			return
+		default:
+		}
+		sim.Advance(24 * time.Hour)
+	}
+	t.Error("Expected a reminder once the key is due")
+}
@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/ctxkeys"
+)
+
+func TestCarrier(t *testing.T) {
+	ctx := ctxkeys.RequestID.With(context.Background(), "req-1")
+	ctx = ctxkeys.Tenant.With(ctx, "acme")
+	ctx = ctxkeys.Principal.With(ctx, ctxkeys.Identity{ID: "user-1", Email: "user@example.com"})
+	ctx = ctxkeys.WithHub(ctx, sentry.CurrentHub().Clone())
+
+	// The carrier survives a job payload
+	payload, err := json.Marshal(ctxkeys.Inject(ctx))
+	if err != nil {
+		t.Fatalf("Could not marshal the carrier: %s", err)
+	}
+	var carrier ctxkeys.Carrier
+	if err := json.Unmarshal(payload, &carrier); err != nil {
+		t.Fatalf("Could not unmarshal the carrier: %s", err)
+	}
+
+	restored := ctxkeys.Extract(context.Background(), carrier)
+	if ctxkeys.RequestID.Value(restored) != "req-1" || ctxkeys.Tenant.Value(restored) != "acme" {
+		t.Errorf("Expected the request ID and tenant to be restored, got %v", ctxkeys.Snapshot(restored))
+	}
+	if principal := ctxkeys.Principal.Value(restored); principal.ID != "user-1" || principal.Email != "user@example.com" {
+		t.Errorf("Expected the principal to be restored, got %+v", principal)
+	}
+
+	// Same trace ID(the first 32 characters of sentry-trace), the span being the hop's own
+	hub := ctxkeys.Hub(restored)
+	if hub == nil || hub.GetTraceparent()[:32] != ctxkeys.Hub(ctx).GetTraceparent()[:32] {
+		t.Errorf("Expected the sentry trace to be continued")
+	}
+}
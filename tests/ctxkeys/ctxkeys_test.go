@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/ctxkeys"
+)
+
+func TestKeys(t *testing.T) {
+	ctx := ctxkeys.RequestID.With(context.Background(), "req-1")
+	ctx = ctxkeys.Principal.With(ctx, ctxkeys.Identity{ID: "user-1"})
+	ctx = ctxkeys.WithHub(ctx, sentry.CurrentHub())
+
+	if id, ok := ctxkeys.RequestID.Get(ctx); !ok || id != "req-1" {
+		t.Errorf("Expected the request ID, got %q", id)
+	}
+	if _, ok := ctxkeys.Tenant.Get(ctx); ok {
+		t.Errorf("Expected no tenant")
+	}
+
+	snapshot := ctxkeys.Snapshot(ctx)
+	if len(snapshot) != 3 || snapshot[0].Key != "principal" || snapshot[1].Key != "request_id" || snapshot[2].Key != "sentry.hub" {
+		t.Errorf("Expected the principal, request ID and hub in order, got %v", snapshot)
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected registering a key twice to panic")
+		}
+	}()
+	ctxkeys.NewKey[string]("request_id")
+}
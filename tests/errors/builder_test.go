@@ -0,0 +1,56 @@
+package tests
+
+import (
+	stderrors "errors"
+	"net/http"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestBuilder(t *testing.T) {
+	cause := errors.NewError("no rows", nil, true)
+	err := errors.E("Could not find the call").
+		Code(errors.NotFound).
+		Tag("service", "dialogue").
+		Extra("call_id", 42).
+		Severity(errors.Warning).
+		Fingerprint("calls", "not_found").
+		Wrap(cause).
+		Err()
+
+	if errors.CodeOf(err) != errors.NotFound || errors.HTTPStatus(err) != http.StatusNotFound {
+		t.Errorf("Expected the code to be set, got %s", errors.CodeOf(err))
+	}
+	if errors.Tags(err)["service"] != "dialogue" || errors.Extras(err)["call_id"] != 42 {
+		t.Errorf("Expected the tags and extras to be set, got %v %v", errors.Tags(err), errors.Extras(err))
+	}
+	if errors.Severity(err) != errors.Warning || len(errors.Fingerprint(err)) != 2 {
+		t.Errorf("Expected the severity and fingerprint to be set, got %s %v", errors.Severity(err), errors.Fingerprint(err))
+	}
+	if !errors.Fatal(err) || !stderrors.Is(err, cause) || errors.DeepestCause(err) != errors.DeepestCause(cause) {
+		t.Error("Expected the cause to be wrapped along with its fatality")
+	}
+	if err.Error() != errors.NewError("Could not find the call", cause, false).Error() {
+		t.Errorf("Expected the message of a wrapped error, got %q", err.Error())
+	}
+	if errors.Stacktrace(err) == "" {
+		t.Error("Expected the error to carry a stack")
+	}
+}
+
+func TestBuilderReuse(t *testing.T) {
+	base := errors.E("Could not synthesize").Tag("service", "tts").HTTPStatus(http.StatusBadGateway)
+	first := base.Err()
+	second := base.Tag("voice", "en-IN").Ignore().Err()
+
+	if _, ok := errors.Tags(first)["voice"]; ok {
+		t.Error("Expected the errors built not to share their tags")
+	}
+	if errors.Tags(second)["voice"] != "en-IN" || !errors.Ignore(second) || errors.Ignore(first) {
+		t.Errorf("Unexpected errors built %v %v", errors.Tags(first), errors.Tags(second))
+	}
+	if errors.HTTPStatus(first) != http.StatusBadGateway || errors.Fatal(first) {
+		t.Errorf("Expected the status without fatality, got %d", errors.HTTPStatus(first))
+	}
+}
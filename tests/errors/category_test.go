@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestCategoryOf(t *testing.T) {
+	for err, expected := range map[error]errors.Category{
+		errors.NewError("boom", nil, false): errors.CategoryInternal,
+		errors.WithCode(errors.NewError("bad phone number", nil, false), errors.InvalidArgument): errors.CategoryValidation,
+		errors.WithCode(errors.NewError("no token", nil, false), errors.Unauthenticated):         errors.CategoryAuth,
+		errors.WithHTTPStatus(errors.NewError("forbidden", nil, false), 403):                     errors.CategoryAuth,
+		errors.WithHTTPStatus(errors.NewError("unprocessable", nil, false), 422):                 errors.CategoryValidation,
+		errors.WithHTTPStatus(errors.NewError("bad gateway", nil, false), 502):                   errors.CategoryDependency,
+		errors.NewError("too slow", context.DeadlineExceeded, false):                             errors.CategoryTimeout,
+		errors.NewError("asr failed", status.Error(codes.Unavailable, "no backends"), false):     errors.CategoryDependency,
+		errors.WithCategory(errors.NewError("db down", nil, false), errors.CategoryDependency):   errors.CategoryDependency,
+		errors.E("db down").HTTPStatus(503).Category(errors.CategoryDependency).Err():            errors.CategoryDependency,
+		// The category closest to the top of the stack wins
+		errors.WithCategory(errors.WithCode(errors.NewError("bad input from the ASR", nil, false), errors.InvalidArgument), errors.CategoryDependency): errors.CategoryDependency,
+	} {
+		if category := errors.CategoryOf(err); category != expected {
+			t.Errorf("Expected %v to be %s, got %s", err, expected, category)
+		}
+	}
+
+	if errors.CategoryOf(nil) != "" || errors.WithCategory(nil, errors.CategoryAuth) != nil {
+		t.Error("Expected no category for a nil error")
+	}
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestCodeOf(t *testing.T) {
+	cause := errors.NewError("Could not find the call", nil, false)
+	if code := errors.CodeOf(cause); code != errors.Unknown {
+		t.Errorf("Expected errors without a code to be unknown, got %s", code)
+	}
+	if code := errors.CodeOf(errors.NewError("Could not fetch the call", errors.WithCode(cause, errors.NotFound), false)); code != errors.NotFound {
+		t.Errorf("Expected the code set on the cause, got %s", code)
+	}
+	if code := errors.CodeOf(errors.NewError("Could not transcribe", context.DeadlineExceeded, false)); code != errors.DeadlineExceeded {
+		t.Errorf("Expected errors caused by deadlines to default to DeadlineExceeded, got %s", code)
+	}
+	if errors.WithCode(nil, errors.NotFound) != nil {
+		t.Errorf("Expected a nil error to stay nil")
+	}
+}
+
+func TestToGRPCStatus(t *testing.T) {
+	err := errors.WithCode(errors.NewErrorWithTagsAndExtras("Could not find the call", nil, false,
+		map[string]string{"call": "42"}, map[string]interface{}{"attempts": 3}), errors.NotFound)
+
+	s := errors.ToGRPCStatus(err)
+	if s.Code() != codes.NotFound {
+		t.Fatalf("Expected the NotFound status, got %s", s.Code())
+	}
+	var info *errdetails.ErrorInfo
+	var extras *structpb.Struct
+	for _, detail := range s.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = detail
+		case *structpb.Struct:
+			extras = detail
+		}
+	}
+	if info == nil || info.Reason != "not_found" || info.Metadata["call"] != "42" {
+		t.Errorf("Expected the code and the tags as the error info, got %v", info)
+	}
+	if extras == nil || extras.Fields["attempts"].GetNumberValue() != 3 {
+		t.Errorf("Expected the extras as a detail, got %v", extras)
+	}
+
+	wrapped := errors.NewError("Could not call the SLU", status.Error(codes.Unavailable, "connection refused"), false)
+	if code := errors.ToGRPCStatus(wrapped).Code(); code != codes.Unavailable {
+		t.Errorf("Expected the status of the cause to be kept, got %s", code)
+	}
+	if errors.ToGRPCStatus(nil) != nil {
+		t.Errorf("Expected no status for a nil error")
+	}
+}
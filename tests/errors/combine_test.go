@@ -0,0 +1,38 @@
+package tests
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestCombine(t *testing.T) {
+	missing := errors.NewErrorWithTagsAndExtras("Could not find the call", nil, false,
+		map[string]string{"campaign": "renewals", "item": "1"}, map[string]interface{}{"call_id": 1})
+	failed := errors.WithSeverity(errors.NewErrorWithTagsAndExtras("Could not dial", nil, true,
+		map[string]string{"item": "2", "carrier": "exotel"}, map[string]interface{}{"attempts": 3}), errors.Critical)
+
+	err := errors.Combine(missing, nil, failed)
+	tags := errors.Tags(err)
+	if tags["campaign"] != "renewals" || tags["carrier"] != "exotel" || tags["item"] != "1" {
+		t.Errorf("Expected the tags of the errors merged, the earlier winning, got %v", tags)
+	}
+	extras := errors.Extras(err)
+	if extras["call_id"] != 1 || extras["attempts"] != 3 || len(extras[errors.ExtraCombinedErrors].([]string)) != 2 {
+		t.Errorf("Expected the extras of the errors merged along with their messages, got %v", extras)
+	}
+	if !errors.Fatal(err) || errors.Severity(err) != errors.Critical {
+		t.Errorf("Expected the combined error to be as fatal and severe as the worst of the errors")
+	}
+	if !stderrors.Is(err, failed) {
+		t.Errorf("Expected the errors combined to be unwrapped")
+	}
+
+	if errors.Combine(nil, nil) != nil {
+		t.Errorf("Expected no error if all the errors are nil")
+	}
+	if errors.Combine(nil, missing) != missing {
+		t.Errorf("Expected the only error to be returned as is")
+	}
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestIsCanceled(t *testing.T) {
+	canceled := []error{
+		context.Canceled,
+		errors.NewError("could not fetch the call", context.Canceled, false),
+		fmt.Errorf("fetching: %w", context.Canceled),
+		&url.Error{Op: "Get", URL: "http://calls", Err: context.Canceled},
+		errors.NewError("upstream failed", status.Error(codes.Canceled, "client went away"), false),
+		errors.WithCode(errors.NewError("hung up", nil, false), errors.Canceled),
+	}
+	for _, err := range canceled {
+		if !errors.IsCanceled(err) {
+			t.Errorf("Expected %v to be canceled", err)
+		}
+		if errors.IsDeadline(err) {
+			t.Errorf("Expected %v not to be a deadline", err)
+		}
+	}
+
+	for _, err := range []error{nil, errors.NewError("boom", nil, false), status.Error(codes.Internal, "boom"), context.DeadlineExceeded} {
+		if errors.IsCanceled(err) {
+			t.Errorf("Expected %v not to be canceled", err)
+		}
+	}
+}
+
+func TestIsDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	_, dialErr := (&net.Dialer{}).DialContext(ctx, "tcp", "10.255.255.1:80")
+
+	deadlines := []error{
+		context.DeadlineExceeded,
+		errors.NewError("could not fetch the call", context.DeadlineExceeded, false),
+		errors.NewError("dial failed", dialErr, false),
+		&net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}},
+		status.Error(codes.DeadlineExceeded, "too slow"),
+		errors.WithCode(errors.NewError("too slow", nil, false), errors.DeadlineExceeded),
+	}
+	for _, err := range deadlines {
+		if !errors.IsDeadline(err) {
+			t.Errorf("Expected %v to be a deadline", err)
+		}
+	}
+
+	for _, err := range []error{nil, errors.NewError("boom", nil, false), context.Canceled} {
+		if errors.IsDeadline(err) {
+			t.Errorf("Expected %v not to be a deadline", err)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	invalid := errors.WithCode(errors.NewError("The call has no number", nil, false), errors.InvalidArgument)
+	for name, test := range map[string]struct {
+		err    error
+		status int
+	}{
+		"nil":        {nil, http.StatusOK},
+		"plain":      {errors.NewError("Could not connect", nil, false), http.StatusInternalServerError},
+		"not found":  {errors.WithCode(errors.NewError("Could not find the call", nil, false), errors.NotFound), http.StatusNotFound},
+		"validation": {invalid, http.StatusBadRequest},
+		"canceled":   {errors.NewError("Could not transcribe", context.Canceled, false), errors.StatusClientClosedRequest},
+		"deadline":   {errors.NewError("Could not transcribe", context.DeadlineExceeded, false), http.StatusGatewayTimeout},
+		"override":   {errors.NewError("Could not create the call", errors.WithHTTPStatus(invalid, http.StatusUnprocessableEntity), false), http.StatusUnprocessableEntity},
+		"legacy":     {errors.NewErrorWithCode("Could not reach the SLU", http.StatusBadGateway, nil), http.StatusBadGateway},
+	} {
+		if status := errors.HTTPStatus(test.err); status != test.status {
+			t.Errorf("Expected the status %d for the %s error, got %d", test.status, name, status)
+		}
+	}
+
+	if status := errors.Code(errors.WithHTTPStatus(invalid, http.StatusUnprocessableEntity), 0); status != http.StatusUnprocessableEntity {
+		t.Errorf("Expected the status to be the code of the error, got %d", status)
+	}
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestRegisterIgnorable(t *testing.T) {
+	canceled := errors.NewError("Could not stream the audio", context.Canceled, false)
+	if errors.Ignore(canceled) {
+		t.Fatal("Expected errors not to be ignored without matchers")
+	}
+
+	unregister := errors.RegisterIgnorable(errors.IgnoreCode(errors.Canceled))
+	if !errors.Ignore(canceled) {
+		t.Error("Expected the errors with the code to be ignored")
+	}
+	if errors.Ignore(errors.NewError("Could not stream the audio", nil, false)) {
+		t.Error("Expected the errors without the code not to be ignored")
+	}
+	unregister()
+	if errors.Ignore(canceled) {
+		t.Error("Expected the matcher to be unregistered")
+	}
+
+	defer errors.RegisterIgnorable(errors.IgnoreType[*net.OpError]())()
+	defer errors.RegisterIgnorable(errors.IgnoreErrors(io.ErrUnexpectedEOF))()
+	defer errors.RegisterIgnorable(func(err error) bool { return errors.Tags(err)["vendor"] == "flaky" })()
+
+	for name, err := range map[string]error{
+		"type":   errors.NewError("Could not reach the TTS", fmt.Errorf("dial: %w", &net.OpError{Op: "dial"}), false),
+		"target": errors.NewError("Could not read the upload", io.ErrUnexpectedEOF, false),
+		"custom": errors.NewErrorWithTags("Could not synthesize", nil, false, map[string]string{"vendor": "flaky"}),
+	} {
+		if !errors.Ignore(err) {
+			t.Errorf("Expected the %s matcher to ignore the error", name)
+		}
+	}
+	if errors.Ignore(nil) {
+		t.Error("Expected nil not to be ignored")
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestToBody(t *testing.T) {
+	notFound := errors.WithCode(errors.NewErrorWithExtras("call not found", nil, false, map[string]interface{}{"call_id": 42}), errors.NotFound)
+	body := errors.ToBody(context.Background(), notFound)
+	if body.Code != errors.NotFound || body.Message != "call not found" || body.Details["call_id"] != float64(42) {
+		t.Errorf("Unexpected body %+v", body)
+	}
+	if body.TraceID != "" {
+		t.Errorf("Expected no trace ID without a span, got %s", body.TraceID)
+	}
+
+	if errors.ToBody(context.Background(), nil) != nil {
+		t.Error("Expected no body for a nil error")
+	}
+}
+
+func TestToBodyCauses(t *testing.T) {
+	driver := stderrors.New(`pq: duplicate key value violates unique constraint "calls_pkey"`)
+	conflict := errors.WithCode(errors.NewError("call already exists", driver, false), errors.AlreadyExists)
+
+	// The messages of the causes are not shown to the clients, through the wraps without a message of their own too
+	for _, err := range []error{
+		conflict,
+		errors.AddTagsToError(conflict, map[string]string{"table": "calls"}),
+		errors.WithCode(fmt.Errorf("call already exists: %w", driver), errors.AlreadyExists),
+	} {
+		if body := errors.ToBody(context.Background(), err); body.Message != "call already exists" {
+			t.Errorf("Expected only the message of the error, got %q", body.Message)
+		}
+	}
+}
+
+func TestToBodyRedaction(t *testing.T) {
+	internal := errors.NewErrorWithExtras("could not connect to 10.0.0.7:5432", nil, false, map[string]interface{}{"dsn": "postgres://"})
+
+	body := errors.ToBody(context.Background(), internal)
+	if body.Code != errors.Unknown || body.Message != "Internal Server Error" || body.Details != nil {
+		t.Errorf("Expected the internal error to be redacted, got %+v", body)
+	}
+	if body := errors.ToBody(context.Background(), internal, errors.WithRedactedMessage("something went wrong")); body.Message != "something went wrong" {
+		t.Errorf("Expected the configured message, got %s", body.Message)
+	}
+	if body := errors.ToBody(context.Background(), internal, errors.WithInternalMessages()); body.Message != internal.Error() || body.Details["dsn"] != "postgres://" {
+		t.Errorf("Expected the internal message to be kept, got %+v", body)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	data, err := errors.MarshalJSON(ctx, errors.WithCode(errors.NewError("missing number", nil, false), errors.InvalidArgument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["code"] != "invalid_argument" || body["message"] != "missing number" || body["trace_id"] != traceID.String() {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+	if _, ok := body["details"]; ok {
+		t.Errorf("Expected no details without extras, got %s", data)
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	callNotFound  = errors.RegisterKind(errors.Kind{Name: "CALL_NOT_FOUND", Code: errors.NotFound, Message: "call not found"})
+	asrOverloaded = errors.RegisterKind(errors.Kind{
+		Name:       "ASR_OVERLOADED",
+		Code:       errors.Unavailable,
+		HTTPStatus: http.StatusTooManyRequests,
+		GRPCCode:   codes.ResourceExhausted,
+		Retryable:  true,
+	})
+)
+
+func TestKinds(t *testing.T) {
+	err := callNotFound.New("")
+	if err.Error() != "call not found" || errors.CodeOf(err) != errors.NotFound || errors.HTTPStatus(err) != http.StatusNotFound {
+		t.Errorf("Expected the defaults of the kind, got %s %s %d", err, errors.CodeOf(err), errors.HTTPStatus(err))
+	}
+	if !callNotFound.Is(fmt.Errorf("handling: %w", errors.NewError("lookup failed", err, false))) || asrOverloaded.Is(err) {
+		t.Errorf("Expected the kind to be found through the wraps")
+	}
+	if errors.Retryable(err) {
+		t.Errorf("Expected %s not to be retryable", err)
+	}
+
+	cause := stderrors.New("queue full")
+	overloaded := asrOverloaded.Wrap(cause, "ASR is overloaded")
+	if !stderrors.Is(overloaded, cause) || !errors.Retryable(overloaded) || errors.HTTPStatus(overloaded) != http.StatusTooManyRequests {
+		t.Errorf("Unexpected error of the kind %s", overloaded)
+	}
+
+	s := errors.ToGRPCStatus(overloaded)
+	if s.Code() != codes.ResourceExhausted {
+		t.Errorf("Expected the gRPC code of the kind, got %s", s.Code())
+	}
+	// The kind is recovered from the statuses returned by the other services
+	if kind, ok := errors.KindOf(errors.NewError("transcribing", s.Err(), false)); !ok || kind != asrOverloaded {
+		t.Errorf("Expected the kind of the status, got %v", kind)
+	}
+	if body := errors.ToBody(context.Background(), err); body.Kind != "CALL_NOT_FOUND" {
+		t.Errorf("Expected the kind in the body, got %+v", body)
+	}
+
+	if _, ok := errors.KindOf(errors.NewError("plain", nil, false)); ok {
+		t.Error("Expected no kind")
+	}
+	if _, ok := errors.KindOf(errors.ToGRPCStatus(errors.NewError("plain", nil, false)).Err()); ok {
+		t.Error("Expected no kind for the statuses of errors of no kind")
+	}
+}
+
+func TestKindRegistry(t *testing.T) {
+	if kind, ok := errors.LookupKind("CALL_NOT_FOUND"); !ok || kind != callNotFound {
+		t.Errorf("Expected the kind to be registered, got %v", kind)
+	}
+
+	registered := errors.Kinds()
+	for i := 1; i < len(registered); i++ {
+		if registered[i-1].Name >= registered[i].Name {
+			t.Errorf("Expected the kinds sorted by their names, got %s before %s", registered[i-1].Name, registered[i].Name)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a kind twice to panic")
+		}
+	}()
+	errors.RegisterKind(errors.Kind{Name: "CALL_NOT_FOUND", Code: errors.NotFound})
+}
+
+func TestRetryable(t *testing.T) {
+	for err, expected := range map[error]bool{
+		nil:                                 false,
+		errors.NewError("boom", nil, false): false,
+		errors.WithCode(errors.NewError("down", nil, false), errors.Unavailable):                                                           true,
+		errors.NewError("calling", errors.ToGRPCStatus(errors.WithCode(errors.NewError("busy", nil, false), errors.Aborted)).Err(), false): true,
+	} {
+		if errors.Retryable(err) != expected {
+			t.Errorf("Expected %v to be retryable: %t", err, expected)
+		}
+	}
+}
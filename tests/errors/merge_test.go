@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Wraps a root cause through the layers of a call: the SLU client, the turn and the call
+func deepChain() error {
+	err := errors.NewErrorWithTagsAndExtras("SLU timed out", nil, false,
+		map[string]string{"service": "slu", "region": "ap-south-1"},
+		map[string]interface{}{"attempt": 3, "model": "intent-v4"})
+	err = errors.NewErrorWithTags("Could not predict the intent", err, false, map[string]string{"service": "dialogue"})
+	err = errors.AddExtrasToError(err, map[string]interface{}{"attempt": 1, "turn": 7})
+	err = errors.WithCode(err, errors.DeadlineExceeded)
+	err = errors.AddTagsToError(err, map[string]string{"service": "gateway", "call": "42"})
+	return errors.NewErrorWithExtras("Could not handle the turn", err, false, map[string]interface{}{"model": "intent-v4"})
+}
+
+func TestMergeStrategies(t *testing.T) {
+	err := deepChain()
+	for strategy, expected := range map[errors.MergeStrategy]struct {
+		tags   map[string]string
+		extras map[string]interface{}
+	}{
+		errors.OuterWins: {
+			tags:   map[string]string{"service": "gateway", "region": "ap-south-1", "call": "42"},
+			extras: map[string]interface{}{"attempt": 1, "turn": 7, "model": "intent-v4"},
+		},
+		errors.InnerWins: {
+			tags:   map[string]string{"service": "slu", "region": "ap-south-1", "call": "42"},
+			extras: map[string]interface{}{"attempt": 3, "turn": 7, "model": "intent-v4"},
+		},
+		errors.Accumulate: {
+			tags:   map[string]string{"service": "gateway,dialogue,slu", "region": "ap-south-1", "call": "42"},
+			extras: map[string]interface{}{"attempt": []interface{}{1, 3}, "turn": 7, "model": "intent-v4"},
+		},
+	} {
+		if tags := errors.TagsWithStrategy(err, strategy); !reflect.DeepEqual(tags, expected.tags) {
+			t.Errorf("Expected the tags %v with the strategy %d, got %v", expected.tags, strategy, tags)
+		}
+		if extras := errors.ExtrasWithStrategy(err, strategy); !reflect.DeepEqual(extras, expected.extras) {
+			t.Errorf("Expected the extras %v with the strategy %d, got %v", expected.extras, strategy, extras)
+		}
+	}
+
+	errors.SetMergeStrategy(errors.InnerWins)
+	defer errors.SetMergeStrategy(errors.OuterWins)
+	if service := errors.Tags(err)["service"]; service != "slu" {
+		t.Errorf("Expected Tags to merge with the strategy configured, got %s", service)
+	}
+}
+
+func TestAddTagsKeepsContext(t *testing.T) {
+	tags := map[string]string{"service": "slu"}
+	original := errors.NewErrorWithTags("SLU timed out", nil, false, tags)
+	wrapped := errors.AddTagsToError(original, map[string]string{"service": "dialogue", "call": "42"})
+
+	if tags["service"] != "slu" || len(errors.Tags(original)) != 1 {
+		t.Errorf("Expected the tags of the original error to be unchanged, got %v", errors.Tags(original))
+	}
+	if service := errors.Tags(wrapped)["service"]; service != "dialogue" {
+		t.Errorf("Expected the tags added to win, got %s", service)
+	}
+	if errors.Stacktrace(wrapped) == "" {
+		t.Error("Expected the wrapped error to keep a stack")
+	}
+
+	// Errors wrapped by other packages used to drop the tags added to them
+	foreign := errors.AddTagsToError(fmt.Errorf("dial: %w", original), map[string]string{"call": "42"})
+	if call := errors.Tags(foreign)["call"]; call != "42" {
+		t.Errorf("Expected the tags added to an error of another package to be kept, got %v", errors.Tags(foreign))
+	}
+	if errors.AddTagsToError(nil, tags) != nil {
+		t.Error("Expected nil to stay nil")
+	}
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	_err "github.com/pkg/errors"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Recovers from the panic of the function as an error
+func recovered(f func()) (err error) {
+	defer func() {
+		err = errors.FromPanic(recover())
+	}()
+	f()
+	return nil
+}
+
+func panicking() {
+	var turns map[string]int
+	turns["first"]++
+}
+
+func TestFromPanic(t *testing.T) {
+	err := recovered(func() { panic("no route to the SLU") })
+	if err == nil || err.Error() != "panic: no route to the SLU" {
+		t.Fatalf("Expected the error of the panic, got %v", err)
+	}
+	if !errors.IsPanic(err) || errors.Tags(err)[errors.PanicTag] != "true" {
+		t.Errorf("Expected the error to be tagged, got %v", errors.Tags(err))
+	}
+	if !errors.Fatal(err) || errors.Severity(err) != errors.Critical {
+		t.Error("Expected the error of the panic to be fatal")
+	}
+	if errors.Extras(err)["panic.type"] != "string" {
+		t.Errorf("Expected the type of the panic, got %v", errors.Extras(err))
+	}
+
+	err = recovered(func() { panic(fmt.Errorf("reading: %w", io.ErrUnexpectedEOF)) })
+	if !stderrors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected the recovered error to be the cause, got %v", err)
+	}
+
+	if errors.FromPanic(nil) != nil || errors.IsPanic(errors.NewError("Could not dial", nil, false)) {
+		t.Error("Expected no error without a panic")
+	}
+}
+
+func TestFromPanicStack(t *testing.T) {
+	err := recovered(panicking)
+
+	if !strings.Contains(err.Error(), "assignment to entry in nil map") {
+		t.Errorf("Expected the runtime error to be the cause, got %v", err)
+	}
+	tracer, ok := err.(interface{ StackTrace() _err.StackTrace })
+	if !ok {
+		t.Fatal("Expected the error to carry the stack of the panic")
+	}
+	// The frames of the runtime raising the panic, and of the deferred function recovering, are dropped
+	if top := fmt.Sprintf("%n", tracer.StackTrace()[0]); top != "panicking" {
+		t.Errorf("Expected the stack to start at the frame of the panic, got %s", top)
+	}
+}
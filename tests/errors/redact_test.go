@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestExtrasRedaction(t *testing.T) {
+	err := errors.NewErrorWithExtras("Could not verify the caller", nil, false, map[string]interface{}{
+		"call_id":      "42",
+		"access_token": "eyJhbGciOi",
+		"Password":     "hunter2",
+		"caller_phone": "9876543210",
+		"otp":          1234,
+		"login.otp":    5678,
+		"tokens_used":  120,
+		"footprint":    "small",
+		"otp_attempts": 2,
+	})
+
+	extras := errors.Extras(err)
+	for _, key := range []string{"call_id", "tokens_used", "footprint", "otp_attempts"} {
+		if extras[key] == errors.Redacted {
+			t.Errorf("Expected the extras which are not sensitive to be kept, got %s redacted", key)
+		}
+	}
+	for _, key := range []string{"access_token", "Password", "caller_phone", "otp", "login.otp"} {
+		if extras[key] != errors.Redacted {
+			t.Errorf("Expected %s to be redacted, got %v", key, extras[key])
+		}
+	}
+
+	wrapped := errors.NewErrorWithExtras("Could not start the call", err, false, map[string]interface{}{"aadhaar": "1234"})
+	if err := errors.AddSensitiveExtra("aadhaar"); err != nil {
+		t.Fatal(err)
+	}
+	defer errors.SetSensitiveExtras(`passw(or)?d`, `secret`, `(^|[-_.])token$`, `api[-_]?key`, `authori[sz]ation`, `cookie`, `(^|[-_.])otp$`, `phone`, `mobile`)
+	if extras := errors.Extras(wrapped); extras["aadhaar"] != errors.Redacted || extras["otp"] != errors.Redacted {
+		t.Errorf("Expected the registered pattern to be redacted, got %v", extras)
+	}
+
+	if err := errors.SetSensitiveExtras("aadhaar"); err != nil {
+		t.Fatal(err)
+	}
+	if extras := errors.Extras(wrapped); extras["otp"] != 1234 || extras["aadhaar"] != errors.Redacted {
+		t.Errorf("Expected only the configured patterns to be redacted, got %v", extras)
+	}
+	if err := errors.SetSensitiveExtras("("); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+}
@@ -0,0 +1,28 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestSeverity(t *testing.T) {
+	cause := errors.NewError("Could not reach the TTS", nil, false)
+	if severity := errors.Severity(cause); severity != errors.Error {
+		t.Errorf("Expected errors to default to the error severity, got %s", severity)
+	}
+	if severity := errors.Severity(errors.NewError("Could not connect to the database", nil, true)); severity != errors.Error {
+		t.Errorf("Expected fatal errors to default to the error severity too, got %s", severity)
+	}
+
+	degraded := errors.WithSeverity(cause, errors.Warning)
+	if severity := errors.Severity(errors.NewError("Falling back to the default voice", degraded, false)); severity != errors.Warning {
+		t.Errorf("Expected the severity set on the cause, got %s", severity)
+	}
+	if severity := errors.Severity(errors.WithSeverity(degraded, errors.Critical)); severity != errors.Critical {
+		t.Errorf("Expected the severity closest to the top to win, got %s", severity)
+	}
+	if errors.WithSeverity(nil, errors.Warning) != nil {
+		t.Errorf("Expected a nil error to stay nil")
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	_err "github.com/pkg/errors"
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Fetches a call, wrapping its errors with the stack of the wrap site
+func fetchCall() error {
+	return errors.WrapWithStack(errors.NewError("Could not reach the database", nil, false), "Could not fetch the call")
+}
+
+func TestWrapWithStack(t *testing.T) {
+	err := fetchCall()
+	if !strings.HasPrefix(err.Error(), "Could not fetch the call") {
+		t.Errorf("Expected the message of the wrap, got %s", err)
+	}
+
+	tracer, ok := err.(interface{ StackTrace() _err.StackTrace })
+	if !ok {
+		t.Fatal("Expected the error to carry a stack trace")
+	}
+	if top := fmt.Sprintf("%n", tracer.StackTrace()[0]); top != "fetchCall" {
+		t.Errorf("Expected the stack to start at the wrap site, got %s", top)
+	}
+
+	if errors.WrapWithStack(nil, "Could not fetch the call") != nil {
+		t.Errorf("Expected a nil error to stay nil")
+	}
+}
+
+func TestInternalFrame(t *testing.T) {
+	if !errors.InternalFrame("github.com/skit-ai/vcore/errors.NewError") {
+		t.Error("Expected the frames of vcore to be internal")
+	}
+	if errors.InternalFrame("github.com/skit-ai/vcore/tests/errors.fetchCall") || errors.InternalFrame("main.main") {
+		t.Error("Expected the frames outside vcore to be external")
+	}
+
+	errors.SkipFrames("github.com/skit-ai/dialogue/errs.")
+	if !errors.InternalFrame("github.com/skit-ai/dialogue/errs.Wrap") {
+		t.Error("Expected the frames of the packages skipped to be internal")
+	}
+}
+
+// Handles a request through middlewares, wrapping the error of the handler
+func middlewareOuter() error { return middlewareInner() }
+func middlewareInner() error { return handleRequest() }
+func handleRequest() error {
+	return errors.WrapWithStack(errors.NewError("Could not handle the request", nil, false), "Request failed")
+}
+
+// Returns the names of the functions of the frames of the error
+func functions(err error) []string {
+	var names []string
+	for _, frame := range err.(interface{ StackTrace() _err.StackTrace }).StackTrace() {
+		names = append(names, fmt.Sprintf("%n", frame))
+	}
+	return names
+}
+
+func TestFilterFrames(t *testing.T) {
+	if names := functions(middlewareOuter()); names[0] != "handleRequest" || names[1] != "middlewareInner" {
+		t.Fatalf("Expected the frames of the middlewares, got %v", names)
+	}
+
+	errors.FilterFrames("github.com/skit-ai/vcore/tests/errors.middleware")
+	names := functions(middlewareOuter())
+	if names[0] != "handleRequest" || names[1] != "TestFilterFrames" {
+		t.Errorf("Expected the frames of the middlewares to be dropped, got %v", names)
+	}
+	if !errors.FilteredFrame("github.com/skit-ai/app/vendor/github.com/skit-ai/vcore/tests/errors.middlewareInner") {
+		t.Error("Expected vendored frames to be filtered by their import path")
+	}
+	if errors.FilteredFrame("github.com/skit-ai/vcore/tests/errors.handleRequest") {
+		t.Error("Expected the other frames not to be filtered")
+	}
+}
+
+func TestStackDepth(t *testing.T) {
+	defer errors.SetStackDepth(0)
+
+	errors.SetStackDepth(2)
+	if names := functions(middlewareOuter()); len(names) != 2 || errors.StackDepth() != 2 {
+		t.Errorf("Expected 2 frames, got %v", names)
+	}
+
+	errors.SetStackDepth(0)
+	if errors.StackDepth() != 32 {
+		t.Errorf("Expected the default depth to be restored, got %d", errors.StackDepth())
+	}
+}
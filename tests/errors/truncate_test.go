@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestTruncateExtras(t *testing.T) {
+	defer errors.SetExtrasLimits(0, 256<<10)
+	errors.SetExtrasLimits(48, 0)
+
+	extras := map[string]interface{}{
+		"call_id":    "42",
+		"transcript": strings.Repeat("नमस्ते ", 10),
+		"turns":      []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+	}
+	truncated := errors.TruncateExtras(extras)
+
+	if truncated["call_id"] != "42" {
+		t.Errorf("Expected the small extras to be kept, got %v", truncated["call_id"])
+	}
+	transcript := truncated["transcript"].(string)
+	if !strings.HasSuffix(transcript, "...[truncated, 190 bytes]") || len(transcript) > 48 {
+		t.Errorf("Expected the transcript to be truncated to 48 bytes, got %q", transcript)
+	}
+	if !utf8.ValidString(transcript) || !strings.HasPrefix(transcript, "नमस्ते न...") {
+		t.Errorf("Expected the transcript to be truncated at a character, got %q", transcript)
+	}
+	if turns, ok := truncated["turns"].(string); !ok || !strings.HasPrefix(turns, "[1,2,3") || !strings.HasSuffix(turns, "...[truncated, 52 bytes]") {
+		t.Errorf("Expected the JSON of the turns to be truncated, got %v", truncated["turns"])
+	}
+	if len(extras["transcript"].(string)) != 190 {
+		t.Error("Expected the extras not to be modified")
+	}
+
+	// The largest values are replaced once the total is exceeded, and dropped once even their replacements exceed it
+	errors.SetExtrasLimits(0, 30)
+	truncated = errors.TruncateExtras(map[string]interface{}{"a": "1234", "b": strings.Repeat("x", 50), "c": strings.Repeat("y", 60)})
+	if _, ok := truncated["c"]; truncated["a"] != "1234" || truncated["b"] != "...[truncated, 50 bytes]" || ok {
+		t.Errorf("Expected the largest values to be replaced and dropped, got %v", truncated)
+	}
+
+	// The values truncated to the limit of every extra are replaced with their original size
+	errors.SetExtrasLimits(32, 64)
+	truncated = errors.TruncateExtras(map[string]interface{}{
+		"a": "1234",
+		"b": strings.Repeat("x", 100),
+		"c": strings.Repeat("y", 200),
+		"d": strings.Repeat("z", 300),
+	})
+	if truncated["b"] != "xxxxxxx...[truncated, 100 bytes]" || truncated["c"] != "...[truncated, 200 bytes]" || len(truncated) != 3 {
+		t.Errorf("Expected the replacements to note the original sizes within the total, got %v", truncated)
+	}
+
+	errors.SetExtrasLimits(0, 0)
+	if value := errors.TruncateExtras(extras)["transcript"]; value != extras["transcript"] {
+		t.Errorf("Expected no truncation without limits, got %v", value)
+	}
+}
+
+func TestTruncatedExtras(t *testing.T) {
+	defer errors.SetExtrasLimits(0, 256<<10)
+
+	err := errors.NewErrorWithExtras("Could not parse the request", nil, false, map[string]interface{}{
+		"call_id": "42",
+		"body":    strings.Repeat("x", 1<<20),
+	})
+	extras := errors.TruncatedExtras(err)
+	if extras["call_id"] != "42" || extras["body"] != "...[truncated, 1048576 bytes]" {
+		t.Errorf("Expected the body to be replaced by default, got %.64v", extras)
+	}
+	if len(errors.Extras(err)["body"].(string)) != 1<<20 {
+		t.Error("Expected Extras to keep the whole body")
+	}
+
+	errors.SetExtrasLimits(32, 0)
+	if body := errors.TruncatedExtras(err)["body"].(string); body != "xxx...[truncated, 1048576 bytes]" {
+		t.Errorf("Expected the body to be truncated, got %.64v", body)
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestStandardMatching(t *testing.T) {
+	opErr := &net.OpError{Op: "dial", Net: "tcp", Err: stderrors.New("connection refused")}
+	wrappers := map[string]error{
+		"rung":     errors.NewError("Could not reach the SLU", opErr, false),
+		"tags":     errors.NewErrorWithTags("Could not reach the SLU", opErr, false, map[string]string{"service": "slu"}),
+		"code":     errors.WithCode(opErr, errors.Unavailable),
+		"status":   errors.WithHTTPStatus(opErr, 502),
+		"severity": errors.WithSeverity(opErr, errors.Warning),
+		"stack":    errors.WrapWithStack(opErr, "Could not reach the SLU"),
+		"combined": errors.Combine(stderrors.New("first"), opErr),
+		"nested":   fmt.Errorf("turn failed: %w", errors.AddTagsToError(errors.NewError("", opErr, false), map[string]string{"k": "v"})),
+	}
+	for name, err := range wrappers {
+		if !stderrors.Is(err, opErr) {
+			t.Errorf("Expected errors.Is to match through the %s wrapper", name)
+		}
+		if found, ok := errors.As[*net.OpError](err); !ok || found != opErr {
+			t.Errorf("Expected As to find the cause through the %s wrapper", name)
+		}
+	}
+
+	if !stderrors.Is(errors.NewError("Could not transcribe", context.Canceled, false), context.Canceled) {
+		t.Error("Expected errors.Is to match context.Canceled")
+	}
+	if _, ok := errors.As[*net.OpError](nil); ok {
+		t.Error("Expected nothing to be found in a nil error")
+	}
+	if _, ok := errors.As[interface{ Timeout() bool }](errors.NewError("Could not dial", opErr, false)); !ok {
+		t.Error("Expected As to match interfaces")
+	}
+}
+
+func TestFindTag(t *testing.T) {
+	inner := errors.NewErrorWithTags("Could not fetch the flow", nil, false, map[string]string{"flow": "inner", "tenant": "acme"})
+	outer := errors.NewErrorWithTags("Could not start the call", fmt.Errorf("loading: %w", inner), false, map[string]string{"flow": "outer"})
+
+	if value, ok := errors.FindTag(outer, "flow"); !ok || value != "outer" {
+		t.Errorf("Expected the outer tag to win, got %q", value)
+	}
+	if value, ok := errors.FindTag(outer, "tenant"); !ok || value != "acme" {
+		t.Errorf("Expected the tag to be found through fmt.Errorf, got %q", value)
+	}
+	if _, ok := errors.FindTag(outer, "missing"); ok {
+		t.Error("Expected a missing tag not to be found")
+	}
+	if value, ok := errors.FindTag(errors.Combine(stderrors.New("plain"), inner), "tenant"); !ok || value != "acme" {
+		t.Errorf("Expected the tag to be found in combined errors, got %q", value)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	root := stderrors.New("connection refused")
+	err := errors.NewError("Could not start the call", fmt.Errorf("loading: %w", errors.Combine(stderrors.New("plain"), root)), false)
+
+	var messages []string
+	if !errors.Walk(err, func(e error) bool {
+		messages = append(messages, e.Error())
+		return true
+	}) {
+		t.Error("Expected the walk to complete")
+	}
+	if len(messages) < 4 || messages[len(messages)-1] != "connection refused" || messages[len(messages)-2] != "plain" {
+		t.Errorf("Expected every layer to be walked through, got %q", messages)
+	}
+
+	visited := 0
+	if errors.Walk(err, func(e error) bool {
+		visited++
+		return e.Error() != "plain"
+	}) || visited != len(messages)-1 {
+		t.Errorf("Expected the walk to stop, after %d of %d layers", visited, len(messages))
+	}
+	if !errors.Walk(nil, func(error) bool { return false }) {
+		t.Error("Expected a nil error not to be walked")
+	}
+}
+
+func TestRootCause(t *testing.T) {
+	root := stderrors.New("connection refused")
+	cases := map[string]error{
+		"rung":     errors.NewError("Could not reach the SLU", &net.OpError{Op: "dial", Net: "tcp", Err: root}, false),
+		"nested":   errors.WrapWithStack(fmt.Errorf("turn failed: %w", errors.WithCode(root, errors.Unavailable)), "Could not reach the SLU"),
+		"combined": errors.Combine(errors.NewError("", root, false), stderrors.New("second")),
+	}
+	for name, err := range cases {
+		if cause := errors.RootCause(err); cause != root {
+			t.Errorf("Expected the root cause of the %s error, got %v", name, cause)
+		}
+	}
+
+	leaf := errors.NewError("Could not parse the request", nil, false)
+	if cause := errors.RootCause(leaf); cause == nil || cause.Error() != "Could not parse the request" {
+		t.Errorf("Expected an error without a cause to be its own root cause, got %v", cause)
+	}
+	if errors.RootCause(nil) != nil {
+		t.Error("Expected a nil root cause of a nil error")
+	}
+}
+
+func TestGRPCStatusThroughWrappers(t *testing.T) {
+	err := errors.NewError("Could not call the SLU", fmt.Errorf("predict: %w", status.Error(codes.Unavailable, "connection refused")), false)
+	if code := errors.ToGRPCStatus(err).Code(); code != codes.Unavailable {
+		t.Errorf("Expected the status of the wrapped cause to be kept, got %s", code)
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+func TestUserMessage(t *testing.T) {
+	err := errors.WithUserMessages(errors.NewError("could not connect to 10.0.0.7:5432", nil, false), map[string]string{
+		"":   "Something went wrong, try again",
+		"hi": "कुछ गलत हो गया, फिर से कोशिश करें",
+	})
+
+	if message, ok := errors.UserMessage(err); !ok || message != "Something went wrong, try again" {
+		t.Errorf("Expected the message for any locale, got %q", message)
+	}
+	if message, _ := errors.UserMessage(err, "hi-IN"); message != "कुछ गलत हो गया, फिर से कोशिश करें" {
+		t.Errorf("Expected the message of the base locale, got %q", message)
+	}
+	if message, _ := errors.UserMessage(err, "ta", "hi"); message != "कुछ गलत हो गया, फिर से कोशिश करें" {
+		t.Errorf("Expected the message of the second locale, got %q", message)
+	}
+	if err.Error() != "could not connect to 10.0.0.7:5432" {
+		t.Errorf("Expected the internal message to be kept, got %s", err)
+	}
+
+	wrapped := errors.WithUserMessage(errors.NewError("retrying", err, false), "Try again in a minute")
+	if message, _ := errors.UserMessage(wrapped, "hi"); message != "Try again in a minute" {
+		t.Errorf("Expected the message closest to the top, got %q", message)
+	}
+
+	if _, ok := errors.UserMessage(errors.NewError("plain", nil, false)); ok {
+		t.Error("Expected no user message")
+	}
+	if errors.WithUserMessage(nil, "message") != nil {
+		t.Error("Expected nil for a nil error")
+	}
+}
+
+func TestUserMessageBodies(t *testing.T) {
+	err := errors.WithUserMessages(errors.NewError("could not connect to 10.0.0.7:5432", nil, false), map[string]string{
+		"":   "Something went wrong, try again",
+		"hi": "कुछ गलत हो गया",
+	})
+
+	if body := errors.ToBody(context.Background(), err); body.Message != "Something went wrong, try again" {
+		t.Errorf("Expected the user message to replace the redacted one, got %s", body.Message)
+	}
+	if body := errors.ToBody(context.Background(), err, errors.WithLocales("hi-IN")); body.Message != "कुछ गलत हो गया" {
+		t.Errorf("Expected the user message of the locale, got %s", body.Message)
+	}
+
+	s := errors.ToGRPCStatus(err)
+	if s.Message() != err.Error() {
+		t.Errorf("Expected the internal message in the status, got %s", s.Message())
+	}
+	var localized *errdetails.LocalizedMessage
+	for _, detail := range s.Details() {
+		if message, ok := detail.(*errdetails.LocalizedMessage); ok {
+			localized = message
+		}
+	}
+	if localized == nil || localized.Message != "Something went wrong, try again" {
+		t.Errorf("Expected the user message as a LocalizedMessage detail, got %v", localized)
+	}
+}
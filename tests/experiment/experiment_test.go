@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/skit-ai/vcore/experiment"
+)
+
+var greeting = experiment.Experiment{
+	Name:     "greeting",
+	Variants: []experiment.Variant{{Name: "control", Weight: 50}, {Name: "short", Weight: 50}},
+}
+
+func TestAssign(t *testing.T) {
+	ctx := context.Background()
+	var exposures []experiment.Exposure
+	client := experiment.New(experiment.WithExposureLogger(experiment.ExposureLoggerFunc(func(_ context.Context, exposure experiment.Exposure) error {
+		exposures = append(exposures, exposure)
+		return nil
+	})))
+	if err := client.Register(greeting); err != nil {
+		t.Fatal(err)
+	}
+
+	// The assignment is deterministic on the unit, and split as per the weights
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		unit := fmt.Sprintf("tenant-%d", i)
+		variant := client.Variant(ctx, "greeting", unit)
+		if again := client.Variant(ctx, "greeting", unit); again != variant {
+			t.Fatalf("Expected %s to be assigned the same variant, got %s and %s", unit, variant, again)
+		}
+		counts[variant]++
+	}
+	if counts["control"] < 400 || counts["short"] < 400 {
+		t.Errorf("Expected the units to be split evenly, got %v", counts)
+	}
+	if len(exposures) != 2000 || exposures[0].Reason != experiment.Assigned {
+		t.Errorf("Expected every assignment to be exposed, got %d exposures", len(exposures))
+	}
+
+	if assignment := client.Assign(ctx, "unknown", "tenant-1", nil); assignment.Reason != experiment.Unknown || assignment.Variant != "" {
+		t.Errorf("Expected unknown experiments to assign no variant, got %+v", assignment)
+	}
+	if len(exposures) != 2000 {
+		t.Error("Expected no exposure to unknown experiments")
+	}
+}
+
+func TestAssignTraffic(t *testing.T) {
+	client := experiment.New()
+	partial := greeting
+	partial.Traffic = 0.1
+	if err := client.Register(partial); err != nil {
+		t.Fatal(err)
+	}
+
+	var enrolled int
+	for i := 0; i < 1000; i++ {
+		assignment := client.Assign(context.Background(), "greeting", fmt.Sprintf("tenant-%d", i), nil)
+		if assignment.Enrolled() {
+			enrolled++
+		} else if assignment.Variant != "control" || assignment.Reason != experiment.NotEnrolled {
+			t.Fatalf("Expected the units not enrolled to get the control, got %+v", assignment)
+		}
+	}
+	if enrolled < 50 || enrolled > 150 {
+		t.Errorf("Expected about 10%% of the units to be enrolled, got %d", enrolled)
+	}
+}
+
+func TestAssignStopped(t *testing.T) {
+	ctx := context.Background()
+	breached := false
+	t.Setenv("EXPERIMENTS_DISABLED", "pricing")
+	client := experiment.New(
+		experiment.WithKillSwitch(experiment.EnvKillSwitch()),
+		experiment.WithGuardrail(func(string) bool { return breached }),
+	)
+	pricing := greeting
+	pricing.Name = "pricing"
+	for _, e := range []experiment.Experiment{greeting, pricing} {
+		if err := client.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if assignment := client.Assign(ctx, "pricing", "tenant-1", nil); assignment.Reason != experiment.Killed || assignment.Variant != "control" {
+		t.Errorf("Expected the killed experiment to assign the control, got %+v", assignment)
+	}
+	breached = true
+	if assignment := client.Assign(ctx, "greeting", "tenant-1", nil); assignment.Reason != experiment.GuardRailed || assignment.Variant != "control" {
+		t.Errorf("Expected the guard-railed experiment to assign the control, got %+v", assignment)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	client := experiment.New()
+	invalid := []experiment.Experiment{
+		{Variants: greeting.Variants},
+		{Name: "greeting"},
+		{Name: "greeting", Variants: []experiment.Variant{{Name: "control", Weight: -1}}},
+	}
+	for _, e := range invalid {
+		if err := client.Register(e); err == nil {
+			t.Errorf("Expected %+v to be invalid", e)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	streadway "github.com/streadway/amqp"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/failover"
+	"github.com/skit-ai/vcore/httpserver"
+)
+
+// In memory bucket, failing every call while down
+type bucket struct {
+	mutex   sync.Mutex
+	objects map[string]string
+	down    bool
+}
+
+func newBucket(objects map[string]string) *bucket {
+	return &bucket{objects: maps.Clone(objects)}
+}
+
+func (b *bucket) setDown(down bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.down = down
+}
+
+func (b *bucket) get(key string) (string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.down {
+		return "", stderrors.New("connection refused")
+	}
+	object, ok := b.objects[key]
+	if !ok {
+		return "", errors.WithCode(errors.NewError(key+" not found", nil, false), errors.NotFound)
+	}
+	return object, nil
+}
+
+func (b *bucket) Stat(_ context.Context, key string) (httpserver.Object, error) {
+	object, err := b.get(key)
+	return httpserver.Object{Size: int64(len(object))}, err
+}
+
+func (b *bucket) Open(_ context.Context, key string, offset int64) (io.ReadCloser, error) {
+	object, err := b.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(object[offset:])), nil
+}
+
+func (b *bucket) Put(_ context.Context, key string, body io.ReadSeeker, _ string) error {
+	data, _ := io.ReadAll(body)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.down {
+		return stderrors.New("connection refused")
+	}
+	b.objects[key] = string(data)
+	return nil
+}
+
+func TestObjectsFailover(t *testing.T) {
+	primary := newBucket(map[string]string{"a.wav": "primary"})
+	secondary := newBucket(map[string]string{"a.wav": "secondary"})
+
+	var failovers []failover.Region
+	var hints []string
+	objects := failover.NewObjects(primary, secondary,
+		failover.WithThreshold(2),
+		failover.WithOnFailover(func(_, to failover.Region) { failovers = append(failovers, to) }),
+		failover.WithReplicationHint(func(_ context.Context, key string, to failover.Region) {
+			hints = append(hints, key+"->"+to.String())
+		}))
+	ctx := context.Background()
+
+	if _, err := objects.Stat(ctx, "missing.wav"); errors.CodeOf(err) != errors.NotFound {
+		t.Errorf("Expected missing objects to be not found, got %v", err)
+	}
+	if _, region := objects.Active(); region != failover.Primary {
+		t.Errorf("Expected objects not found to keep the primary healthy, got %s", region)
+	}
+
+	// Reads fall back to the secondary while the primary is down, until failing over to it
+	primary.setDown(true)
+	for i := 0; i < 2; i++ {
+		if object, err := objects.Stat(ctx, "a.wav"); err != nil || object.Size != int64(len("secondary")) {
+			t.Errorf("Expected the object of the secondary, got %v %v", object, err)
+		}
+	}
+	if _, region := objects.Active(); region != failover.Secondary || !slices.Equal(failovers, []failover.Region{failover.Secondary}) {
+		t.Fatalf("Expected to fail over to the secondary, got %s %v", region, failovers)
+	}
+
+	if err := objects.Put(ctx, "b.wav", bytes.NewReader([]byte("written")), "audio/wav"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := secondary.objects["b.wav"]; !ok || !slices.Equal(hints, []string{"b.wav->primary"}) {
+		t.Errorf("Expected the write to the secondary to hint its replication to the primary, got %v", hints)
+	}
+
+	// Failing back once the primary has succeeded threshold times in a row
+	primary.setDown(false)
+	objects.Report(failover.Primary, nil)
+	objects.Report(failover.Primary, nil)
+	if _, region := objects.Active(); region != failover.Primary {
+		t.Errorf("Expected to fail back to the primary, got %s", region)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	primary := newBucket(map[string]string{"a": "same", "b": "only in the primary", "c": "short"})
+	secondary := newBucket(map[string]string{"a": "same", "c": "longer one", "d": "only in the secondary"})
+	objects := failover.NewObjects(primary, secondary)
+
+	report, err := objects.Reconcile(context.Background(), slices.Values([]string{"a", "b", "c", "d", "e"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 5 || report.Reconciled() {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if !slices.Equal(report.Missing[failover.Secondary], []string{"b"}) || !slices.Equal(report.Missing[failover.Primary], []string{"d"}) {
+		t.Errorf("Unexpected missing keys %v", report.Missing)
+	}
+	if !slices.Equal(report.Mismatched, []string{"c"}) || report.Failed != nil {
+		t.Errorf("Unexpected mismatched keys %v, failures %v", report.Mismatched, report.Failed)
+	}
+
+	secondary.setDown(true)
+	if report, _ := objects.Reconcile(context.Background(), slices.Values([]string{"a"})); report.Failed["a"] == "" {
+		t.Errorf("Expected the key to fail to be checked, got %+v", report)
+	}
+}
+
+type publisher struct {
+	published []string
+	err       error
+}
+
+func (p *publisher) Publish(_, _, routingKey, _ string, _ streadway.Table, _ bool) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, routingKey)
+	return nil
+}
+
+func TestPublishersFailover(t *testing.T) {
+	primary, secondary := &publisher{err: stderrors.New("broker down")}, &publisher{}
+	publishers := failover.NewPublishers(primary, secondary, failover.WithThreshold(1))
+
+	if err := publishers.Publish("calls", "topic", "call.ended", "{}", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, region := publishers.Active(); region != failover.Secondary || len(secondary.published) != 1 {
+		t.Errorf("Expected to publish to and fail over to the secondary, got %s %v", region, secondary.published)
+	}
+
+	secondary.err = stderrors.New("broker down")
+	if err := publishers.Publish("calls", "topic", "call.ended", "{}", nil, false); err == nil {
+		t.Error("Expected an error with both brokers down")
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	primary, secondary := newBucket(nil), newBucket(nil)
+	objects := failover.NewObjects(primary, secondary, failover.WithThreshold(1),
+		failover.WithHealthCheck(func(ctx context.Context, store httpserver.ObjectStore) error {
+			_, err := store.Stat(ctx, "health")
+			if errors.CodeOf(err) == errors.NotFound {
+				return nil
+			}
+			return err
+		}))
+
+	primary.setDown(true)
+	objects.Check(context.Background())
+	if _, region := objects.Active(); region != failover.Secondary {
+		t.Errorf("Expected the health check to fail over, got %s", region)
+	}
+	primary.setDown(false)
+	objects.Check(context.Background())
+	if _, region := objects.Active(); region != failover.Primary {
+		t.Errorf("Expected the health check to fail back, got %s", region)
+	}
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/skit-ai/vcore/features"
+)
+
+var service = []features.Feature{
+	{Name: "mirroring", Disabled: true},
+	{Name: "shadowdiff", Disabled: true, Requires: []string{"mirroring"}},
+}
+
+func TestResolve(t *testing.T) {
+	set, err := features.Resolve("-profiling, +mirroring, shadowdiff", service...)
+	if err != nil {
+		t.Fatalf("Could not resolve the features: %s", err)
+	}
+	if set.Enabled(features.Profiling) || !set.Enabled(features.Tracing) || !set.Enabled("shadowdiff") {
+		t.Errorf("Expected profiling off and tracing and shadowdiff on, got %s", set)
+	}
+	if expected := "crash-reports=on goroutine-monitor=off mirroring=on profiling=off shadowdiff=on tracing=on"; set.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, set.String())
+	}
+}
+
+func TestResolveValidatesDependencies(t *testing.T) {
+	if _, err := features.Resolve("shadowdiff", service...); err == nil {
+		t.Errorf("Expected shadowdiff to be rejected without mirroring")
+	}
+	if _, err := features.Resolve("-unknown", service...); err == nil {
+		t.Errorf("Expected an unknown feature to be rejected")
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/skit-ai/vcore/grpcserver"
+	"google.golang.org/grpc"
+)
+
+func recording(calls *[]string, name string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		*calls = append(*calls, name)
+		return handler(ctx, req)
+	}
+}
+
+func TestInterceptorChainOrder(t *testing.T) {
+	var calls []string
+	interceptor, err := grpcserver.NewInterceptorChain().
+		Unary(grpcserver.Validation, "validate", recording(&calls, "validate")).
+		Unary(grpcserver.Auth, "jwt", recording(&calls, "jwt")).
+		Unary(grpcserver.Recovery, "sentry", recording(&calls, "sentry")).
+		Unary(grpcserver.Auth, "scopes", recording(&calls, "scopes")).
+		UnaryInterceptor()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := interceptor(context.Background(), "request", &grpc.UnaryServerInfo{FullMethod: "/skit.Dialogue/Turn"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls = append(calls, "handler")
+			return "response", nil
+		})
+	if err != nil || response != "response" {
+		t.Fatalf("Expected the response of the handler, got %v and %v", response, err)
+	}
+	if order := strings.Join(calls, ","); order != "sentry,jwt,scopes,validate,handler" {
+		t.Errorf("Expected the interceptors to run in the order of their positions, got %s", order)
+	}
+}
+
+func TestInterceptorChainValidate(t *testing.T) {
+	noop := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	}
+
+	for name, chain := range map[string]*grpcserver.InterceptorChain{
+		"position": grpcserver.NewInterceptorChain().Unary(grpcserver.Unspecified, "jwt", noop),
+		"duplicate": grpcserver.NewInterceptorChain().
+			Unary(grpcserver.Auth, "jwt", noop).
+			Unary(grpcserver.Validation, "jwt", noop),
+		"recoveries": grpcserver.NewInterceptorChain().
+			Unary(grpcserver.Recovery, "sentry", noop).
+			Unary(grpcserver.Recovery, "recovery", noop),
+	} {
+		if _, err := chain.ServerOptions(); err == nil {
+			t.Errorf("Expected the chain with the invalid %s to be rejected", name)
+		}
+	}
+
+	if _, err := grpcserver.NewInterceptorChain().Unary(grpcserver.Auth, "jwt", noop).ServerOptions(); err != nil {
+		t.Errorf("Expected the chain to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/guardrail"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+func TestGuardrail(t *testing.T) {
+	sim := simulation.New(42, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC))
+	var alerts []guardrail.Alert
+	guard := guardrail.New(map[guardrail.Unit]guardrail.Limit{
+		guardrail.LLMTokens:   {Soft: 800, Hard: 1000},
+		guardrail.CallMinutes: {Hard: 60},
+	},
+		guardrail.WithClock(sim),
+		guardrail.WithTenantLimits("globex", map[guardrail.Unit]guardrail.Limit{guardrail.LLMTokens: {Hard: 5000}}),
+		guardrail.WithAlerter(func(ctx context.Context, alert guardrail.Alert) {
+			alerts = append(alerts, alert)
+		}),
+	)
+	ctx := context.Background()
+
+	guard.Record(ctx, "acme", guardrail.LLMTokens, 500)
+	if decision := guard.Check("acme", guardrail.LLMTokens, false); decision != guardrail.Allow {
+		t.Errorf("Expected the usage within the limits to be allowed, got %s", decision)
+	}
+
+	guard.Record(ctx, "acme", guardrail.LLMTokens, 400)
+	guard.Record(ctx, "acme", guardrail.LLMTokens, 50)
+	if decision := guard.Check("acme", guardrail.LLMTokens, false); decision != guardrail.Degrade {
+		t.Errorf("Expected the usage past the soft threshold to be degraded, got %s", decision)
+	}
+
+	guard.Record(ctx, "acme", guardrail.LLMTokens, 100)
+	if decision := guard.Check("acme", guardrail.LLMTokens, false); decision != guardrail.Block {
+		t.Errorf("Expected the usage past the hard threshold to be blocked, got %s", decision)
+	}
+	if decision := guard.Check("acme", guardrail.LLMTokens, true); decision != guardrail.Allow {
+		t.Errorf("Expected critical usage to be allowed, got %s", decision)
+	}
+	if decision := guard.Check("acme", guardrail.CallMinutes, false); decision != guardrail.Allow {
+		t.Errorf("Expected the units to be tracked separately, got %s", decision)
+	}
+
+	guard.Record(ctx, "globex", guardrail.LLMTokens, 1050)
+	if decision := guard.Check("globex", guardrail.LLMTokens, false); decision != guardrail.Allow {
+		t.Errorf("Expected the limits of the tenant to apply, got %s", decision)
+	}
+
+	if len(alerts) != 2 || alerts[0].Decision != guardrail.Degrade || alerts[0].Threshold != 800 ||
+		alerts[1].Decision != guardrail.Block || alerts[1].Spent != 1050 || alerts[1].Tenant != "acme" {
+		t.Errorf("Expected an alert per threshold crossed, got %+v", alerts)
+	}
+
+	sim.Advance(14 * time.Hour)
+	if spent := guard.Spent("acme", guardrail.LLMTokens); spent != 0 {
+		t.Errorf("Expected the spend to be reset in the next window, got %v", spent)
+	}
+	if decision := guard.Check("acme", guardrail.LLMTokens, false); decision != guardrail.Allow {
+		t.Errorf("Expected the usage to be allowed in the next window, got %s", decision)
+	}
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/skit-ai/vcore/hashring"
+)
+
+func keys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("call-%d", i)
+	}
+	return keys
+}
+
+func TestDistribution(t *testing.T) {
+	ring := hashring.New()
+	ring.Add("worker-0", "worker-1", "worker-2", "worker-3")
+
+	counts := make(map[string]int)
+	for _, key := range keys(20000) {
+		member, _ := ring.Get(key)
+		counts[member]++
+	}
+	for member, count := range counts {
+		// Within 20% of the average of 5000
+		if math.Abs(float64(count)-5000) > 1000 {
+			t.Errorf("Expected the keys to be spread evenly, %s has %d", member, count)
+		}
+	}
+}
+
+func TestStableAcrossScaling(t *testing.T) {
+	ring := hashring.New()
+	ring.Add("worker-0", "worker-1", "worker-2", "worker-3")
+
+	before := make(map[string]string)
+	for _, key := range keys(10000) {
+		before[key], _ = ring.Get(key)
+	}
+
+	ring.Add("worker-4")
+	moved := 0
+	for key, member := range before {
+		after, _ := ring.Get(key)
+		if after != member {
+			moved++
+			if after != "worker-4" {
+				t.Fatalf("Expected keys to move to the new member only, %s moved from %s to %s", key, member, after)
+			}
+		}
+	}
+	// About a fifth of the keys move to the new member
+	if moved < 1000 || moved > 3000 {
+		t.Errorf("Expected about 2000 keys to move, %d did", moved)
+	}
+
+	ring.Remove("worker-4")
+	for key, member := range before {
+		if after, _ := ring.Get(key); after != member {
+			t.Fatalf("Expected %s to move back to %s, got %s", key, member, after)
+		}
+	}
+}
+
+func TestGetN(t *testing.T) {
+	ring := hashring.New()
+	if _, ok := ring.Get("call-1"); ok || ring.GetN("call-1", 2) != nil {
+		t.Error("Expected no members of an empty ring")
+	}
+
+	ring.Add("worker-0", "worker-1", "worker-2")
+	members := ring.GetN("call-1", 5)
+	if len(members) != 3 {
+		t.Fatalf("Expected all the members, got %v", members)
+	}
+	if first, _ := ring.Get("call-1"); members[0] != first {
+		t.Errorf("Expected the member of the key first, got %v", members)
+	}
+	slices.Sort(members)
+	if !slices.Equal(members, ring.Members()) {
+		t.Errorf("Expected distinct members, got %v", members)
+	}
+}
+
+func TestBoundedLoads(t *testing.T) {
+	ring := hashring.New(hashring.WithLoadFactor(1.25))
+	ring.Add("worker-0", "worker-1", "worker-2", "worker-3")
+
+	// The same hot key acquired over and over again spills over to the other members
+	for i := 0; i < 100; i++ {
+		if _, ok := ring.Acquire("hot-tenant"); !ok {
+			t.Fatal("Expected a member")
+		}
+	}
+	for member, load := range ring.Loads() {
+		if load > 32 {
+			t.Errorf("Expected the load of %s to be bounded to 32, got %d", member, load)
+		}
+	}
+
+	home, _ := ring.Get("hot-tenant")
+	for i := 0; i < 100; i++ {
+		ring.Release(home)
+	}
+	if member, _ := ring.Acquire("hot-tenant"); member != home {
+		t.Errorf("Expected the key on its member once released, got %s instead of %s", member, home)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ring := hashring.New()
+	updates := make(chan []string)
+	done := make(chan struct{})
+	go func() {
+		ring.Watch(context.Background(), updates)
+		close(done)
+	}()
+
+	updates <- []string{"worker-0", "worker-1"}
+	updates <- []string{"worker-1", "worker-2"}
+	close(updates)
+	<-done
+
+	if members := ring.Members(); !slices.Equal(members, []string{"worker-1", "worker-2"}) {
+		t.Errorf("Expected the members of the last update, got %v", members)
+	}
+}
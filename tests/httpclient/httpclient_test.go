@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/httpclient"
+)
+
+type call struct {
+	ID string `json:"id"`
+}
+
+func TestRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Query().Get("expand") != "turns" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(call{ID: r.URL.Path})
+	}))
+	defer server.Close()
+
+	client := httpclient.New(server.URL+"/", httpclient.WithRetries(2, time.Millisecond), httpclient.WithHeader("Authorization", "Bearer token"))
+
+	var out call
+	if err := client.Do(context.Background(), http.MethodGet, "/v1/calls/42", nil, &out, httpclient.WithQuery("expand", "turns")); err != nil {
+		t.Fatal(err)
+	}
+	if out.ID != "/v1/calls/42" || attempts.Load() != 3 {
+		t.Errorf("Expected the call after 3 attempts, got %+v after %d", out, attempts.Load())
+	}
+
+	// Requests which are not idempotent are not retried
+	attempts.Store(0)
+	err := client.Do(context.Background(), http.MethodPost, "/v1/calls", call{ID: "42"}, nil)
+	var statusErr *httpclient.StatusError
+	if !errors.As(err, &statusErr) || statusErr.Status != http.StatusServiceUnavailable || attempts.Load() != 1 {
+		t.Errorf("Expected the post to fail once with 503, got %v after %d attempts", err, attempts.Load())
+	}
+}
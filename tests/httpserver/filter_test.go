@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skit-ai/vcore/httpserver"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+func TestAllowHosts(t *testing.T) {
+	handler := httpserver.AllowHosts("admin.internal", "*.skit.ai")(okHandler)
+	for host, status := range map[string]int{
+		"admin.internal":      http.StatusOK,
+		"ADMIN.internal:8080": http.StatusOK,
+		"calls.skit.ai":       http.StatusOK,
+		"skit.ai":             http.StatusMisdirectedRequest,
+		"evil.com":            http.StatusMisdirectedRequest,
+	} {
+		r := httptest.NewRequest("GET", "/admin", nil)
+		r.Host = host
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != status {
+			t.Errorf("Expected %d for the host %s, got %d", status, host, w.Code)
+		}
+	}
+}
+
+func TestAllowIPs(t *testing.T) {
+	middleware, err := httpserver.AllowIPs([]string{"10.0.0.0/8", "192.168.1.7"}, []string{"172.16.0.0/12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := middleware(okHandler)
+
+	for name, test := range map[string]struct {
+		remote    string
+		forwarded string
+		status    int
+	}{
+		"allowed":         {"10.1.2.3:4000", "", http.StatusOK},
+		"single":          {"192.168.1.7:4000", "", http.StatusOK},
+		"denied":          {"8.8.8.8:4000", "", http.StatusForbidden},
+		"proxied":         {"172.16.0.2:4000", "10.1.2.3", http.StatusOK},
+		"proxied twice":   {"172.16.0.2:4000", "10.1.2.3, 172.16.0.9", http.StatusOK},
+		"spoofed":         {"172.16.0.2:4000", "10.1.2.3, 8.8.8.8", http.StatusForbidden},
+		"untrusted proxy": {"8.8.8.8:4000", "10.1.2.3", http.StatusForbidden},
+	} {
+		r := httptest.NewRequest("GET", "/admin", nil)
+		r.RemoteAddr = test.remote
+		if test.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("Expected %d for the %s client, got %d", test.status, name, w.Code)
+		}
+	}
+
+	if _, err := httpserver.AllowIPs([]string{"10.0.0.0/33"}, nil); err == nil {
+		t.Error("Expected an invalid CIDR to be rejected")
+	}
+}
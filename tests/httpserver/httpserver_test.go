@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/httpserver"
+	"golang.org/x/net/http2"
+)
+
+// Returns a free address on the loopback interface
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// Waits for the server to accept requests
+func waitFor(t *testing.T, client *http.Client, url string) *http.Response {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if response, err := client.Get(url); err == nil {
+			return response
+		}
+	}
+	t.Fatalf("The server at %s did not come up", url)
+	return nil
+}
+
+func TestRunH2C(t *testing.T) {
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- httpserver.Run(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, r.Proto)
+		}), httpserver.WithAddr(addr), httpserver.WithH2C(), httpserver.WithHTTP2(10, time.Minute))
+	}()
+
+	h2c := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	response := waitFor(t, h2c, "http://"+addr)
+	proto, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(proto) != "HTTP/2.0" {
+		t.Errorf("Expected the request to be served over h2c, got %s", proto)
+	}
+
+	response = waitFor(t, http.DefaultClient, "http://"+addr)
+	proto, _ = io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(proto) != "HTTP/1.1" {
+		t.Errorf("Expected HTTP/1 to be served along with h2c, got %s", proto)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected the server to shut down gracefully, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The server did not shut down")
+	}
+}
+
+func TestRunUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "media.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- httpserver.Run(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "ok")
+		}), httpserver.WithUnixSocket(path, 0660))
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	response := waitFor(t, client, "http://media")
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != "ok" {
+		t.Errorf("Expected the request to be served over the socket, got %s", body)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Error(err)
+	} else if info.Mode().Perm() != 0660 {
+		t.Errorf("Expected the socket to have the permissions set, got %v", info.Mode().Perm())
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected the server to shut down gracefully, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket to be removed once the server is shut down")
+	}
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/httpserver"
+)
+
+type memoryObjects struct {
+	objects map[string][]byte
+	offsets []int64
+	down    bool
+}
+
+func (m *memoryObjects) Stat(ctx context.Context, key string) (httpserver.Object, error) {
+	if m.down {
+		return httpserver.Object{}, errors.NewError("connection refused", nil, false)
+	}
+	content, ok := m.objects[key]
+	if !ok {
+		return httpserver.Object{}, errors.WithCode(errors.NewError("no such key", nil, false), errors.NotFound)
+	}
+	return httpserver.Object{
+		Size:        int64(len(content)),
+		ContentType: "binary/octet-stream",
+		ModTime:     time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		ETag:        `"` + key + `-v1"`,
+	}, nil
+}
+
+func (m *memoryObjects) Open(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	m.offsets = append(m.offsets, offset)
+	return io.NopCloser(bytes.NewReader(m.objects[key][offset:])), nil
+}
+
+func (m *memoryObjects) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return "https://bucket.s3.amazonaws.com/" + key + "?X-Amz-Expires=" + expiry.String(), nil
+}
+
+func recordingKey(r *http.Request) string {
+	return strings.TrimPrefix(r.URL.Path, "/recordings/")
+}
+
+func TestServeObjectRanges(t *testing.T) {
+	recording := bytes.Repeat([]byte("0123456789"), 100)
+	store := &memoryObjects{objects: map[string][]byte{"call.mp3": recording}}
+	handler := httpserver.ServeObject(store, recordingKey)
+
+	r := httptest.NewRequest("GET", "/recordings/call.mp3", nil)
+	r.Header.Set("Range", "bytes=500-509")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("Expected 206, got %d", w.Code)
+	}
+	if w.Body.String() != "0123456789" || w.Header().Get("Content-Range") != "bytes 500-509/1000" {
+		t.Errorf("Unexpected range %s of %q", w.Header().Get("Content-Range"), w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "audio/mpeg" {
+		t.Errorf("Expected the type of the audio format, got %s", w.Header().Get("Content-Type"))
+	}
+	if len(store.offsets) != 1 || store.offsets[0] != 500 {
+		t.Errorf("Expected the object to be opened at the range only, got %v", store.offsets)
+	}
+
+	r = httptest.NewRequest("GET", "/recordings/call.mp3", nil)
+	r.Header.Set("If-None-Match", `"call.mp3-v1"`)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for the ETag of the object, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/recordings/missing.mp3", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing object, got %d", w.Code)
+	}
+}
+
+func TestServeObjectRedirects(t *testing.T) {
+	store := &memoryObjects{objects: map[string][]byte{"short.wav": make([]byte, 10), "long.wav": make([]byte, 100)}}
+	handler := httpserver.ServeObject(store, recordingKey, httpserver.WithSignedRedirects(50, time.Minute))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/recordings/short.wav", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "audio/wav" {
+		t.Errorf("Expected the short recording to be served, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/recordings/long.wav", nil))
+	if w.Code != http.StatusFound || !strings.HasPrefix(w.Header().Get("Location"), "https://bucket.s3.amazonaws.com/long.wav") {
+		t.Errorf("Expected a redirect to the signed URL, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	store.down = true
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/recordings/short.wav", nil))
+	if w.Code != http.StatusFound {
+		t.Errorf("Expected a redirect while the store is down, got %d", w.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/httpserver"
+)
+
+func TestJSONConditional(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	config := map[string]interface{}{"voice": "en-IN-female", "barge_in": true}
+	render := func(headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/config", nil)
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		if err := httpserver.JSON(w, r, http.StatusOK, config, httpserver.WithLastModified(modified)); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+
+	first := render(nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || !strings.Contains(first.Body.String(), "en-IN-female") {
+		t.Fatalf("Expected the body tagged with an ETag, got %d %q %s", first.Code, etag, first.Body)
+	}
+
+	for name, test := range map[string]struct {
+		headers map[string]string
+		status  int
+	}{
+		"matching etag":      {map[string]string{"If-None-Match": `"stale", ` + etag}, http.StatusNotModified},
+		"weak etag":          {map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		"stale etag":         {map[string]string{"If-None-Match": `"stale"`}, http.StatusOK},
+		"not modified since": {map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusNotModified},
+		"modified since":     {map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		"etag first":         {map[string]string{"If-None-Match": `"stale"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusOK},
+	} {
+		w := render(test.headers)
+		if w.Code != test.status {
+			t.Errorf("Expected %d for the request with the %s, got %d", test.status, name, w.Code)
+		}
+		if w.Code == http.StatusNotModified && w.Body.Len() > 0 {
+			t.Errorf("Expected no body with 304 for the request with the %s", name)
+		}
+	}
+}
+
+func TestETag(t *testing.T) {
+	body := []byte(`{"voice":"en-IN-female"}`)
+	if strong, weak := httpserver.ETag(body, false), httpserver.ETag(body, true); weak != "W/"+strong || !strings.HasPrefix(strong, `"`) {
+		t.Errorf("Expected the weak ETag to be the strong one prefixed by W/, got %s and %s", strong, weak)
+	}
+	if httpserver.ETag(body, false) == httpserver.ETag([]byte(`{}`), false) {
+		t.Error("Expected different bodies to have different ETags")
+	}
+}
+
+func TestError(t *testing.T) {
+	r := httptest.NewRequest("GET", "/calls/42", nil)
+	w := httptest.NewRecorder()
+	httpserver.Error(w, r, errors.WithCode(errors.NewError("call not found", nil, false), errors.NotFound))
+
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a JSON 404, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if body := w.Body.String(); body != `{"code":"not_found","message":"call not found"}` {
+		t.Errorf("Unexpected body %s", body)
+	}
+}
+
+func TestErrorUserMessage(t *testing.T) {
+	r := httptest.NewRequest("GET", "/calls/42", nil)
+	r.Header.Set("Accept-Language", "hi-IN,hi;q=0.9,en;q=0.8")
+	w := httptest.NewRecorder()
+	httpserver.Error(w, r, errors.WithUserMessages(errors.NewError("could not connect", nil, false), map[string]string{
+		"":   "Something went wrong",
+		"hi": "कुछ गलत हो गया",
+	}))
+
+	if body := w.Body.String(); body != `{"code":"unknown","message":"कुछ गलत हो गया"}` {
+		t.Errorf("Unexpected body %s", body)
+	}
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/skit-ai/vcore/httpserver"
+)
+
+func TestServeStatic(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html":         {Data: []byte("<html>dashboard</html>")},
+		"app.3f2b1c.js":      {Data: []byte("console.log('dashboard')")},
+		"app.3f2b1c.js.br":   {Data: []byte("brotli")},
+		"app.3f2b1c.js.gz":   {Data: []byte("gzip")},
+		".env":               {Data: []byte("SECRET=1")},
+		"reports/index.html": {Data: []byte("<html>reports</html>")},
+	}
+	handler := httpserver.ServeStatic(assets, httpserver.WithSPA())
+	serve := func(target string, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", target, nil)
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve("/", nil); w.Body.String() != "<html>dashboard</html>" || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("Expected the index to be served revalidated, got %q with %q", w.Body, w.Header().Get("Cache-Control"))
+	}
+	if w := serve("/calls/42", nil); w.Body.String() != "<html>dashboard</html>" {
+		t.Errorf("Expected the index for the routes of the app, got %d", w.Code)
+	}
+	if w := serve("/reports/", nil); w.Body.String() != "<html>reports</html>" {
+		t.Errorf("Expected the index of the directory, got %q", w.Body)
+	}
+	if w := serve("/missing.js", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for the missing assets, got %d", w.Code)
+	}
+	for _, target := range []string{"/.env", "/../../etc/passwd", "/%2e%2e/%2e%2e/etc/passwd"} {
+		if w := serve(target, nil); w.Body.String() == "SECRET=1" || w.Code == http.StatusOK && w.Body.String() != "<html>dashboard</html>" {
+			t.Errorf("Expected %s not to be served, got %d %q", target, w.Code, w.Body)
+		}
+	}
+
+	w := serve("/app.3f2b1c.js", map[string]string{"Accept-Encoding": "gzip, br"})
+	if w.Body.String() != "brotli" || w.Header().Get("Content-Encoding") != "br" || w.Header().Get("Content-Type") != "text/javascript; charset=utf-8" {
+		t.Errorf("Expected the brotli variant typed as the asset, got %q %q %q", w.Body, w.Header().Get("Content-Encoding"), w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("Cache-Control") != "public, max-age=3600" {
+		t.Errorf("Expected the asset to be cached, got %q", w.Header().Get("Cache-Control"))
+	}
+	if w := serve("/app.3f2b1c.js", map[string]string{"Accept-Encoding": "br;q=0, gzip"}); w.Body.String() != "gzip" {
+		t.Errorf("Expected the gzip variant as brotli is refused, got %q", w.Body)
+	}
+	if w := serve("/app.3f2b1c.js", nil); w.Body.String() != "console.log('dashboard')" || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected the uncompressed asset, got %q", w.Body)
+	}
+
+	etag := serve("/app.3f2b1c.js", nil).Header().Get("ETag")
+	if w := serve("/app.3f2b1c.js", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for the cached asset, got %d", w.Code)
+	}
+}
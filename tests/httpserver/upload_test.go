@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/httpserver"
+)
+
+// Header of a WAVE file, sniffed as audio/wave
+var wave = append([]byte("RIFF\x24\x00\x00\x00WAVEfmt "), make([]byte, 1024)...)
+
+func uploadRequest(t *testing.T, files map[string][]byte, values map[string]string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range values {
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		part, err := writer.CreateFormFile("audio", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write(content)
+	}
+	_ = writer.Close()
+
+	r := httptest.NewRequest("POST", "/upload", &body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+func store(stored map[string][]byte) httpserver.Sink {
+	return func(ctx context.Context, part httpserver.Part, content io.Reader) error {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		stored[part.FileName] = data
+		return nil
+	}
+}
+
+func TestUpload(t *testing.T) {
+	stored := map[string][]byte{}
+	r := uploadRequest(t, map[string][]byte{"call.wav": wave}, map[string]string{"call_id": "42"})
+
+	uploaded, err := httpserver.Upload(r, store(stored), httpserver.WithAllowedTypes("audio/*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploaded.Parts) != 1 {
+		t.Fatalf("Expected a part, got %v", uploaded.Parts)
+	}
+	part := uploaded.Parts[0]
+	if part.FormName != "audio" || part.ContentType != "audio/wave" || part.Size != int64(len(wave)) {
+		t.Errorf("Unexpected part %+v", part)
+	}
+	if !bytes.Equal(stored["call.wav"], wave) {
+		t.Errorf("Expected the file to be streamed to the sink, got %d bytes", len(stored["call.wav"]))
+	}
+	if uploaded.Values.Get("call_id") != "42" {
+		t.Errorf("Expected the form values, got %v", uploaded.Values)
+	}
+}
+
+func TestUploadRejections(t *testing.T) {
+	infected := stderrors.New("infected")
+	for name, test := range map[string]struct {
+		files  map[string][]byte
+		values map[string]string
+		opts   []httpserver.UploadOption
+		err    error
+		status int
+	}{
+		"type": {
+			files:  map[string][]byte{"call.wav": []byte("#!/bin/sh\nrm -rf /")},
+			opts:   []httpserver.UploadOption{httpserver.WithAllowedTypes("audio/wave", "audio/mpeg")},
+			err:    httpserver.ErrTypeNotAllowed,
+			status: http.StatusUnsupportedMediaType,
+		},
+		"size": {
+			files:  map[string][]byte{"call.wav": wave},
+			opts:   []httpserver.UploadOption{httpserver.WithPartLimit(int64(len(wave) - 1))},
+			err:    httpserver.ErrPartTooLarge,
+			status: http.StatusRequestEntityTooLarge,
+		},
+		"fields": {
+			values: map[string]string{"call_id": "42", "notes": "a long note"},
+			opts:   []httpserver.UploadOption{httpserver.WithFieldLimit(8)},
+			err:    httpserver.ErrPartTooLarge,
+			status: http.StatusRequestEntityTooLarge,
+		},
+		"parts": {
+			files:  map[string][]byte{"a.wav": wave, "b.wav": wave},
+			opts:   []httpserver.UploadOption{httpserver.WithMaxParts(1)},
+			err:    httpserver.ErrTooManyParts,
+			status: http.StatusRequestEntityTooLarge,
+		},
+		"scanner": {
+			files: map[string][]byte{"call.wav": wave},
+			opts: []httpserver.UploadOption{httpserver.WithScanner(
+				func(ctx context.Context, part httpserver.Part, content io.Reader) io.Reader {
+					return io.MultiReader(content, &failingReader{infected})
+				},
+			)},
+			err:    infected,
+			status: http.StatusInternalServerError,
+		},
+	} {
+		r := uploadRequest(t, test.files, test.values)
+		_, err := httpserver.Upload(r, store(map[string][]byte{}), test.opts...)
+		if err == nil {
+			t.Errorf("Expected the %s to be rejected", name)
+			continue
+		}
+		if err != test.err && errors.DeepestCause(err) != test.err {
+			t.Errorf("Expected %v for the %s, got %v", test.err, name, err)
+		}
+		if status := errors.HTTPStatus(err); status != test.status {
+			t.Errorf("Expected %d for the %s, got %d", test.status, name, status)
+		}
+	}
+}
+
+func TestUploadNotMultipart(t *testing.T) {
+	r := httptest.NewRequest("POST", "/upload", bytes.NewReader(wave))
+	if _, err := httpserver.Upload(r, store(map[string][]byte{})); errors.HTTPStatus(err) != http.StatusBadRequest {
+		t.Errorf("Expected a bad request, got %v", err)
+	}
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/latency"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+func TestTracker(t *testing.T) {
+	ctx := context.Background()
+	sim := simulation.New(42, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var breakdowns []latency.Breakdown
+	var changes []bool
+	tracker := latency.NewTracker(
+		latency.Budget{Total: time.Second, Stages: map[latency.Stage]time.Duration{latency.ASR: 300 * time.Millisecond, latency.TTS: 400 * time.Millisecond}},
+		latency.WithClock(sim),
+		latency.WithRecorder(func(_ context.Context, breakdown latency.Breakdown) {
+			breakdowns = append(breakdowns, breakdown)
+		}),
+		latency.WithDegradation(2, func(degraded bool) {
+			changes = append(changes, degraded)
+		}),
+	)
+
+	// Runs a turn with the ASR taking asr, run twice, and the TTS taking tts
+	turn := func(id string, asr, tts time.Duration) latency.Breakdown {
+		turn := tracker.StartTurn(id)
+		for i := 0; i < 2; i++ {
+			stop := turn.Start(latency.ASR)
+			sim.Advance(asr / 2)
+			stop()
+		}
+		turn.Record(latency.TTS, tts)
+		sim.Advance(tts)
+		return turn.End(ctx)
+	}
+
+	breakdown := turn("t-1", 200*time.Millisecond, 300*time.Millisecond)
+	if breakdown.Exceeded || breakdown.Stages[latency.ASR] != 200*time.Millisecond || breakdown.Total != 500*time.Millisecond {
+		t.Errorf("Expected the turn to be within its budget with the stages summed up, got %+v", breakdown)
+	}
+
+	breakdown = turn("t-2", 400*time.Millisecond, 500*time.Millisecond)
+	if !breakdown.Exceeded || len(breakdown.Over) != 2 || breakdown.Over[0] != latency.ASR || breakdown.Over[1] != latency.TTS {
+		t.Errorf("Expected the ASR and the TTS to exceed their targets, got %+v", breakdown)
+	}
+	if tracker.Degraded() {
+		t.Error("Expected the call not to be degraded after a single turn over budget")
+	}
+
+	// Within the targets of the stages, but over the total
+	third := tracker.StartTurn("t-3")
+	third.Record(latency.ASR, 300*time.Millisecond)
+	third.Record(latency.NLU, 500*time.Millisecond)
+	sim.Advance(1200 * time.Millisecond)
+	if breakdown = third.End(ctx); !breakdown.Exceeded || len(breakdown.Over) != 0 {
+		t.Errorf("Expected the turn to exceed the total budget, got %+v", breakdown)
+	}
+	if !tracker.Degraded() {
+		t.Error("Expected the call to be degraded after 2 consecutive turns over budget")
+	}
+
+	turn("t-4", 100*time.Millisecond, 100*time.Millisecond)
+	turn("t-5", 100*time.Millisecond, 100*time.Millisecond)
+	if tracker.Degraded() || len(changes) != 2 || !changes[0] || changes[1] {
+		t.Errorf("Expected the call to be restored after 2 turns within budget, got the changes %v", changes)
+	}
+	if len(breakdowns) != 5 {
+		t.Errorf("Expected the breakdown of every turn to be recorded, got %d", len(breakdowns))
+	}
+}
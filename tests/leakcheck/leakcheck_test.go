@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/leakcheck"
+)
+
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func leak(stop chan struct{}) {
+	<-stop
+}
+
+func TestVerifyNone(t *testing.T) {
+	r := &recorder{}
+	leakcheck.VerifyNone(r, leakcheck.WithTimeout(50*time.Millisecond))
+	if len(r.errors) > 0 {
+		t.Fatalf("Expected no leaks, got %v", r.errors)
+	}
+
+	stop := make(chan struct{})
+	go leak(stop)
+
+	leakcheck.VerifyNone(r, leakcheck.WithTimeout(50*time.Millisecond))
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "leakcheck.leak(") {
+		t.Errorf("Expected the leaked goroutine to be reported, got %v", r.errors)
+	}
+
+	r.errors = nil
+	leakcheck.VerifyNone(r, leakcheck.WithTimeout(50*time.Millisecond), leakcheck.IgnoreFunction("github.com/skit-ai/vcore/tests/leakcheck.leak"))
+	if len(r.errors) > 0 {
+		t.Errorf("Expected the ignored goroutine not to be reported, got %v", r.errors)
+	}
+
+	close(stop)
+	leakcheck.VerifyNone(t)
+}
+
+func TestVerifyNoneNetworkIO(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := make(chan struct{})
+	go func() {
+		defer close(accepted)
+		_, _ = listener.Accept()
+	}()
+
+	// Goroutines blocked on network I/O(eg. of servers which are not shut down) are leaks too
+	r := &recorder{}
+	leakcheck.VerifyNone(r, leakcheck.WithTimeout(50*time.Millisecond))
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "TestVerifyNoneNetworkIO") {
+		t.Errorf("Expected the goroutine blocked on the listener to be reported, got %v", r.errors)
+	}
+
+	listener.Close()
+	<-accepted
+	leakcheck.VerifyNone(t)
+}
+
+func TestMonitor(t *testing.T) {
+	alerts := 0
+	monitor := leakcheck.NewMonitor(
+		leakcheck.WithThreshold(100),
+		leakcheck.WithIncreases(3),
+		leakcheck.WithCooldown(time.Hour),
+		leakcheck.WithAlertHandler(func(int, []leakcheck.Goroutine) { alerts++ }),
+	)
+
+	ctx := context.Background()
+	now := time.Now()
+	for i, count := range []int{90, 95, 120, 110, 130, 140, 150, 160, 170} {
+		monitor.Sample(ctx, count, now.Add(time.Duration(i)*time.Minute))
+	}
+	// Alerted once at 150(past the threshold after 3 consecutive increases), then cooling down
+	if alerts != 1 {
+		t.Errorf("Expected 1 alert, got %d", alerts)
+	}
+}
+
+func TestMonitorDisabled(t *testing.T) {
+	t.Setenv("FEATURES", "")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Disabled by default, the monitor returns without waiting for the context
+	start := time.Now()
+	leakcheck.NewMonitor(leakcheck.WithInterval(time.Millisecond)).Start(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the disabled monitor to return immediately, returned after %s", elapsed)
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/skit-ai/vcore/locale"
+)
+
+func TestDetectTies(t *testing.T) {
+	// None of the trigrams are in the profiles, all the latin languages tie
+	first := locale.Detect("xyz qqq")
+	for i := 1; i < len(first); i++ {
+		if first[i-1].Score == first[i].Score && first[i-1].Language > first[i].Language {
+			t.Fatalf("Expected the ties to be ordered by language, got %v", first)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		guesses := locale.Detect("xyz qqq")
+		for j := range guesses {
+			if guesses[j] != first[j] {
+				t.Fatalf("Expected the detection to be deterministic, got %v and %v", first, guesses)
+			}
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		text     string
+		language string
+	}{
+		{"नमस्ते, मेरा बैलेंस क्या है", "hi"},
+		{"வணக்கம்", "ta"},
+		{"I want to check my balance", "en"},
+		{"mujhe balance check karna hai", "hi-Latn"},
+	}
+	for _, c := range cases {
+		if language, score := locale.DetectBest(c.text); language != c.language || score <= 0 {
+			t.Errorf("Expected %q to be detected as %s, got %s(%f)", c.text, c.language, language, score)
+		}
+	}
+
+	// The latin letters of mixed text are shared among the trigram based guesses
+	guesses := locale.Detect("नमस्ते, what is the balance")
+	var sum, hindi float64
+	for _, guess := range guesses {
+		sum += guess.Score
+		if guess.Language == "hi" {
+			hindi = guess.Score
+		}
+	}
+	// 4 of the 20 letters are in Devanagari
+	if guesses[0].Language != "en" || hindi != 0.2 || sum < 0.99 || sum > 1.01 {
+		t.Errorf("Expected the scores of mixed text to sum up to one, got %v", guesses)
+	}
+
+	if language, score := locale.DetectBest("1234 !!"); language != "und" || score != 0 {
+		t.Errorf("Expected text without letters to be undetermined, got %s(%f)", language, score)
+	}
+}
@@ -0,0 +1,32 @@
+package tests
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+
+	"github.com/skit-ai/vcore/locale"
+)
+
+func TestNegotiate(t *testing.T) {
+	negotiator, err := locale.NewNegotiator("en-IN", "hi-IN", "ta-IN")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid and empty preferences are skipped, the first valid one wins
+	if supported, confidence := negotiator.Negotiate("", "hi;q=0.9,en;q=0.5", "ta"); supported != "hi-IN" || confidence == language.No {
+		t.Errorf("Expected hi-IN to be negotiated, got %s(%s)", supported, confidence)
+	}
+	// Falling back to the first supported locale
+	if supported, confidence := negotiator.Negotiate("fr-FR"); supported != "en-IN" || confidence != language.No {
+		t.Errorf("Expected the fallback without a match, got %s(%s)", supported, confidence)
+	}
+
+	if _, err := locale.NewNegotiator("not a locale"); err == nil {
+		t.Error("Expected an error for an invalid locale")
+	}
+	if base := locale.Base("hi-Latn"); base != "hi" {
+		t.Errorf("Expected the base of hi-Latn to be hi, got %s", base)
+	}
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stdlog "log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/skit-ai/vcore/log"
+)
+
+func TestWithFields(t *testing.T) {
+	var output bytes.Buffer
+	stdlog.SetOutput(&output)
+	defer stdlog.SetOutput(os.Stderr)
+
+	logger := log.WithFields(map[string]interface{}{"call_id": "c-1", "tenant": "acme"})
+	agent := logger.WithFields(map[string]interface{}{"agent": "Ravi Kumar", "tenant": "globex"})
+	agent.Warnf("Transferring the call")
+	logger.Warn("Hanging up")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 entries, got %q", output.String())
+	}
+	if !strings.HasSuffix(lines[0], `Transferring the call agent="Ravi Kumar" call_id=c-1 tenant=globex`) {
+		t.Errorf("Expected the fields to follow the message, got %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "Hanging up call_id=c-1 tenant=acme") {
+		t.Errorf("Expected the fields of the logger to be kept, got %s", lines[1])
+	}
+	if len(log.WithFields(nil).Fields()) != 0 || logger.Fields()["tenant"] != "acme" {
+		t.Error("Expected the fields of the loggers not to be shared")
+	}
+}
+
+func TestLoggerOnContext(t *testing.T) {
+	var output bytes.Buffer
+	stdlog.SetOutput(&output)
+	defer stdlog.SetOutput(os.Stderr)
+	log.SetFormat(log.FormatJSON)
+	defer log.SetFormat(log.FormatText)
+
+	if log.FromContext(context.Background()) == nil {
+		t.Fatal("Expected the default logger without a logger on the context")
+	}
+
+	ctx := log.ToContext(context.Background(), log.WithFields(map[string]interface{}{"call_id": "c-1", "turn": 3}))
+	log.FromContext(ctx).Warnf("Could not reach the %s", "TTS")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["call_id"] != "c-1" || entry["turn"] != float64(3) || entry["message"] != "Could not reach the TTS" {
+		t.Errorf("Expected the fields of the logger on the context, got %v", entry)
+	}
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	stdlog "log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+)
+
+func TestJSONFormat(t *testing.T) {
+	var output bytes.Buffer
+	stdlog.SetOutput(&output)
+	defer stdlog.SetOutput(os.Stderr)
+	log.SetFormat(log.FormatJSON)
+	defer log.SetFormat(log.FormatText)
+
+	log.Warnf("Falling back to the %s voice", "default")
+	err := errors.NewErrorWithTags("Could not reach the TTS", nil, false, map[string]string{"service": "tts", "message": "clash"})
+	log.Error(errors.WithSeverity(err, errors.Critical), "Could not synthesize")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected an entry per line, got %q", output.String())
+	}
+	var warning, failure map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &warning); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &failure); err != nil {
+		t.Fatal(err)
+	}
+
+	if warning["level"] != "warn" || warning["message"] != "Falling back to the default voice" || warning["timestamp"] == nil {
+		t.Errorf("Unexpected entry %v", warning)
+	}
+	if caller, _ := warning["caller"].(string); !strings.HasPrefix(caller, "log/json_test.go:") {
+		t.Errorf("Expected the caller to be the test, got %q", caller)
+	}
+	if failure["level"] != "critical" || failure["message"] != "Could not synthesize" || failure["error"] != "Could not reach the TTS" {
+		t.Errorf("Unexpected entry %v", failure)
+	}
+	if failure["service"] != "tts" || failure["fields.message"] != "clash" || failure["stacktrace"] == nil {
+		t.Errorf("Expected the tags of the error as fields, got %v", failure)
+	}
+}
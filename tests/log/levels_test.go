@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/log"
+)
+
+func resetLevels() {
+	_ = log.SetLevels("warn")
+}
+
+func TestModuleLevels(t *testing.T) {
+	defer resetLevels()
+	var output bytes.Buffer
+	stdlog.SetOutput(&output)
+	defer stdlog.SetOutput(os.Stderr)
+
+	asr := log.Module("asr")
+	if asr != log.Module("asr") || asr.Level() != log.WARN {
+		t.Fatal("Expected the logger of the module to log at the level of the default logger")
+	}
+
+	log.SetModuleLevel("asr", log.DEBUG)
+	asr.Debugf("Streaming %d bytes", 320)
+	log.Module("tts").Debugf("Synthesizing")
+	log.Debug("Routing the call")
+	if lines := output.String(); !strings.Contains(lines, "Streaming 320 bytes") || strings.Contains(lines, "Synthesizing") || strings.Contains(lines, "Routing") {
+		t.Errorf("Expected only the module to log at its level, got %s", lines)
+	}
+
+	log.SetLevel(log.INFO)
+	if !log.Module("tts").IsInfo() || !asr.IsDebug() {
+		t.Error("Expected the modules without a level to follow the default logger")
+	}
+	log.ClearModuleLevel("asr")
+	if asr.IsDebug() {
+		t.Error("Expected the cleared module to follow the default logger")
+	}
+}
+
+func TestSetLevels(t *testing.T) {
+	defer resetLevels()
+
+	if err := log.SetLevels("info, asr=debug,transport/amqp=TRACE"); err != nil {
+		t.Fatal(err)
+	}
+	if log.Levels() != "info,asr=debug,transport/amqp=trace" || !log.IsInfo() || !log.Module("transport/amqp").IsTrace() {
+		t.Errorf("Unexpected levels %s", log.Levels())
+	}
+
+	if err := log.SetLevels("debug,asr=loud"); err == nil {
+		t.Error("Expected an invalid spec to be rejected")
+	}
+	if log.Levels() != "info,asr=debug,transport/amqp=trace" {
+		t.Errorf("Expected an invalid spec to set nothing, got %s", log.Levels())
+	}
+
+	// Specs replace the levels of the modules, keeping the level of the default logger if they have none
+	if err := log.SetLevels("tts=error"); err != nil || log.Levels() != "info,tts=error" {
+		t.Errorf("Expected the spec to replace the levels of the modules, got %s(%v)", log.Levels(), err)
+	}
+}
+
+func TestLevelsHandler(t *testing.T) {
+	defer resetLevels()
+	handler := log.Handler()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("debug,asr=trace\n")))
+	var levels struct {
+		Level   string            `json:"level"`
+		Modules map[string]string `json:"modules"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &levels); err != nil {
+		t.Fatal(err)
+	}
+	if levels.Level != "debug" || levels.Modules["asr"] != "trace" || !log.IsDebug() {
+		t.Errorf("Expected the levels to be set, got %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("verbose")))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid spec to be rejected, got %d", recorder.Code)
+	}
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected other methods to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestReloadOnSIGHUP(t *testing.T) {
+	defer resetLevels()
+	path := filepath.Join(t.TempDir(), "log-levels")
+	if err := os.WriteFile(path, []byte("info,asr=debug\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keeping the signals from terminating the tests until the reloading is notified of them
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go log.ReloadOnSIGHUP(ctx, path)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for log.Levels() != "info,asr=debug" && time.Now().Before(deadline) {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if log.Levels() != "info,asr=debug" {
+		t.Errorf("Expected the levels to be reloaded on SIGHUP, got %s", log.Levels())
+	}
+}
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"bytes"
+	stdlog "log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+)
+
+func TestErrorLevelBySeverity(t *testing.T) {
+	var output bytes.Buffer
+	stdlog.SetOutput(&output)
+	defer stdlog.SetOutput(os.Stderr)
+
+	var levels []int
+	remove := log.AddHook(log.WARN, func(level int, err error, message string) {
+		levels = append(levels, level)
+	})
+	defer remove()
+
+	cause := errors.NewError("Could not reach the TTS", nil, false)
+	log.Error(errors.WithSeverity(cause, errors.Warning))
+	log.Errorf(cause, "Falling back to the default voice")
+	log.Error(errors.WithSeverity(cause, errors.Critical))
+
+	if len(levels) != 3 || levels[0] != log.WARN || levels[1] != log.ERROR || levels[2] != log.ERROR {
+		t.Errorf("Expected the errors logged at the levels of their severities, got %v", levels)
+	}
+	lines := output.String()
+	if !strings.Contains(lines, "[WARN]") || !strings.Contains(lines, "[ERROR]") || !strings.Contains(lines, "[CRITICAL]") {
+		t.Errorf("Expected the entries prefixed by the severities, got %s", lines)
+	}
+}
@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/skit-ai/vcore/log"
+)
+
+func TestTraceFields(t *testing.T) {
+	if fields := log.FromContext(context.Background()).Fields(); len(fields) != 0 {
+		t.Errorf("Expected no fields without a span, got %v", fields)
+	}
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := log.ToContext(context.Background(), log.WithFields(map[string]interface{}{"call_id": "c-1"}))
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	fields := log.FromContext(ctx).Fields()
+	if fields[log.TraceIDKey] != traceID.String() || fields[log.SpanIDKey] != spanID.String() || fields["call_id"] != "c-1" {
+		t.Errorf("Expected the IDs of the OTEL span along with the fields of the logger, got %v", fields)
+	}
+
+	// Without an OTEL span, the Sentry span is logged
+	span := sentry.StartSpan(context.Background(), "call")
+	defer span.Finish()
+	fields = log.FromContext(span.Context()).Fields()
+	if fields[log.TraceIDKey] != span.TraceID.String() || fields[log.SpanIDKey] != span.SpanID.String() {
+		t.Errorf("Expected the IDs of the Sentry span, got %v", fields)
+	}
+}
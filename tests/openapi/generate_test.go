@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/skit-ai/vcore/openapi"
+)
+
+func TestGenerate(t *testing.T) {
+	s := spec()
+	s.Register(openapi.Operation{
+		Method:  http.MethodDelete,
+		Path:    "/v1/calls/{call_id}/turns/{type}",
+		ID:      "delete_turn",
+		Summary: "Delete a turn",
+	})
+
+	source, err := openapi.Generate(s, "callsclient")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"package callsclient",
+		`"github.com/skit-ai/vcore/tests/openapi"`,
+		"func (c *Client) GetCall(ctx context.Context, id string, opts ...httpclient.RequestOption) (*openapi.Call, error) {",
+		`c.Do(ctx, "GET", "/v1/calls/"+url.PathEscape(id), nil, out, opts...)`,
+		"func (c *Client) CreateCall(ctx context.Context, body openapi.Call, opts ...httpclient.RequestOption) (*openapi.Call, error) {",
+		"// DeleteTurn calls DELETE /v1/calls/{call_id}/turns/{type}: Delete a turn",
+		"func (c *Client) DeleteTurn(ctx context.Context, callID string, typeParam string, opts ...httpclient.RequestOption) error {",
+		`c.Do(ctx, "DELETE", "/v1/calls/"+url.PathEscape(callID)+"/turns/"+url.PathEscape(typeParam), nil, nil, opts...)`,
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("Expected the client to contain %q, got\n%s", expected, source)
+		}
+	}
+}
+
+func TestGenerateWithoutIDs(t *testing.T) {
+	s := openapi.New("calls", "1.0.0")
+	s.Register(openapi.Operation{Method: http.MethodGet, Path: "/v1/calls", Response: []Call{}})
+	if _, err := openapi.Generate(s, "callsclient"); err == nil {
+		t.Errorf("Expected an operation without an ID to not be generated")
+	}
+
+	s = openapi.New("calls", "1.0.0")
+	s.Register(openapi.Operation{Method: http.MethodGet, Path: "/v1/calls", ID: "listCalls", Response: struct{ ID string }{}})
+	if _, err := openapi.Generate(s, "callsclient"); err == nil {
+		t.Errorf("Expected an anonymous response type to not be generated")
+	}
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/openapi"
+)
+
+type Turn struct {
+	Text string `json:"text"`
+	// Recursive types reference themselves
+	Next *Turn `json:"next,omitempty"`
+}
+
+type Call struct {
+	ID       string            `json:"id" description:"ID of the call"`
+	Started  time.Time         `json:"started"`
+	Duration *float64          `json:"duration"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Turns    []Turn            `json:"turns"`
+	Audio    []byte            `json:"audio,omitempty"`
+	internal string
+	Ignored  string `json:"-"`
+}
+
+type Error struct {
+	Message string `json:"message"`
+}
+
+func spec() *openapi.Spec {
+	spec := openapi.New("calls", "1.0.0")
+	spec.Register(openapi.Operation{
+		Method:   "get",
+		Path:     "/v1/calls/{id}",
+		ID:       "getCall",
+		Response: Call{},
+		Errors:   map[int]interface{}{http.StatusNotFound: Error{}},
+	})
+	spec.HandleFunc(openapi.Operation{
+		Method:     http.MethodPost,
+		Path:       "/v1/calls",
+		ID:         "createCall",
+		Parameters: []openapi.Parameter{{Name: "dry_run", In: "query"}},
+		Request:    Call{},
+		Response:   Call{},
+		Status:     http.StatusCreated,
+	}, func(w http.ResponseWriter, r *http.Request) {})
+	return spec
+}
+
+func TestDocument(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	spec().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var document struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string              `json:"operationId"`
+			Parameters  []openapi.Parameter `json:"parameters"`
+			Responses   map[string]struct {
+				Content map[string]struct {
+					Schema openapi.Schema `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]openapi.Schema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	if document.OpenAPI != "3.0.3" {
+		t.Errorf("Expected an OpenAPI 3 document, got %q", document.OpenAPI)
+	}
+	get := document.Paths["/v1/calls/{id}"]["get"]
+	if get.OperationID != "getCall" || len(get.Parameters) != 1 || get.Parameters[0].Name != "id" || !get.Parameters[0].Required {
+		t.Errorf("Expected the operation with its path parameter, got %+v", get)
+	}
+	if ref := get.Responses["404"].Content["application/json"].Schema.Ref; ref != "#/components/schemas/Error" {
+		t.Errorf("Expected the error response to reference its schema, got %q", ref)
+	}
+	if _, ok := document.Paths["/v1/calls"]["post"].Responses["201"]; !ok {
+		t.Errorf("Expected the created response of the post")
+	}
+
+	call := document.Components.Schemas["Call"]
+	if strings.Join(call.Required, ",") != "id,started,turns" {
+		t.Errorf("Expected the required fields of the call, got %v", call.Required)
+	}
+	if len(call.Properties) != 6 || call.Properties["started"].Format != "date-time" || !call.Properties["duration"].Nullable ||
+		call.Properties["audio"].Format != "byte" || call.Properties["tags"].AdditionalProperties.Type != "string" ||
+		call.Properties["id"].Description != "ID of the call" {
+		t.Errorf("Expected the properties of the call, got %+v", call.Properties)
+	}
+	if next := document.Components.Schemas["Turn"].Properties["next"]; next == nil || next.Ref != "#/components/schemas/Turn" {
+		t.Errorf("Expected the recursive turn to reference itself, got %+v", next)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := spec().Validate(); err != nil {
+		t.Errorf("Expected the spec to be valid, got %s", err)
+	}
+
+	invalid := spec()
+	invalid.Register(openapi.Operation{Method: http.MethodGet, Path: "/v1/calls/{id}"})
+	invalid.Register(openapi.Operation{Method: "FETCH", Path: "/v1/calls/{id"})
+	invalid.Register(openapi.Operation{Method: http.MethodDelete, Path: "/v1/calls", ID: "getCall",
+		Parameters: []openapi.Parameter{{Name: "id", In: "path"}}})
+
+	err := invalid.Validate()
+	if err == nil {
+		t.Fatal("Expected the spec to be invalid")
+	}
+	for _, problem := range []string{"registered more than once", "invalid method", "single parameter", "not unique", "not in the path"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected the problem %q to be reported, got %s", problem, err)
+		}
+	}
+}
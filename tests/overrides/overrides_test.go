@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/overrides"
+	"google.golang.org/grpc"
+)
+
+const config = `
+"*":
+  timeout: 10s
+"/v1/calls/*":
+  timeout: 30s
+  scopes: [calls]
+"POST /v1/calls/*/recordings":
+  body_limit: 8
+"/v1/calls/export":
+  scopes: [calls, export]
+"/skit.Dialogue/*":
+  sample_rate: 0.5
+"/skit.Dialogue/Stream":
+  timeout: 0s
+`
+
+func load(t *testing.T) *overrides.Routes {
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	routes, err := overrides.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return routes
+}
+
+func TestResolve(t *testing.T) {
+	routes := load(t)
+
+	cases := []struct {
+		method, path string
+		timeout      time.Duration
+		scopes       string
+		bodyLimit    int64
+	}{
+		{"GET", "/health", 10 * time.Second, "", 0},
+		{"GET", "/v1/calls/42", 30 * time.Second, "calls", 0},
+		{"GET", "/v1/calls/export", 30 * time.Second, "calls,export", 0},
+		{"POST", "/v1/calls/42/recordings", 30 * time.Second, "calls", 8},
+		{"GET", "/v1/calls/42/recordings", 30 * time.Second, "calls", 0},
+		{"", "/skit.Dialogue/Stream", 0, "", 0},
+		{"", "/skit.Dialogue/Turn", 10 * time.Second, "", 0},
+	}
+	for _, c := range cases {
+		override := routes.Resolve(c.method, c.path)
+		if override.Timeout == nil || *override.Timeout != c.timeout {
+			t.Errorf("%s %s: expected a timeout of %s, got %v", c.method, c.path, c.timeout, override.Timeout)
+		}
+		if scopes := strings.Join(override.Scopes, ","); scopes != c.scopes {
+			t.Errorf("%s %s: expected the scopes %q, got %q", c.method, c.path, c.scopes, scopes)
+		}
+		var bodyLimit int64
+		if override.BodyLimit != nil {
+			bodyLimit = *override.BodyLimit
+		}
+		if bodyLimit != c.bodyLimit {
+			t.Errorf("%s %s: expected a body limit of %d, got %d", c.method, c.path, c.bodyLimit, bodyLimit)
+		}
+	}
+
+	override := routes.Resolve("", "/skit.Dialogue/Stream")
+	if override.Sampled(func() float64 { return 0.7 }) || !override.Sampled(func() float64 { return 0.2 }) {
+		t.Errorf("Expected the errors of the stream to be sampled at 0.5")
+	}
+	if !routes.Resolve("GET", "/health").Sampled(func() float64 { return 0.99 }) {
+		t.Errorf("Expected all errors to be sampled without a sample rate")
+	}
+
+	export := routes.Resolve("GET", "/v1/calls/export")
+	if export.Allows("calls") || !export.Allows("export", "calls", "admin") {
+		t.Errorf("Expected the export to require both the scopes")
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	if _, err := overrides.New(overrides.Config{"v1/calls": {}}); err == nil {
+		t.Errorf("Expected a pattern not starting with / to be rejected")
+	}
+	rate := 2.0
+	if _, err := overrides.New(overrides.Config{"*": {SampleRate: &rate}}); err == nil {
+		t.Errorf("Expected an invalid sample rate to be rejected")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	routes := load(t)
+
+	handler := routes.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Errorf("Expected the request to have a deadline")
+		}
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write([]byte(strings.Join(overrides.FromContext(r.Context()).Scopes, ",")))
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/v1/calls/42", nil))
+	if recorder.Body.String() != "calls" {
+		t.Errorf("Expected the override on the context of the request, got %q", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/v1/calls/42/recordings", strings.NewReader("too large a body")))
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected a body over the limit to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	routes := load(t)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/skit.Dialogue/Turn"}
+	_, _ = routes.UnaryServerInterceptor()(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > 10*time.Second {
+			t.Errorf("Expected the call to have a deadline of 10s")
+		}
+		if overrides.FromContext(ctx).SampleRate == nil {
+			t.Errorf("Expected the override on the context of the call")
+		}
+		return nil, nil
+	})
+}
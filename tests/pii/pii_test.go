@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/skit-ai/vcore/pii"
+)
+
+func TestLuhn(t *testing.T) {
+	if !pii.Luhn("4111 1111 1111 1111") {
+		t.Error("expected a valid card number")
+	}
+	if pii.Luhn("4111 1111 1111 1112") {
+		t.Error("expected an invalid card number")
+	}
+}
+
+func TestScrub(t *testing.T) {
+	text := "my card is 4111-1111-1111-1111 and you can call me on +91 98765 43210 or mail a.b@example.com"
+	scrubbed, entities := pii.Scrub(text)
+
+	expected := "my card is [CARD] and you can call me on [PHONE] or mail [EMAIL]"
+	if scrubbed != expected {
+		t.Errorf("expected %q, got %q", expected, scrubbed)
+	}
+
+	for _, entity := range entities {
+		if text[entity.Start:entity.End] != entity.Text {
+			t.Errorf("offsets of %s do not match the original text", entity.Type)
+		}
+	}
+}
+
+func TestScrubOTP(t *testing.T) {
+	scrubber := pii.New(pii.WithStrategy(pii.Mask))
+	scrubbed, entities := scrubber.Scrub("the otp is 4821")
+
+	if scrubbed != "the otp is ****" {
+		t.Errorf("unexpected scrubbed text %q", scrubbed)
+	}
+	if len(entities) != 1 || entities[0].Type != pii.OTP {
+		t.Errorf("expected a single OTP entity, got %v", entities)
+	}
+}
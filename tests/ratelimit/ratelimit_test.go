@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mediocregopher/radix/v3"
+	"github.com/mediocregopher/radix/v3/resp/resp2"
+	"github.com/skit-ai/vcore/ctxkeys"
+	"github.com/skit-ai/vcore/overrides"
+	"github.com/skit-ai/vcore/ratelimit"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+func TestMemoryStore(t *testing.T) {
+	sim := simulation.New(42, time.Now())
+	store := ratelimit.NewMemoryStore(sim)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if result, _ := store.Take(ctx, "acme", 1, 3, 1); !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("Expected the burst to be allowed, got %+v", result)
+		}
+	}
+	result, _ := store.Take(ctx, "acme", 1, 3, 1)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Errorf("Expected the request over the burst to be limited for a second, got %+v", result)
+	}
+	if result, _ := store.Take(ctx, "globex", 1, 3, 1); !result.Allowed {
+		t.Error("Expected the buckets of the keys to be separate")
+	}
+
+	sim.Advance(1500 * time.Millisecond)
+	if result, _ := store.Take(ctx, "acme", 1, 3, 1); !result.Allowed {
+		t.Errorf("Expected the bucket to be refilled, got %+v", result)
+	}
+	if result, _ := store.Take(ctx, "acme", 1, 3, 1); result.Allowed || result.RetryAfter != 500*time.Millisecond {
+		t.Errorf("Expected the bucket to be refilled at the rate, got %+v", result)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	sim := simulation.New(42, time.Now())
+	limiter := ratelimit.New(1, 2, ratelimit.WithStore(ratelimit.NewMemoryStore(sim)))
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(tenant string, override overrides.Override) *httptest.ResponseRecorder {
+		ctx := ctxkeys.Tenant.With(context.Background(), tenant)
+		ctx = overrides.WithOverride(ctx, override)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/calls", nil).WithContext(ctx))
+		return w
+	}
+
+	request("acme", overrides.Override{})
+	request("acme", overrides.Override{})
+	w := request("acme", overrides.Override{})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected 429 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := request("globex", overrides.Override{}); w.Code != http.StatusOK {
+		t.Errorf("Expected the other tenant to be allowed, got %d", w.Code)
+	}
+
+	rate, burst := 10.0, 5
+	if w := request("acme", overrides.Override{RateLimit: &rate, Burst: &burst}); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "4" {
+		t.Errorf("Expected the overridden route to have its own bucket, got %d %s", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestMiddlewareWithoutKey(t *testing.T) {
+	sim := simulation.New(42, time.Now())
+	limiter := ratelimit.New(1, 1, ratelimit.WithStore(ratelimit.NewMemoryStore(sim)))
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(remote string) int {
+		r := httptest.NewRequest("GET", "/v1/calls", nil)
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Without a tenant or realip.Middleware, the clients are limited by the addresses of their connections
+	request("203.0.113.7:4000")
+	if code := request("203.0.113.7:4001"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected the client to be limited, got %d", code)
+	}
+	if code := request("198.51.100.9:4000"); code != http.StatusOK {
+		t.Errorf("Expected the other client not to share the bucket, got %d", code)
+	}
+	// Requests without an address are not limited
+	request("@")
+	if code := request("@"); code != http.StatusOK {
+		t.Errorf("Expected the requests without an address not to be limited, got %d", code)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	var commands [][]string
+	stub := radix.Stub("tcp", "127.0.0.1:6379", func(args []string) interface{} {
+		commands = append(commands, args)
+		return []string{"0", "0.5", "0.25"}
+	})
+	store := ratelimit.NewRedisStore(stub)
+
+	result, err := store.Take(context.Background(), "tenant:acme", 2, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed || result.Remaining != 0 || result.RetryAfter != 250*time.Millisecond {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if args := commands[0]; args[0] != "EVALSHA" || args[3] != "ratelimit:tenant:acme" || args[4] != "2" || args[5] != "10" || args[6] != "1" {
+		t.Errorf("Unexpected command %v", args)
+	}
+}
+
+func TestStoreOutage(t *testing.T) {
+	stub := radix.Stub("tcp", "127.0.0.1:6379", func(args []string) interface{} {
+		return resp2.Error{E: stderrors.New("LOADING Redis is loading the dataset in memory")}
+	})
+	limiter := ratelimit.New(1, 1, ratelimit.WithStore(ratelimit.NewRedisStore(stub)))
+	if result := limiter.Allow(context.Background(), "tenant:acme"); !result.Allowed {
+		t.Error("Expected the requests to be allowed while the store fails")
+	}
+}
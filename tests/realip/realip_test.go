@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skit-ai/vcore/realip"
+)
+
+func TestClientIP(t *testing.T) {
+	resolver, err := realip.New("10.0.0.0/8", "2001:db8::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, test := range map[string]struct {
+		header  string
+		remote  string
+		headers map[string]string
+		ip      string
+	}{
+		"direct":          {"X-Forwarded-For", "203.0.113.7:4000", nil, "203.0.113.7"},
+		"spoofed":         {"X-Forwarded-For", "203.0.113.7:4000", map[string]string{"X-Forwarded-For": "1.1.1.1"}, "203.0.113.7"},
+		"x-forwarded-for": {"X-Forwarded-For", "10.0.0.2:4000", map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.9, 10.0.0.3"}, "198.51.100.9"},
+		"forwarded":       {"Forwarded", "10.0.0.2:4000", map[string]string{"Forwarded": `for=198.51.100.9;proto=https, for="[2001:db8::1]:4711"`}, "198.51.100.9"},
+		"x-real-ip":       {"X-Real-IP", "10.0.0.2:4000", map[string]string{"X-Real-IP": "198.51.100.9"}, "198.51.100.9"},
+		"obfuscated":      {"Forwarded", "10.0.0.2:4000", map[string]string{"Forwarded": "for=_hidden"}, "10.0.0.2"},
+		// Headers the proxies do not set are passed on as the clients set them
+		"spoofed forwarded": {"X-Forwarded-For", "10.0.0.2:4000", map[string]string{"Forwarded": "for=10.0.0.1", "X-Forwarded-For": "198.51.100.9"}, "198.51.100.9"},
+		"spoofed x-real-ip": {"Forwarded", "10.0.0.2:4000", map[string]string{"X-Real-IP": "10.0.0.1"}, "10.0.0.2"},
+		"ipv4 mapped ipv6":  {"X-Forwarded-For", "[::ffff:203.0.113.7]:4000", nil, "203.0.113.7"},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = test.remote
+		for key, value := range test.headers {
+			r.Header.Set(key, value)
+		}
+		if ip, ok := resolver.WithHeader(test.header).ClientIP(r); !ok || ip.String() != test.ip {
+			t.Errorf("Expected the %s client to be %s, got %s", name, test.ip, ip)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	resolver, _ := realip.New("10.0.0.0/8")
+	var resolved string
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _ := realip.FromContext(r.Context())
+		resolved = ip.String()
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.2:4000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.9")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if resolved != "198.51.100.9" {
+		t.Errorf("Expected the IP of the client on the context, got %s", resolved)
+	}
+
+	if _, err := realip.New("10.0.0.0/8", "not a cidr"); err == nil {
+		t.Error("Expected an invalid CIDR to be rejected")
+	}
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/skit-ai/vcore/resources"
+)
+
+func updateCall(ctx context.Context, fail bool) (rolledBack *bool, err error) {
+	rolledBack = new(bool)
+	release := resources.Track(ctx, "sql.Tx", "update call", func() error {
+		*rolledBack = true
+		return nil
+	})
+	if fail {
+		// Early return leaking the transaction
+		return rolledBack, errors.New("could not update the call")
+	}
+	release()
+	return rolledBack, nil
+}
+
+func TestTracker(t *testing.T) {
+	ctx, tracker := resources.WithTracker(context.Background())
+
+	released, _ := updateCall(ctx, false)
+	leaked, _ := updateCall(ctx, true)
+	if open := tracker.Open(); len(open) != 1 {
+		t.Fatalf("Expected 1 resource open, got %v", open)
+	}
+
+	leaks := tracker.Finish()
+	if len(leaks) != 1 || leaks[0].Kind != "sql.Tx" || !strings.Contains(leaks[0].Stack, "updateCall") {
+		t.Errorf("Expected the leaked transaction with the stack opening it, got %v", leaks)
+	}
+	if *released || !*leaked {
+		t.Errorf("Expected only the leaked transaction to be rolled back")
+	}
+	if open := tracker.Open(); len(open) != 0 {
+		t.Errorf("Expected no resource open once finished, got %v", open)
+	}
+}
+
+func TestUntracked(t *testing.T) {
+	// Resources are not tracked without a tracker on the context
+	resources.Track(context.Background(), "file", "/tmp/audio.wav", nil)()
+}
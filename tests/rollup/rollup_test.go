@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/rollup"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+// Flusher recording the rollups flushed, failing while down
+type flusher struct {
+	mutex   sync.Mutex
+	rollups []rollup.Rollup[string]
+	down    bool
+}
+
+func (f *flusher) flush(_ context.Context, rollups []rollup.Rollup[string]) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.down {
+		return stderrors.New("connection refused")
+	}
+	f.rollups = append(f.rollups, rollups...)
+	return nil
+}
+
+func TestRollupWindows(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sim := simulation.New(42, start)
+	f := &flusher{}
+	latencies := rollup.New("latency", time.Minute, f.flush,
+		rollup.WithAllowedLateness[string](10*time.Second), rollup.WithClock[string](sim))
+
+	latencies.Observe("acme", start.Add(10*time.Second), 1)
+	latencies.Observe("acme", start.Add(50*time.Second), 3)
+	latencies.Observe("globex", start.Add(20*time.Second), 2)
+	// Within the allowed lateness, the first window is still open
+	latencies.Observe("acme", start.Add(65*time.Second), 4)
+	if !latencies.Observe("acme", start.Add(55*time.Second), 2) {
+		t.Error("Expected the event within the allowed lateness to be aggregated")
+	}
+	if err := latencies.Flush(ctx); err != nil || len(f.rollups) != 0 {
+		t.Fatalf("Expected no window to be closed yet, got %v(%v)", f.rollups, err)
+	}
+
+	// Past the lateness, the first window is closed and further events of it are late
+	latencies.Observe("acme", start.Add(75*time.Second), 6)
+	if latencies.Observe("acme", start.Add(59*time.Second), 100) {
+		t.Error("Expected the event of the closed window to be late")
+	}
+	if err := latencies.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.rollups) != 2 {
+		t.Fatalf("Expected the rollups of the first window, got %v", f.rollups)
+	}
+	for _, r := range f.rollups {
+		switch r.Key {
+		case "acme":
+			if r.Count != 3 || r.Sum != 6 || r.Min != 1 || r.Max != 3 || r.Mean() != 2 || !r.Start.Equal(start) || !r.End.Equal(start.Add(time.Minute)) {
+				t.Errorf("Unexpected rollup of acme %+v", r)
+			}
+		case "globex":
+			if r.Count != 1 || r.Sum != 2 {
+				t.Errorf("Unexpected rollup of globex %+v", r)
+			}
+		}
+	}
+
+	// The remaining windows are flushed on shutdown
+	if err := latencies.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.rollups) != 3 || f.rollups[2].Count != 2 || f.rollups[2].Max != 6 {
+		t.Errorf("Expected the open window to be flushed on shutdown, got %v", f.rollups)
+	}
+}
+
+func TestRollupClockAndRetries(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sim := simulation.New(42, start)
+	f := &flusher{down: true}
+	calls := rollup.New("calls", time.Minute, f.flush, rollup.WithClock[string](sim))
+
+	calls.Observe("acme", start.Add(time.Second), 1)
+	// Without events, the watermark advances with the clock
+	sim.Advance(2 * time.Minute)
+	if err := calls.Flush(ctx); err == nil {
+		t.Fatal("Expected the flush to fail")
+	}
+	if !calls.Watermark().Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Expected the watermark to follow the clock, got %s", calls.Watermark())
+	}
+
+	// The rollups are flushed again once the flusher recovers
+	f.down = false
+	if err := calls.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.rollups) != 1 || f.rollups[0].Count != 1 {
+		t.Errorf("Expected the rollup to be flushed again, got %v", f.rollups)
+	}
+}
@@ -0,0 +1,32 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skit-ai/vcore/routes"
+)
+
+func TestNormalize(t *testing.T) {
+	for path, expected := range map[string]string{
+		"/calls/3f2b1c9e-8d4a-4b6e-9f1a-2c3d4e5f6a7b": "/calls/{id}",
+		"/v2/calls/42/recordings/7":                   "/v2/calls/{id}/recordings/{id}",
+		"/objects/5f8d0d55b54764421b7156c3":           "/objects/{id}",
+		"/jobs/01ARZ3NDEKTSV4RRFFQ69G5FAV/status":     "/jobs/{id}/status",
+		"/v1/health":       "/v1/health",
+		"/users/deadbeef/": "/users/deadbeef/",
+		"/":                "/",
+	} {
+		if normalized := routes.Normalize(path); normalized != expected {
+			t.Errorf("Expected %s to be normalized to %s, got %s", path, expected, normalized)
+		}
+	}
+}
+
+func TestNormalizerWithPattern(t *testing.T) {
+	normalizer := routes.New(routes.WithPattern(`^CA[0-9a-f]{32}$`, "{sid}"))
+	r := httptest.NewRequest("POST", "/twilio/CA0123456789abcdef0123456789abcdef/events/12", nil)
+	if name := normalizer.Name(r); name != "POST /twilio/{sid}/events/{id}" {
+		t.Errorf("Expected the call SID to be replaced, got %s", name)
+	}
+}
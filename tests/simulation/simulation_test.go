@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/latency"
+	"github.com/skit-ai/vcore/simulation"
+)
+
+// Retries with an exponential backoff and a jitter, recording the times of the attempts
+func retry(clock simulation.Clock, random func() int64, attempts *[]time.Duration, start time.Time, attempt int) {
+	*attempts = append(*attempts, clock.Since(start))
+	if attempt == 4 {
+		return
+	}
+	backoff := time.Duration(1<<attempt)*time.Second + time.Duration(random())*time.Millisecond
+	clock.AfterFunc(backoff, func() {
+		retry(clock, random, attempts, start, attempt+1)
+	})
+}
+
+func run(seed int64) []time.Duration {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sim := simulation.New(seed, start)
+	random := func() int64 { return sim.Int63n(1000) }
+
+	var attempts []time.Duration
+	retry(sim, random, &attempts, start, 0)
+	sim.Advance(time.Hour)
+	return attempts
+}
+
+func TestSimulationIsDeterministic(t *testing.T) {
+	first := run(7)
+	if len(first) != 5 {
+		t.Fatalf("Expected the 5 attempts to be made within the hour simulated, got %v", first)
+	}
+	for i := 1; i < len(first); i++ {
+		if gap := first[i] - first[i-1]; gap < time.Duration(1<<(i-1))*time.Second || gap >= time.Duration(1<<(i-1))*time.Second+time.Second {
+			t.Errorf("Expected attempt %d to back off by %s plus jitter, got %s", i, time.Duration(1<<(i-1))*time.Second, gap)
+		}
+	}
+	if second := run(7); !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected runs with the same seed to be identical, got %v and %v", first, second)
+	}
+}
+
+func TestSimulationTimers(t *testing.T) {
+	sim := simulation.New(1, time.Now())
+
+	var fired []string
+	sim.AfterFunc(2*time.Second, func() { fired = append(fired, "b") })
+	sim.AfterFunc(time.Second, func() { fired = append(fired, "a") })
+	stopped := sim.AfterFunc(time.Second, func() { fired = append(fired, "stopped") })
+	after := sim.After(3 * time.Second)
+
+	if !stopped.Stop() || stopped.Stop() {
+		t.Errorf("Expected a pending timer to stop only once")
+	}
+	sim.Advance(2 * time.Second)
+	if !reflect.DeepEqual(fired, []string{"a", "b"}) || sim.Pending() != 1 {
+		t.Errorf("Expected the due timers to fire in order, got %v with %d pending", fired, sim.Pending())
+	}
+
+	select {
+	case <-after:
+		t.Errorf("Expected After not to fire before its deadline")
+	default:
+	}
+	if !sim.Next() {
+		t.Fatalf("Expected the pending timer to fire")
+	}
+	if fired := <-after; !fired.Equal(sim.Now()) {
+		t.Errorf("Expected After to receive the simulated time, got %s", fired)
+	}
+}
+
+func TestSimulatedLatency(t *testing.T) {
+	sim := simulation.New(1, time.Now())
+	tracker := latency.NewTracker(latency.Budget{Total: 2 * time.Second}, latency.WithClock(sim))
+
+	turn := tracker.StartTurn("1")
+	stop := turn.Start(latency.ASR)
+	sim.Advance(1500 * time.Millisecond)
+	stop()
+	sim.Advance(time.Second)
+
+	breakdown := turn.End(context.Background())
+	if breakdown.Stages[latency.ASR] != 1500*time.Millisecond || breakdown.Total != 2500*time.Millisecond || !breakdown.Exceeded {
+		t.Errorf("Expected the simulated durations exactly, got %+v", breakdown)
+	}
+}
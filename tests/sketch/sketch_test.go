@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/skit-ai/vcore/sketch"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	a, b := sketch.NewHyperLogLog(14), sketch.NewHyperLogLog(14)
+	for i := 0; i < 60000; i++ {
+		a.AddString("caller-" + strconv.Itoa(i))
+		// Half of the callers of b are callers of a
+		b.AddString("caller-" + strconv.Itoa(i+30000))
+	}
+	a.AddString("caller-0")
+
+	if count := a.Count(); math.Abs(float64(count)-60000)/60000 > 0.03 {
+		t.Errorf("Expected about 60000 distinct callers, got %d", count)
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if count := a.Count(); math.Abs(float64(count)-90000)/90000 > 0.03 {
+		t.Errorf("Expected about 90000 distinct callers once merged, got %d", count)
+	}
+
+	small := sketch.NewHyperLogLog(14)
+	for i := 0; i < 100; i++ {
+		small.AddString(strconv.Itoa(i % 10))
+	}
+	if count := small.Count(); count != 10 {
+		t.Errorf("Expected the small counts to be exact, got %d", count)
+	}
+
+	data, _ := a.MarshalBinary()
+	restored := &sketch.HyperLogLog{}
+	if err := restored.UnmarshalBinary(data); err != nil || restored.Count() != a.Count() {
+		t.Errorf("Expected the HyperLogLog to be restored, got %d(%v)", restored.Count(), err)
+	}
+	if err := a.Merge(sketch.NewHyperLogLog(10)); err == nil {
+		t.Error("Expected HyperLogLogs of other precisions not to be merged")
+	}
+}
+
+func TestBloom(t *testing.T) {
+	seen := sketch.NewBloom(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		if seen.TestAndAdd([]byte("event-"+strconv.Itoa(i))) && i < 10 {
+			t.Errorf("Expected the first events not to be found")
+		}
+	}
+	for i := 0; i < 10000; i++ {
+		if !seen.ContainsString("event-" + strconv.Itoa(i)) {
+			t.Fatalf("Expected the event %d to be found", i)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if seen.ContainsString("other-" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 200 {
+		t.Errorf("Expected a false positive rate of about 1%%, got %d in 10000", falsePositives)
+	}
+
+	other := sketch.NewBloom(10000, 0.01)
+	other.AddString("other-merged")
+	if err := seen.Merge(other); err != nil || !seen.ContainsString("other-merged") {
+		t.Errorf("Expected the items of the other filter to be merged(%v)", err)
+	}
+
+	data, _ := seen.MarshalBinary()
+	restored := &sketch.Bloom{}
+	if err := restored.UnmarshalBinary(data); err != nil || !restored.ContainsString("event-42") {
+		t.Errorf("Expected the Bloom filter to be restored(%v)", err)
+	}
+	if err := restored.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("Expected truncated data not to be restored")
+	}
+	if err := seen.Merge(sketch.NewBloom(10, 0.01)); err == nil {
+		t.Error("Expected Bloom filters of other sizes not to be merged")
+	}
+}
+
+func TestCountMin(t *testing.T) {
+	calls := sketch.NewCountMin(0.001, 0.01)
+	for i := 0; i < 1000; i++ {
+		calls.AddString("caller-"+strconv.Itoa(i), 1)
+	}
+	calls.AddString("caller-7", 99)
+
+	if count := calls.CountString("caller-7"); count < 100 || count > 100+uint64(0.001*float64(calls.Total())) {
+		t.Errorf("Expected about 100 calls of caller-7, got %d", count)
+	}
+	if count := calls.CountString("caller-unknown"); count > uint64(0.001*float64(calls.Total())) {
+		t.Errorf("Expected about no calls of an unknown caller, got %d", count)
+	}
+
+	other := sketch.NewCountMin(0.001, 0.01)
+	other.AddString("caller-7", 50)
+	if err := calls.Merge(other); err != nil || calls.CountString("caller-7") < 150 || calls.Total() != 1149 {
+		t.Errorf("Expected the counts to be merged, got %d of %d(%v)", calls.CountString("caller-7"), calls.Total(), err)
+	}
+
+	data, _ := calls.MarshalBinary()
+	restored := &sketch.CountMin{}
+	if err := restored.UnmarshalBinary(data); err != nil || restored.CountString("caller-7") != calls.CountString("caller-7") || restored.Total() != calls.Total() {
+		t.Errorf("Expected the Count-Min sketch to be restored(%v)", err)
+	}
+	if err := restored.UnmarshalBinary([]byte{1, 1}); err == nil {
+		t.Error("Expected the data of another sketch not to be restored")
+	}
+}
+
+func TestTopK(t *testing.T) {
+	tenants := sketch.NewTopK(10)
+	for i := 0; i < 1000; i++ {
+		// A few heavy tenants among many light ones
+		tenants.Add("tenant-"+strconv.Itoa(i), 1)
+		if i%10 == 0 {
+			tenants.Add("acme", 5)
+		}
+		if i%20 == 0 {
+			tenants.Add("globex", 5)
+		}
+	}
+
+	top := tenants.Top(2)
+	if len(top) != 2 || top[0].Key != "acme" || top[1].Key != "globex" {
+		t.Fatalf("Expected the heavy tenants on top, got %v", top)
+	}
+	if top[0].Count < 500 || top[0].Count-top[0].Error > 500 {
+		t.Errorf("Expected the count of acme to bound its 500 additions, got %+v", top[0])
+	}
+	if len(tenants.Top(-1)) != 10 {
+		t.Errorf("Expected up to the capacity of items to be tracked, got %d", len(tenants.Top(-1)))
+	}
+
+	other := sketch.NewTopK(10)
+	other.Add("initech", 2000)
+	if err := tenants.Merge(other); err != nil || tenants.Top(1)[0].Key != "initech" {
+		t.Errorf("Expected the items of the other TopK to be merged, got %v(%v)", tenants.Top(3), err)
+	}
+
+	data, _ := tenants.MarshalBinary()
+	restored := &sketch.TopK{}
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if item, ok := restored.Count("acme"); !ok || item != tenants.Top(2)[1] {
+		t.Errorf("Expected the TopK to be restored, got %+v", item)
+	}
+	if err := tenants.Merge(sketch.NewTopK(5)); err == nil {
+		t.Error("Expected TopKs of other capacities not to be merged")
+	}
+}
+
+func TestTDigest(t *testing.T) {
+	latencies := sketch.NewTDigest(100)
+	// Uniform latencies of 1 to 10000ms
+	for i := 0; i < 10000; i++ {
+		latencies.Add(float64((i*7919)%10000 + 1))
+	}
+
+	for _, q := range []float64{0.5, 0.95, 0.99, 0.999} {
+		// Within 0.5% of the range, and closer at the extreme quantiles
+		if estimate := latencies.Quantile(q); math.Abs(estimate-q*10000) > 50*math.Min(1, 20*(1-q)) {
+			t.Errorf("Expected the quantile %g to be about %g, got %g", q, q*10000, estimate)
+		}
+	}
+	if latencies.Quantile(0) != 1 || latencies.Quantile(1) != 10000 {
+		t.Errorf("Expected the extremes to be exact, got %g and %g", latencies.Quantile(0), latencies.Quantile(1))
+	}
+	if cdf := latencies.CDF(9500); math.Abs(cdf-0.95) > 0.005 {
+		t.Errorf("Expected 95%% of the latencies within 9500ms, got %g", cdf)
+	}
+
+	// The t-digests of the replicas are merged into that of the service
+	slow := sketch.NewTDigest(100)
+	for i := 0; i < 10000; i++ {
+		slow.Add(float64(10000 + i%10000 + 1))
+	}
+	if err := latencies.Merge(slow); err != nil {
+		t.Fatal(err)
+	}
+	if latencies.Count() != 20000 {
+		t.Errorf("Expected the counts to be merged, got %g", latencies.Count())
+	}
+	if median := latencies.Quantile(0.5); math.Abs(median-10000) > 200 {
+		t.Errorf("Expected the median of the merged latencies to be about 10000, got %g", median)
+	}
+
+	data, _ := latencies.MarshalBinary()
+	restored := &sketch.TDigest{}
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Quantile(0.95) != latencies.Quantile(0.95) || restored.Count() != latencies.Count() {
+		t.Errorf("Expected the t-digest to be restored, got %g", restored.Quantile(0.95))
+	}
+	if err := restored.UnmarshalBinary(data[:len(data)-8]); err == nil {
+		t.Error("Expected truncated data not to be restored")
+	}
+	if !math.IsNaN(sketch.NewTDigest(100).Quantile(0.5)) {
+		t.Error("Expected no quantiles without values")
+	}
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/httpserver"
+	"github.com/skit-ai/vcore/simulation"
+	"github.com/skit-ai/vcore/snapshot"
+)
+
+// In memory bucket
+type bucket struct {
+	mutex   sync.Mutex
+	objects map[string]string
+}
+
+func (b *bucket) Stat(_ context.Context, key string) (httpserver.Object, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	object, ok := b.objects[key]
+	if !ok {
+		return httpserver.Object{}, errors.WithCode(errors.NewError(key+" not found", nil, false), errors.NotFound)
+	}
+	return httpserver.Object{Size: int64(len(object))}, nil
+}
+
+func (b *bucket) Open(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	if _, err := b.Stat(ctx, key); err != nil {
+		return nil, err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return io.NopCloser(strings.NewReader(b.objects[key][offset:])), nil
+}
+
+func (b *bucket) Put(_ context.Context, key string, body io.ReadSeeker, _ string) error {
+	data, _ := io.ReadAll(body)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.objects[key] = string(data)
+	return nil
+}
+
+// Cache snapshotting its entries as JSON
+type cache struct {
+	entries map[string]int
+}
+
+func (c *cache) Snapshot(w io.Writer) error {
+	return json.NewEncoder(w).Encode(c.entries)
+}
+
+func (c *cache) Restore(r io.Reader) error {
+	return json.NewDecoder(r).Decode(&c.entries)
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	store := &bucket{objects: make(map[string]string)}
+	sim := simulation.New(42, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	old := snapshot.New(store, "replica-0", snapshot.WithClock(sim))
+	old.Register("cache", 1, &cache{entries: map[string]int{"a": 1, "b": 2}})
+	if err := old.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.objects["replica-0/cache.snapshot"]; !ok {
+		t.Fatalf("Expected the snapshot to be written under the prefix, got %v", store.objects)
+	}
+
+	restored := &cache{}
+	snapshots := snapshot.New(store, "replica-0", snapshot.WithClock(sim), snapshot.WithMaxAge(time.Hour))
+	snapshots.Register("cache", 1, restored)
+	if err := snapshots.Restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if restored.entries["a"] != 1 || restored.entries["b"] != 2 {
+		t.Errorf("Expected the entries to be restored, got %v", restored.entries)
+	}
+
+	// Components of another version start cold
+	bumped := &cache{}
+	snapshots = snapshot.New(store, "replica-0", snapshot.WithClock(sim))
+	snapshots.Register("cache", 2, bumped)
+	if err := snapshots.Restore(ctx); err != nil || bumped.entries != nil {
+		t.Errorf("Expected the snapshot of another version not to be restored, got %v(%v)", bumped.entries, err)
+	}
+
+	// As do components whose snapshots are too old, or missing
+	sim.Advance(2 * time.Hour)
+	stale, missing := &cache{}, &cache{}
+	snapshots = snapshot.New(store, "replica-0", snapshot.WithClock(sim), snapshot.WithMaxAge(time.Hour))
+	snapshots.Register("cache", 1, stale)
+	snapshots.Register("aggregates", 1, missing)
+	if err := snapshots.Restore(ctx); err != nil || stale.entries != nil || missing.entries != nil {
+		t.Errorf("Expected the stale and missing snapshots not to be restored, got %v, %v(%v)", stale.entries, missing.entries, err)
+	}
+}
+
+func TestRestoreCorrupted(t *testing.T) {
+	ctx := context.Background()
+	store := &bucket{objects: map[string]string{
+		"replica-0/cache.snapshot": "not a snapshot",
+	}}
+
+	snapshots := snapshot.New(store, "replica-0")
+	snapshots.Register("cache", 1, &cache{})
+	snapshots.Register("aggregates", 1, &cache{})
+	if err := snapshots.Restore(ctx); err == nil || !strings.Contains(err.Error(), "cache") {
+		t.Errorf("Expected the corrupted snapshot to fail to restore, got %v", err)
+	}
+}
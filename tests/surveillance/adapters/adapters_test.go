@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+
+	"github.com/skit-ai/vcore/surveillance"
+	"github.com/skit-ai/vcore/surveillance/adapters"
+)
+
+func TestAdaptersRecordStatus(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(data))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("SENTRY_DSN", strings.Replace(server.URL, "http://", "http://public@", 1)+"/1")
+	t.Setenv("SENTRY_TRACING", "true")
+	t.Setenv("SENTRY_TRACES_SAMPLE_RATE", "1")
+	client := surveillance.InitSentry("test")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(adapters.GinMiddleware(client))
+	router.GET("/gin", func(c *gin.Context) {
+		c.String(http.StatusInternalServerError, "could not fetch the call")
+	})
+
+	e := echo.New()
+	e.Use(adapters.EchoMiddleware(client))
+	e.GET("/echo", func(c echo.Context) error {
+		// Responded by the error handler of Echo
+		return echo.NewHTTPError(http.StatusServiceUnavailable)
+	})
+
+	for path, handler := range map[string]http.Handler{"/gin": router, "/echo": e} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code < 500 {
+			t.Errorf("Expected %s to fail, got %d", path, w.Code)
+		}
+	}
+	client.Flush(5 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	sent := strings.Join(bodies, "\n")
+	for _, status := range []string{`"http.response.status_code":500`, `"http.response.status_code":503`} {
+		if !strings.Contains(sent, status) {
+			t.Errorf("Expected a transaction with %s, got %s", status, sent)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/simulation"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestAdaptiveSampling(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+	t.Setenv("SENTRY_DSN", dsn)
+
+	sim := simulation.New(1, time.Now())
+	client := surveillance.InitSentry("test",
+		surveillance.WithAdaptiveSampling(10, 0.05),
+		surveillance.WithClock(sim),
+		surveillance.WithRandom(sim.Float64),
+	)
+
+	sent := 0
+	for i := 0; i < 200; i++ {
+		if client.Capture(errors.NewError("Could not connect", nil, false), false) != "" {
+			sent++
+		}
+	}
+	if sent < 10 || sent > 60 {
+		t.Errorf("Expected the spike to be sampled down, sent %d of 200", sent)
+	}
+
+	sim.Advance(time.Minute)
+	if rate := client.EffectiveSampleRate(); rate != 0.05 {
+		t.Errorf("Expected the rate to be reduced to the floor after the spike, got %f", rate)
+	}
+
+	// Doubling every quiet minute
+	sim.Advance(2 * time.Minute)
+	if rate := client.EffectiveSampleRate(); rate != 0.2 {
+		t.Errorf("Expected the rate to double every quiet minute, got %f", rate)
+	}
+	sim.Advance(3 * time.Minute)
+	if rate := client.EffectiveSampleRate(); rate != 1 {
+		t.Errorf("Expected the rate to ramp back up once the volume subsides, got %f", rate)
+	}
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestCaptureWithAttachments(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(data))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(strings.Replace(server.URL, "http://", "http://public@", 1)+"/1", "test",
+		surveillance.WithDedupWindow(time.Minute))
+	attachments := []surveillance.Attachment{{Filename: "request.json", ContentType: "application/json", Bytes: []byte(`{"turn":3}`)}}
+
+	ctx := context.Background()
+	err := errors.NewError("Could not parse the intent", nil, false)
+	if eventID := client.CaptureWithAttachments(ctx, err, attachments); eventID == "" {
+		t.Fatal("Expected the error to be captured")
+	}
+	// The errors with attachments are admitted as the others, eg. deduplicated and dropped once the client is closed
+	if eventID := client.CaptureWithAttachments(ctx, err, attachments); eventID != "" {
+		t.Error("Expected the duplicate to be dropped")
+	}
+	client.Close()
+	if eventID := client.CaptureWithAttachments(ctx, errors.NewError("Could not synthesize", nil, false), attachments); eventID != "" {
+		t.Error("Expected the error captured after Close to be dropped")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(bodies) != 1 || !strings.Contains(bodies[0], `"filename":"request.json"`) {
+		t.Errorf("Expected the error to be sent once with its attachment, got %v", bodies)
+	}
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func project(events *atomic.Int32) (*httptest.Server, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events.Add(1)
+	}))
+	return server, strings.Replace(server.URL, "http://", "http://public@", 1) + "/1"
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+	t.Setenv("SENTRY_DSN", dsn)
+	client := surveillance.InitSentry("test")
+
+	// The breadcrumbs are recorded on the hub of the request
+	hub := sentry.NewHub(nil, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	client.AddDBBreadcrumb(ctx, "SELECT * FROM calls", nil)
+	client.AddHTTPBreadcrumb(ctx, "POST", "https://tts.example.com/synthesize", 503)
+	client.AddTransitionBreadcrumb(ctx, "greeting", "confirmation", nil)
+
+	breadcrumbs := hub.Scope().ApplyToEvent(&sentry.Event{}, nil, nil).Breadcrumbs
+	if len(breadcrumbs) != 3 {
+		t.Fatalf("Expected 3 breadcrumbs on the hub of the request, got %d", len(breadcrumbs))
+	}
+	if crumb := breadcrumbs[0]; crumb.Category != surveillance.BreadcrumbDB || crumb.Message != "SELECT * FROM calls" {
+		t.Errorf("Expected the query to be recorded, got %+v", crumb)
+	}
+	if crumb := breadcrumbs[1]; crumb.Category != surveillance.BreadcrumbHTTP || crumb.Level != sentry.LevelWarning || crumb.Data["status_code"] != 503 {
+		t.Errorf("Expected the failed call to be recorded as a warning, got %+v", crumb)
+	}
+	if crumb := breadcrumbs[2]; crumb.Category != surveillance.BreadcrumbTransition || crumb.Message != "greeting -> confirmation" {
+		t.Errorf("Expected the transition to be recorded, got %+v", crumb)
+	}
+
+	client.ClearBreadcrumbs(ctx)
+	if breadcrumbs := hub.Scope().ApplyToEvent(&sentry.Event{}, nil, nil).Breadcrumbs; len(breadcrumbs) != 0 {
+		t.Errorf("Expected the breadcrumbs to be cleared, got %d", len(breadcrumbs))
+	}
+}
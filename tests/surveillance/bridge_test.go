@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestBridgeLogs(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(dsn, "test")
+	stop := client.BridgeLogs(surveillance.WithBridgeLevel(log.WARN), surveillance.WithBridgeDedupWindow(time.Minute))
+
+	err := errors.NewError("Could not fetch the call", nil, false)
+	log.Errorf(err, "Could not handle the turn %d", 3)
+	// Identical errors are captured once within the window
+	log.Error(err)
+	log.Warnf("Falling back to the default voice")
+	log.Infof("Turn %d handled", 4)
+	log.Error(errors.NewErrorToIgnore("Client went away", nil))
+	// Errors captured by the wrapper are logged without feeding the bridge
+	client.Capture(errors.NewError("Could not save the recording", nil, false), false)
+
+	stop()
+	log.Error(errors.NewError("Could not close the call", nil, false))
+	client.Flush(5 * time.Second)
+
+	if events.Load() != 3 {
+		t.Errorf("Expected the error, the warning and the captured error to be sent, got %d events", events.Load())
+	}
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestBufferRetriesWhileUnreachable(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Sentry is down for the first two attempts
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Setenv("SENTRY_DSN", strings.Replace(server.URL, "http://", "http://public@", 1)+"/1")
+	client := surveillance.InitSentry("test", surveillance.WithBuffer(10, t.TempDir()))
+	defer client.Close()
+
+	client.Capture(errors.NewError("Could not reach the NLU", nil, false), false)
+	if !client.Flush(10 * time.Second) {
+		t.Fatalf("Expected the event to be delivered once Sentry is reachable, got %+v", client.BufferStats())
+	}
+
+	stats := client.BufferStats()
+	if stats.Sent != 1 || stats.Retries != 2 || stats.Dropped != 0 || stats.Queued != 0 {
+		t.Errorf("Expected the event to be sent after 2 retries, got %+v", stats)
+	}
+}
+
+func TestBufferFlushTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Setenv("SENTRY_DSN", strings.Replace(server.URL, "http://", "http://public@", 1)+"/1")
+	client := surveillance.InitSentry("test", surveillance.WithBuffer(10, ""))
+	defer client.Close()
+
+	client.Capture(errors.NewError("Could not reach the NLU", nil, false), false)
+	if client.Flush(10 * time.Millisecond) {
+		t.Fatal("Expected the flush to time out while Sentry is unreachable")
+	}
+
+	// The flushes timing out leave no goroutine waiting behind
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		client.Flush(time.Millisecond)
+	}
+	if leaked := runtime.NumGoroutine() - goroutines; leaked >= 10 {
+		t.Errorf("Expected no goroutines to be left by the flushes, got %d more", leaked)
+	}
+}
+
+func TestBufferDirectoryPerProject(t *testing.T) {
+	var fallbackEvents, billingEvents atomic.Int32
+	fallbackServer, fallbackDSN := project(&fallbackEvents)
+	defer fallbackServer.Close()
+	billingServer, billingDSN := project(&billingEvents)
+	defer billingServer.Close()
+	billingDSN = strings.TrimSuffix(billingDSN, "/1") + "/2"
+
+	// An envelope of the fallback project left behind by a previous run
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "1"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	persisted := filepath.Join(dir, "1", "00000000000000000001-previous.envelope")
+	if err := os.WriteFile(persisted, []byte("{}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The clients of both projects share the directory of the buffer, as they read the same environment variables
+	billing := surveillance.NewSentry(billingDSN, "test", surveillance.WithBuffer(10, dir))
+	defer billing.Close()
+	billing.Flush(5 * time.Second)
+	if _, err := os.Stat(persisted); err != nil || billingEvents.Load() != 0 {
+		t.Fatalf("Expected the envelope of the fallback project to be left to it, got %d events sent to billing(%v)", billingEvents.Load(), err)
+	}
+
+	t.Setenv("SENTRY_DSN", fallbackDSN)
+	fallback := surveillance.InitSentry("test", surveillance.WithBuffer(10, dir))
+	defer fallback.Close()
+	fallback.Flush(5 * time.Second)
+	if _, err := os.Stat(persisted); !os.IsNotExist(err) || fallbackEvents.Load() != 1 {
+		t.Errorf("Expected the envelope to be sent to the fallback project, got %d events(%v)", fallbackEvents.Load(), err)
+	}
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	stderrors "errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestCaptureWithContext(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(dsn, "test", surveillance.WithDedupWindow(time.Minute))
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.CurrentHub().Clone())
+
+	eventID, err := client.CaptureWithContextErr(ctx, errors.NewError("Could not transcribe", nil, false), false)
+	if err != nil || eventID == "" {
+		t.Fatalf("Expected the error to be sent, got %q and %v", eventID, err)
+	}
+
+	_, err = client.CaptureWithContextErr(ctx, errors.NewError("Could not transcribe", nil, false), false)
+	var dropped *surveillance.DropError
+	if !stderrors.Is(err, surveillance.ErrDropped) || !stderrors.As(err, &dropped) || dropped.Reason != "duplicate" {
+		t.Errorf("Expected the duplicate to be dropped, got %v", err)
+	}
+
+	if eventID, err = client.CaptureWithContextErr(context.Background(), nil, false); err != nil || eventID != "" {
+		t.Errorf("Expected nothing to be captured for a nil error, got %q and %v", eventID, err)
+	}
+
+	client.Close()
+	if _, err = client.CaptureWithContextErr(ctx, errors.NewError("Could not synthesize", nil, false), false); err != surveillance.ErrClosed {
+		t.Errorf("Expected the errors captured after Close to fail, got %v", err)
+	}
+	if events.Load() != 1 {
+		t.Errorf("Expected 1 event to be sent, got %d", events.Load())
+	}
+}
+
+func TestCaptureWithContextSampledOut(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("SENTRY_SAMPLING", "0.000000001")
+	client := surveillance.NewSentry(dsn, "test", surveillance.WithSyncCapture())
+	defer client.Close()
+
+	eventID, err := client.CaptureWithContextErr(context.Background(), errors.NewError("Could not transcribe", nil, false), false)
+	if eventID != "" || !stderrors.Is(err, surveillance.ErrDropped) {
+		t.Errorf("Expected the error to be sampled out without panicking, got %q and %v", eventID, err)
+	}
+
+	if _, err = surveillance.NewSentry("", "test").CaptureWithContextErr(context.Background(), errors.NewError("Could not connect", nil, false), false); err != surveillance.ErrNotInitialized {
+		t.Errorf("Expected the errors of a client without a DSN to fail, got %v", err)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	// Closing(or shutting down) a client which is closed already does not close its transport again, which panics
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(dsn, "test")
+	client.Close()
+	client.Close()
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected shutting down a closed client to be a no-op, got %v", err)
+	}
+
+	client = surveillance.NewSentry(dsn, "test")
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/skit-ai/vcore/surveillance/crash"
+)
+
+func TestInstallDisabled(t *testing.T) {
+	t.Setenv("FEATURES", "-crash-reports")
+	dir := filepath.Join(t.TempDir(), "crashes")
+
+	reporter, err := crash.Install(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter.AddBreadcrumb("call", "started")
+	if path := reporter.Write("panic: test"); path != "" {
+		t.Errorf("Expected no report to be written, got %s", path)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the directory not to be created, got %v", err)
+	}
+}
+
+func TestReports(t *testing.T) {
+	t.Setenv("FEATURES", "")
+	t.Cleanup(func() {
+		debug.SetCrashOutput(nil, debug.CrashOptions{})
+	})
+	dir := filepath.Join(t.TempDir(), "crashes")
+
+	reporter, err := crash.Install(dir, crash.WithSignals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := reporter.Write("panic: test")
+
+	// The reports are only readable by the user of the process
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("Expected the directory to be private, got %v(%v)", info.Mode().Perm(), err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the report to be private, got %v(%v)", info.Mode().Perm(), err)
+	}
+
+	// The reports left by the previous run are uploaded, the other files of the directory are left as is
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("kept"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var uploaded []string
+	if _, err := crash.Install(dir, crash.WithSignals(), crash.WithUploader(func(ctx context.Context, path string) error {
+		uploaded = append(uploaded, path)
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if len(uploaded) != 1 || uploaded[0] != path {
+		t.Errorf("Expected only %s to be uploaded, got %v", path, uploaded)
+	}
+	if _, err := os.Stat(notes); err != nil {
+		t.Errorf("Expected the other files not to be removed, got %v", err)
+	}
+}
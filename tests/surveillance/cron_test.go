@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestMonitor(t *testing.T) {
+	var mutex sync.Mutex
+	var envelopes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		envelopes = append(envelopes, string(body))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	t.Setenv("SENTRY_DSN", strings.Replace(server.URL, "http://", "http://public@", 1)+"/1")
+	client := surveillance.InitSentry("test")
+
+	if err := client.Monitor("reconcile", func() error { return nil }, surveillance.WithCrontab("0 * * * *")); err != nil {
+		t.Fatalf("Expected the job to succeed, got %s", err)
+	}
+	failure := errors.NewError("Could not reconcile", nil, false)
+	if err := client.Monitor("reconcile", func() error { return failure }); err != failure {
+		t.Fatalf("Expected the error of the job, got %v", err)
+	}
+	client.Flush(5 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	all := strings.Join(envelopes, "\n")
+	if count := strings.Count(all, `"type":"check_in"`); count != 4 {
+		t.Errorf("Expected 4 check-ins, got %d", count)
+	}
+	for _, expected := range []string{`"status":"in_progress"`, `"status":"ok"`, `"status":"error"`, `"value":"0 * * * *"`, "Could not reconcile"} {
+		if !strings.Contains(all, expected) {
+			t.Errorf("Expected %s to be sent", expected)
+		}
+	}
+}
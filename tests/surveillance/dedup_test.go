@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/simulation"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestDedupSummaries(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	sim := simulation.New(42, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := surveillance.NewSentry(dsn, "test", surveillance.WithDedupWindow(time.Minute), surveillance.WithClock(sim))
+
+	client.Capture(errors.NewError("Could not transcribe", nil, false), false)
+	client.Capture(errors.NewError("Could not transcribe", nil, false), false)
+	// The summary of the duplicate is sent to the project of the client once the window elapses
+	sim.Advance(time.Minute)
+	client.Flush(5 * time.Second)
+	if events.Load() != 2 {
+		t.Fatalf("Expected the error and its summary to be sent to the client's project, got %d events", events.Load())
+	}
+
+	// Summaries of the windows elapsing after Close are dropped
+	client.Capture(errors.NewError("Could not synthesize", nil, false), false)
+	client.Capture(errors.NewError("Could not synthesize", nil, false), false)
+	client.Close()
+	sim.Advance(time.Minute)
+	if events.Load() != 3 {
+		t.Errorf("Expected no summary to be sent after Close, got %d events", events.Load())
+	}
+}
@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestEnvironments(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	t.Setenv("SENTRY_DSN", dsn)
+	t.Setenv("ENVIRONMENT", "dev")
+	t.Setenv("SENTRY_REPORT_ENVIRONMENTS", "staging, production")
+
+	client := surveillance.InitSentry("test")
+	if eventID := client.Capture(errors.NewError("Could not connect", nil, false), false); eventID != "" {
+		t.Errorf("Expected no event to be captured in dev, got %s", eventID)
+	}
+
+	client = surveillance.InitSentry("test", surveillance.WithEnvironments([]string{"dev"}, nil))
+	if eventID := client.Capture(errors.NewError("Could not connect", nil, false), false); eventID == "" {
+		t.Errorf("Expected the event to be captured in an allowed environment")
+	}
+	client.Flush(5 * time.Second)
+
+	if events.Load() != 1 {
+		t.Errorf("Expected 1 event to be sent, got %d", events.Load())
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestEventBuilder(t *testing.T) {
+	event := surveillance.NewEvent().
+		WithMessage("Reconciliation found mismatches").
+		WithLevel(sentry.LevelWarning).
+		WithTag("job", "reconcile").
+		WithContext("call", sentry.Context{"id": "call-1"}).
+		WithException(errors.NewErrorWithTags("Missing the recording", nil, false, map[string]string{"bucket": "calls"})).
+		WithException(errors.NewError("Duration mismatch", nil, false)).
+		Build()
+
+	// Each error is sent with the chain of its causes(its stack included)
+	if len(event.Exception) != 4 || event.Exception[0].Value != "Missing the recording" || event.Exception[2].Value != "Duration mismatch" {
+		t.Fatalf("Expected both exceptions in order, got %+v", event.Exception)
+	}
+	if !event.Exception[2].Mechanism.IsExceptionGroup || *event.Exception[2].Mechanism.ParentID != 0 {
+		t.Errorf("Expected the exceptions to be grouped")
+	}
+	if event.Level != sentry.LevelWarning || event.Tags["job"] != "reconcile" || event.Tags["bucket"] != "calls" || event.Contexts["call"]["id"] != "call-1" {
+		t.Errorf("Expected the level, tags and context set, got %+v", event)
+	}
+}
+
+func TestEventBuilderSend(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(body))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	t.Setenv("SENTRY_DSN", strings.Replace(server.URL, "http://", "http://public@", 1)+"/1")
+
+	client := surveillance.InitSentry("test")
+	if eventID := client.NewEvent().WithMessage("Custom event").WithExtra("turns", 3).Send(context.Background()); eventID == "" {
+		t.Fatalf("Expected the event to be sent")
+	}
+	client.Flush(5 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(bodies) != 1 || !strings.Contains(bodies[0], "Custom event") || !strings.Contains(bodies[0], `"turns":3`) {
+		t.Errorf("Expected the custom event to be sent, got %v", bodies)
+	}
+}
+
+func TestEventBuilderSendDropped(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+	client := surveillance.NewSentry(dsn, "test")
+
+	// Ignored errors are not sent, as by Capture
+	if eventID := client.NewEvent().WithException(errors.NewErrorToIgnore("Caller hung up", nil)).Send(context.Background()); eventID != "" {
+		t.Errorf("Expected the event of an ignored error not to be sent, got %s", eventID)
+	}
+
+	// The client is bound to a clone of the global hub rather than to the global hub itself
+	global := sentry.CurrentHub().Client()
+	if eventID := client.NewEvent().WithMessage("Custom event").Send(context.Background()); eventID == "" {
+		t.Error("Expected the event to be sent")
+	}
+	if sentry.CurrentHub().Client() != global {
+		t.Error("Expected the client not to be bound to the global hub")
+	}
+
+	client.Close()
+	if eventID := client.NewEvent().WithMessage("Custom event").Send(context.Background()); eventID != "" {
+		t.Errorf("Expected the event not to be sent once the client is closed, got %s", eventID)
+	}
+	if events.Load() != 1 {
+		t.Errorf("Expected only the event sent before closing the client, got %d", events.Load())
+	}
+}
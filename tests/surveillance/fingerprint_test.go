@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestFingerprintRules(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(data))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	dsn := strings.Replace(server.URL, "http://", "http://public@", 1) + "/1"
+
+	rules, err := surveillance.ParseFingerprintRules("code:503=slu:unavailable, code:404")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(dsn, "test", surveillance.WithFingerprintRules(rules...))
+
+	unavailable := errors.NewErrorWithCode("SLU is unavailable", 503, nil)
+	client.Capture(errors.NewError("Could not predict the intent", unavailable, false), false)
+	client.Capture(errors.NewErrorWithCode("No such call", 404, nil), false)
+	// Fingerprints set on the error win over the rules
+	client.Capture(errors.WithFingerprint(unavailable, "slu", "turn"), false)
+	client.Flush(5 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	sent := strings.Join(bodies, "\n")
+	for _, expected := range []string{
+		`"fingerprint":["slu","unavailable"]`,
+		`"fingerprint":["code","404","*errors.rung"]`,
+		`"fingerprint":["slu","turn"]`,
+	} {
+		if !strings.Contains(sent, expected) {
+			t.Errorf("Expected an event with the fingerprint %s", expected)
+		}
+	}
+}
+
+func TestParseFingerprintRules(t *testing.T) {
+	rules, err := surveillance.ParseFingerprintRules("tag:component:slu, type:*net.OpError=network")
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %v(%v)", rules, err)
+	}
+	if rules[0].Tag != "component" || rules[0].Value != "slu" || rules[1].ErrorType != "*net.OpError" || rules[1].Fingerprint[0] != "network" {
+		t.Errorf("Expected the rules to be parsed, got %+v", rules)
+	}
+
+	for _, invalid := range []string{"code:abc", "message:timeout"} {
+		if _, err := surveillance.ParseFingerprintRules(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
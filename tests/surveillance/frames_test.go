@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+// Fails to transcribe, wrapping the error
+func transcribe() error {
+	return errors.WrapWithStack(errors.NewError("Could not reach the ASR", nil, false), "Could not transcribe")
+}
+
+func TestInternalFramesTrimmed(t *testing.T) {
+	events := make(chan sentry.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		for _, line := range strings.Split(string(data), "\n") {
+			var event sentry.Event
+			if json.Unmarshal([]byte(line), &event) == nil && len(event.Exception) > 0 {
+				events <- event
+			}
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(strings.Replace(server.URL, "http://", "http://public@", 1)+"/1", "test")
+	client.Capture(transcribe(), false)
+	client.Flush(5 * time.Second)
+
+	select {
+	case event := <-events:
+		for _, exception := range event.Exception {
+			if exception.Stacktrace == nil {
+				continue
+			}
+			frames := exception.Stacktrace.Frames
+			if top := frames[len(frames)-1]; strings.HasPrefix(top.Module, "github.com/skit-ai/vcore/errors") {
+				t.Errorf("Expected the frames of vcore to be dropped from the top of %s, got %s.%s", exception.Type, top.Module, top.Function)
+			}
+		}
+		last := event.Exception[len(event.Exception)-1]
+		if frames := last.Stacktrace.Frames; frames[len(frames)-1].Function != "transcribe" {
+			t.Errorf("Expected the wrap site at the top of the stack trace, got %s", frames[len(frames)-1].Function)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the error to be sent")
+	}
+}
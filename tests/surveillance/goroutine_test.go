@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestGo(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(data))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("SENTRY_DSN", strings.Replace(server.URL, "http://", "http://public@", 1)+"/1")
+	client := surveillance.InitSentry("test")
+
+	// The scope of the request launching the goroutine, cloned from the current hub as by the middlewares
+	hub := sentry.CurrentHub().Clone()
+	hub.Scope().SetTag("call_id", "c-1")
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	panics := make(chan *sentry.EventID, 10)
+	var runs int
+	client.Go(ctx, func(context.Context) {
+		runs++
+		panic("nil map")
+	}, surveillance.WithRestarts(2, time.Millisecond), surveillance.WithPanicHandler(func(_ context.Context, recovered interface{}, eventID *sentry.EventID) {
+		panics <- eventID
+	}))
+
+	for i := 0; i < 3; i++ {
+		select {
+		case eventID := <-panics:
+			if eventID == nil {
+				t.Errorf("Expected the panic %d to be captured", i)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the goroutine to be restarted twice, got %d panics", i)
+		}
+	}
+	select {
+	case <-panics:
+		t.Error("Expected the goroutine not to be restarted past its restarts")
+	case <-time.After(50 * time.Millisecond):
+	}
+	client.Flush(5 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if runs != 3 || len(bodies) != 3 || !strings.Contains(bodies[0], `"call_id":"c-1"`) {
+		t.Errorf("Expected the panics to be captured with the scope of the request, got %d runs and %v", runs, bodies)
+	}
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/skit-ai/vcore/errors"
+	sentryWrapper "github.com/skit-ai/vcore/sentry"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("SENTRY_DSN", dsn)
+	client := surveillance.InitSentry("test")
+
+	hub := sentry.CurrentHub().Clone()
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	transaction := sentry.StartTransaction(ctx, "call")
+	defer transaction.Finish()
+
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return status.Error(codes.Unavailable, "no healthy upstream")
+	}
+
+	// The errors of the calls are not captured by default, the server being the one to report them
+	interceptor := client.UnaryClientInterceptor()
+	if err := interceptor(transaction.Context(), "/skit.NLU/Predict", nil, nil, nil, invoker); status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected the error of the call, got %v", err)
+	}
+	if traces := outgoing.Get(sentry.SentryTraceHeader); len(traces) != 1 || traces[0] == "" {
+		t.Errorf("Expected the trace to be continued on the server, got %v", outgoing)
+	}
+	breadcrumbs := hub.Scope().ApplyToEvent(&sentry.Event{}, nil, nil).Breadcrumbs
+	if len(breadcrumbs) != 1 || breadcrumbs[0].Message != "/skit.NLU/Predict" || breadcrumbs[0].Level != sentry.LevelWarning ||
+		breadcrumbs[0].Data["status_code"] != codes.Unavailable.String() {
+		t.Errorf("Expected the failed call to be recorded as a breadcrumb, got %+v", breadcrumbs)
+	}
+
+	reporting := client.UnaryClientInterceptor(sentryWrapper.WithReportOn(sentryWrapper.ReportAlways))
+	_ = reporting(transaction.Context(), "/skit.NLU/Predict", nil, nil, nil, invoker)
+	// Errors to be ignored are not captured, even when configured to
+	_ = reporting(transaction.Context(), "/skit.NLU/Predict", nil, nil, nil, func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return errors.NewErrorToIgnore("Caller hung up", nil)
+	})
+	client.Flush(5 * time.Second)
+	if events.Load() != 1 {
+		t.Errorf("Expected the error to be captured once configured to, got %d events", events.Load())
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	err error
+}
+
+func (s *clientStream) RecvMsg(interface{}) error {
+	return s.err
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+	t.Setenv("SENTRY_DSN", dsn)
+	client := surveillance.InitSentry("test")
+
+	hub := sentry.NewHub(nil, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &clientStream{err: status.Error(codes.Internal, "stream reset")}, nil
+	}
+
+	stream, err := client.StreamClientInterceptor()(ctx, &grpc.StreamDesc{}, nil, "/skit.ASR/Recognize", streamer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The call is recorded once, when the stream ends
+	if breadcrumbs := hub.Scope().ApplyToEvent(&sentry.Event{}, nil, nil).Breadcrumbs; len(breadcrumbs) != 0 {
+		t.Errorf("Expected no breadcrumb before the stream ends, got %+v", breadcrumbs)
+	}
+	_ = stream.RecvMsg(nil)
+	_ = stream.RecvMsg(nil)
+	breadcrumbs := hub.Scope().ApplyToEvent(&sentry.Event{}, nil, nil).Breadcrumbs
+	if len(breadcrumbs) != 1 || breadcrumbs[0].Data["status_code"] != codes.Internal.String() {
+		t.Errorf("Expected the stream to be recorded once it ends, got %+v", breadcrumbs)
+	}
+}
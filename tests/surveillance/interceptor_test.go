@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	sentryWrapper "github.com/skit-ai/vcore/sentry"
+	"github.com/skit-ai/vcore/surveillance"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServerInterceptorReportOn(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(dsn, "test")
+	interceptor := client.UnaryServerInterceptor(sentryWrapper.WithReportOn(sentryWrapper.SkipCodes(codes.NotFound)))
+	unavailable := status.Error(codes.Unavailable, "no healthy upstream")
+	defer errors.RegisterIgnorable(errors.IgnoreErrors(unavailable))()
+
+	// The errors to be ignored are not reported either, whether set to be or matched by a registered matcher
+	info := &grpc.UnaryServerInfo{FullMethod: "/skit.Calls/Get"}
+	for _, err := range []error{
+		status.Error(codes.NotFound, "no such call"),
+		status.Error(codes.Canceled, "client went away"),
+		status.Error(codes.Internal, "could not read the call"),
+		errors.NewErrorToIgnore("Caller hung up", nil),
+		unavailable,
+	} {
+		_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, err
+		})
+	}
+	client.Flush(5 * time.Second)
+
+	if events.Load() != 1 {
+		t.Errorf("Expected only the internal error to be reported, got %d events", events.Load())
+	}
+}
+
+func TestReportIf(t *testing.T) {
+	reportOn := sentryWrapper.ReportIf(func(err error) bool {
+		return status.Code(err) != codes.InvalidArgument
+	})
+
+	if reportOn(nil) || reportOn(context.Canceled) || reportOn(status.Error(codes.InvalidArgument, "bad request")) {
+		t.Errorf("Expected nil errors, cancellations and rejected errors to not be reported")
+	}
+	if !reportOn(status.Error(codes.Unavailable, "down")) {
+		t.Errorf("Expected the error satisfying the predicate to be reported")
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+}
+
+func (serverStream) Context() context.Context {
+	return context.Background()
+}
+
+func TestStreamServerInterceptorPanic(t *testing.T) {
+	client := surveillance.NewSentry("", "test")
+	interceptor := client.StreamServerInterceptor()
+
+	info := &grpc.StreamServerInfo{FullMethod: "/skit.Dialogue/Stream"}
+	err := interceptor(nil, serverStream{}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		panic("could not decode the audio")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Expected the panic to be returned as an internal error, got %v", err)
+	}
+}
+
+func TestUnaryServerInterceptorPanic(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(data))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	dsn := strings.Replace(server.URL, "http://", "http://public@", 1) + "/1"
+
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(dsn, "test")
+	interceptor := client.UnaryServerInterceptor()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/skit.Calls/Get"}
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("could not decode the call")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Expected the panic to be returned as an internal error, got %v", err)
+	}
+	client.Flush(5 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	sent := strings.Join(bodies, "\n")
+	for _, expected := range []string{`"panic":"true"`, `panic: could not decode the call`, `"level":"fatal"`} {
+		if !strings.Contains(sent, expected) {
+			t.Errorf("Expected the event of the panic to contain %s, got %.512s", expected, sent)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Returns the value of the vcore_sentry counter(or the counter of the full name) with the label, summed over the other
+// labels
+func counter(t *testing.T, registry *prometheus.Registry, name, label, value string) float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var total float64
+	for _, family := range families {
+		if family.GetName() != "vcore_sentry_"+name && family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matches := label == ""
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == label && pair.GetValue() == value {
+					matches = true
+				}
+			}
+			if matches {
+				total += metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return total
+}
+
+func TestMetrics(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	if err := surveillance.RegisterMetrics(registry); err != nil {
+		t.Fatal(err)
+	}
+	if err := surveillance.RegisterMetrics(registry); err == nil {
+		t.Errorf("Expected registering the metrics twice to fail")
+	}
+
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(dsn, "test", surveillance.WithDedupWindow(time.Minute))
+
+	captured := counter(t, registry, "events_captured_total", "level", "error")
+	ignored := counter(t, registry, "events_ignored_total", "", "")
+	duplicates := counter(t, registry, "events_dropped_total", "reason", "duplicate")
+
+	err := errors.NewError("Could not connect", nil, false)
+	client.Capture(err, false)
+	client.Capture(err, false)
+	client.Capture(errors.NewErrorToIgnore("Client went away", nil), false)
+	client.Flush(5 * time.Second)
+
+	if delta := counter(t, registry, "events_captured_total", "level", "error") - captured; delta != 1 {
+		t.Errorf("Expected 1 event to be captured, got %v", delta)
+	}
+	if delta := counter(t, registry, "events_ignored_total", "", "") - ignored; delta != 1 {
+		t.Errorf("Expected 1 event to be ignored, got %v", delta)
+	}
+	if delta := counter(t, registry, "events_dropped_total", "reason", "duplicate") - duplicates; delta != 1 {
+		t.Errorf("Expected 1 duplicate to be dropped, got %v", delta)
+	}
+	if events.Load() != 1 {
+		t.Errorf("Expected 1 event to be sent, got %d", events.Load())
+	}
+}
+
+func TestErrorCategoryMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if err := surveillance.RegisterMetrics(registry); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := func(category errors.Category) float64 {
+		return counter(t, registry, "vcore_errors_seen_total", "category", string(category))
+	}
+	validation, dependency, internal := seen(errors.CategoryValidation), seen(errors.CategoryDependency), seen(errors.CategoryInternal)
+
+	client := surveillance.NewSentry("", "test")
+	client.Capture(errors.WithCode(errors.NewError("Invalid phone number", nil, false), errors.InvalidArgument), false)
+	client.Capture(errors.WithCategory(errors.NewError("Could not query the database", nil, false), errors.CategoryDependency), false)
+	client.Capture(errors.NewError("Client went away", context.Canceled, false), false)
+	surveillance.CountError(errors.NewError("Unexpected state", nil, false))
+	surveillance.CountError(nil)
+
+	if delta := seen(errors.CategoryValidation) - validation; delta != 1 {
+		t.Errorf("Expected 1 validation error, got %v", delta)
+	}
+	if delta := seen(errors.CategoryDependency) - dependency; delta != 1 {
+		t.Errorf("Expected 1 dependency failure, got %v", delta)
+	}
+	if delta := seen(errors.CategoryInternal) - internal; delta != 1 {
+		t.Errorf("Expected 1 internal error and the canceled one not to be counted, got %v", delta)
+	}
+}
+
+func TestSendFailureMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	if err := surveillance.RegisterMetrics(registry); err != nil {
+		t.Fatal(err)
+	}
+	rejected := counter(t, registry, "send_failures_total", "reason", "rejected")
+
+	// The default transport of the client reports the events it fails to deliver too
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(strings.Replace(server.URL, "http://", "http://public@", 1)+"/1", "test")
+	client.Capture(errors.NewError("Could not connect", nil, false), false)
+	client.Flush(5 * time.Second)
+
+	if delta := counter(t, registry, "send_failures_total", "reason", "rejected") - rejected; delta != 1 {
+		t.Errorf("Expected the rejected event to be counted, got %v", delta)
+	}
+}
+
+func TestMetricsWithoutSentry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if err := surveillance.RegisterMetrics(registry); err != nil {
+		t.Fatal(err)
+	}
+	dropped := counter(t, registry, "events_dropped_total", "", "")
+
+	// The errors of a service without sentry are not counted as dropped, as they were never to be sent
+	client := surveillance.NewSentry("", "test")
+	interceptor := client.UnaryServerInterceptor()
+	_, _ = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/skit.Calls/Get"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Internal, "could not read the call")
+	})
+	client.Capture(errors.NewError("Could not connect", nil, false), false)
+
+	if delta := counter(t, registry, "events_dropped_total", "", "") - dropped; delta != 0 {
+		t.Errorf("Expected no event to be counted as dropped, got %v", delta)
+	}
+}
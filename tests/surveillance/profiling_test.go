@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestProfileRegion(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(data))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("SENTRY_DSN", strings.Replace(server.URL, "http://", "http://public@", 1)+"/1")
+	t.Setenv("SENTRY_TRACING", "true")
+	t.Setenv("SENTRY_TRACES_SAMPLE_RATE", "1")
+	client := surveillance.InitSentry("test", surveillance.WithProfiling(1, 0))
+
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.CurrentHub().Clone())
+	transaction := surveillance.StartTransaction(ctx, "transcribe", "job")
+	client.ProfileRegion(transaction.Context(), "decode", func(ctx context.Context) {
+		for start := time.Now(); time.Since(start) < 50*time.Millisecond; {
+		}
+	})
+	transaction.Finish()
+	client.Flush(5 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	sent := strings.Join(bodies, "\n")
+	if !strings.Contains(sent, `"filename":"decode.pprof"`) || !strings.Contains(sent, surveillance.TagProfileID) {
+		t.Errorf("Expected the profile of the region to be attached to the transaction, got %s", sent)
+	}
+}
+
+func TestProfilesOfUnsentTransactions(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(data))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("SENTRY_DSN", strings.Replace(server.URL, "http://", "http://public@", 1)+"/1")
+	t.Setenv("SENTRY_TRACING", "true")
+	t.Setenv("SENTRY_TRACES_SAMPLE_RATE", "1")
+	client := surveillance.InitSentry("test", surveillance.WithProfiling(1, 0))
+
+	// Profiles of transactions which are never finished do not stop the others from being profiled
+	for i := 0; i < 40; i++ {
+		ctx := sentry.SetHubOnContext(context.Background(), sentry.CurrentHub().Clone())
+		client.ProfileRegion(surveillance.StartTransaction(ctx, "abandoned", "job").Context(), "abandoned", func(context.Context) {})
+	}
+
+	ctx := sentry.SetHubOnContext(context.Background(), sentry.CurrentHub().Clone())
+	transaction := surveillance.StartTransaction(ctx, "transcribe", "job")
+	client.ProfileRegion(transaction.Context(), "decode", func(context.Context) {})
+	transaction.Finish()
+	client.Flush(5 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if sent := strings.Join(bodies, "\n"); !strings.Contains(sent, `"filename":"decode.pprof"`) {
+		t.Errorf("Expected the profile of the region to be attached to the transaction, got %s", sent)
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestCaptureQueue(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(data))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	dsn := strings.Replace(server.URL, "http://", "http://public@", 1) + "/1"
+
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(dsn, "test", surveillance.WithCaptureQueue(2, surveillance.Block))
+
+	var eventIDs []string
+	for i := 0; i < 20; i++ {
+		eventID := client.Capture(errors.NewError(fmt.Sprintf("Could not handle the turn %d", i), nil, false), false)
+		if eventID == "" {
+			t.Fatalf("Expected the event ID of the queued error %d", i)
+		}
+		eventIDs = append(eventIDs, string(eventID))
+	}
+	if !client.Flush(5 * time.Second) {
+		t.Fatal("Expected the queue to be flushed")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(bodies) != 20 {
+		t.Fatalf("Expected all the errors to be sent with the blocking policy, got %d", len(bodies))
+	}
+	sent := strings.Join(bodies, "\n")
+	for _, eventID := range eventIDs {
+		if !strings.Contains(sent, eventID) {
+			t.Errorf("Expected the event %s to be sent with the ID returned", eventID)
+		}
+	}
+}
+
+func TestParseDropPolicy(t *testing.T) {
+	for policy, expected := range map[string]surveillance.DropPolicy{
+		"":       surveillance.DropNewest,
+		"oldest": surveillance.DropOldest,
+		"Block":  surveillance.Block,
+	} {
+		if parsed, err := surveillance.ParseDropPolicy(policy); err != nil || parsed != expected {
+			t.Errorf("Expected %q to be parsed as %s, got %s(%v)", policy, expected, parsed, err)
+		}
+	}
+	if _, err := surveillance.ParseDropPolicy("random"); err == nil {
+		t.Errorf("Expected an unknown policy to be rejected")
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestRouter(t *testing.T) {
+	var fallbackEvents, billingEvents atomic.Int32
+	fallbackServer, fallbackDSN := project(&fallbackEvents)
+	defer fallbackServer.Close()
+	billingServer, billingDSN := project(&billingEvents)
+	defer billingServer.Close()
+
+	t.Setenv("SENTRY_DSN", fallbackDSN)
+	router := surveillance.NewRouter(surveillance.InitSentry("test"), surveillance.RouteByTag("service"))
+	router.Register("billing", surveillance.NewSentry(billingDSN, "test"))
+
+	router.Capture(errors.NewErrorWithTags("Could not charge", nil, false, map[string]string{"service": "billing"}), false)
+	router.Capture(errors.NewErrorWithTags("Could not transcribe", nil, false, map[string]string{"service": "asr"}), false)
+	router.Capture(errors.NewError("Could not connect", nil, false), false)
+	router.Flush(5 * time.Second)
+
+	if billingEvents.Load() != 1 || fallbackEvents.Load() != 2 {
+		t.Errorf("Expected 1 event for billing and 2 for the fallback project, got %d and %d", billingEvents.Load(), fallbackEvents.Load())
+	}
+}
+
+func TestRouterClose(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	// Services sharing a DSN share the client, which is closed once
+	client := surveillance.NewSentry(dsn, "test")
+	router := surveillance.NewRouter(surveillance.NewSentry("", "test"), surveillance.RouteByTag("service"))
+	router.Register("billing", client)
+	router.Register("payments", client)
+	router.Close()
+
+	err := errors.NewErrorWithTags("Could not charge", nil, false, map[string]string{"service": "payments"})
+	if _, err = router.CaptureWithContextErr(context.Background(), err, false); err != surveillance.ErrClosed {
+		t.Errorf("Expected the errors captured after Close to fail, got %v", err)
+	}
+}
+
+func TestRouterMiddleware(t *testing.T) {
+	var fallbackEvents, billingEvents atomic.Int32
+	fallbackServer, fallbackDSN := project(&fallbackEvents)
+	defer fallbackServer.Close()
+	billingServer, billingDSN := project(&billingEvents)
+	defer billingServer.Close()
+
+	t.Setenv("SENTRY_DSN", fallbackDSN)
+	fallback := surveillance.InitSentry("test")
+	billing := surveillance.NewSentry(billingDSN, "test")
+
+	// Panics recovered by the middleware of a client are sent to its project, not the one of the global hub
+	handler := billing.SentryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("Could not charge")
+	}))
+	func() {
+		defer func() {
+			recover()
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/charge", nil))
+	}()
+
+	// Capturing on the global hub does not bind the client to it
+	billing.Capture(errors.NewError("Could not refund", nil, false), false)
+	fallback.Capture(errors.NewError("Could not connect", nil, false), false)
+	billing.Flush(5 * time.Second)
+	fallback.Flush(5 * time.Second)
+
+	if billingEvents.Load() != 2 || fallbackEvents.Load() != 1 {
+		t.Errorf("Expected 2 events for billing and 1 for the fallback project, got %d and %d", billingEvents.Load(), fallbackEvents.Load())
+	}
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	stderrors "errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestParseSamplingRules(t *testing.T) {
+	rules, err := surveillance.ParseSamplingRules("tag:timeout=0.05, tag:service:asr=0.5,type:*net.OpError=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []surveillance.SamplingRule{
+		{Tag: "timeout", Rate: 0.05},
+		{Tag: "service", Value: "asr", Rate: 0.5},
+		{ErrorType: "*net.OpError", Rate: 1},
+	}
+	if len(rules) != len(expected) {
+		t.Fatalf("Expected %d rules, got %v", len(expected), rules)
+	}
+	for i := range expected {
+		if rules[i] != expected[i] {
+			t.Errorf("Expected the rule %v, got %v", expected[i], rules[i])
+		}
+	}
+	if rules[1].String() != "tag:service:asr=0.5" {
+		t.Errorf("Expected the rule to be formatted as configured, got %s", rules[1])
+	}
+
+	for _, invalid := range []string{"tag:timeout", "tag:timeout=2", "code:500=0.5"} {
+		if _, err := surveillance.ParseSamplingRules(invalid); err == nil {
+			t.Errorf("Expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestSamplingRules(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("SENTRY_DSN", dsn)
+	client := surveillance.InitSentry("test", surveillance.WithSamplingRules(
+		surveillance.SamplingRule{Tag: "timeout", Rate: 0},
+		surveillance.SamplingRule{ErrorType: "*errors.errorString", Rate: 1},
+	))
+
+	timeout := errors.NewErrorWithTags("Could not reach the NLU", stderrors.New("i/o timeout"), false, map[string]string{"timeout": "nlu"})
+	for i := 0; i < 3; i++ {
+		if eventID := client.Capture(timeout, false); eventID != "" {
+			t.Errorf("Expected the timeout to be sampled out, got %s", eventID)
+		}
+	}
+	// The first rule matching decides, the others are sent
+	client.Capture(errors.NewError("Could not parse the intent", stderrors.New("unexpected token"), false), false)
+	client.Capture(errors.NewError("Could not synthesize", nil, false), false)
+	client.Flush(5 * time.Second)
+
+	if events.Load() != 2 {
+		t.Errorf("Expected the errors not sampled out to be sent, got %d events", events.Load())
+	}
+	if sampledOut := client.SampledOut(); sampledOut["tag:timeout=0"] != 3 || len(sampledOut) != 1 {
+		t.Errorf("Expected the errors sampled out to be counted by their rule, got %v", sampledOut)
+	}
+}
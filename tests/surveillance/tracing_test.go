@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestStartTransaction(t *testing.T) {
+	transaction := surveillance.StartTransaction(context.Background(), "consume calls", "queue.process")
+	defer transaction.Finish()
+	if transaction.Name != "consume calls" || transaction.Op != "queue.process" || sentry.GetHubFromContext(transaction.Context()) == nil {
+		t.Errorf("Expected a transaction on a hub of its own, got %+v", transaction)
+	}
+
+	span := surveillance.StartSpan(transaction.Context(), "db.query")
+	defer span.Finish()
+	if span.TraceID != transaction.TraceID || span.ParentSpanID != transaction.SpanID {
+		t.Errorf("Expected the span to be a child of the transaction, got %+v", span)
+	}
+}
+
+func TestRequestTransaction(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+	t.Setenv("SENTRY_DSN", dsn)
+	client := surveillance.InitSentry("test")
+
+	var transaction *sentry.Span
+	handler := client.HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+		transaction = sentry.TransactionFromContext(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/calls/c-1", nil))
+	if transaction == nil || transaction.Status != sentry.SpanStatusNotFound || transaction.Data["http.response.status_code"] != http.StatusNotFound {
+		t.Errorf("Expected the transaction to finish with the status of the response, got %+v", transaction)
+	}
+
+	// Panics finish the transaction as internal errors
+	panicking := client.HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+		transaction = sentry.TransactionFromContext(r.Context())
+		panic("nil map")
+	})
+	func() {
+		defer func() { _ = recover() }()
+		panicking(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/calls/c-1", nil))
+	}()
+	if transaction.Status != sentry.SpanStatusInternalError {
+		t.Errorf("Expected the transaction of the panic to be an internal error, got %s", transaction.Status)
+	}
+}
+
+func TestRequestWriterInterfaces(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+	t.Setenv("SENTRY_DSN", dsn)
+	client := surveillance.InitSentry("test")
+
+	// The writer of the handler implements the optional interfaces of the writer of the server, and only those
+	interfaces := func(w http.ResponseWriter) (flusher, hijacker, readerFrom, pusher bool) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker = w.(http.Hijacker)
+		_, readerFrom = w.(io.ReaderFrom)
+		_, pusher = w.(http.Pusher)
+		return
+	}
+	var expected, got [4]bool
+	handler := client.HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+		got[0], got[1], got[2], got[3] = interfaces(w)
+	})
+
+	recorder := httptest.NewRecorder()
+	expected[0], expected[1], expected[2], expected[3] = interfaces(recorder)
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/calls/c-1", nil))
+	if got != expected {
+		t.Errorf("Expected the interfaces %v of the recorder, got %v", expected, got)
+	}
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected[0], expected[1], expected[2], expected[3] = interfaces(w)
+		handler(w, r)
+	}))
+	defer upstream.Close()
+	response, err := http.Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if got != expected || !got[1] {
+		t.Errorf("Expected the interfaces %v of the server, got %v", expected, got)
+	}
+}
+
+func TestRPCTransaction(t *testing.T) {
+	var events atomic.Int32
+	server, dsn := project(&events)
+	defer server.Close()
+	t.Setenv("SENTRY_DSN", dsn)
+	client := surveillance.InitSentry("test")
+
+	traceparent := "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1"
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(sentry.SentryTraceHeader, traceparent))
+
+	var transaction *sentry.Span
+	_, _ = client.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/skit.Calls/Get"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		transaction = sentry.TransactionFromContext(ctx)
+		return nil, status.Error(codes.NotFound, "no such call")
+	})
+
+	if transaction == nil || transaction.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || transaction.Name != "/skit.Calls/Get" {
+		t.Fatalf("Expected the RPC to continue the trace of the client, got %+v", transaction)
+	}
+	if transaction.Status != sentry.SpanStatusNotFound || transaction.Tags["rpc.grpc.status_code"] != codes.NotFound.String() {
+		t.Errorf("Expected the transaction to finish with the status of the RPC, got %s %v", transaction.Status, transaction.Tags)
+	}
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestTruncateOversizedExtras(t *testing.T) {
+	var mutex sync.Mutex
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		body = string(data)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	dsn := strings.Replace(server.URL, "http://", "http://public@", 1) + "/1"
+
+	t.Setenv("ENVIRONMENT", "production")
+	client := surveillance.NewSentry(dsn, "test", surveillance.WithPayloadLimits(1024, 64<<10))
+
+	payload := strings.Repeat("intent:book_appointment ", 10000)
+	err := errors.NewErrorWithExtras("Could not parse the SLU response", nil, false, map[string]interface{}{
+		"slu_response": payload,
+		"turns":        []string{payload[:2000]},
+		"call_id":      "42",
+	})
+	client.Capture(err, false)
+	client.Flush(5 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if body == "" {
+		t.Fatal("Expected the event to be sent")
+	}
+	if len(body) > 64<<10 {
+		t.Errorf("Expected the event to be truncated, got %d bytes", len(body))
+	}
+	for _, expected := range []string{`"payload.truncated":"true"`, `"payload.original_size":"4`, "...[truncated, 240000 bytes]", `"call_id":"42"`} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected the event to contain %q", expected)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+func TestSetUser(t *testing.T) {
+	if _, ok := surveillance.UserFromContext(context.Background()); ok {
+		t.Error("Expected no user without SetUser")
+	}
+	ctx := surveillance.SetUser(context.Background(), "u-1", "ravi@example.com", map[string]string{"tenant": "acme"})
+	if user, ok := surveillance.UserFromContext(ctx); !ok || user.ID != "u-1" || user.Email != "ravi@example.com" || user.Data["tenant"] != "acme" {
+		t.Errorf("Expected the user set on the context, got %+v", user)
+	}
+}
+
+func TestUserOfRequest(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(data))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("SENTRY_DSN", strings.Replace(server.URL, "http://", "http://public@", 1)+"/1")
+	client := surveillance.InitSentry("test", surveillance.WithUserKeys("X-User-ID", "X-User-Email"))
+
+	handler := client.HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+		client.CaptureWithContext(r.Context(), errors.NewError("Could not fetch the balance", nil, false), false)
+	})
+	request := httptest.NewRequest(http.MethodGet, "/balance", nil)
+	request.Header.Set("X-User-ID", "u-42")
+	handler(httptest.NewRecorder(), request)
+	client.Flush(5 * time.Second)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(bodies) != 1 || !strings.Contains(bodies[0], `"id":"u-42"`) {
+		t.Errorf("Expected the event to identify the user of the request from its headers, got %v", bodies)
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/skit-ai/vcore/simulation"
+	"github.com/skit-ai/vcore/topk"
+)
+
+func TestTrackerWindow(t *testing.T) {
+	sim := simulation.New(42, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tenants := topk.New("tenants", 2, time.Minute, topk.WithClock(sim))
+
+	for i := 0; i < 10; i++ {
+		tenants.Add("acme")
+	}
+	tenants.AddN("globex", 5)
+	tenants.Add("initech")
+
+	sim.Advance(30 * time.Second)
+	tenants.AddN("initech", 8)
+	top := tenants.Top()
+	if len(top) != 2 || top[0].Key != "acme" || top[0].Count != 10 || top[1].Key != "initech" || top[1].Count != 9 {
+		t.Fatalf("Expected acme and initech on top, got %v", top)
+	}
+
+	// The counts of acme roll out of the window
+	sim.Advance(45 * time.Second)
+	if top := tenants.Top(); len(top) != 1 || top[0].Key != "initech" || top[0].Count != 8 {
+		t.Errorf("Expected the earlier counts to roll out of the window, got %v", top)
+	}
+}
+
+func TestTrackerReport(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(topk.Collectors()...)
+
+	intents := topk.New("intents", 3, time.Minute)
+	intents.AddN("cancel", 4)
+	intents.AddN("repeat", 2)
+	intents.Report()
+
+	expected := `
+		# HELP vcore_topk_count Counts of the top items of the trackers within their windows, by tracker and item
+		# TYPE vcore_topk_count gauge
+		vcore_topk_count{key="cancel",tracker="intents"} 4
+		vcore_topk_count{key="repeat",tracker="intents"} 2
+	`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "vcore_topk_count"); err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	intents.Run(ctx)
+	if count := testutil.CollectAndCount(topk.Collectors()[0]); count != 0 {
+		t.Errorf("Expected the gauge to be removed once done, got %d series", count)
+	}
+}
+
+func TestTrackerHandler(t *testing.T) {
+	codes := topk.New("codes", 5, time.Minute)
+	codes.AddN("UNAVAILABLE", 7)
+	codes.AddN("NOT_FOUND", 3)
+
+	recorder := httptest.NewRecorder()
+	codes.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?limit=1", nil))
+	var report struct {
+		Tracker string `json:"tracker"`
+		Items   []struct {
+			Key   string `json:"key"`
+			Count uint64 `json:"count"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Tracker != "codes" || len(report.Items) != 1 || report.Items[0].Key != "UNAVAILABLE" || report.Items[0].Count != 7 {
+		t.Errorf("Unexpected report %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	codes.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?limit=none", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid limit to be rejected, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/transcript"
+)
+
+func TestAssemble(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) time.Time {
+		return start.Add(offset)
+	}
+	// Interleaved as they arrive from the ASR and the bot
+	events := []transcript.Event{
+		{Type: transcript.ASRFinal, Text: " I want to check my balance ", Confidence: 0.9, Start: at(3250 * time.Millisecond), End: at(5 * time.Second)},
+		{Type: transcript.BotPrompt, Text: "Hi, how can I help you?", Start: at(0), End: at(2 * time.Second)},
+		{Type: transcript.ASRPartial, Text: "I want", Confidence: 0.5, Start: at(3 * time.Second)},
+		{Type: transcript.ASRPartial, Text: "I want to check", Confidence: 0.6, Start: at(3100 * time.Millisecond)},
+		{Type: transcript.DTMF, Text: "1234", Start: at(7100 * time.Millisecond)},
+		{Type: transcript.BotPrompt, Text: "Thanks", Start: at(9 * time.Second), End: at(10 * time.Second)},
+		// Never finalized, as the call dropped
+		{Type: transcript.ASRPartial, Text: "cancel", Confidence: 0.4, Start: at(12 * time.Second)},
+	}
+
+	assembled := transcript.Assemble(events)
+	if len(assembled.Turns) != 4 || !assembled.Start.Equal(start) {
+		t.Fatalf("Expected 4 turns from the start of the call, got %+v", assembled)
+	}
+	user := assembled.Turns[1]
+	if user.Speaker != transcript.User || user.Text != "I want to check my balance" || user.DTMF != "1234" || user.Confidence != 0.9 {
+		t.Errorf("Expected the partials to be superseded by the final and the DTMF to be merged, got %+v", user)
+	}
+	if user.Duration() != 3850*time.Millisecond {
+		t.Errorf("Expected the turn to last until the DTMF, got %s", user.Duration())
+	}
+	if last := assembled.Turns[3]; last.Text != "cancel" || len(last.Utterances) != 1 || !last.Utterances[0].Partial {
+		t.Errorf("Expected the partial without a final to be retained as partial, got %+v", last)
+	}
+
+	expected := "[00:00.000] BOT: Hi, how can I help you?\n" +
+		"[00:03.250] USER (0.90): I want to check my balance\n" +
+		"[00:03.250] USER [DTMF]: 1234\n" +
+		"[00:09.000] BOT: Thanks\n" +
+		"[00:12.000] USER (0.40): cancel\n"
+	if text := assembled.Text(); text != expected {
+		t.Errorf("Expected the transcript\n%s\ngot\n%s", expected, text)
+	}
+
+	data, err := assembled.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded transcript.Transcript
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Turns) != 4 || decoded.Turns[1].DTMF != "1234" {
+		t.Errorf("Expected the transcript to round trip through JSON, got %+v (%v)", decoded, err)
+	}
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	streadway "github.com/streadway/amqp"
+
+	"github.com/skit-ai/vcore/transport/amqp"
+)
+
+// Records the acknowledgements of the deliveries
+type acknowledger struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (a *acknowledger) record(call string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.calls = append(a.calls, call)
+	return nil
+}
+
+func (a *acknowledger) Ack(tag uint64, multiple bool) error {
+	return a.record(fmt.Sprintf("ack %d multiple=%t", tag, multiple))
+}
+
+func (a *acknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	return a.record(fmt.Sprintf("nack %d multiple=%t requeue=%t", tag, multiple, requeue))
+}
+
+func (a *acknowledger) Reject(tag uint64, requeue bool) error {
+	return a.record(fmt.Sprintf("reject %d requeue=%t", tag, requeue))
+}
+
+func (a *acknowledger) Calls() []string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return append([]string(nil), a.calls...)
+}
+
+// Returns a channel of the deliveries with the tags 1 to n
+func deliveries(acknowledger *acknowledger, n int) chan streadway.Delivery {
+	deliveries := make(chan streadway.Delivery, n)
+	for tag := 1; tag <= n; tag++ {
+		deliveries <- streadway.Delivery{Acknowledger: acknowledger, DeliveryTag: uint64(tag), Body: []byte(fmt.Sprint(tag))}
+	}
+	return deliveries
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBatchSize(t *testing.T) {
+	acks := &acknowledger{}
+	queue := deliveries(acks, 5)
+	close(queue)
+
+	var sizes []int
+	err := amqp.BatchDeliveries(context.Background(), queue, amqp.BatchOptions{Size: 2, Linger: time.Hour}, func(ctx context.Context, batch []streadway.Delivery) error {
+		sizes = append(sizes, len(batch))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Full batches are flushed as they fill up, the rest once the deliveries are closed. Each batch is acknowledged
+	// up to its last delivery at once.
+	if fmt.Sprint(sizes) != "[2 2 1]" {
+		t.Errorf("Expected batches of 2, 2 and 1 deliveries, got %v", sizes)
+	}
+	if calls := acks.Calls(); !equal(calls, []string{"ack 2 multiple=true", "ack 4 multiple=true", "ack 5 multiple=true"}) {
+		t.Errorf("Unexpected acknowledgements %v", calls)
+	}
+}
+
+func TestBatchLinger(t *testing.T) {
+	acks := &acknowledger{}
+	queue := deliveries(acks, 3)
+	defer close(queue)
+
+	batches := make(chan int, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go amqp.BatchDeliveries(ctx, queue, amqp.BatchOptions{Size: 10, Linger: 10 * time.Millisecond}, func(ctx context.Context, batch []streadway.Delivery) error {
+		batches <- len(batch)
+		return nil
+	})
+
+	// The batch is flushed once it lingers, short of its size
+	select {
+	case size := <-batches:
+		if size != 3 {
+			t.Errorf("Expected a batch of 3 deliveries, got %d", size)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the batch to be flushed after lingering")
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	for _, requeue := range []bool{false, true} {
+		acks := &acknowledger{}
+		queue := deliveries(acks, 3)
+		close(queue)
+
+		// Only the deliveries reported are failed
+		amqp.BatchDeliveries(context.Background(), queue, amqp.BatchOptions{Size: 3, Requeue: requeue}, func(ctx context.Context, batch []streadway.Delivery) error {
+			return &amqp.BatchError{Errors: map[int]error{1: stderrors.New("duplicate key")}}
+		})
+
+		expected := []string{"ack 1 multiple=false", fmt.Sprintf("nack 2 multiple=false requeue=%t", requeue), "ack 3 multiple=false"}
+		if calls := acks.Calls(); !equal(calls, expected) {
+			t.Errorf("Expected %v, got %v", expected, calls)
+		}
+	}
+}
+
+func TestBatchFailure(t *testing.T) {
+	for _, requeue := range []bool{false, true} {
+		acks := &acknowledger{}
+		queue := deliveries(acks, 3)
+		close(queue)
+
+		// Any other error fails the whole batch at once
+		amqp.BatchDeliveries(context.Background(), queue, amqp.BatchOptions{Size: 3, Requeue: requeue}, func(ctx context.Context, batch []streadway.Delivery) error {
+			return stderrors.New("connection refused")
+		})
+
+		expected := []string{fmt.Sprintf("nack 3 multiple=true requeue=%t", requeue)}
+		if calls := acks.Calls(); !equal(calls, expected) {
+			t.Errorf("Expected %v, got %v", expected, calls)
+		}
+	}
+}
+
+func TestBatchShutdown(t *testing.T) {
+	acks := &acknowledger{}
+	queue := deliveries(acks, 2)
+	defer close(queue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handled := make(chan error, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- amqp.BatchDeliveries(ctx, queue, amqp.BatchOptions{Size: 10, Linger: time.Hour}, func(ctx context.Context, batch []streadway.Delivery) error {
+			handled <- ctx.Err()
+			return nil
+		})
+	}()
+
+	// Letting the deliveries be batched before shutting down
+	for len(queue) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !stderrors.Is(err, context.Canceled) {
+			t.Errorf("Expected the consumption to be cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the consumption to stop once cancelled")
+	}
+
+	// The last batch is handled on a context which is not done, and acknowledged
+	if err := <-handled; err != nil {
+		t.Errorf("Expected the last batch to be handled on a live context, got %v", err)
+	}
+	if calls := acks.Calls(); !equal(calls, []string{"ack 2 multiple=true"}) {
+		t.Errorf("Unexpected acknowledgements %v", calls)
+	}
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"bytes"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	streadway "github.com/streadway/amqp"
+
+	"github.com/skit-ai/vcore/transport/amqp"
+	"github.com/skit-ai/vcore/transport/compression"
+)
+
+func TestDecompress(t *testing.T) {
+	body := []byte(strings.Repeat(`{"call_id":"c-1","text":"I want to pay my bill"}`, 20))
+	compressed, err := compression.Compress(compression.Zstd, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delivery := streadway.Delivery{ContentEncoding: string(compression.Zstd), Body: compressed}
+	if err := amqp.Decompress(&delivery); err != nil || !bytes.Equal(delivery.Body, body) || delivery.ContentEncoding != "" {
+		t.Errorf("Expected the body to be decompressed as per its content encoding, got %q(%v)", delivery.ContentEncoding, err)
+	}
+
+	// Bodies without a compression encoding are left as is, even if they look compressed
+	delivery = streadway.Delivery{ContentEncoding: "identity", Body: compressed}
+	if err := amqp.Decompress(&delivery); err != nil || !bytes.Equal(delivery.Body, compressed) {
+		t.Errorf("Expected the body not to be decompressed, got %v", err)
+	}
+
+	delivery = streadway.Delivery{ContentEncoding: string(compression.Gzip), Body: []byte{0x1f, 0x8b, 0x00}}
+	if err := amqp.Decompress(&delivery); err == nil {
+		t.Error("Expected a corrupt body to fail to decompress")
+	}
+}
+
+func TestDecompressTooLarge(t *testing.T) {
+	compressed, err := compression.Compress(compression.Gzip, make([]byte, compression.MaxDecompressedSize+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deliveries decompressing past the maximum size are left as is
+	delivery := streadway.Delivery{ContentEncoding: string(compression.Gzip), Body: compressed}
+	if err := amqp.Decompress(&delivery); !stderrors.Is(err, compression.ErrTooLarge) || !bytes.Equal(delivery.Body, compressed) {
+		t.Errorf("Expected the body to be left compressed, got %v", err)
+	}
+}
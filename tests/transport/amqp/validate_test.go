@@ -0,0 +1,49 @@
+package tests
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/transport/amqp"
+)
+
+type callEnded struct {
+	CallID   string  `json:"call_id"`
+	Duration float64 `json:"duration"`
+}
+
+func (e *callEnded) Validate() error {
+	if e.CallID == "" {
+		return stderrors.New("call_id is required")
+	}
+	return nil
+}
+
+func TestJSONValidator(t *testing.T) {
+	validate := amqp.JSONValidator[callEnded]()
+
+	if err := validate("call.ended", []byte(`{"call_id":"42","duration":12.5}`)); err != nil {
+		t.Errorf("Expected a valid event, got %s", err)
+	}
+	for _, body := range []string{`{"duration":12.5}`, `{"call_id":"42","durations":12.5}`, `{"call_id":42}`, `not json`} {
+		if err := validate("call.ended", []byte(body)); err == nil {
+			t.Errorf("Expected %s to be invalid", body)
+		}
+	}
+}
+
+func TestPublishRejectsInvalidEvents(t *testing.T) {
+	// The producer has no channel, invalid events are to be rejected before publishing them
+	producer := &amqp.Producer{}
+	producer.SetValidator(amqp.JSONValidator[callEnded]())
+
+	err := producer.Publish("calls", "topic", "call.ended", `{"duration":12.5}`, nil, false)
+	if err == nil || errors.CodeOf(err) != errors.InvalidArgument {
+		t.Fatalf("Expected an InvalidArgument error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "call_id is required") || errors.Extras(err)["routing_key"] != "call.ended" {
+		t.Errorf("Expected the details of the validation, got %s %v", err, errors.Extras(err))
+	}
+}
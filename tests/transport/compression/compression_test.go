@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"bytes"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/skit-ai/vcore/transport/compression"
+)
+
+// Returns the value of the counter of the algorithm
+func counter(t *testing.T, registry *prometheus.Registry, name string, algorithm compression.Algorithm) float64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "vcore_compression_"+name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == "algorithm" && pair.GetValue() == string(algorithm) {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestRoundTrip(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"speaker":"user","text":"I want to pay my bill"}`, 50))
+
+	for _, algorithm := range []compression.Algorithm{compression.Gzip, compression.Snappy, compression.Zstd} {
+		compressor := compression.New(algorithm, 64)
+		compressed, used, err := compressor.Compress(payload)
+		if err != nil {
+			t.Fatalf("Could not compress with %s: %s", algorithm, err)
+		}
+		if used != algorithm || compression.Detect(compressed) != algorithm {
+			t.Errorf("Expected the payload to be compressed with %s, got %s(detected %s)", algorithm, used, compression.Detect(compressed))
+		}
+		if ratio := compressor.Stats().Ratio(); ratio >= 1 {
+			t.Errorf("Expected %s to shrink the payload, got a ratio of %f", algorithm, ratio)
+		}
+
+		decompressed, err := compression.Decompress(compressed)
+		if err != nil || !bytes.Equal(decompressed, payload) {
+			t.Errorf("Could not decompress the payload compressed with %s: %v", algorithm, err)
+		}
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	compressor := compression.New(compression.Zstd, 64)
+	payload := []byte(`{"text":"hi"}`)
+
+	compressed, used, _ := compressor.Compress(payload)
+	if used != compression.None || !bytes.Equal(compressed, payload) {
+		t.Errorf("Expected a payload below the threshold to be left as is")
+	}
+	if decompressed, _ := compression.Decompress(payload); !bytes.Equal(decompressed, payload) {
+		t.Errorf("Expected an uncompressed payload to be returned as is")
+	}
+	if stats := compressor.Stats(); stats.Skipped != 1 || stats.Compressed != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(compression.Collectors()...)
+	in, out := counter(t, registry, "bytes_in_total", compression.Gzip), counter(t, registry, "bytes_out_total", compression.Gzip)
+	skipped := counter(t, registry, "payloads_total", compression.None)
+
+	compressor := compression.New(compression.Gzip, 64)
+	payload := []byte(strings.Repeat("I want to pay my bill. ", 100))
+	compressed, _, _ := compressor.Compress(payload)
+	_, _, _ = compressor.Compress([]byte("hi"))
+
+	if delta := counter(t, registry, "bytes_in_total", compression.Gzip) - in; delta != float64(len(payload)) {
+		t.Errorf("Expected the bytes before compression to be counted, got %f", delta)
+	}
+	if delta := counter(t, registry, "bytes_out_total", compression.Gzip) - out; delta != float64(len(compressed)) {
+		t.Errorf("Expected the bytes after compression to be counted, got %f", delta)
+	}
+	if delta := counter(t, registry, "payloads_total", compression.None) - skipped; delta != 1 {
+		t.Errorf("Expected the payload below the threshold to be counted, got %f", delta)
+	}
+}
+
+func TestDecompressTooLarge(t *testing.T) {
+	// Payloads of a few kilobytes decompressing past the maximum size
+	payload := make([]byte, compression.MaxDecompressedSize+1)
+	for _, algorithm := range []compression.Algorithm{compression.Gzip, compression.Snappy, compression.Zstd} {
+		compressed, err := compression.Compress(algorithm, payload)
+		if err != nil {
+			t.Fatalf("Could not compress with %s: %s", algorithm, err)
+		}
+		if _, err := compression.Decompress(compressed); !stderrors.Is(err, compression.ErrTooLarge) {
+			t.Errorf("Expected the payload compressed with %s to be too large, got %v", algorithm, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/skit-ai/vcore/transport/pgqueue"
+)
+
+// Leases the number of jobs as scheduled, returning the jobs leased by tenant
+func lease(scheduler *pgqueue.Scheduler, jobs int) map[string]int {
+	leased := make(map[string]int)
+	for i := 0; i < jobs; i++ {
+		tenant, ok := scheduler.Next()
+		if !ok {
+			break
+		}
+		scheduler.Served(tenant)
+		leased[tenant]++
+	}
+	return leased
+}
+
+func TestSchedulerWeights(t *testing.T) {
+	scheduler := pgqueue.NewScheduler(func(tenant string) float64 {
+		if tenant == "acme" {
+			return 2
+		}
+		return 1
+	})
+	if _, ok := scheduler.Next(); ok {
+		t.Fatal("Expected no tenant to be served without tenants")
+	}
+
+	scheduler.SetTenants([]string{"acme", "globex", "initech"})
+	leased := lease(scheduler, 400)
+	if leased["acme"] != 200 || leased["globex"] != 100 || leased["initech"] != 100 {
+		t.Errorf("Expected the jobs to be shared by the weights, got %v", leased)
+	}
+}
+
+func TestSchedulerJoiningAndIdleTenants(t *testing.T) {
+	scheduler := pgqueue.NewScheduler(nil)
+	scheduler.SetTenants([]string{"campaign"})
+	lease(scheduler, 1000)
+
+	// A tenant joining shares the workers from then on, without starving the tenant served so far
+	scheduler.SetTenants([]string{"campaign", "support"})
+	leased := lease(scheduler, 10)
+	if leased["campaign"] != 5 || leased["support"] != 5 {
+		t.Errorf("Expected the joining tenant to get its share, got %v", leased)
+	}
+
+	// Idle tenants are skipped until the tenants are configured again
+	scheduler.Idle("campaign")
+	if tenants := scheduler.Tenants(); len(tenants) != 1 || tenants[0] != "support" {
+		t.Errorf("Expected the idle tenant to be skipped, got %v", tenants)
+	}
+	if leased := lease(scheduler, 10); leased["support"] != 10 {
+		t.Errorf("Expected the remaining tenant to be served, got %v", leased)
+	}
+	scheduler.SetTenants([]string{"campaign", "support"})
+	// Within a job of its share, the other tenant having been served meanwhile
+	if leased := lease(scheduler, 10); leased["campaign"] > 6 || leased["support"] < 4 {
+		t.Errorf("Expected the tenant with jobs again to get its share rather than a burst, got %v", leased)
+	}
+}
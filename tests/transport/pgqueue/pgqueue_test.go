@@ -0,0 +1,297 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	stderrors "errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/transport/pgqueue"
+)
+
+type row struct {
+	id          int64
+	queue       string
+	payload     []byte
+	priority    int64
+	tenant      string
+	availableAt time.Time
+	attempts    int64
+	lease       string
+	leasedUntil time.Time
+}
+
+// In memory table of the jobs, interpreting the statements of the queue
+type table struct {
+	mutex  sync.Mutex
+	nextID int64
+	rows   map[int64]*row
+}
+
+func (t *table) Open(string) (driver.Conn, error) {
+	return &conn{table: t}, nil
+}
+
+type conn struct {
+	table *table
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{table: c.table, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, stderrors.New("transactions are not supported")
+}
+
+type stmt struct {
+	table *table
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func milliseconds(value driver.Value) time.Duration {
+	return time.Duration(value.(int64)) * time.Millisecond
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	t := s.table
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	r, ok := t.rows[args[0].(int64)]
+	if !ok || r.lease != args[1].(string) {
+		return driver.RowsAffected(0), nil
+	}
+	switch {
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(t.rows, r.id)
+	case strings.Contains(s.query, "lease = NULL"):
+		r.lease, r.leasedUntil = "", time.Time{}
+		r.availableAt = time.Now().Add(milliseconds(args[2]))
+	default:
+		r.leasedUntil = time.Now().Add(milliseconds(args[2]))
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	t := s.table
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	if strings.HasPrefix(s.query, "INSERT") {
+		t.nextID++
+		t.rows[t.nextID] = &row{
+			id:          t.nextID,
+			queue:       args[0].(string),
+			payload:     args[1].([]byte),
+			priority:    args[2].(int64),
+			tenant:      args[3].(string),
+			availableAt: now.Add(milliseconds(args[4])),
+		}
+		return &rows{columns: []string{"id"}, values: [][]driver.Value{{t.nextID}}}, nil
+	}
+
+	// Leasing the job of the highest priority available
+	var available []*row
+	for _, r := range t.rows {
+		if r.queue == args[0].(string) && !r.availableAt.After(now) && (r.lease == "" || r.leasedUntil.Before(now)) {
+			available = append(available, r)
+		}
+	}
+	sort.Slice(available, func(i, j int) bool {
+		if available[i].priority != available[j].priority {
+			return available[i].priority > available[j].priority
+		}
+		if !available[i].availableAt.Equal(available[j].availableAt) {
+			return available[i].availableAt.Before(available[j].availableAt)
+		}
+		return available[i].id < available[j].id
+	})
+	result := &rows{columns: []string{"id", "queue", "payload", "priority", "tenant", "attempts", "lease"}}
+	if len(available) > 0 {
+		r := available[0]
+		r.attempts++
+		r.lease = args[len(args)-2].(string)
+		r.leasedUntil = now.Add(milliseconds(args[len(args)-1]))
+		result.values = [][]driver.Value{{r.id, r.queue, r.payload, r.priority, r.tenant, r.attempts, r.lease}}
+	}
+	return result, nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+var jobs = &table{rows: make(map[int64]*row)}
+
+func init() {
+	sql.Register("pgqueuetest", jobs)
+}
+
+func open(t *testing.T) *pgqueue.Queue {
+	db, err := sql.Open("pgqueuetest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		jobs.mutex.Lock()
+		jobs.rows = make(map[int64]*row)
+		jobs.mutex.Unlock()
+	})
+	return pgqueue.New(db, "")
+}
+
+func TestDequeue(t *testing.T) {
+	ctx := context.Background()
+	queue := open(t)
+
+	for _, job := range []struct {
+		payload string
+		opts    pgqueue.EnqueueOptions
+	}{
+		{"low", pgqueue.EnqueueOptions{}},
+		{"delayed", pgqueue.EnqueueOptions{Priority: 10, Delay: time.Hour}},
+		{"high", pgqueue.EnqueueOptions{Priority: 5}},
+	} {
+		if _, err := queue.Enqueue(ctx, "calls", []byte(job.payload), job.opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The highest priority first, the delayed job not being available yet
+	for _, expected := range []string{"high", "low"} {
+		job, err := queue.Dequeue(ctx, "calls", time.Minute)
+		if err != nil || string(job.Payload) != expected || job.Attempts != 1 {
+			t.Fatalf("Expected the job %s to be leased, got %+v(%v)", expected, job, err)
+		}
+		if err := queue.Ack(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := queue.Dequeue(ctx, "calls", time.Minute); err != pgqueue.ErrEmpty {
+		t.Errorf("Expected no job to be available, got %v", err)
+	}
+}
+
+func TestVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	queue := open(t)
+	if _, err := queue.Enqueue(ctx, "calls", []byte("c-1"), pgqueue.EnqueueOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The worker leasing the job crashes, the job is leased again once its visibility timeout lapses
+	crashed, err := queue.Dequeue(ctx, "calls", 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queue.Dequeue(ctx, "calls", time.Minute); err != pgqueue.ErrEmpty {
+		t.Fatalf("Expected the leased job not to be available, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	job, err := queue.Dequeue(ctx, "calls", time.Minute)
+	if err != nil || job.ID != crashed.ID || job.Attempts != 2 {
+		t.Fatalf("Expected the job to be leased again, got %+v(%v)", job, err)
+	}
+
+	// The lapsed lease can no longer acknowledge the job
+	if err := queue.Ack(ctx, crashed); err == nil {
+		t.Error("Expected the lapsed lease not to acknowledge the job")
+	}
+	if err := queue.Extend(ctx, job, time.Minute); err != nil {
+		t.Error(err)
+	}
+	if err := queue.Ack(ctx, job); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConsume(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	queue := open(t)
+	for _, payload := range []string{"ok", "failing"} {
+		if _, err := queue.Enqueue(ctx, "calls", []byte(payload), pgqueue.EnqueueOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mutex sync.Mutex
+	attempts := make(map[string]int)
+	opts := pgqueue.ConsumeOptions{
+		PollInterval: time.Millisecond,
+		MaxAttempts:  3,
+		Backoff:      func(int) time.Duration { return 0 },
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		queue.Consume(ctx, "calls", opts, func(_ context.Context, job *pgqueue.Job) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			attempts[string(job.Payload)]++
+			if string(job.Payload) == "failing" {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		})
+	}()
+	for ctx.Err() == nil {
+		jobs.mutex.Lock()
+		remaining := len(jobs.rows)
+		jobs.mutex.Unlock()
+		if remaining == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// The failing job is retried until it exhausts its attempts, and then dropped
+	jobs.mutex.Lock()
+	remaining := len(jobs.rows)
+	jobs.mutex.Unlock()
+	if attempts["ok"] != 1 || attempts["failing"] != 3 || remaining != 0 {
+		t.Errorf("Expected the failing job to be dropped after 3 attempts, got %v and %d jobs remaining", attempts, remaining)
+	}
+}
@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skit-ai/vcore/transport/pgqueue"
+)
+
+func TestQuarantineHandlerValidation(t *testing.T) {
+	// Invalid requests are rejected before the database is queried
+	handler := pgqueue.New(nil, "").QuarantineHandler()
+
+	for _, request := range []*http.Request{
+		httptest.NewRequest("GET", "/calls?limit=0", nil),
+		httptest.NewRequest("GET", "/calls?limit=many", nil),
+		httptest.NewRequest("POST", "/calls/abc/redrive", nil),
+		httptest.NewRequest("DELETE", "/calls/abc", nil),
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s %s to be a bad request, got %d", request.Method, request.URL, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("PUT", "/calls/42", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected an unsupported method to be rejected, got %d", w.Code)
+	}
+}
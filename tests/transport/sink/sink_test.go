@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	stderrors "errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Vernacular-ai/gorm"
+	streadway "github.com/streadway/amqp"
+
+	"github.com/skit-ai/vcore/transport/amqp"
+	"github.com/skit-ai/vcore/transport/sink"
+)
+
+// In memory database of the messages processed and of the calls projected, committed by transaction
+type database struct {
+	mutex     sync.Mutex
+	processed map[string]bool
+	calls     []string
+}
+
+func (d *database) Open(string) (driver.Conn, error) {
+	return &conn{db: d}, nil
+}
+
+type conn struct {
+	db *database
+	tx *tx
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	c.tx = &tx{conn: c}
+	return c.tx, nil
+}
+
+type tx struct {
+	conn      *conn
+	processed []string
+	calls     []string
+}
+
+func (t *tx) Commit() error {
+	t.conn.db.mutex.Lock()
+	defer t.conn.db.mutex.Unlock()
+	for _, id := range t.processed {
+		t.conn.db.processed[id] = true
+	}
+	t.conn.db.calls = append(t.conn.db.calls, t.calls...)
+	t.conn.tx = nil
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.conn.tx = nil
+	return nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	switch {
+	case strings.Contains(s.query, "processed_messages"):
+		s.conn.tx.processed = append(s.conn.tx.processed, args[1].(string))
+	case strings.Contains(s.query, "calls"):
+		s.conn.tx.calls = append(s.conn.tx.calls, args[0].(string))
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.conn.db.mutex.Lock()
+	defer s.conn.db.mutex.Unlock()
+	r := &rows{}
+	for _, arg := range args[1:] {
+		if id := arg.(string); s.conn.db.processed[id] {
+			r.ids = append(r.ids, id)
+		}
+	}
+	return r, nil
+}
+
+type rows struct {
+	ids []string
+}
+
+func (r *rows) Columns() []string {
+	return []string{"message_id"}
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.ids) == 0 {
+		return io.EOF
+	}
+	dest[0], r.ids = r.ids[0], r.ids[1:]
+	return nil
+}
+
+var db = &database{processed: make(map[string]bool)}
+
+func init() {
+	sql.Register("sinktest", db)
+}
+
+func TestHandleBatchPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	gormDB, err := gorm.Open("sinktest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.processed["m0"] = true
+
+	// Fails the call c2 once, by its index in the deliveries handled
+	failing := true
+	s := sink.New(gormDB, func(_ context.Context, tx *gorm.DB, deliveries []streadway.Delivery) error {
+		for index, delivery := range deliveries {
+			if string(delivery.Body) == "c2" && failing {
+				return &amqp.BatchError{Errors: map[int]error{index: stderrors.New("invalid call")}}
+			}
+		}
+		for _, delivery := range deliveries {
+			if err := tx.Exec("INSERT INTO calls (id) VALUES (?)", string(delivery.Body)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	deliveries := []streadway.Delivery{
+		{MessageId: "m0", Body: []byte("c0")},
+		{MessageId: "m1", Body: []byte("c1")},
+		{MessageId: "m2", Body: []byte("c2")},
+		{MessageId: "m3", Body: []byte("c3")},
+	}
+	err = s.HandleBatch(ctx, deliveries)
+	var batchErr *amqp.BatchError
+	if !stderrors.As(err, &batchErr) || len(batchErr.Errors) != 1 || batchErr.Errors[2] == nil {
+		t.Fatalf("Expected the failed delivery to be reported by its index in the batch, got %v", err)
+	}
+	// The deliveries acknowledged(all but the failed one) are those committed
+	sort.Strings(db.calls)
+	if strings.Join(db.calls, ",") != "c1,c3" || !db.processed["m1"] || db.processed["m2"] || !db.processed["m3"] {
+		t.Fatalf("Expected the other deliveries to be committed, got %v %v", db.calls, db.processed)
+	}
+
+	// The failed delivery is handled once redelivered, the others being skipped
+	failing = false
+	if err := s.HandleBatch(ctx, deliveries); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(db.calls)
+	if strings.Join(db.calls, ",") != "c1,c2,c3" || !db.processed["m2"] {
+		t.Errorf("Expected the redelivery to be committed once, got %v %v", db.calls, db.processed)
+	}
+}
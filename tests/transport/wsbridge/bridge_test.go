@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/skit-ai/vcore/transport/wsbridge"
+)
+
+// Stream whose upstream messages are pushed by the test and which records the messages sent to it
+type fakeStream struct {
+	upstream chan []byte
+	mutex    sync.Mutex
+	sent     [][]byte
+	once     sync.Once
+}
+
+func newFakeStream() *fakeStream {
+	return &fakeStream{upstream: make(chan []byte, 16)}
+}
+
+func (s *fakeStream) Send(payload []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sent = append(s.sent, append([]byte(nil), payload...))
+	return nil
+}
+
+func (s *fakeStream) Recv() ([]byte, error) {
+	payload, ok := <-s.upstream
+	if !ok {
+		return nil, io.EOF
+	}
+	return payload, nil
+}
+
+func (s *fakeStream) CloseSend() error {
+	return nil
+}
+
+func (s *fakeStream) end() {
+	s.once.Do(func() { close(s.upstream) })
+}
+
+func (s *fakeStream) messages() [][]byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sent
+}
+
+func frame(final bool, seq uint64, payload string) []byte {
+	data := make([]byte, 9+len(payload))
+	if final {
+		data[0] = 1
+	}
+	binary.BigEndian.PutUint64(data[1:9], seq)
+	copy(data[9:], payload)
+	return data
+}
+
+func dial(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Could not dial the bridge: %s", err)
+	}
+	return conn
+}
+
+func readSession(t *testing.T, conn *websocket.Conn) (token string, seq uint64) {
+	t.Helper()
+	messageType, data, err := conn.ReadMessage()
+	if err != nil || messageType != websocket.TextMessage {
+		t.Fatalf("Expected the session control message, got %d %s: %v", messageType, data, err)
+	}
+	var c struct {
+		Type  string `json:"type"`
+		Token string `json:"token"`
+		Seq   uint64 `json:"seq"`
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.Type != "session" {
+		t.Fatalf("Invalid session control message %s", data)
+	}
+	return c.Token, c.Seq
+}
+
+// Reads the frames of a message and returns its sequence number and payload
+func readMessage(t *testing.T, conn *websocket.Conn) (uint64, string) {
+	t.Helper()
+	var payload []byte
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Could not read a frame: %s", err)
+		}
+		payload = append(payload, data[9:]...)
+		if data[0]&1 != 0 {
+			return binary.BigEndian.Uint64(data[1:9]), string(payload)
+		}
+	}
+}
+
+func TestBridgeFraming(t *testing.T) {
+	stream := newFakeStream()
+	bridge := wsbridge.New(func(ctx context.Context, r *http.Request) (wsbridge.Stream, error) {
+		return stream, nil
+	}, wsbridge.WithMaxFrameSize(4))
+	server := httptest.NewServer(bridge)
+	defer server.Close()
+
+	conn := dial(t, server, "")
+	defer conn.Close()
+	readSession(t, conn)
+
+	// A message split by the client is reassembled before being sent upstream
+	_ = conn.WriteMessage(websocket.BinaryMessage, frame(false, 1, "hello "))
+	_ = conn.WriteMessage(websocket.BinaryMessage, frame(true, 1, "world"))
+
+	// A message larger than the frame size is split by the bridge
+	stream.upstream <- []byte("transcript")
+	if seq, payload := readMessage(t, conn); seq != 1 || payload != "transcript" {
+		t.Errorf("Expected message 1 `transcript`, got %d `%s`", seq, payload)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(stream.messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sent := stream.messages(); len(sent) != 1 || string(sent[0]) != "hello world" {
+		t.Errorf("Expected `hello world` to be sent upstream, got %q", sent)
+	}
+
+	// The end of the upstream stream closes the connection normally
+	stream.end()
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("Expected a normal closure, got %v", err)
+	}
+
+	if err := bridge.Shutdown(context.Background()); err != nil {
+		t.Errorf("Could not shut down the bridge: %s", err)
+	}
+}
+
+func TestBridgeResume(t *testing.T) {
+	stream := newFakeStream()
+	bridge := wsbridge.New(func(ctx context.Context, r *http.Request) (wsbridge.Stream, error) {
+		return stream, nil
+	})
+	server := httptest.NewServer(bridge)
+	defer server.Close()
+
+	conn := dial(t, server, "")
+	token, _ := readSession(t, conn)
+
+	stream.upstream <- []byte("first")
+	if seq, payload := readMessage(t, conn); seq != 1 || payload != "first" {
+		t.Fatalf("Expected message 1 `first`, got %d `%s`", seq, payload)
+	}
+
+	// Dropping the connection without a close frame leaves the session resumable
+	conn.Close()
+	stream.upstream <- []byte("second")
+
+	conn = dial(t, server, "?resume="+token+"&last=1")
+	if _, seq := readSession(t, conn); seq != 1 {
+		t.Errorf("Expected the session to resume after message 1, got %d", seq)
+	}
+	if seq, payload := readMessage(t, conn); seq != 2 || payload != "second" {
+		t.Errorf("Expected message 2 `second`, got %d `%s`", seq, payload)
+	}
+	conn.Close()
+
+	// Messages already written are replayed to a client which missed them
+	conn = dial(t, server, "?resume="+token+"&last=0")
+	readSession(t, conn)
+	if seq, payload := readMessage(t, conn); seq != 1 || payload != "first" {
+		t.Errorf("Expected message 1 `first` to be replayed, got %d `%s`", seq, payload)
+	}
+	conn.Close()
+
+	// Unknown sessions cannot be resumed
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?resume=unknown&last=0"
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp.StatusCode != http.StatusGone {
+		t.Errorf("Expected an unknown session to be gone, got %v", err)
+	}
+
+	stream.end()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := bridge.Shutdown(ctx); err != nil {
+		t.Errorf("Could not shut down the bridge: %s", err)
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skit-ai/vcore/simulation"
+	"github.com/skit-ai/vcore/watchdog"
+)
+
+func consume(heartbeat *watchdog.Heartbeat, beats <-chan bool, stuck chan struct{}) {
+	for beat := range beats {
+		heartbeat.Beat()
+		if !beat {
+			// Blocked as on a deadlock
+			<-stuck
+		}
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	restarts := 0
+	w := watchdog.New()
+	heartbeat := w.Register("consumer", 50*time.Millisecond, watchdog.WithRestart(func(context.Context) error {
+		restarts++
+		return nil
+	}))
+
+	beats, stuck := make(chan bool), make(chan struct{})
+	defer close(stuck)
+	go consume(heartbeat, beats, stuck)
+
+	beats <- true
+	if stalls := w.Check(context.Background(), time.Now()); len(stalls) != 0 {
+		t.Fatalf("Expected no stalls right after a heartbeat, got %v", stalls)
+	}
+
+	beats <- false
+	for heartbeat.Stats().Beats < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	stalls := w.Check(context.Background(), time.Now().Add(time.Second))
+	if len(stalls) != 1 || stalls[0].Name != "consumer" {
+		t.Fatalf("Expected the consumer to stall, got %v", stalls)
+	}
+	if !strings.Contains(stalls[0].Stack, "watchdog.consume") {
+		t.Errorf("Expected the stack of the stuck goroutine, got %q", stalls[0].Stack)
+	}
+	if stats := heartbeat.Stats(); restarts != 1 || stats.Stalls != 1 || stats.Restarts != 1 || stats.Beats != 2 {
+		t.Errorf("Expected the stalled loop to be restarted once, got %d restarts and %+v", restarts, stats)
+	}
+}
+
+func TestStartOnClock(t *testing.T) {
+	sim := simulation.New(42, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	stalled := make(chan watchdog.Stall, 1)
+	w := watchdog.New(watchdog.WithClock(sim), watchdog.WithStallHandler(func(stall watchdog.Stall) {
+		stalled <- stall
+	}))
+	w.Register("consumer", time.Minute).Beat()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	// The heartbeats are checked as the simulated time advances, without waiting for the real interval
+	for i := 0; i < 500; i++ {
+		sim.Advance(time.Second)
+		select {
+		case stall := <-stalled:
+			if stall.Name != "consumer" || stall.Since <= time.Minute {
+				t.Errorf("Expected the consumer to stall after its timeout, got %+v", stall)
+			}
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+	t.Fatal("Expected the consumer to stall on the simulated clock")
+}
@@ -0,0 +1,221 @@
+// Package topk tracks the heavy hitters of a service(eg. the noisiest tenants, intents or error codes) over a rolling
+// window, to speed up the triage of incidents. Eg.
+//
+//	tenants := topk.New("tenant_errors", 10, 5*time.Minute)
+//	go tenants.Run(ctx)
+//	mux.Handle("/admin/topk/tenants", tenants.Handler())
+//	...
+//	tenants.Add(tenant)
+//
+// The items are counted with the Space-Saving algorithm(see sketch.TopK), in a fixed amount of memory however many
+// distinct items there are. The top items are exposed as JSON by the handler, and as the gauge vcore_topk_count(see
+// Collectors) set by Run.
+package topk
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/httpserver"
+	"github.com/skit-ai/vcore/simulation"
+	"github.com/skit-ai/vcore/sketch"
+)
+
+var counts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "vcore",
+	Subsystem: "topk",
+	Name:      "count",
+	Help:      "Counts of the top items of the trackers within their windows, by tracker and item",
+}, []string{"tracker", "key"})
+
+// Collectors returns the metrics of the trackers, to be registered by the service
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{counts}
+}
+
+// Slot of the window, counting the items of a part of it
+type slot struct {
+	start time.Time
+	items *sketch.TopK
+}
+
+// Tracker of the top items of a rolling window
+type Tracker struct {
+	name     string
+	k        int
+	window   time.Duration
+	slots    int
+	capacity int
+	interval time.Duration
+	clock    simulation.Clock
+
+	mutex sync.Mutex
+	// Slots of the window, the latest last
+	ring []slot
+	// Items of the gauge, to be removed once they drop out of the top
+	reported []string
+}
+
+// Option configures a Tracker
+type Option func(*Tracker)
+
+// WithSlots configures the number of parts of the window counted separately, defaults to 6. The window rolls by a
+// part at a time, so more parts roll it more smoothly at the cost of memory.
+func WithSlots(slots int) Option {
+	return func(t *Tracker) {
+		t.slots = slots
+	}
+}
+
+// WithCapacity configures the number of items counted, defaults to 10 times k. More items count the top items more
+// accurately when there are many distinct items.
+func WithCapacity(capacity int) Option {
+	return func(t *Tracker) {
+		t.capacity = capacity
+	}
+}
+
+// WithInterval configures how often Run sets the gauge of the top items, defaults to a part of the window(see
+// WithSlots)
+func WithInterval(interval time.Duration) Option {
+	return func(t *Tracker) {
+		t.interval = interval
+	}
+}
+
+// WithClock configures the clock of the window, eg. a simulation.Simulation in tests
+func WithClock(clock simulation.Clock) Option {
+	return func(t *Tracker) {
+		t.clock = clock
+	}
+}
+
+// New returns a tracker of the k items added the most within the rolling window. The name labels the gauge of the
+// tracker.
+func New(name string, k int, window time.Duration, opts ...Option) *Tracker {
+	t := &Tracker{name: name, k: max(k, 1), window: window, slots: 6, clock: simulation.Real}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.slots = max(t.slots, 1)
+	if t.capacity < t.k {
+		t.capacity = 10 * t.k
+	}
+	if t.interval <= 0 {
+		t.interval = t.window / time.Duration(t.slots)
+	}
+	return t
+}
+
+// Returns the duration of a slot
+func (t *Tracker) slotSize() time.Duration {
+	return max(t.window/time.Duration(t.slots), 1)
+}
+
+// Drops the slots out of the window, and returns the slot of the time. Must hold the mutex.
+func (t *Tracker) roll(now time.Time) *slot {
+	start := now.Truncate(t.slotSize())
+	expired := 0
+	for expired < len(t.ring) && !t.ring[expired].start.After(start.Add(-t.window)) {
+		expired++
+	}
+	t.ring = t.ring[expired:]
+
+	if len(t.ring) == 0 || t.ring[len(t.ring)-1].start.Before(start) {
+		t.ring = append(t.ring, slot{start: start, items: sketch.NewTopK(t.capacity)})
+	}
+	return &t.ring[len(t.ring)-1]
+}
+
+// Add counts the item once
+func (t *Tracker) Add(key string) {
+	t.AddN(key, 1)
+}
+
+// AddN counts the item the number of times, eg. the bytes of a tenant
+func (t *Tracker) AddN(key string, count uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.roll(t.clock.Now()).items.Add(key, count)
+}
+
+// Top returns the top items within the window, by their counts
+func (t *Tracker) Top() []sketch.Item {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.roll(t.clock.Now())
+	merged := sketch.NewTopK(t.capacity)
+	for _, s := range t.ring {
+		_ = merged.Merge(s.items)
+	}
+	return merged.Top(t.k)
+}
+
+// Report sets the gauge of the top items, removing the items dropped out of the top
+func (t *Tracker) Report() {
+	top := t.Top()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, key := range t.reported {
+		counts.DeleteLabelValues(t.name, key)
+	}
+	t.reported = t.reported[:0]
+	for _, item := range top {
+		counts.WithLabelValues(t.name, item.Key).Set(float64(item.Count))
+		t.reported = append(t.reported, item.Key)
+	}
+}
+
+// Run reports the top items every interval(see WithInterval), until the context is done. The gauge of the tracker is
+// removed once done.
+func (t *Tracker) Run(ctx context.Context) {
+	defer func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		for _, key := range t.reported {
+			counts.DeleteLabelValues(t.name, key)
+		}
+		t.reported = nil
+	}()
+
+	for {
+		t.Report()
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.clock.After(t.interval):
+		}
+	}
+}
+
+// Top items of a tracker, as served by its handler
+type report struct {
+	Tracker string        `json:"tracker"`
+	Window  string        `json:"window"`
+	Items   []sketch.Item `json:"items"`
+}
+
+// Handler serves the top items within the window as JSON, eg. on an admin endpoint. The number of items can be
+// limited with the query parameter "limit".
+func (t *Tracker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		items := t.Top()
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit <= 0 {
+				httpserver.Error(w, r, errors.WithCode(errors.NewError("limit is to be a positive number", err, false), errors.InvalidArgument))
+				return
+			}
+			items = items[:min(limit, len(items))]
+		}
+		_ = httpserver.JSON(w, r, http.StatusOK, report{Tracker: t.name, Window: t.window.String(), Items: items})
+	})
+}
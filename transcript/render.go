@@ -0,0 +1,49 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JSON renders the transcript as JSON
+func (t *Transcript) JSON() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// Text renders the transcript as plain text with one line per turn. Eg.
+//
+//	[00:00.000] BOT: Hi, how can I help you?
+//	[00:03.250] USER (0.91): I want to check my balance
+//	[00:07.100] USER [DTMF]: 1234
+func (t *Transcript) Text() string {
+	var builder strings.Builder
+	for _, turn := range t.Turns {
+		offset := formatOffset(turn.Start.Sub(t.Start))
+		speaker := strings.ToUpper(string(turn.Speaker))
+
+		if turn.Text != "" {
+			if turn.Speaker == User {
+				builder.WriteString(fmt.Sprintf("[%s] %s (%.2f): %s\n", offset, speaker, turn.Confidence, turn.Text))
+			} else {
+				builder.WriteString(fmt.Sprintf("[%s] %s: %s\n", offset, speaker, turn.Text))
+			}
+		}
+		if turn.DTMF != "" {
+			builder.WriteString(fmt.Sprintf("[%s] %s [DTMF]: %s\n", offset, speaker, turn.DTMF))
+		}
+	}
+	return builder.String()
+}
+
+// Formats an offset from the start of the transcript as mm:ss.mmm
+func formatOffset(offset time.Duration) string {
+	if offset < 0 {
+		offset = 0
+	}
+	minutes := offset / time.Minute
+	seconds := (offset % time.Minute) / time.Second
+	millis := (offset % time.Second) / time.Millisecond
+	return fmt.Sprintf("%02d:%02d.%03d", minutes, seconds, millis)
+}
@@ -0,0 +1,192 @@
+// Package transcript assembles the events of a conversation(ASR partials/finals, bot prompts and DTMF inputs)
+// into a canonical turn-by-turn transcript
+package transcript
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+type Speaker string
+type EventType string
+
+const (
+	User Speaker = "user"
+	Bot  Speaker = "bot"
+)
+
+const (
+	ASRPartial EventType = "asr_partial"
+	ASRFinal   EventType = "asr_final"
+	BotPrompt  EventType = "bot_prompt"
+	DTMF       EventType = "dtmf"
+)
+
+// Event is a single event observed during a conversation
+type Event struct {
+	Type       EventType `json:"type"`
+	Text       string    `json:"text"`
+	Confidence float64   `json:"confidence"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+}
+
+// Speaker returns the party which produced the event
+func (e Event) Speaker() Speaker {
+	if e.Type == BotPrompt {
+		return Bot
+	}
+	return User
+}
+
+// Utterance is an event which made it to the transcript.
+// Partial is set when the speaker's segment never received an ASR final.
+type Utterance struct {
+	Type       EventType `json:"type"`
+	Text       string    `json:"text"`
+	Confidence float64   `json:"confidence"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Partial    bool      `json:"partial,omitempty"`
+}
+
+// Turn is a contiguous set of utterances by the same speaker
+type Turn struct {
+	Index      int         `json:"index"`
+	Speaker    Speaker     `json:"speaker"`
+	Text       string      `json:"text"`
+	DTMF       string      `json:"dtmf,omitempty"`
+	Confidence float64     `json:"confidence"`
+	Start      time.Time   `json:"start"`
+	End        time.Time   `json:"end"`
+	Utterances []Utterance `json:"utterances"`
+}
+
+// Duration of the turn
+func (t Turn) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// Transcript is the turn-by-turn representation of a conversation
+type Transcript struct {
+	Start time.Time `json:"start"`
+	Turns []Turn    `json:"turns"`
+}
+
+// Assemble merges interleaved events into a transcript.
+// Events are ordered by their start time. ASR partials are superseded by any later partial or final of the
+// same user segment, and are retained(marked as partial) only when the segment ends without an ASR final.
+// Consecutive utterances of the same speaker are merged into a single turn.
+func Assemble(events []Event) *Transcript {
+	sorted := make([]Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	transcript := &Transcript{}
+	if len(sorted) > 0 {
+		transcript.Start = sorted[0].Start
+	}
+
+	var utterances []Utterance
+	var pending *Event
+
+	// Flushes a partial which was never followed by a final
+	flushPending := func() {
+		if pending != nil {
+			utterances = append(utterances, toUtterance(*pending, true))
+			pending = nil
+		}
+	}
+
+	for i := range sorted {
+		event := sorted[i]
+		switch event.Type {
+		case ASRPartial:
+			pending = &event
+		case ASRFinal:
+			pending = nil
+			utterances = append(utterances, toUtterance(event, false))
+		default:
+			flushPending()
+			utterances = append(utterances, toUtterance(event, false))
+		}
+	}
+	flushPending()
+
+	for _, utterance := range utterances {
+		speaker := Event{Type: utterance.Type}.Speaker()
+		if n := len(transcript.Turns); n == 0 || transcript.Turns[n-1].Speaker != speaker {
+			transcript.Turns = append(transcript.Turns, Turn{
+				Index:   n,
+				Speaker: speaker,
+				Start:   utterance.Start,
+			})
+		}
+		transcript.Turns[len(transcript.Turns)-1].add(utterance)
+	}
+
+	for i := range transcript.Turns {
+		transcript.Turns[i].finalize()
+	}
+
+	return transcript
+}
+
+func toUtterance(event Event, partial bool) Utterance {
+	end := event.End
+	if end.IsZero() {
+		end = event.Start
+	}
+
+	confidence := event.Confidence
+	if event.Type == BotPrompt || event.Type == DTMF {
+		confidence = 1
+	}
+
+	return Utterance{
+		Type:       event.Type,
+		Text:       strings.TrimSpace(event.Text),
+		Confidence: confidence,
+		Start:      event.Start,
+		End:        end,
+		Partial:    partial,
+	}
+}
+
+func (t *Turn) add(utterance Utterance) {
+	t.Utterances = append(t.Utterances, utterance)
+	if utterance.End.After(t.End) {
+		t.End = utterance.End
+	}
+}
+
+// Builds the text of the turn and computes its confidence as the mean confidence of the spoken utterances
+func (t *Turn) finalize() {
+	var texts []string
+	var dtmf strings.Builder
+	var confidence float64
+	var spoken int
+
+	for _, utterance := range t.Utterances {
+		if utterance.Type == DTMF {
+			dtmf.WriteString(utterance.Text)
+			continue
+		}
+		if utterance.Text != "" {
+			texts = append(texts, utterance.Text)
+		}
+		confidence += utterance.Confidence
+		spoken++
+	}
+
+	t.Text = strings.Join(texts, " ")
+	t.DTMF = dtmf.String()
+	if spoken > 0 {
+		t.Confidence = confidence / float64(spoken)
+	} else {
+		t.Confidence = 1
+	}
+}
@@ -0,0 +1,165 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// BatchHandler handles a batch of deliveries(eg. with a bulk insert). Returning a *BatchError fails only the
+// deliveries it reports, any other error fails the whole batch.
+type BatchHandler func(ctx context.Context, deliveries []amqp.Delivery) error
+
+// BatchError reports the deliveries of a batch which failed, by their index in the batch
+type BatchError struct {
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	indices := make([]int, 0, len(e.Errors))
+	for index := range e.Errors {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	messages := make([]string, 0, len(indices))
+	for _, index := range indices {
+		messages = append(messages, fmt.Sprintf("%d: %s", index, e.Errors[index]))
+	}
+	return fmt.Sprintf("%d deliveries of the batch failed (%s)", len(e.Errors), strings.Join(messages, "; "))
+}
+
+type BatchOptions struct {
+	// Maximum number of deliveries in a batch. Defaults to 100.
+	Size int
+	// Maximum time to wait for a batch to fill up after its first delivery. Defaults to 1s.
+	Linger time.Duration
+	// Requeue the failed deliveries instead of dead-lettering(or dropping) them
+	Requeue bool
+	// Maximum time to handle the last batch once the context is done. Defaults to 5s.
+	ShutdownTimeout time.Duration
+}
+
+// ConsumeBatch consumes the queue in batches, acknowledging the deliveries handled and rejecting the ones which
+// failed. Blocks until the context is done or the deliveries channel is closed.
+func (consumer *Consumer) ConsumeBatch(ctx context.Context, queueName string, opts BatchOptions, handler BatchHandler) error {
+	if opts.Size <= 0 {
+		opts.Size = 100
+	}
+
+	// Let the broker send a whole batch ahead of acknowledgements
+	if err := consumer.channel.Qos(opts.Size, 0, false); err != nil {
+		return fmt.Errorf("Qos: %s", err)
+	}
+
+	log.Printf("starting batch Consume on queue %q (consumer tag %q, batch size %d)", queueName, consumer.tag, opts.Size)
+	deliveries, err := consumer.channel.Consume(
+		queueName,    // name
+		consumer.tag, // consumerTag,
+		false,        // auto-ack
+		false,        // exclusive
+		false,        // no-local
+		false,        // no-wait
+		nil,          // args
+	)
+	if err != nil {
+		return fmt.Errorf("Queue Consume: %s", err)
+	}
+
+	return BatchDeliveries(ctx, deliveries, opts, handler)
+}
+
+// BatchDeliveries handles the deliveries in batches as ConsumeBatch does, for the deliveries consumed otherwise(eg.
+// with arguments of their own). The deliveries are to be consumed without auto-ack.
+func BatchDeliveries(ctx context.Context, deliveries <-chan amqp.Delivery, opts BatchOptions, handler BatchHandler) error {
+	if opts.Size <= 0 {
+		opts.Size = 100
+	}
+	if opts.Linger <= 0 {
+		opts.Linger = time.Second
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 5 * time.Second
+	}
+
+	batch := make([]amqp.Delivery, 0, opts.Size)
+	timer := time.NewTimer(opts.Linger)
+	timer.Stop()
+
+	flush := func(ctx context.Context) {
+		if len(batch) > 0 {
+			handleBatch(ctx, batch, opts.Requeue, handler)
+			batch = make([]amqp.Delivery, 0, opts.Size)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The last batch is handled on a context of its own, the one consumed on being done already
+			shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), opts.ShutdownTimeout)
+			flush(shutdownCtx)
+			cancel()
+			return ctx.Err()
+		case delivery, ok := <-deliveries:
+			if !ok {
+				flush(ctx)
+				return nil
+			}
+			if err := Decompress(&delivery); err != nil {
+				log.Printf("Rejecting delivery (message ID %q) whose body could not be decompressed: %s", delivery.MessageId, err)
+				if rejectErr := delivery.Reject(false); rejectErr != nil {
+					log.Printf("Reject: %s", rejectErr)
+				}
+				continue
+			}
+			if len(batch) == 0 {
+				timer.Reset(opts.Linger)
+			}
+			batch = append(batch, delivery)
+			if len(batch) >= opts.Size {
+				timer.Stop()
+				flush(ctx)
+			}
+		case <-timer.C:
+			flush(ctx)
+		}
+	}
+}
+
+func handleBatch(ctx context.Context, batch []amqp.Delivery, requeue bool, handler BatchHandler) {
+	err := handler(ctx, batch)
+	if err == nil {
+		// Acknowledges every delivery up to the last one of the batch
+		if ackErr := batch[len(batch)-1].Ack(true); ackErr != nil {
+			log.Printf("Ack: %s", ackErr)
+		}
+		return
+	}
+
+	batchErr, ok := err.(*BatchError)
+	if !ok {
+		log.Printf("Batch of %d deliveries failed: %s", len(batch), err)
+		if nackErr := batch[len(batch)-1].Nack(true, requeue); nackErr != nil {
+			log.Printf("Nack: %s", nackErr)
+		}
+		return
+	}
+
+	for index, delivery := range batch {
+		if deliveryErr, failed := batchErr.Errors[index]; failed {
+			log.Printf("Delivery %d (message ID %q) of the batch failed: %s", index, delivery.MessageId, deliveryErr)
+			err = delivery.Nack(false, requeue)
+		} else {
+			err = delivery.Ack(false)
+		}
+		if err != nil {
+			log.Printf("Ack/Nack: %s", err)
+		}
+	}
+}
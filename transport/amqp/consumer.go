@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/skit-ai/vcore/transport/compression"
 	"github.com/streadway/amqp"
 )
 
@@ -120,5 +121,37 @@ func (consumer *Consumer) Consume(queue_name string) (<-chan amqp.Delivery, erro
 		return deliveries, fmt.Errorf("Queue Consume: %s", err)
 	}
 
-	return deliveries, err
+	// The bodies compressed by the producer are decompressed before they are handed over. The deliveries being
+	// acknowledged already, the ones which could not be decompressed are handed over as is(with their content
+	// encoding) rather than lost.
+	decompressed := make(chan amqp.Delivery)
+	go func() {
+		defer close(decompressed)
+		for delivery := range deliveries {
+			if err := Decompress(&delivery); err != nil {
+				log.Printf("Handing over delivery (message ID %q) whose body could not be decompressed as is: %s", delivery.MessageId, err)
+			}
+			decompressed <- delivery
+		}
+	}()
+
+	return decompressed, nil
+}
+
+// Decompress decompresses the body of a delivery compressed by the producer(see Producer.SetCompression), as per
+// its content encoding. Consume and ConsumeBatch decompress the deliveries they hand over, call it for the deliveries
+// consumed otherwise. Deliveries which fail to decompress(eg. with compression.ErrTooLarge) are left as is.
+func Decompress(delivery *amqp.Delivery) error {
+	switch compression.Algorithm(delivery.ContentEncoding) {
+	case compression.Gzip, compression.Snappy, compression.Zstd:
+	default:
+		return nil
+	}
+
+	body, err := compression.Decompress(delivery.Body)
+	if err != nil {
+		return err
+	}
+	delivery.Body, delivery.ContentEncoding = body, ""
+	return nil
 }
@@ -0,0 +1,32 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/skit-ai/vcore/ctxkeys"
+	"github.com/streadway/amqp"
+)
+
+// Headers returns the headers carrying the observability context(trace, request ID, tenant, principal) of ctx
+// along with the headers given, to be passed to Publish
+func Headers(ctx context.Context, headers amqp.Table) amqp.Table {
+	table := amqp.Table{}
+	for key, value := range ctxkeys.Inject(ctx) {
+		table[key] = value
+	}
+	for key, value := range headers {
+		table[key] = value
+	}
+	return table
+}
+
+// Context restores the observability context carried by the headers of the delivery on ctx
+func Context(ctx context.Context, delivery amqp.Delivery) context.Context {
+	carrier := ctxkeys.Carrier{}
+	for key, value := range delivery.Headers {
+		if s, ok := value.(string); ok {
+			carrier[key] = s
+		}
+	}
+	return ctxkeys.Extract(ctx, carrier)
+}
@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/skit-ai/vcore/transport/compression"
 	"github.com/streadway/amqp"
 )
 
 type Producer struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
+	conn       *amqp.Connection
+	channel    *amqp.Channel
+	compressor *compression.Compressor
+	validator  Validator
 }
 
 var (
@@ -70,7 +73,21 @@ func (producer *Producer) Publish(exchange, exchangeType, routingKey, body strin
 		// defer confirmOne(confirms)
 	}
 
-	var err error
+	if err := producer.validate(routingKey, body); err != nil {
+		log.Printf("Validate: %s", err)
+		return err
+	}
+
+	payload, algorithm, err := producer.compressor.Compress([]byte(body))
+	if err != nil {
+		log.Printf("Compress: %s", err)
+		return err
+	}
+	contentEncoding := ""
+	if algorithm != compression.None {
+		contentEncoding = string(algorithm)
+	}
+
 	log.Printf("Publishing %dB body with routingKey %q (%q)", len(body), routingKey, body)
 	if err = producer.channel.Publish(
 		exchange,   // publish to an exchange
@@ -80,8 +97,8 @@ func (producer *Producer) Publish(exchange, exchangeType, routingKey, body strin
 		amqp.Publishing{
 			Headers:         headers,
 			ContentType:     "text/plain",
-			ContentEncoding: "",
-			Body:            []byte(body),
+			ContentEncoding: contentEncoding,
+			Body:            payload,
 			DeliveryMode:    amqp.Persistent, // 1=non-persistent, 2=persistent
 			Priority:        0,               // 0-9
 			// a bunch of application/implementation-specific fields
@@ -93,6 +110,20 @@ func (producer *Producer) Publish(exchange, exchangeType, routingKey, body strin
 	return err
 }
 
+// SetCompression compresses the bodies published which are larger than the compressor's threshold.
+// Consumers decompress them as per their content encoding(see Decompress).
+func (producer *Producer) SetCompression(compressor *compression.Compressor) {
+	producer.compressor = compressor
+}
+
+// CompressionStats returns the stats of the bodies compressed
+func (producer *Producer) CompressionStats() compression.Stats {
+	if producer.compressor == nil {
+		return compression.Stats{}
+	}
+	return producer.compressor.Stats()
+}
+
 func (producer *Producer) Shutdown() error {
 
 	if producer == nil {
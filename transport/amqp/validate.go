@@ -0,0 +1,50 @@
+package amqp
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Validator validates the body of an event published with the routing key, eg. against the schema of the event
+type Validator func(routingKey string, body []byte) error
+
+// JSONValidator returns a validator decoding the bodies into a T strictly(unknown fields are rejected), and calling
+// its Validate method if it has one, eg.
+//
+//	producer.SetValidator(amqp.JSONValidator[events.CallEnded]())
+func JSONValidator[T any]() Validator {
+	return func(_ string, body []byte) error {
+		var event T
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&event); err != nil {
+			return err
+		}
+		if validator, ok := any(&event).(interface{ Validate() error }); ok {
+			return validator.Validate()
+		}
+		return nil
+	}
+}
+
+// SetValidator validates the bodies before they are published, so that invalid events are rejected by the producer
+// with an InvalidArgument error(see errors.CodeOf) instead of reaching the consumers
+func (producer *Producer) SetValidator(validator Validator) {
+	producer.validator = validator
+}
+
+// Returns the error of the validation of the body, nil if there is no validator
+func (producer *Producer) validate(routingKey, body string) error {
+	if producer.validator == nil {
+		return nil
+	}
+	if err := producer.validator(routingKey, []byte(body)); err != nil {
+		return errors.WithCode(errors.NewErrorWithExtras("Invalid event for "+routingKey, err, false, map[string]interface{}{
+			"routing_key": routingKey,
+			"body_size":   len(body),
+		}), errors.InvalidArgument)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+// Package compression compresses message payloads with gzip, snappy or zstd.
+// Payloads smaller than a threshold are left as is, and compressed payloads are recognized by the magic bytes of
+// their format, so consumers decompress payloads regardless of whether(and how) the producer compressed them.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	stderrors "errors"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/skit-ai/vcore/errors"
+)
+
+type Algorithm string
+
+const (
+	None   Algorithm = "none"
+	Gzip   Algorithm = "gzip"
+	Snappy Algorithm = "snappy"
+	Zstd   Algorithm = "zstd"
+)
+
+// Magic bytes at the start of the payloads of each format. Snappy payloads use the framing format, whose stream
+// identifier serves as the magic bytes.
+var (
+	gzipMagic   = []byte{0x1f, 0x8b}
+	snappyMagic = []byte("\xff\x06\x00\x00sNaPpY")
+	zstdMagic   = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// MaxDecompressedSize is the size past which payloads are not decompressed, so that a small payload decompressing to
+// gigabytes(eg. a zip bomb) does not exhaust the memory of the consumer
+const MaxDecompressedSize = 64 << 20
+
+// ErrTooLarge is returned for the payloads decompressing to more than MaxDecompressedSize bytes
+var ErrTooLarge = errors.NewError("the decompressed payload is too large", nil, false)
+
+// Encoders and decoders of zstd are safe for concurrent use and expensive to create
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func initZstd() {
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxDecompressedSize))
+}
+
+var (
+	payloadsCompressed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "compression",
+		Name:      "payloads_total",
+		Help:      "Payloads compressed by the compressors, by algorithm(none for the payloads below the threshold)",
+	}, []string{"algorithm"})
+	bytesIn = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "compression",
+		Name:      "bytes_in_total",
+		Help:      "Bytes of the payloads compressed before compression, by algorithm",
+	}, []string{"algorithm"})
+	bytesOut = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "compression",
+		Name:      "bytes_out_total",
+		Help:      "Bytes of the payloads compressed after compression, by algorithm",
+	}, []string{"algorithm"})
+)
+
+// Collectors returns the metrics of the compressors, to be registered by the service. The compression ratio of an
+// algorithm is the rate of bytes_out_total over the rate of bytes_in_total.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{payloadsCompressed, bytesIn, bytesOut}
+}
+
+// Stats of the payloads compressed
+type Stats struct {
+	// Payloads compressed and the ones skipped for being below the threshold
+	Compressed uint64 `json:"compressed"`
+	Skipped    uint64 `json:"skipped"`
+	// Bytes of the payloads compressed, before and after compression
+	BytesIn  uint64 `json:"bytes_in"`
+	BytesOut uint64 `json:"bytes_out"`
+}
+
+// Ratio of the size of the compressed payloads to their original size
+func (s Stats) Ratio() float64 {
+	if s.BytesIn == 0 {
+		return 1
+	}
+	return float64(s.BytesOut) / float64(s.BytesIn)
+}
+
+// Compressor compresses the payloads of at least Threshold bytes
+type Compressor struct {
+	Algorithm Algorithm
+	Threshold int
+
+	compressed, skipped, bytesIn, bytesOut atomic.Uint64
+}
+
+func New(algorithm Algorithm, threshold int) *Compressor {
+	return &Compressor{Algorithm: algorithm, Threshold: threshold}
+}
+
+// Compress returns the compressed payload along with the algorithm used, which is None if the payload was left as is
+func (c *Compressor) Compress(payload []byte) ([]byte, Algorithm, error) {
+	if c == nil || c.Algorithm == None || c.Algorithm == "" || len(payload) < c.Threshold {
+		if c != nil {
+			c.skipped.Add(1)
+			payloadsCompressed.WithLabelValues(string(None)).Inc()
+		}
+		return payload, None, nil
+	}
+
+	compressed, err := Compress(c.Algorithm, payload)
+	if err != nil {
+		return nil, None, err
+	}
+
+	c.compressed.Add(1)
+	c.bytesIn.Add(uint64(len(payload)))
+	c.bytesOut.Add(uint64(len(compressed)))
+	payloadsCompressed.WithLabelValues(string(c.Algorithm)).Inc()
+	bytesIn.WithLabelValues(string(c.Algorithm)).Add(float64(len(payload)))
+	bytesOut.WithLabelValues(string(c.Algorithm)).Add(float64(len(compressed)))
+	return compressed, c.Algorithm, nil
+}
+
+// Stats returns the stats of the payloads compressed so far
+func (c *Compressor) Stats() Stats {
+	return Stats{
+		Compressed: c.compressed.Load(),
+		Skipped:    c.skipped.Load(),
+		BytesIn:    c.bytesIn.Load(),
+		BytesOut:   c.bytesOut.Load(),
+	}
+}
+
+// Compress compresses the payload with the algorithm
+func Compress(algorithm Algorithm, payload []byte) ([]byte, error) {
+	switch algorithm {
+	case None:
+		return payload, nil
+	case Gzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Snappy:
+		var buf bytes.Buffer
+		w := snappy.NewBufferedWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		zstdOnce.Do(initZstd)
+		return zstdEncoder.EncodeAll(payload, nil), nil
+	}
+	return nil, errors.NewError("unknown compression algorithm "+string(algorithm), nil, false)
+}
+
+// Detect returns the algorithm the payload was compressed with, as per its magic bytes
+func Detect(payload []byte) Algorithm {
+	switch {
+	case bytes.HasPrefix(payload, zstdMagic):
+		return Zstd
+	case bytes.HasPrefix(payload, snappyMagic):
+		return Snappy
+	case bytes.HasPrefix(payload, gzipMagic):
+		return Gzip
+	}
+	return None
+}
+
+// Decompress decompresses the payload as per its magic bytes. Payloads which are not compressed are returned as is,
+// and the ones decompressing to more than MaxDecompressedSize bytes fail with ErrTooLarge.
+func Decompress(payload []byte) ([]byte, error) {
+	switch Detect(payload) {
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readLimited(r)
+	case Snappy:
+		return readLimited(snappy.NewReader(bytes.NewReader(payload)))
+	case Zstd:
+		zstdOnce.Do(initZstd)
+		decompressed, err := zstdDecoder.DecodeAll(payload, nil)
+		if stderrors.Is(err, zstd.ErrDecoderSizeExceeded) || stderrors.Is(err, zstd.ErrWindowSizeExceeded) {
+			return nil, ErrTooLarge
+		}
+		return decompressed, err
+	}
+	return payload, nil
+}
+
+// Reads at most MaxDecompressedSize bytes of the reader, failing with ErrTooLarge if there are more
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxDecompressedSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,222 @@
+package pgqueue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+var jobsHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "vcore",
+	Subsystem: "pgqueue",
+	Name:      "jobs_handled_total",
+	Help:      "Jobs handled by the consumers, by queue, tenant and result(ok, retried, dropped or quarantined)",
+}, []string{"queue", "tenant", "result"})
+
+// Tenants returns the tenants with jobs on the queue, available or not
+func (q *Queue) Tenants(ctx context.Context, queue string) ([]string, error) {
+	// Skipping from a tenant to the next on the index rather than scanning the jobs, as a tenant can have many
+	query := fmt.Sprintf(`WITH RECURSIVE tenants AS (
+			(SELECT tenant FROM %[1]s WHERE queue = $1 ORDER BY tenant LIMIT 1)
+			UNION ALL
+			SELECT (SELECT tenant FROM %[1]s WHERE queue = $1 AND tenant > tenants.tenant ORDER BY tenant LIMIT 1)
+			FROM tenants WHERE tenants.tenant IS NOT NULL
+		)
+		SELECT tenant FROM tenants WHERE tenant IS NOT NULL`, q.table)
+
+	rows, err := q.db.QueryContext(ctx, query, queue)
+	if err != nil {
+		return nil, errors.NewError("Could not list the tenants of the queue", err, false)
+	}
+	defer rows.Close()
+
+	tenants := []string{}
+	for rows.Next() {
+		var tenant string
+		if err := rows.Scan(&tenant); err != nil {
+			return nil, errors.NewError("Could not read a tenant of the queue", err, false)
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewError("Could not list the tenants of the queue", err, false)
+	}
+	return tenants, nil
+}
+
+// DequeueTenant leases the job of the highest priority available on the queue for the tenant, for the visibility
+// timeout. Returns ErrEmpty if the tenant has no job available.
+func (q *Queue) DequeueTenant(ctx context.Context, queue, tenant string, visibility time.Duration) (*Job, error) {
+	return q.dequeue(ctx, "AND tenant = $2", visibility, queue, tenant)
+}
+
+// Share of a tenant of the scheduler
+type share struct {
+	// Virtual time the tenant has been served up to, the tenant of the earliest being served next
+	pass   float64
+	weight float64
+	idle   bool
+}
+
+// Scheduler shares the jobs leased among the tenants by their weights, with stride scheduling: every job leased for a
+// tenant advances its virtual time by the inverse of its weight, and the tenant of the earliest virtual time is
+// served next. Tenants joining(or with jobs again) start at the virtual time of the tenants served, so that they get
+// their share from then on rather than a burst making up for the time they were idle.
+type Scheduler struct {
+	weight func(tenant string) float64
+
+	mutex  sync.Mutex
+	shares map[string]*share
+	// Virtual time of the latest tenant served
+	virtual float64
+}
+
+// NewScheduler returns a scheduler sharing the jobs among the tenants by their weights. The weights default to 1.
+func NewScheduler(weight func(tenant string) float64) *Scheduler {
+	if weight == nil {
+		weight = func(string) float64 { return 1 }
+	}
+	return &Scheduler{weight: weight, shares: make(map[string]*share)}
+}
+
+// SetTenants configures the tenants with jobs. The other tenants are forgotten.
+func (s *Scheduler) SetTenants(tenants []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	listed := make(map[string]bool, len(tenants))
+	for _, tenant := range tenants {
+		listed[tenant] = true
+		sh, ok := s.shares[tenant]
+		if !ok {
+			sh = &share{}
+			s.shares[tenant] = sh
+		}
+		sh.weight = s.weight(tenant)
+		if sh.weight <= 0 {
+			sh.weight = 1
+		}
+		sh.idle = false
+		sh.pass = max(sh.pass, s.virtual)
+	}
+	for tenant := range s.shares {
+		if !listed[tenant] {
+			delete(s.shares, tenant)
+		}
+	}
+}
+
+// Next returns the tenant to be served next, or false if no tenant has jobs. The tenant is to be marked as served
+// once a job of it is leased(see Served), or as idle if it has none available(see Idle).
+func (s *Scheduler) Next() (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	next, found := "", false
+	for tenant, sh := range s.shares {
+		if sh.idle {
+			continue
+		}
+		// Breaking ties by the names, for the tenants to be served in a stable order
+		if !found || sh.pass < s.shares[next].pass || (sh.pass == s.shares[next].pass && tenant < next) {
+			next, found = tenant, true
+		}
+	}
+	return next, found
+}
+
+// Served advances the virtual time of the tenant a job of which was leased
+func (s *Scheduler) Served(tenant string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if sh, ok := s.shares[tenant]; ok {
+		s.virtual = max(s.virtual, sh.pass)
+		sh.pass += 1 / sh.weight
+	}
+}
+
+// Idle skips the tenant until the tenants are configured again(see SetTenants), eg. as its jobs are all leased or
+// delayed
+func (s *Scheduler) Idle(tenant string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if sh, ok := s.shares[tenant]; ok {
+		sh.idle = true
+	}
+}
+
+// Tenants returns the tenants of the scheduler which have jobs, sorted
+func (s *Scheduler) Tenants() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	tenants := make([]string, 0, len(s.shares))
+	for tenant, sh := range s.shares {
+		if !sh.idle {
+			tenants = append(tenants, tenant)
+		}
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// Dequeues the jobs of a queue for the tenants in turn, as scheduled
+type fairDequeuer struct {
+	queue     *Queue
+	name      string
+	opts      ConsumeOptions
+	scheduler *Scheduler
+
+	// Serializes the listings of the tenants by the workers
+	mutex     sync.Mutex
+	refreshed time.Time
+}
+
+// Lists the tenants with jobs, if they were listed longer ago than the refresh interval(or the poll interval, once no
+// tenant has jobs available)
+func (d *fairDequeuer) refresh(ctx context.Context) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	interval := d.opts.TenantRefresh
+	if len(d.scheduler.Tenants()) == 0 {
+		interval = min(interval, d.opts.PollInterval)
+	}
+	if time.Since(d.refreshed) < interval {
+		return nil
+	}
+
+	tenants, err := d.queue.Tenants(ctx, d.name)
+	if err != nil {
+		return err
+	}
+	d.scheduler.SetTenants(tenants)
+	d.refreshed = time.Now()
+	return nil
+}
+
+func (d *fairDequeuer) dequeue(ctx context.Context) (*Job, error) {
+	if err := d.refresh(ctx); err != nil {
+		return nil, err
+	}
+	for {
+		tenant, ok := d.scheduler.Next()
+		if !ok {
+			return nil, ErrEmpty
+		}
+		job, err := d.queue.DequeueTenant(ctx, d.name, tenant, d.opts.Visibility)
+		if err == ErrEmpty {
+			d.scheduler.Idle(tenant)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		d.scheduler.Served(tenant)
+		return job, nil
+	}
+}
@@ -0,0 +1,287 @@
+// Package pgqueue is a job queue backed by a Postgres table, for deployments without Redis or a message broker.
+//
+// Jobs have priorities and can be delayed. Workers lease jobs with SELECT ... FOR UPDATE SKIP LOCKED, so that
+// concurrent workers never lease the same job. A leased job becomes available again once its visibility timeout
+// lapses without it being acknowledged, eg. when its worker crashes. Jobs which exhaust their attempts can be
+// quarantined, to be inspected, redriven or purged through an admin API(see QuarantineHandler). Jobs can belong to
+// tenants, among whom the workers are shared by their weights(see ConsumeOptions.Fair).
+package pgqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/log"
+)
+
+// ErrEmpty is returned by Dequeue when no job is available
+var ErrEmpty = errors.NewError("no job available", nil, false)
+
+type Job struct {
+	ID       int64
+	Queue    string
+	Payload  []byte
+	Priority int
+	// Tenant the job belongs to, if any(see EnqueueOptions.Tenant)
+	Tenant string
+	// Number of times the job has been leased, including the current lease
+	Attempts int
+	// Identifies the lease, so that a worker whose lease lapsed cannot acknowledge the job leased by another
+	lease string
+}
+
+type Queue struct {
+	db    *sql.DB
+	table string
+}
+
+// New returns a queue storing its jobs in the table(defaults to "jobs")
+func New(db *sql.DB, table string) *Queue {
+	if table == "" {
+		table = "jobs"
+	}
+	return &Queue{db: db, table: table}
+}
+
+// Migrate creates the tables of the jobs and of the quarantine(see Quarantine) with their indexes, if they do not
+// exist
+func (q *Queue) Migrate(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			queue TEXT NOT NULL,
+			payload BYTEA NOT NULL,
+			priority INT NOT NULL DEFAULT 0,
+			tenant TEXT NOT NULL DEFAULT '',
+			available_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			attempts INT NOT NULL DEFAULT 0,
+			lease TEXT,
+			leased_until TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`, q.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_dequeue_idx ON %s (queue, priority DESC, available_at, id)`, q.table, q.table),
+		// Tables created before the tenants
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT ''`, q.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_tenant_idx ON %s (queue, tenant, priority DESC, available_at, id)`, q.table, q.table),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id BIGINT PRIMARY KEY,
+			queue TEXT NOT NULL,
+			payload BYTEA NOT NULL,
+			priority INT NOT NULL DEFAULT 0,
+			tenant TEXT NOT NULL DEFAULT '',
+			attempts INT NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			quarantined_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`, q.quarantineTable()),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_queue_idx ON %s (queue, quarantined_at)`, q.quarantineTable(), q.quarantineTable()),
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT ''`, q.quarantineTable()),
+	}
+	for _, statement := range statements {
+		if _, err := q.db.ExecContext(ctx, statement); err != nil {
+			return errors.NewError("Could not migrate the table of the queue", err, false)
+		}
+	}
+	return nil
+}
+
+// EnqueueOptions of a job. Jobs of a higher priority are dequeued first, delayed jobs become available after
+// the delay.
+type EnqueueOptions struct {
+	Priority int
+	Delay    time.Duration
+	// Tenant the job belongs to(eg. the client running a campaign), for the workers to be shared among the tenants
+	// (see ConsumeOptions.Fair). Defaults to no tenant, the jobs without a tenant being shared as those of any other.
+	Tenant string
+}
+
+// Enqueue adds a job to the queue and returns its ID
+func (q *Queue) Enqueue(ctx context.Context, queue string, payload []byte, opts EnqueueOptions) (int64, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (queue, payload, priority, tenant, available_at)
+		VALUES ($1, $2, $3, $4, now() + $5 * interval '1 millisecond') RETURNING id`, q.table)
+
+	var id int64
+	if err := q.db.QueryRowContext(ctx, query, queue, payload, opts.Priority, opts.Tenant, opts.Delay.Milliseconds()).Scan(&id); err != nil {
+		return 0, errors.NewError("Could not enqueue the job", err, false)
+	}
+	return id, nil
+}
+
+// Dequeue leases the job of the highest priority available on the queue for the visibility timeout.
+// Returns ErrEmpty if no job is available.
+func (q *Queue) Dequeue(ctx context.Context, queue string, visibility time.Duration) (*Job, error) {
+	return q.dequeue(ctx, "", visibility, queue)
+}
+
+// Leases the job matching the queue(and the condition, if any) with the arguments following the queue
+func (q *Queue) dequeue(ctx context.Context, condition string, visibility time.Duration, args ...interface{}) (*Job, error) {
+	query := fmt.Sprintf(`UPDATE %[1]s SET
+			attempts = attempts + 1,
+			lease = $%[3]d,
+			leased_until = now() + $%[4]d * interval '1 millisecond'
+		WHERE id = (
+			SELECT id FROM %[1]s
+			WHERE queue = $1 %[2]s AND available_at <= now() AND (leased_until IS NULL OR leased_until < now())
+			ORDER BY priority DESC, available_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, queue, payload, priority, tenant, attempts, lease`, q.table, condition, len(args)+1, len(args)+2)
+
+	job := &Job{}
+	err := q.db.QueryRowContext(ctx, query, append(args, newLease(), visibility.Milliseconds())...).
+		Scan(&job.ID, &job.Queue, &job.Payload, &job.Priority, &job.Tenant, &job.Attempts, &job.lease)
+	if err == sql.ErrNoRows {
+		return nil, ErrEmpty
+	} else if err != nil {
+		return nil, errors.NewError("Could not dequeue a job", err, false)
+	}
+	return job, nil
+}
+
+// Ack removes a job once it has been handled. Fails if the lease of the job has been taken over by another worker.
+func (q *Queue) Ack(ctx context.Context, job *Job) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1 AND lease = $2`, q.table)
+	return q.expectOne(q.db.ExecContext(ctx, query, job.ID, job.lease))
+}
+
+// Nack releases a job to be retried after the delay
+func (q *Queue) Nack(ctx context.Context, job *Job, delay time.Duration) error {
+	query := fmt.Sprintf(`UPDATE %s SET
+			lease = NULL,
+			leased_until = NULL,
+			available_at = now() + $3 * interval '1 millisecond'
+		WHERE id = $1 AND lease = $2`, q.table)
+	return q.expectOne(q.db.ExecContext(ctx, query, job.ID, job.lease, delay.Milliseconds()))
+}
+
+// Extend extends the lease of a job taking longer than its visibility timeout
+func (q *Queue) Extend(ctx context.Context, job *Job, visibility time.Duration) error {
+	query := fmt.Sprintf(`UPDATE %s SET leased_until = now() + $3 * interval '1 millisecond'
+		WHERE id = $1 AND lease = $2`, q.table)
+	return q.expectOne(q.db.ExecContext(ctx, query, job.ID, job.lease, visibility.Milliseconds()))
+}
+
+func (q *Queue) expectOne(result sql.Result, err error) error {
+	if err != nil {
+		return errors.NewError("Could not update the job", err, false)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return errors.NewError("The lease of the job has lapsed", nil, false)
+	}
+	return nil
+}
+
+// Handler handles a job. Jobs whose handler returns an error are retried after the backoff.
+type Handler func(ctx context.Context, job *Job) error
+
+type ConsumeOptions struct {
+	// Number of jobs handled concurrently. Defaults to 1.
+	Concurrency int
+	// Interval at which an empty queue is polled. Defaults to 1s.
+	PollInterval time.Duration
+	// Visibility timeout of the jobs leased. Defaults to 30s.
+	Visibility time.Duration
+	// Maximum number of attempts of a job, after which it is dropped(or quarantined). Defaults to unlimited.
+	MaxAttempts int
+	// Quarantine the jobs which exhaust their attempts instead of dropping them, to be inspected and redriven(see
+	// QuarantineHandler)
+	Quarantine bool
+	// Delay after which failed jobs are retried, given the number of attempts made. Defaults to 10s.
+	Backoff func(attempts int) time.Duration
+	// Share the workers among the tenants of the queue by their weights, so that the jobs of a tenant(eg. a
+	// campaign of 500k contacts) cannot starve those of the others. The priorities of the jobs only order the jobs
+	// of a tenant then.
+	Fair bool
+	// Weight of a tenant when fair, eg. by its plan. A tenant of weight 2 is leased twice as many jobs as a tenant of
+	// weight 1 while both have jobs available. Defaults to 1 for every tenant.
+	Weight func(tenant string) float64
+	// Interval at which the tenants with jobs are listed when fair. The jobs of a tenant without jobs until then wait
+	// for up to the interval. Defaults to 10s.
+	TenantRefresh time.Duration
+}
+
+// Consume handles the jobs of the queue until the context is done
+func (q *Queue) Consume(ctx context.Context, queue string, opts ConsumeOptions, handler Handler) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Visibility <= 0 {
+		opts.Visibility = 30 * time.Second
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = func(int) time.Duration { return 10 * time.Second }
+	}
+	if opts.TenantRefresh <= 0 {
+		opts.TenantRefresh = 10 * time.Second
+	}
+
+	dequeue := func(ctx context.Context) (*Job, error) {
+		return q.Dequeue(ctx, queue, opts.Visibility)
+	}
+	if opts.Fair {
+		// The workers share the scheduler, so that the jobs leased by each count towards the shares of the tenants
+		dequeue = (&fairDequeuer{queue: q, name: queue, opts: opts, scheduler: NewScheduler(opts.Weight)}).dequeue
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, queue, opts, dequeue, handler)
+		}()
+	}
+	wg.Wait()
+}
+
+func (q *Queue) work(ctx context.Context, queue string, opts ConsumeOptions, dequeue func(context.Context) (*Job, error), handler Handler) {
+	for ctx.Err() == nil {
+		job, err := dequeue(ctx)
+		if err != nil {
+			if err != ErrEmpty && ctx.Err() == nil {
+				log.Error(err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(opts.PollInterval):
+			}
+			continue
+		}
+
+		result := "ok"
+		if err = handler(ctx, job); err == nil {
+			err = q.Ack(ctx, job)
+		} else if opts.MaxAttempts > 0 && job.Attempts >= opts.MaxAttempts && opts.Quarantine {
+			log.Errorf(err, "Quarantining job %d of queue %s after %d attempts", job.ID, queue, job.Attempts)
+			result = "quarantined"
+			err = q.Quarantine(ctx, job, err)
+		} else if opts.MaxAttempts > 0 && job.Attempts >= opts.MaxAttempts {
+			log.Errorf(err, "Dropping job %d of queue %s after %d attempts", job.ID, queue, job.Attempts)
+			result = "dropped"
+			err = q.Ack(ctx, job)
+		} else {
+			log.Warnf("Job %d of queue %s failed(attempt %d), retrying: %s", job.ID, queue, job.Attempts, err)
+			result = "retried"
+			err = q.Nack(ctx, job, opts.Backoff(job.Attempts))
+		}
+		jobsHandled.WithLabelValues(queue, job.Tenant, result).Inc()
+		if err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+func newLease() string {
+	lease := make([]byte, 16)
+	_, _ = rand.Read(lease)
+	return hex.EncodeToString(lease)
+}
@@ -0,0 +1,216 @@
+package pgqueue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/httpserver"
+)
+
+// QuarantinedJob is a job which exhausted its attempts, kept to be inspected and redriven(or purged)
+type QuarantinedJob struct {
+	ID       int64     `json:"id"`
+	Queue    string    `json:"queue"`
+	Payload  []byte    `json:"payload"`
+	Priority int       `json:"priority"`
+	Tenant   string    `json:"tenant"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	At       time.Time `json:"quarantined_at"`
+}
+
+var (
+	jobsQuarantined = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "pgqueue",
+		Name:      "jobs_quarantined_total",
+		Help:      "Jobs quarantined after exhausting their attempts, by queue",
+	}, []string{"queue"})
+	jobsRedriven = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "pgqueue",
+		Name:      "jobs_redriven_total",
+		Help:      "Quarantined jobs enqueued again, by queue",
+	}, []string{"queue"})
+	jobsPurged = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vcore",
+		Subsystem: "pgqueue",
+		Name:      "jobs_purged_total",
+		Help:      "Quarantined jobs deleted, by queue",
+	}, []string{"queue"})
+)
+
+// Collectors returns the metrics of the queue and the quarantine, to be registered with a registry, eg.
+// prometheus.MustRegister(pgqueue.Collectors()...). The growth of a quarantine is jobs_quarantined_total less
+// jobs_redriven_total and jobs_purged_total, the throughput of a tenant is the rate of jobs_handled_total.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{jobsHandled, jobsQuarantined, jobsRedriven, jobsPurged}
+}
+
+func (q *Queue) quarantineTable() string {
+	return q.table + "_quarantine"
+}
+
+// Quarantine moves a job to the quarantine along with the error it failed with, eg. once it has exhausted its
+// attempts(see ConsumeOptions.Quarantine). Fails if the lease of the job has been taken over by another worker.
+func (q *Queue) Quarantine(ctx context.Context, job *Job, cause error) error {
+	query := fmt.Sprintf(`WITH job AS (
+			DELETE FROM %s WHERE id = $1 AND lease = $2 RETURNING id, queue, payload, priority, tenant, attempts
+		)
+		INSERT INTO %s (id, queue, payload, priority, tenant, attempts, error)
+		SELECT id, queue, payload, priority, tenant, attempts, $3 FROM job`, q.table, q.quarantineTable())
+
+	message := ""
+	if cause != nil {
+		message = cause.Error()
+	}
+	if err := q.expectOne(q.db.ExecContext(ctx, query, job.ID, job.lease, message)); err != nil {
+		return err
+	}
+	jobsQuarantined.WithLabelValues(job.Queue).Inc()
+	return nil
+}
+
+// Quarantined returns up to limit jobs quarantined from the queue, the latest first
+func (q *Queue) Quarantined(ctx context.Context, queue string, limit int) ([]QuarantinedJob, error) {
+	query := fmt.Sprintf(`SELECT id, queue, payload, priority, tenant, attempts, error, quarantined_at FROM %s
+		WHERE queue = $1 ORDER BY quarantined_at DESC, id DESC LIMIT $2`, q.quarantineTable())
+
+	rows, err := q.db.QueryContext(ctx, query, queue, limit)
+	if err != nil {
+		return nil, errors.NewError("Could not list the quarantined jobs", err, false)
+	}
+	defer rows.Close()
+
+	jobs := []QuarantinedJob{}
+	for rows.Next() {
+		var job QuarantinedJob
+		if err := rows.Scan(&job.ID, &job.Queue, &job.Payload, &job.Priority, &job.Tenant, &job.Attempts, &job.Error, &job.At); err != nil {
+			return nil, errors.NewError("Could not read a quarantined job", err, false)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewError("Could not list the quarantined jobs", err, false)
+	}
+	return jobs, nil
+}
+
+// Redrive enqueues a quarantined job of the queue again, with its attempts reset. Returns a NotFound error(see
+// errors.CodeOf) if the queue has no such job quarantined.
+func (q *Queue) Redrive(ctx context.Context, queue string, id int64) error {
+	query := fmt.Sprintf(`WITH job AS (
+			DELETE FROM %s WHERE queue = $1 AND id = $2 RETURNING id, queue, payload, priority, tenant
+		)
+		INSERT INTO %s (id, queue, payload, priority, tenant)
+		SELECT id, queue, payload, priority, tenant FROM job`, q.quarantineTable(), q.table)
+
+	if err := q.expectQuarantined(q.db.ExecContext(ctx, query, queue, id)); err != nil {
+		return err
+	}
+	jobsRedriven.WithLabelValues(queue).Inc()
+	return nil
+}
+
+// Purge deletes a quarantined job of the queue. Returns a NotFound error(see errors.CodeOf) if the queue has no such
+// job quarantined.
+func (q *Queue) Purge(ctx context.Context, queue string, id int64) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE queue = $1 AND id = $2`, q.quarantineTable())
+	if err := q.expectQuarantined(q.db.ExecContext(ctx, query, queue, id)); err != nil {
+		return err
+	}
+	jobsPurged.WithLabelValues(queue).Inc()
+	return nil
+}
+
+// PurgeAll deletes the jobs quarantined from the queue, returning the number of jobs deleted
+func (q *Queue) PurgeAll(ctx context.Context, queue string) (int64, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE queue = $1`, q.quarantineTable())
+	result, err := q.db.ExecContext(ctx, query, queue)
+	if err != nil {
+		return 0, errors.NewError("Could not purge the quarantined jobs", err, false)
+	}
+	purged, _ := result.RowsAffected()
+	jobsPurged.WithLabelValues(queue).Add(float64(purged))
+	return purged, nil
+}
+
+func (q *Queue) expectQuarantined(result sql.Result, err error) error {
+	if err != nil {
+		return errors.NewError("Could not update the quarantined job", err, false)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return errors.WithCode(errors.NewError("No such job is quarantined", nil, false), errors.NotFound)
+	}
+	return nil
+}
+
+// QuarantineHandler returns the admin API of the quarantine, to be mounted with its prefix stripped, eg.
+//
+//	mux.Handle("/admin/quarantine/", http.StripPrefix("/admin/quarantine", queue.QuarantineHandler()))
+//
+// It serves:
+//
+//	GET    /{queue}?limit=100       lists the jobs quarantined from the queue
+//	POST   /{queue}/{id}/redrive    enqueues a quarantined job again
+//	DELETE /{queue}/{id}            deletes a quarantined job
+//	DELETE /{queue}                 deletes the jobs quarantined from the queue
+//
+// The API is to be exposed to the operators only, eg. behind httpserver.AllowHosts.
+func (q *Queue) QuarantineHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{queue}", func(w http.ResponseWriter, r *http.Request) {
+		limit := 100
+		if value := r.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				httpserver.Error(w, r, errors.WithCode(errors.NewError("limit is to be a positive number", err, false), errors.InvalidArgument))
+				return
+			}
+			limit = parsed
+		}
+
+		jobs, err := q.Quarantined(r.Context(), r.PathValue("queue"), limit)
+		if err != nil {
+			httpserver.Error(w, r, err)
+			return
+		}
+		_ = httpserver.JSON(w, r, http.StatusOK, jobs)
+	})
+	mux.HandleFunc("POST /{queue}/{id}/redrive", func(w http.ResponseWriter, r *http.Request) {
+		q.serveJob(w, r, q.Redrive)
+	})
+	mux.HandleFunc("DELETE /{queue}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		q.serveJob(w, r, q.Purge)
+	})
+	mux.HandleFunc("DELETE /{queue}", func(w http.ResponseWriter, r *http.Request) {
+		purged, err := q.PurgeAll(r.Context(), r.PathValue("queue"))
+		if err != nil {
+			httpserver.Error(w, r, err)
+			return
+		}
+		_ = httpserver.JSON(w, r, http.StatusOK, map[string]int64{"purged": purged})
+	})
+	return mux
+}
+
+// Serves an action on the quarantined job of the path
+func (q *Queue) serveJob(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, queue string, id int64) error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httpserver.Error(w, r, errors.WithCode(errors.NewError("Invalid job ID", err, false), errors.InvalidArgument))
+		return
+	}
+	if err := action(r.Context(), r.PathValue("queue"), id); err != nil {
+		httpserver.Error(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,211 @@
+// Package sink ties batch consumption of AMQP queues to database transactions for projection services.
+//
+// Each batch is written within a transaction which also records the IDs of the messages handled. Deliveries are
+// acknowledged only once the transaction commits, and redeliveries of messages already recorded are skipped, so
+// the effects of every message are applied exactly once even when the consumer crashes between the commit and the
+// acknowledgement.
+package sink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Vernacular-ai/gorm"
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/transport/amqp"
+	streadway "github.com/streadway/amqp"
+)
+
+// Handler writes a batch of deliveries within the transaction
+type Handler func(ctx context.Context, tx *gorm.DB, deliveries []streadway.Delivery) error
+
+type Sink struct {
+	db       *gorm.DB
+	handler  Handler
+	table    string
+	consumer string
+}
+
+// Option configures a Sink
+type Option func(*Sink)
+
+// WithTable configures the table recording the messages handled. Defaults to "processed_messages".
+func WithTable(table string) Option {
+	return func(s *Sink) {
+		s.table = table
+	}
+}
+
+// WithConsumer scopes the messages recorded to the consumer, for projections sharing a table to consume the same
+// messages independently. Defaults to "default".
+func WithConsumer(consumer string) Option {
+	return func(s *Sink) {
+		s.consumer = consumer
+	}
+}
+
+func New(db *gorm.DB, handler Handler, opts ...Option) *Sink {
+	s := &Sink{
+		db:       db,
+		handler:  handler,
+		table:    "processed_messages",
+		consumer: "default",
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Migrate creates the table recording the messages handled, if it does not exist
+func (s *Sink) Migrate() error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		consumer VARCHAR(255) NOT NULL,
+		message_id VARCHAR(255) NOT NULL,
+		processed_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (consumer, message_id)
+	)`, s.table)
+	if err := s.db.Exec(query).Error; err != nil {
+		return errors.NewError("Could not create the table of processed messages", err, false)
+	}
+	return nil
+}
+
+// Run consumes the queue in batches until the context is done
+func (s *Sink) Run(ctx context.Context, consumer *amqp.Consumer, queueName string, opts amqp.BatchOptions) error {
+	return consumer.ConsumeBatch(ctx, queueName, opts, s.HandleBatch)
+}
+
+// HandleBatch writes the deliveries not handled before within a transaction. Can be used as an amqp.BatchHandler.
+// Deliveries without a message ID cannot be deduplicated and are always handled.
+//
+// If the handler fails some deliveries with an *amqp.BatchError, the transaction is rolled back and the batch is
+// written again without them, so that the deliveries acknowledged are exactly those committed. The failed deliveries
+// are then reported by their index in the batch.
+func (s *Sink) HandleBatch(ctx context.Context, deliveries []streadway.Delivery) error {
+	failed := make(map[int]error)
+	for {
+		err := s.write(ctx, deliveries, failed)
+		batchErr, ok := err.(*amqp.BatchError)
+		if !ok {
+			if err != nil {
+				return err
+			}
+			break
+		}
+		if len(batchErr.Errors) == 0 {
+			// Failing no delivery in particular fails them all, rather than acknowledging deliveries rolled back
+			return errors.NewError("The batch failed", batchErr, false)
+		}
+		for index, deliveryErr := range batchErr.Errors {
+			failed[index] = deliveryErr
+		}
+	}
+
+	if len(failed) > 0 {
+		return &amqp.BatchError{Errors: failed}
+	}
+	return nil
+}
+
+// Writes the deliveries within a transaction, but for the failed ones. An *amqp.BatchError of the handler is
+// returned with the indices of the deliveries in the batch.
+func (s *Sink) write(ctx context.Context, deliveries []streadway.Delivery, failed map[int]error) (err error) {
+	tx := s.db.BeginTx(ctx, nil)
+	if tx.Error != nil {
+		return errors.NewError("Could not begin a transaction", tx.Error, false)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	processed, err := s.processed(tx, deliveries)
+	if err != nil {
+		return err
+	}
+
+	// Indices of the fresh deliveries in the batch
+	fresh := make([]streadway.Delivery, 0, len(deliveries))
+	indices := make([]int, 0, len(deliveries))
+	for index, delivery := range deliveries {
+		if _, ok := failed[index]; ok {
+			continue
+		}
+		if delivery.MessageId == "" || !processed[delivery.MessageId] {
+			fresh = append(fresh, delivery)
+			indices = append(indices, index)
+			// Duplicates within the batch are handled once
+			if delivery.MessageId != "" {
+				processed[delivery.MessageId] = true
+			}
+		}
+	}
+	if len(fresh) == 0 {
+		return tx.Commit().Error
+	}
+
+	if err = s.handler(ctx, tx, fresh); err != nil {
+		if batchErr, ok := err.(*amqp.BatchError); ok {
+			remapped := make(map[int]error, len(batchErr.Errors))
+			for index, deliveryErr := range batchErr.Errors {
+				if index >= 0 && index < len(indices) {
+					remapped[indices[index]] = deliveryErr
+				}
+			}
+			return &amqp.BatchError{Errors: remapped}
+		}
+		return err
+	}
+
+	// A consumer handling the same messages concurrently fails here on the primary key, rolling back its batch,
+	// which is then skipped on redelivery
+	insert := fmt.Sprintf("INSERT INTO %s (consumer, message_id, processed_at) VALUES (?, ?, ?)", s.table)
+	now := time.Now()
+	for _, delivery := range fresh {
+		if delivery.MessageId == "" {
+			continue
+		}
+		if err = tx.Exec(insert, s.consumer, delivery.MessageId, now).Error; err != nil {
+			return errors.NewError("Could not record a processed message", err, false)
+		}
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return errors.NewError("Could not commit the transaction", err, false)
+	}
+	return nil
+}
+
+// Returns the IDs of the deliveries already processed
+func (s *Sink) processed(tx *gorm.DB, deliveries []streadway.Delivery) (map[string]bool, error) {
+	processed := make(map[string]bool)
+
+	ids := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		if delivery.MessageId != "" {
+			ids = append(ids, delivery.MessageId)
+		}
+	}
+	if len(ids) == 0 {
+		return processed, nil
+	}
+
+	query := fmt.Sprintf("SELECT message_id FROM %s WHERE consumer = ? AND message_id IN (?)", s.table)
+	rows, err := tx.Raw(query, s.consumer, ids).Rows()
+	if err != nil {
+		return nil, errors.NewError("Could not look up the processed messages", err, false)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.NewError("Could not look up the processed messages", err, false)
+		}
+		processed[id] = true
+	}
+	return processed, rows.Err()
+}
@@ -0,0 +1,248 @@
+// Package wsbridge pipes bidirectional streams(eg. streaming ASR over gRPC) to WebSocket clients and back.
+//
+// Messages received upstream are written to the client only as fast as it consumes them, stalling the upstream
+// stream(and hence gRPC's flow control) once too many are pending. Clients that drop can reconnect within the
+// resume window with the token of their session and the sequence number of the last message they received, upon
+// which the messages they missed are replayed.
+package wsbridge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/skit-ai/vcore/log"
+)
+
+// Stream is the upstream side of the bridge
+type Stream interface {
+	Send(payload []byte) error
+	Recv() ([]byte, error)
+	// CloseSend signals that the client has nothing more to send
+	CloseSend() error
+}
+
+// Dialer opens the upstream stream of a new session. The stream must be bound to ctx, which is cancelled once
+// the session is closed, rather than to the context of the request which ends if the client drops.
+type Dialer func(ctx context.Context, r *http.Request) (Stream, error)
+
+// Query parameters with which clients resume sessions
+const (
+	ResumeParam = "resume"
+	LastParam   = "last"
+)
+
+type Bridge struct {
+	dial     Dialer
+	upgrader websocket.Upgrader
+
+	maxFrameSize   int
+	maxMessageSize int
+	maxPending     int
+	replay         int
+	resumeWindow   time.Duration
+	pingInterval   time.Duration
+	writeTimeout   time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mutex    sync.Mutex
+	sessions map[string]*session
+	shutdown bool
+}
+
+// Option configures a Bridge
+type Option func(*Bridge)
+
+// WithUpgrader configures the upgrader of WebSocket connections(eg. to check the origin of requests)
+func WithUpgrader(upgrader websocket.Upgrader) Option {
+	return func(b *Bridge) {
+		b.upgrader = upgrader
+	}
+}
+
+// WithMaxFrameSize configures the maximum payload of a frame, larger messages being split. Defaults to 32KiB.
+func WithMaxFrameSize(size int) Option {
+	return func(b *Bridge) {
+		b.maxFrameSize = size
+	}
+}
+
+// WithMaxMessageSize configures the maximum size of a message reassembled from a client's frames. Defaults to 1MiB.
+func WithMaxMessageSize(size int) Option {
+	return func(b *Bridge) {
+		b.maxMessageSize = size
+	}
+}
+
+// WithMaxPending configures the number of messages received upstream but yet to be written to the client, beyond
+// which the upstream stream is no longer read. Defaults to 64.
+func WithMaxPending(messages int) Option {
+	return func(b *Bridge) {
+		b.maxPending = messages
+	}
+}
+
+// WithResume configures the number of messages written to the client retained for replay and the window within
+// which a dropped client can resume its session. Defaults to 256 messages and 30s. A window of 0 disables resumption.
+func WithResume(messages int, window time.Duration) Option {
+	return func(b *Bridge) {
+		b.replay = messages
+		b.resumeWindow = window
+	}
+}
+
+// WithPingInterval configures the interval at which clients are pinged. Defaults to 20s.
+func WithPingInterval(interval time.Duration) Option {
+	return func(b *Bridge) {
+		b.pingInterval = interval
+	}
+}
+
+// WithWriteTimeout configures the deadline of writes to clients. Defaults to 10s.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(b *Bridge) {
+		b.writeTimeout = timeout
+	}
+}
+
+func New(dial Dialer, opts ...Option) *Bridge {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Bridge{
+		dial:           dial,
+		maxFrameSize:   32 << 10,
+		maxMessageSize: 1 << 20,
+		maxPending:     64,
+		replay:         256,
+		resumeWindow:   30 * time.Second,
+		pingInterval:   20 * time.Second,
+		writeTimeout:   10 * time.Second,
+		ctx:            ctx,
+		cancel:         cancel,
+		sessions:       make(map[string]*session),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection and attaches it to a new or resumed session
+func (b *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mutex.Lock()
+	if b.shutdown {
+		b.mutex.Unlock()
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	b.wg.Add(1)
+	b.mutex.Unlock()
+	defer b.wg.Done()
+
+	var s *session
+	var last uint64
+	if token := r.URL.Query().Get(ResumeParam); token != "" {
+		b.mutex.Lock()
+		s = b.sessions[token]
+		b.mutex.Unlock()
+
+		var err error
+		if last, err = strconv.ParseUint(r.URL.Query().Get(LastParam), 10, 64); err != nil {
+			http.Error(w, "invalid "+LastParam, http.StatusBadRequest)
+			return
+		}
+		if s == nil || !s.canResume(last) {
+			http.Error(w, "session cannot be resumed", http.StatusGone)
+			return
+		}
+	}
+
+	if s == nil {
+		var err error
+		if s, err = b.open(r); err != nil {
+			log.Warnf("Could not open the upstream stream of a session: %s", err)
+			http.Error(w, "could not open stream", http.StatusBadGateway)
+			return
+		}
+		last = 0
+	}
+
+	conn, err := b.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has replied to the client
+		s.detached()
+		return
+	}
+	s.attach(conn, last)
+}
+
+// Opens a new session
+func (b *Bridge) open(r *http.Request) (*session, error) {
+	ctx, cancel := context.WithCancel(b.ctx)
+	stream, err := b.dial(ctx, r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := newSession(b, newToken(), stream, cancel)
+	b.mutex.Lock()
+	b.sessions[s.token] = s
+	b.mutex.Unlock()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		s.pumpUpstream()
+	}()
+	return s, nil
+}
+
+func (b *Bridge) remove(token string) {
+	b.mutex.Lock()
+	delete(b.sessions, token)
+	b.mutex.Unlock()
+}
+
+// Shutdown stops accepting connections, closes every session(sending clients a going away close frame) and waits
+// for them to wind down or the context to be done
+func (b *Bridge) Shutdown(ctx context.Context) error {
+	b.mutex.Lock()
+	b.shutdown = true
+	sessions := make([]*session, 0, len(b.sessions))
+	for _, s := range b.sessions {
+		sessions = append(sessions, s)
+	}
+	b.mutex.Unlock()
+
+	for _, s := range sessions {
+		s.closeWith(websocket.CloseGoingAway, "shutting down")
+	}
+	b.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newToken() string {
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+	return hex.EncodeToString(token)
+}
@@ -0,0 +1,68 @@
+package wsbridge
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/skit-ai/vcore/errors"
+)
+
+// Every binary WebSocket frame starts with a header of flags(1 byte) followed by the sequence number(8 bytes,
+// big endian) of the message. Messages larger than the maximum frame size are split into frames, all but the
+// last one without the final flag. Clients reassemble the frames of a message the same way and may set the
+// sequence number to 0.
+const headerSize = 9
+
+const flagFinal byte = 1
+
+// Control messages are exchanged as JSON in text frames
+type control struct {
+	Type string `json:"type"`
+	// Resume token of the session
+	Token string `json:"token,omitempty"`
+	// Sequence number of the last message sent to the client
+	Seq uint64 `json:"seq"`
+}
+
+const (
+	// Sent by the bridge when a client(re)connects
+	controlSession = "session"
+	// Sent by the client once it has nothing more to send
+	controlEnd = "end"
+)
+
+// Splits a message into frames of at most size bytes of payload
+func encodeFrames(seq uint64, payload []byte, size int) [][]byte {
+	var frames [][]byte
+	for {
+		chunk := payload
+		if len(chunk) > size {
+			chunk = chunk[:size]
+		}
+		payload = payload[len(chunk):]
+
+		frame := make([]byte, headerSize+len(chunk))
+		if len(payload) == 0 {
+			frame[0] = flagFinal
+		}
+		binary.BigEndian.PutUint64(frame[1:headerSize], seq)
+		copy(frame[headerSize:], chunk)
+		frames = append(frames, frame)
+
+		if len(payload) == 0 {
+			return frames
+		}
+	}
+}
+
+func decodeFrame(frame []byte) (final bool, seq uint64, payload []byte, err error) {
+	if len(frame) < headerSize {
+		return false, 0, nil, errors.NewError("frame shorter than its header", nil, false)
+	}
+	return frame[0]&flagFinal != 0, binary.BigEndian.Uint64(frame[1:headerSize]), frame[headerSize:], nil
+}
+
+func encodeControl(c control) []byte {
+	data, _ := json.Marshal(c)
+	return data
+}
@@ -0,0 +1,71 @@
+package wsbridge
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// rawCodec passes messages through as bytes, leaving the (de)serialization of protobufs to the WebSocket client
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return *v.(*[]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "wsbridge-raw"
+}
+
+type grpcStream struct {
+	stream grpc.ClientStream
+}
+
+func (s *grpcStream) Send(payload []byte) error {
+	return s.stream.SendMsg(&payload)
+}
+
+func (s *grpcStream) Recv() ([]byte, error) {
+	var payload []byte
+	err := s.stream.RecvMsg(&payload)
+	return payload, err
+}
+
+func (s *grpcStream) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+// NewGRPCDialer returns a dialer opening a bidirectional stream on the method(eg. "/asr.ASR/Stream") of the
+// connection. WebSocket clients exchange serialized protobufs. The headers of the WebSocket request listed are
+// forwarded to the gRPC server as metadata.
+func NewGRPCDialer(conn *grpc.ClientConn, method string, headers ...string) Dialer {
+	desc := &grpc.StreamDesc{
+		StreamName:    method[strings.LastIndex(method, "/")+1:],
+		ServerStreams: true,
+		ClientStreams: true,
+	}
+
+	return func(ctx context.Context, r *http.Request) (Stream, error) {
+		md := metadata.MD{}
+		for _, header := range headers {
+			if values := r.Header.Values(header); len(values) > 0 {
+				md.Set(header, values...)
+			}
+		}
+		ctx = metadata.NewOutgoingContext(ctx, md)
+
+		stream, err := conn.NewStream(ctx, desc, method, grpc.ForceCodec(rawCodec{}))
+		if err != nil {
+			return nil, err
+		}
+		return &grpcStream{stream: stream}, nil
+	}
+}
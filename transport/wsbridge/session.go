@@ -0,0 +1,370 @@
+package wsbridge
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Close frames carry at most these many bytes of reason
+const maxCloseReason = 123
+
+type message struct {
+	seq     uint64
+	payload []byte
+}
+
+// session pairs an upstream stream with the WebSocket connection(if any) of its client
+type session struct {
+	bridge *Bridge
+	token  string
+	stream Stream
+	cancel context.CancelFunc
+	// Serializes the messages sent upstream
+	sendMutex sync.Mutex
+
+	mutex sync.Mutex
+	cond  *sync.Cond
+	// Messages received upstream which are pending or retained for replay
+	buffered []message
+	// Sequence number of the next message received upstream
+	next uint64
+	// Sequence number of the last message written to the client
+	written      uint64
+	upstreamDone bool
+	upstreamErr  error
+	// Connection of the client, nil while detached
+	conn *websocket.Conn
+	// Incremented on every attachment of a connection
+	generation int
+	expiry     *time.Timer
+	closed     bool
+}
+
+func newSession(bridge *Bridge, token string, stream Stream, cancel context.CancelFunc) *session {
+	s := &session{
+		bridge: bridge,
+		token:  token,
+		stream: stream,
+		cancel: cancel,
+		next:   1,
+	}
+	s.cond = sync.NewCond(&s.mutex)
+	return s
+}
+
+// Reads the upstream stream as long as the client keeps up
+func (s *session) pumpUpstream() {
+	for {
+		s.mutex.Lock()
+		for !s.closed && s.next-1-s.written >= uint64(s.bridge.maxPending) {
+			s.cond.Wait()
+		}
+		closed := s.closed
+		s.mutex.Unlock()
+		if closed {
+			return
+		}
+
+		payload, err := s.stream.Recv()
+
+		s.mutex.Lock()
+		if err != nil {
+			s.upstreamDone = true
+			if err != io.EOF {
+				s.upstreamErr = err
+			}
+			s.cond.Broadcast()
+			s.mutex.Unlock()
+			return
+		}
+		s.buffered = append(s.buffered, message{seq: s.next, payload: payload})
+		s.next++
+		s.cond.Broadcast()
+		s.mutex.Unlock()
+	}
+}
+
+// resumable is true if the messages after last are still buffered. Must be called with the mutex held.
+func (s *session) resumable(last uint64) bool {
+	oldest := s.next
+	if len(s.buffered) > 0 {
+		oldest = s.buffered[0].seq
+	}
+	return !s.closed && last+1 >= oldest && last < s.next
+}
+
+func (s *session) canResume(last uint64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.resumable(last)
+}
+
+// Attaches the connection to the session and serves it, replaying the messages after last
+func (s *session) attach(conn *websocket.Conn, last uint64) {
+	s.mutex.Lock()
+	if !s.resumable(last) {
+		s.mutex.Unlock()
+		closeConn(conn, websocket.ClosePolicyViolation, "session cannot be resumed", s.bridge.writeTimeout)
+		return
+	}
+	if s.expiry != nil {
+		s.expiry.Stop()
+		s.expiry = nil
+	}
+	// The previous connection of a client resuming before the bridge noticed it was gone
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.generation++
+	generation := s.generation
+	s.conn = conn
+	s.written = last
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+
+	conn.SetReadLimit(int64(headerSize + s.bridge.maxMessageSize))
+
+	done := make(chan struct{})
+	s.bridge.wg.Add(2)
+	go func() {
+		defer s.bridge.wg.Done()
+		s.writeLoop(conn, generation, last)
+	}()
+	go func() {
+		defer s.bridge.wg.Done()
+		s.ping(conn, done)
+	}()
+
+	s.readLoop(conn, generation)
+	close(done)
+}
+
+// attached is true if the connection of the generation is still attached. Must be called with the mutex held.
+func (s *session) attached(generation int) bool {
+	return !s.closed && s.conn != nil && s.generation == generation
+}
+
+// Writes messages to the client as they are received upstream
+func (s *session) writeLoop(conn *websocket.Conn, generation int, last uint64) {
+	hello := encodeControl(control{Type: controlSession, Token: s.token, Seq: last})
+	if err := s.write(conn, websocket.TextMessage, hello); err != nil {
+		s.detach(generation)
+		return
+	}
+
+	for {
+		s.mutex.Lock()
+		for s.attached(generation) && s.written+1 >= s.next && !s.upstreamDone {
+			s.cond.Wait()
+		}
+		if !s.attached(generation) {
+			s.mutex.Unlock()
+			return
+		}
+		if s.written+1 >= s.next {
+			// The upstream stream has ended and every message has been written
+			err := s.upstreamErr
+			s.mutex.Unlock()
+
+			if err != nil {
+				s.closeWith(websocket.CloseInternalServerErr, err.Error())
+			} else {
+				s.closeWith(websocket.CloseNormalClosure, "")
+			}
+			return
+		}
+		msg := s.buffered[s.written+1-s.buffered[0].seq]
+		s.mutex.Unlock()
+
+		for _, frame := range encodeFrames(msg.seq, msg.payload, s.bridge.maxFrameSize) {
+			if err := s.write(conn, websocket.BinaryMessage, frame); err != nil {
+				s.detach(generation)
+				return
+			}
+		}
+
+		s.mutex.Lock()
+		if s.generation == generation {
+			s.written = msg.seq
+			s.trim()
+			s.cond.Broadcast()
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// Drops the messages older than the replay window. Must be called with the mutex held.
+func (s *session) trim() {
+	for len(s.buffered) > 0 && s.buffered[0].seq+uint64(s.bridge.replay) <= s.written {
+		s.buffered[0] = message{}
+		s.buffered = s.buffered[1:]
+	}
+}
+
+func (s *session) write(conn *websocket.Conn, messageType int, data []byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(s.bridge.writeTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteMessage(messageType, data)
+}
+
+// Reads the messages of the client, reassembling their frames, and sends them upstream
+func (s *session) readLoop(conn *websocket.Conn, generation int) {
+	extendDeadline := func() {
+		if s.bridge.pingInterval > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(2 * s.bridge.pingInterval))
+		}
+	}
+	extendDeadline()
+	conn.SetPongHandler(func(string) error {
+		extendDeadline()
+		return nil
+	})
+
+	var partial []byte
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				// The client is done with the session
+				s.closeWith(0, "")
+			} else {
+				s.detach(generation)
+			}
+			return
+		}
+		extendDeadline()
+
+		switch messageType {
+		case websocket.TextMessage:
+			var c control
+			if json.Unmarshal(data, &c) == nil && c.Type == controlEnd {
+				s.sendMutex.Lock()
+				_ = s.stream.CloseSend()
+				s.sendMutex.Unlock()
+			}
+		case websocket.BinaryMessage:
+			final, _, payload, err := decodeFrame(data)
+			if err != nil {
+				s.closeWith(websocket.CloseProtocolError, err.Error())
+				return
+			}
+			if len(partial)+len(payload) > s.bridge.maxMessageSize {
+				s.closeWith(websocket.CloseMessageTooBig, "message too big")
+				return
+			}
+
+			partial = append(partial, payload...)
+			if final {
+				// Errors sending upstream surface on receiving from it, ending the session
+				s.sendMutex.Lock()
+				_ = s.stream.Send(partial)
+				s.sendMutex.Unlock()
+				partial = nil
+			}
+		}
+	}
+}
+
+func (s *session) ping(conn *websocket.Conn, done <-chan struct{}) {
+	if s.bridge.pingInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.bridge.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.bridge.writeTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Detaches the connection of the generation, leaving the session open for the client to resume
+func (s *session) detach(generation int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.attached(generation) {
+		return
+	}
+	s.conn.Close()
+	s.conn = nil
+	s.startExpiry()
+	s.cond.Broadcast()
+}
+
+// Called when a new or resumed session could not be attached to a connection
+func (s *session) detached() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == nil && s.expiry == nil {
+		s.startExpiry()
+	}
+}
+
+// Closes the session unless it is resumed within the window. Must be called with the mutex held.
+func (s *session) startExpiry() {
+	if s.closed {
+		return
+	}
+
+	generation := s.generation
+	s.expiry = time.AfterFunc(s.bridge.resumeWindow, func() {
+		s.mutex.Lock()
+		expired := s.conn == nil && s.generation == generation
+		s.mutex.Unlock()
+
+		if expired {
+			s.closeWith(0, "")
+		}
+	})
+}
+
+// Closes the session, sending the client a close frame with the code(unless 0) and ending the upstream stream
+func (s *session) closeWith(code int, reason string) {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+	s.closed = true
+	if s.expiry != nil {
+		s.expiry.Stop()
+	}
+	conn := s.conn
+	s.conn = nil
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+
+	if conn != nil {
+		closeConn(conn, code, reason, s.bridge.writeTimeout)
+	}
+
+	s.sendMutex.Lock()
+	_ = s.stream.CloseSend()
+	s.sendMutex.Unlock()
+	s.cancel()
+	s.bridge.remove(s.token)
+}
+
+func closeConn(conn *websocket.Conn, code int, reason string, timeout time.Duration) {
+	if code != 0 {
+		if len(reason) > maxCloseReason {
+			reason = reason[:maxCloseReason]
+		}
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(timeout))
+	}
+	conn.Close()
+}
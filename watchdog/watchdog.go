@@ -0,0 +1,274 @@
+// Package watchdog detects stuck event loops(eg. a consumer blocked on a deadlock) which miss their heartbeats.
+//
+// Loops register with the watchdog and beat on every iteration. A loop missing its heartbeat is reported along with
+// the stack of its goroutine, and restarted if it was registered with a restart function.
+package watchdog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/skit-ai/vcore/errors"
+	"github.com/skit-ai/vcore/leakcheck"
+	"github.com/skit-ai/vcore/log"
+	"github.com/skit-ai/vcore/simulation"
+	"github.com/skit-ai/vcore/surveillance"
+)
+
+// Stall of a loop which missed its heartbeat
+type Stall struct {
+	Name string
+	// Time elapsed since the last heartbeat
+	Since time.Duration
+	// Stack of the goroutine which beat last(empty if it has exited)
+	Stack string
+}
+
+// Stats of a loop
+type Stats struct {
+	Beats    uint64
+	Stalls   uint64
+	Restarts uint64
+	LastBeat time.Time
+}
+
+type Watchdog struct {
+	interval time.Duration
+	clock    simulation.Clock
+	sentry   *surveillance.Sentry
+	onStall  func(Stall)
+
+	mutex sync.Mutex
+	loops map[string]*Heartbeat
+}
+
+// Option configures a Watchdog
+type Option func(*Watchdog)
+
+// WithInterval configures the interval at which the heartbeats are checked. Defaults to 1s.
+func WithInterval(interval time.Duration) Option {
+	return func(w *Watchdog) {
+		w.interval = interval
+	}
+}
+
+// WithClock configures the clock of the heartbeats. Defaults to simulation.Real.
+func WithClock(clock simulation.Clock) Option {
+	return func(w *Watchdog) {
+		w.clock = clock
+	}
+}
+
+// WithSentry configures the client the stalls are captured with. Defaults to surveillance.SentryClient.
+func WithSentry(sentry *surveillance.Sentry) Option {
+	return func(w *Watchdog) {
+		w.sentry = sentry
+	}
+}
+
+// WithStallHandler configures a handler called on every stall, eg. to increment a metric
+func WithStallHandler(handler func(Stall)) Option {
+	return func(w *Watchdog) {
+		w.onStall = handler
+	}
+}
+
+func New(opts ...Option) *Watchdog {
+	w := &Watchdog{
+		interval: time.Second,
+		clock:    simulation.Real,
+		loops:    make(map[string]*Heartbeat),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Heartbeat of a registered loop
+type Heartbeat struct {
+	watchdog *Watchdog
+	name     string
+	timeout  time.Duration
+	restart  func(ctx context.Context) error
+
+	mutex     sync.Mutex
+	last      time.Time
+	goroutine int
+	stalled   bool
+	stats     Stats
+}
+
+// LoopOption configures a registered loop
+type LoopOption func(*Heartbeat)
+
+// WithRestart configures the function restarting the loop(eg. reconnecting a consumer) once it has stalled
+func WithRestart(restart func(ctx context.Context) error) LoopOption {
+	return func(h *Heartbeat) {
+		h.restart = restart
+	}
+}
+
+// Register registers a loop which is to beat at least once per timeout. Registering a name again replaces the loop.
+func (w *Watchdog) Register(name string, timeout time.Duration, opts ...LoopOption) *Heartbeat {
+	h := &Heartbeat{watchdog: w, name: name, timeout: timeout, last: w.clock.Now()}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	w.mutex.Lock()
+	w.loops[name] = h
+	w.mutex.Unlock()
+	return h
+}
+
+// Beat records a heartbeat of the loop. Call it from the goroutine running the loop, so that its stack can be
+// dumped once it stalls. The goroutine is recorded on the first beat(and on the first one after a restart).
+func (h *Heartbeat) Beat() {
+	h.mutex.Lock()
+	if h.goroutine == 0 {
+		h.goroutine = goroutineID()
+	}
+	h.last = h.watchdog.clock.Now()
+	h.stalled = false
+	h.stats.Beats++
+	h.stats.LastBeat = h.last
+	h.mutex.Unlock()
+}
+
+// Unregister stops watching the loop, eg. once it exits
+func (h *Heartbeat) Unregister() {
+	h.watchdog.mutex.Lock()
+	defer h.watchdog.mutex.Unlock()
+
+	if h.watchdog.loops[h.name] == h {
+		delete(h.watchdog.loops, h.name)
+	}
+}
+
+// Stats returns the stats of the loop
+func (h *Heartbeat) Stats() Stats {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.stats
+}
+
+// Stats returns the stats of the registered loops by their names
+func (w *Watchdog) Stats() map[string]Stats {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	stats := make(map[string]Stats, len(w.loops))
+	for name, h := range w.loops {
+		stats[name] = h.Stats()
+	}
+	return stats
+}
+
+// Start checks the heartbeats of the registered loops on the clock until the context is done
+func (w *Watchdog) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.clock.After(w.interval):
+			w.Check(ctx, w.clock.Now())
+		}
+	}
+}
+
+// Check reports the loops which have missed their heartbeat as of now, restarting them if configured to. A loop is
+// reported once per stall.
+func (w *Watchdog) Check(ctx context.Context, now time.Time) []Stall {
+	w.mutex.Lock()
+	loops := make([]*Heartbeat, 0, len(w.loops))
+	for _, h := range w.loops {
+		loops = append(loops, h)
+	}
+	w.mutex.Unlock()
+
+	var stalls []Stall
+	for _, h := range loops {
+		h.mutex.Lock()
+		since := now.Sub(h.last)
+		if h.stalled || since <= h.timeout {
+			h.mutex.Unlock()
+			continue
+		}
+		h.stalled = true
+		h.stats.Stalls++
+		goroutine := h.goroutine
+		h.mutex.Unlock()
+
+		stall := Stall{Name: h.name, Since: since, Stack: stack(goroutine)}
+		stalls = append(stalls, stall)
+		w.report(ctx, stall)
+
+		if h.restart != nil {
+			h.mutex.Lock()
+			h.stats.Restarts++
+			h.mutex.Unlock()
+
+			if err := h.restart(ctx); err != nil {
+				log.Error(errors.NewError(fmt.Sprintf("Could not restart the stalled loop %s", h.name), err, false))
+			} else {
+				// The restarted loop is given a fresh timeout to beat, from the goroutine it was restarted on
+				h.mutex.Lock()
+				h.last = w.clock.Now()
+				h.goroutine = 0
+				h.stalled = false
+				h.mutex.Unlock()
+			}
+		}
+	}
+	return stalls
+}
+
+func (w *Watchdog) report(ctx context.Context, stall Stall) {
+	err := errors.NewErrorWithTags(
+		fmt.Sprintf("Loop %s missed its heartbeat for %s", stall.Name, stall.Since.Round(time.Millisecond)),
+		nil, false, map[string]string{"watchdog.loop": stall.Name},
+	)
+
+	sentry := w.sentry
+	if sentry == nil {
+		sentry = surveillance.SentryClient
+	}
+	var attachments []surveillance.Attachment
+	if stall.Stack != "" {
+		attachments = append(attachments, surveillance.Attachment{Filename: "stack.txt", ContentType: "text/plain", Bytes: []byte(stall.Stack)})
+	}
+	sentry.CaptureWithAttachments(ctx, err, attachments)
+
+	if w.onStall != nil {
+		w.onStall(stall)
+	}
+}
+
+// Returns the stack of the goroutine(empty if it has exited)
+func stack(id int) string {
+	for _, g := range leakcheck.Goroutines() {
+		if g.ID == id {
+			return g.Stack
+		}
+	}
+	return ""
+}
+
+// Returns the ID of the calling goroutine, parsed from the header of its stack("goroutine 18 [running]:")
+func goroutineID() int {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		id, _ := strconv.Atoi(string(buf[:i]))
+		return id
+	}
+	return 0
+}